@@ -22,9 +22,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protolambda/zrnt/eth2/beacon/altair"
+	"github.com/protolambda/zrnt/eth2/beacon/bellatrix"
 	"github.com/protolambda/zrnt/eth2/beacon/capella"
 	zrntcommon "github.com/protolambda/zrnt/eth2/beacon/common"
 	"github.com/protolambda/zrnt/eth2/beacon/deneb"
+	"github.com/protolambda/zrnt/eth2/beacon/phase0"
 	"github.com/protolambda/zrnt/eth2/configs"
 	"github.com/protolambda/ztyp/tree"
 )
@@ -59,6 +62,12 @@ func BlockFromJSON(forkName string, data []byte) (*BeaconBlock, error) {
 // NewBeaconBlock wraps a ZRNT block.
 func NewBeaconBlock(obj blockObject) *BeaconBlock {
 	switch obj := obj.(type) {
+	case *phase0.BeaconBlock:
+		return &BeaconBlock{obj}
+	case *altair.BeaconBlock:
+		return &BeaconBlock{obj}
+	case *bellatrix.BeaconBlock:
+		return &BeaconBlock{obj}
 	case *capella.BeaconBlock:
 		return &BeaconBlock{obj}
 	case *deneb.BeaconBlock:
@@ -71,6 +80,12 @@ func NewBeaconBlock(obj blockObject) *BeaconBlock {
 // Slot returns the slot number of the block.
 func (b *BeaconBlock) Slot() uint64 {
 	switch obj := b.blockObj.(type) {
+	case *phase0.BeaconBlock:
+		return uint64(obj.Slot)
+	case *altair.BeaconBlock:
+		return uint64(obj.Slot)
+	case *bellatrix.BeaconBlock:
+		return uint64(obj.Slot)
 	case *capella.BeaconBlock:
 		return uint64(obj.Slot)
 	case *deneb.BeaconBlock:
@@ -80,9 +95,17 @@ func (b *BeaconBlock) Slot() uint64 {
 	}
 }
 
-// ExecutionPayload parses and returns the execution payload of the block.
+// ExecutionPayload parses and returns the execution payload of the block. Nil is
+// returned for blocks preceding the merge, which carry no execution payload.
 func (b *BeaconBlock) ExecutionPayload() (*types.Block, error) {
 	switch obj := b.blockObj.(type) {
+	case *phase0.BeaconBlock, *altair.BeaconBlock:
+		return nil, nil
+	case *bellatrix.BeaconBlock:
+		if obj.Body.ExecutionPayload.BlockHash == (zrntcommon.Hash32{}) {
+			return nil, nil // default payload before the merge transition
+		}
+		return convertPayload(&obj.Body.ExecutionPayload, &obj.ParentRoot)
 	case *capella.BeaconBlock:
 		return convertPayload(&obj.Body.ExecutionPayload, &obj.ParentRoot)
 	case *deneb.BeaconBlock:
@@ -95,6 +118,12 @@ func (b *BeaconBlock) ExecutionPayload() (*types.Block, error) {
 // Header returns the block's header data.
 func (b *BeaconBlock) Header() Header {
 	switch obj := b.blockObj.(type) {
+	case *phase0.BeaconBlock:
+		return headerFromZRNT(obj.Header(configs.Mainnet))
+	case *altair.BeaconBlock:
+		return headerFromZRNT(obj.Header(configs.Mainnet))
+	case *bellatrix.BeaconBlock:
+		return headerFromZRNT(obj.Header(configs.Mainnet))
 	case *capella.BeaconBlock:
 		return headerFromZRNT(obj.Header(configs.Mainnet))
 	case *deneb.BeaconBlock:
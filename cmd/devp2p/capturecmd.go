@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	captureCommand = &cli.Command{
+		Name:  "capture",
+		Usage: "Message capture commands",
+		Subcommands: []*cli.Command{
+			captureDecodeCommand,
+		},
+	}
+	captureDecodeCommand = &cli.Command{
+		Name:      "decode",
+		Usage:     "Pretty-prints the messages of a capture file",
+		ArgsUsage: "<file>",
+		Action:    captureDecode,
+		Flags: []cli.Flag{
+			capturePeerFlag,
+			captureProtocolFlag,
+			captureCodeFlag,
+		},
+	}
+)
+
+var (
+	capturePeerFlag = &cli.StringFlag{
+		Name:  "peer",
+		Usage: "Only show messages of peers whose node ID starts with the given hex prefix",
+	}
+	captureProtocolFlag = &cli.StringFlag{
+		Name:  "protocol",
+		Usage: "Only show messages of the given protocol (e.g. snap or eth/68)",
+	}
+	captureCodeFlag = &cli.StringFlag{
+		Name:  "code",
+		Usage: "Only show messages with the given comma separated codes",
+	}
+)
+
+// capturedPacket describes how to decode a message of a sub-protocol.
+type capturedPacket struct {
+	name string
+	new  func() interface{}
+}
+
+var capturedPackets = map[string]map[uint64]capturedPacket{
+	eth.ProtocolName: {
+		eth.StatusMsg:                     {"Status", func() interface{} { return new(eth.StatusPacket) }},
+		eth.NewBlockHashesMsg:             {"NewBlockHashes", func() interface{} { return new(eth.NewBlockHashesPacket) }},
+		eth.TransactionsMsg:               {"Transactions", func() interface{} { return new(eth.TransactionsPacket) }},
+		eth.GetBlockHeadersMsg:            {"GetBlockHeaders", func() interface{} { return new(eth.GetBlockHeadersPacket) }},
+		eth.BlockHeadersMsg:               {"BlockHeaders", func() interface{} { return new(eth.BlockHeadersPacket) }},
+		eth.GetBlockBodiesMsg:             {"GetBlockBodies", func() interface{} { return new(eth.GetBlockBodiesPacket) }},
+		eth.BlockBodiesMsg:                {"BlockBodies", func() interface{} { return new(eth.BlockBodiesPacket) }},
+		eth.NewBlockMsg:                   {"NewBlock", func() interface{} { return new(eth.NewBlockPacket) }},
+		eth.NewPooledTransactionHashesMsg: {"NewPooledTransactionHashes", func() interface{} { return new(eth.NewPooledTransactionHashesPacket) }},
+		eth.GetPooledTransactionsMsg:      {"GetPooledTransactions", func() interface{} { return new(eth.GetPooledTransactionsPacket) }},
+		eth.PooledTransactionsMsg:         {"PooledTransactions", func() interface{} { return new(eth.PooledTransactionsPacket) }},
+		eth.GetReceiptsMsg:                {"GetReceipts", func() interface{} { return new(eth.GetReceiptsPacket) }},
+		eth.ReceiptsMsg:                   {"Receipts", func() interface{} { return new(eth.ReceiptsPacket) }},
+	},
+	snap.ProtocolName: {
+		snap.GetAccountRangeMsg:  {"GetAccountRange", func() interface{} { return new(snap.GetAccountRangePacket) }},
+		snap.AccountRangeMsg:     {"AccountRange", func() interface{} { return new(snap.AccountRangePacket) }},
+		snap.GetStorageRangesMsg: {"GetStorageRanges", func() interface{} { return new(snap.GetStorageRangesPacket) }},
+		snap.StorageRangesMsg:    {"StorageRanges", func() interface{} { return new(snap.StorageRangesPacket) }},
+		snap.GetByteCodesMsg:     {"GetByteCodes", func() interface{} { return new(snap.GetByteCodesPacket) }},
+		snap.ByteCodesMsg:        {"ByteCodes", func() interface{} { return new(snap.ByteCodesPacket) }},
+		snap.GetTrieNodesMsg:     {"GetTrieNodes", func() interface{} { return new(snap.GetTrieNodesPacket) }},
+		snap.TrieNodesMsg:        {"TrieNodes", func() interface{} { return new(snap.TrieNodesPacket) }},
+	},
+}
+
+// captureFilter selects the records to display.
+type captureFilter struct {
+	peer    string
+	proto   string
+	version string
+	codes   map[uint64]bool
+}
+
+func newCaptureFilter(ctx *cli.Context) (*captureFilter, error) {
+	f := &captureFilter{peer: strings.ToLower(strings.TrimPrefix(ctx.String(capturePeerFlag.Name), "0x"))}
+	f.proto, f.version, _ = strings.Cut(ctx.String(captureProtocolFlag.Name), "/")
+
+	if spec := ctx.String(captureCodeFlag.Name); spec != "" {
+		f.codes = make(map[uint64]bool)
+		for _, code := range strings.Split(spec, ",") {
+			n, err := strconv.ParseUint(strings.TrimSpace(code), 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid message code %q", code)
+			}
+			f.codes[n] = true
+		}
+	}
+	return f, nil
+}
+
+func (f *captureFilter) match(rec *p2p.CaptureRecord) bool {
+	switch {
+	case f.peer != "" && !strings.HasPrefix(rec.Peer.String(), f.peer):
+		return false
+	case f.proto != "" && rec.Protocol != f.proto:
+		return false
+	case f.version != "" && strconv.FormatUint(uint64(rec.Version), 10) != f.version:
+		return false
+	case f.codes != nil && !f.codes[rec.Code]:
+		return false
+	}
+	return true
+}
+
+func captureDecode(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need capture file as argument")
+	}
+	filter, err := newCaptureFilter(ctx)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var (
+		reader = p2p.NewCaptureReader(bufio.NewReader(file))
+		out    = bufio.NewWriter(os.Stdout)
+	)
+	defer out.Flush()
+
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid capture file: %v", err)
+		}
+		if filter.match(rec) {
+			printCapturedMsg(out, rec)
+		}
+	}
+}
+
+// printCapturedMsg writes a summary line of a message to out, followed by its
+// decoded contents.
+func printCapturedMsg(out io.Writer, rec *p2p.CaptureRecord) {
+	dir := ">>"
+	if rec.Received {
+		dir = "<<"
+	}
+	name := "unknown"
+	packet, known := capturedPackets[rec.Protocol][rec.Code]
+	if known {
+		name = packet.name
+	}
+	fmt.Fprintf(out, "%s %s %s %s/%d %s (code %#x, %d bytes)\n",
+		time.Unix(0, int64(rec.Time)).UTC().Format(time.RFC3339Nano), dir,
+		rec.Peer.TerminalString(), rec.Protocol, rec.Version, name, rec.Code, len(rec.Payload))
+
+	if !known {
+		fmt.Fprintf(out, "%x\n\n", rec.Payload)
+		return
+	}
+	msg := packet.new()
+	if err := rlp.DecodeBytes(rec.Payload, msg); err != nil {
+		fmt.Fprintf(out, "invalid payload: %v\n%x\n\n", err, rec.Payload)
+		return
+	}
+	enc, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		fmt.Fprintf(out, "%+v\n\n", msg)
+		return
+	}
+	fmt.Fprintf(out, "%s\n\n", enc)
+}
@@ -38,6 +38,10 @@ type crawler struct {
 	// settings
 	revalidateInterval time.Duration
 	mu                 sync.RWMutex
+
+	// service mode
+	stop     chan struct{}                // stops the crawl when closed
+	onUpdate func(n nodeJSON, status int) // invoked after every node update
 }
 
 const (
@@ -144,6 +148,8 @@ loop:
 			}
 		case <-timeoutCh:
 			break loop
+		case <-c.stop:
+			break loop
 		case <-statusTicker.C:
 			log.Info("Crawling in progress",
 				"added", added.Load(),
@@ -210,14 +216,19 @@ func (c *crawler) updateNode(n *enode.Node) int {
 	}
 	// Store/update node in output set.
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if node.Score <= 0 {
 		log.Debug("Removing node", "id", n.ID())
 		delete(c.output, n.ID())
-		return nodeRemoved
+		status = nodeRemoved
+	} else {
+		log.Debug("Updating node", "id", n.ID(), "seq", n.Seq(), "score", node.Score)
+		c.output[n.ID()] = node
+	}
+	c.mu.Unlock()
+
+	if c.onUpdate != nil {
+		c.onUpdate(node, status)
 	}
-	log.Debug("Updating node", "id", n.ID(), "seq", n.Seq(), "score", node.Score)
-	c.output[n.ID()] = node
 	return status
 }
 
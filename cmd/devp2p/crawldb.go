@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// maxRecordHistory is the number of past node records kept for every node.
+const maxRecordHistory = 32
+
+// crawlEntry is everything the crawler knows about a node.
+type crawlEntry struct {
+	nodeJSON
+
+	// History contains the distinct records seen for the node, oldest first.
+	History []recordHistoryEntry `json:"history"`
+
+	// These are extracted from the latest record and devp2p handshake.
+	ForkID *forkIDJSON `json:"forkID,omitempty"`
+	Client string      `json:"client,omitempty"`
+	Caps   []string    `json:"caps,omitempty"`
+
+	// These track the devp2p reachability of the node.
+	LastDial     time.Time `json:"lastDial,omitempty"`
+	LastDialOK   time.Time `json:"lastDialOK,omitempty"`
+	DialError    string    `json:"dialError,omitempty"`
+	DialFailures int       `json:"dialFailures,omitempty"`
+}
+
+// recordHistoryEntry is a record seen for a node.
+type recordHistoryEntry struct {
+	Seq       uint64      `json:"seq"`
+	FirstSeen time.Time   `json:"firstSeen"`
+	Record    *enode.Node `json:"record"`
+}
+
+// forkIDJSON is the JSON encoding of a fork ID.
+type forkIDJSON struct {
+	Hash hexutil.Bytes  `json:"hash"`
+	Next hexutil.Uint64 `json:"next"`
+}
+
+// reachable reports whether the last devp2p connection attempt succeeded.
+func (e *crawlEntry) reachable() bool {
+	return !e.LastDialOK.IsZero() && e.LastDialOK.Equal(e.LastDial)
+}
+
+// update merges a crawled node record and liveness info into the entry.
+func (e *crawlEntry) update(n nodeJSON) {
+	e.nodeJSON = n
+	if n.N == nil {
+		return
+	}
+	if len(e.History) == 0 || e.History[len(e.History)-1].Seq < n.N.Seq() {
+		e.History = append(e.History, recordHistoryEntry{Seq: n.N.Seq(), FirstSeen: n.LastCheck, Record: n.N})
+		if len(e.History) > maxRecordHistory {
+			e.History = e.History[len(e.History)-maxRecordHistory:]
+		}
+	}
+	if id := enrForkID(n.N); id != nil {
+		e.ForkID = &forkIDJSON{Hash: id.Hash[:], Next: hexutil.Uint64(id.Next)}
+	} else {
+		e.ForkID = nil
+	}
+}
+
+// enrForkID returns the fork ID of the "eth" entry in a node record, or nil if
+// the record has no such entry.
+func enrForkID(n *enode.Node) *forkid.ID {
+	var eth struct {
+		ForkID forkid.ID
+		Tail   []rlp.RawValue `rlp:"tail"`
+	}
+	if n.Load(enr.WithEntry("eth", &eth)) != nil {
+		return nil
+	}
+	return &eth.ForkID
+}
+
+// crawlDB stores the crawled nodes in a LevelDB database, keyed by node ID.
+type crawlDB struct {
+	db   *leveldb.DB
+	lock sync.Mutex // serializes read-modify-write cycles
+}
+
+func openCrawlDB(path string, readonly bool) (*crawlDB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: readonly})
+	if err != nil {
+		return nil, err
+	}
+	return &crawlDB{db: db}, nil
+}
+
+func (db *crawlDB) close() error {
+	return db.db.Close()
+}
+
+// get retrieves the entry of a node, or nil if the node is unknown.
+func (db *crawlDB) get(id enode.ID) (*crawlEntry, error) {
+	blob, err := db.db.Get(id[:], nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := new(crawlEntry)
+	if err := json.Unmarshal(blob, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// modify applies fn to the entry of a node, creating it if needed, and stores
+// the result.
+func (db *crawlDB) modify(id enode.ID, fn func(*crawlEntry)) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	entry, err := db.get(id)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		entry = new(crawlEntry)
+	}
+	fn(entry)
+
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return db.db.Put(id[:], blob, nil)
+}
+
+// iterate calls fn for all stored entries, until it returns false.
+func (db *crawlDB) iterate(fn func(*crawlEntry) bool) error {
+	it := db.db.NewIterator(util.BytesPrefix(nil), nil)
+	defer it.Release()
+
+	for it.Next() {
+		entry := new(crawlEntry)
+		if err := json.Unmarshal(it.Value(), entry); err != nil {
+			return err
+		}
+		if !fn(entry) {
+			break
+		}
+	}
+	return it.Error()
+}
+
+// nodeSet returns the live nodes matching the filter as a node set.
+func (db *crawlDB) nodeSet(filter func(*crawlEntry) bool) (nodeSet, error) {
+	ns := make(nodeSet)
+	err := db.iterate(func(e *crawlEntry) bool {
+		if e.N != nil && e.Score > 0 && filter(e) {
+			ns[e.N.ID()] = e.nodeJSON
+		}
+		return true
+	})
+	return ns, err
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// signedNode creates a node record with the given sequence number.
+func signedNode(t *testing.T, key *ecdsa.PrivateKey, seq uint64) *enode.Node {
+	var r enr.Record
+	r.Set(enr.IPv4(net.IP{127, 0, 0, 1}))
+	r.SetSeq(seq)
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatal(err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// Tests that the crawler database tracks the record history and reachability of
+// nodes, and serves them over the HTTP API.
+func TestCrawlDB(t *testing.T) {
+	db, err := openCrawlDB(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.close()
+
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		start   = truncNow()
+		svc     = &crawlService{db: db, dialInterval: time.Hour}
+	)
+	// Node 1 updates its record twice, node 2 goes offline
+	for i, seq := range []uint64{1, 1, 2, 3} {
+		now := start.Add(time.Duration(i) * time.Minute)
+		svc.update(nodeJSON{N: signedNode(t, key1, seq), Seq: seq, Score: i + 1, FirstResponse: start, LastResponse: now, LastCheck: now}, nodeUpdated)
+	}
+	node2 := signedNode(t, key2, 1)
+	svc.update(nodeJSON{N: node2, Seq: 1, Score: 0, FirstResponse: start, LastResponse: start, LastCheck: start}, nodeRemoved)
+
+	entry, err := db.get(enode.PubkeyToIDV4(&key1.PublicKey))
+	if err != nil || entry == nil {
+		t.Fatalf("node 1 not stored: %v", err)
+	}
+	if len(entry.History) != 3 {
+		t.Fatalf("record history length mismatch: have %d, want 3", len(entry.History))
+	}
+	for i, h := range entry.History {
+		if h.Seq != uint64(i+1) || h.Record.Seq() != h.Seq {
+			t.Errorf("history entry %d: seq mismatch: have %d", i, h.Seq)
+		}
+	}
+	if want := start.Add(2 * time.Minute); !entry.History[1].FirstSeen.Equal(want) {
+		t.Errorf("history entry 1: first seen mismatch: have %v, want %v", entry.History[1].FirstSeen, want)
+	}
+	// Nodes without TCP endpoints are never dialed
+	if !entry.LastDial.IsZero() || entry.reachable() {
+		t.Errorf("node without TCP endpoint was dialed")
+	}
+
+	// Check the API
+	srv := httptest.NewServer(svc.handler())
+	defer srv.Close()
+
+	var ns nodeSet
+	getJSON(t, srv.URL+"/nodes?filter=-min-age+2m", &ns)
+	if len(ns) != 1 || ns[entry.N.ID()].Seq != 3 {
+		t.Errorf("node set mismatch: %v", ns)
+	}
+	var reachable nodeSet
+	getJSON(t, srv.URL+"/nodes?filter=-reachable", &reachable)
+	if len(reachable) != 0 {
+		t.Errorf("unreachable nodes exported: %v", reachable)
+	}
+	var stats crawlStats
+	getJSON(t, srv.URL+"/stats", &stats)
+	if stats.Nodes != 2 || stats.Live != 1 || stats.Reachable != 0 {
+		t.Errorf("stats mismatch: %+v", stats)
+	}
+	var removed crawlEntry
+	getJSON(t, srv.URL+"/nodes/"+node2.ID().String(), &removed)
+	if removed.N.ID() != node2.ID() || removed.Score != 0 {
+		t.Errorf("removed node mismatch: %+v", removed)
+	}
+	if resp, err := http.Get(srv.URL + "/nodes?filter=-bogus"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid filter not rejected: %v", err)
+	}
+}
+
+func getJSON(t *testing.T, url string, result interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+}
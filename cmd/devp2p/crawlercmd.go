@@ -0,0 +1,345 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/urfave/cli/v2"
+)
+
+// rlpxDialTimeout is the time allowed for retrieving the devp2p handshake of a
+// crawled node.
+const rlpxDialTimeout = 10 * time.Second
+
+var (
+	crawlerCommand = &cli.Command{
+		Name:  "crawler",
+		Usage: "Persistent DHT crawler",
+		Subcommands: []*cli.Command{
+			crawlerRunCommand,
+			crawlerExportCommand,
+		},
+	}
+	crawlerRunCommand = &cli.Command{
+		Name:      "run",
+		Usage:     "Crawls the DHT until interrupted, tracking the nodes in a database",
+		ArgsUsage: "<database>",
+		Action:    crawlerRun,
+		Flags: flags.Merge(discoveryNodeFlags, []cli.Flag{
+			crawlParallelismFlag,
+			crawlV5Flag,
+			crawlDialIntervalFlag,
+			httpAddrFlag,
+		}),
+	}
+	crawlerExportCommand = &cli.Command{
+		Name:      "export",
+		Usage:     "Writes the live nodes of a crawler database as a node set",
+		ArgsUsage: "<database> filters..",
+		Action:    crawlerExport,
+
+		SkipFlagParsing: true,
+	}
+)
+
+var (
+	crawlV5Flag = &cli.BoolFlag{
+		Name:  "v5",
+		Usage: "Crawl the discovery v5 DHT instead of v4",
+	}
+	crawlDialIntervalFlag = &cli.DurationFlag{
+		Name:  "dial-interval",
+		Usage: "Minimum time between devp2p connection attempts to a node",
+		Value: 30 * time.Minute,
+	}
+)
+
+func crawlerRun(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need database directory as argument")
+	}
+	db, err := openCrawlDB(ctx.Args().First(), false)
+	if err != nil {
+		return err
+	}
+	defer db.close()
+
+	// Resume with the live nodes of the previous runs.
+	input, err := db.nodeSet(func(*crawlEntry) bool { return true })
+	if err != nil {
+		return err
+	}
+	var (
+		disc      resolver
+		bootnodes []*enode.Node
+		iter      enode.Iterator
+	)
+	if ctx.Bool(crawlV5Flag.Name) {
+		v5, config := startV5(ctx)
+		defer v5.Close()
+		disc, bootnodes, iter = v5, config.Bootnodes, v5.RandomNodes()
+	} else {
+		v4, config := startV4(ctx)
+		defer v4.Close()
+		disc, bootnodes, iter = v4, config.Bootnodes, v4.RandomNodes()
+	}
+	c, err := newCrawler(input, bootnodes, disc, iter)
+	if err != nil {
+		return err
+	}
+	svc := &crawlService{db: db, dialInterval: ctx.Duration(crawlDialIntervalFlag.Name)}
+	c.revalidateInterval = 10 * time.Minute
+	c.stop = make(chan struct{})
+	c.onUpdate = svc.update
+
+	if addr := ctx.String(httpAddrFlag.Name); addr != "" {
+		log.Info("Starting crawler API server", "addr", addr)
+		go func() {
+			if err := http.ListenAndServe(addr, svc.handler()); err != nil {
+				log.Error("Crawler API server failed", "err", err)
+			}
+		}()
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		log.Info("Stopping crawler")
+		close(c.stop)
+	}()
+	c.run(0, ctx.Int(crawlParallelismFlag.Name))
+	return nil
+}
+
+func crawlerExport(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need database directory as argument")
+	}
+	filter, limit, err := parseCrawlFilter(ctx.Args().Tail())
+	if err != nil {
+		return err
+	}
+	db, err := openCrawlDB(ctx.Args().First(), true)
+	if err != nil {
+		return err
+	}
+	defer db.close()
+
+	ns, err := db.nodeSet(filter)
+	if err != nil {
+		return err
+	}
+	if limit >= 0 {
+		ns = ns.topN(limit)
+	}
+	writeNodesJSON("-", ns)
+	return nil
+}
+
+// parseCrawlFilter parses the filters for the nodes of a crawler database. In
+// addition to the node set filters, nodes can be filtered by their reachability
+// (-reachable) and client name (-client <prefix>).
+func parseCrawlFilter(args []string) (filter func(*crawlEntry) bool, limit int, err error) {
+	var (
+		rest      []string
+		reachable bool
+		client    string
+	)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-reachable":
+			reachable = true
+		case "-client":
+			if i == len(args)-1 {
+				return nil, 0, errors.New("-client requires an argument")
+			}
+			i++
+			client = strings.ToLower(args[i])
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if limit, err = parseFilterLimit(rest); err != nil {
+		return nil, 0, err
+	}
+	nodeFilter, err := andFilter(rest)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter = func(e *crawlEntry) bool {
+		if reachable && !e.reachable() {
+			return false
+		}
+		if client != "" && !strings.HasPrefix(strings.ToLower(e.Client), client) {
+			return false
+		}
+		return nodeFilter(e.nodeJSON)
+	}
+	return filter, limit, nil
+}
+
+// crawlService records the crawled nodes in the database and serves them over
+// HTTP.
+type crawlService struct {
+	db           *crawlDB
+	dialInterval time.Duration
+}
+
+// update stores a crawled node, and retrieves its devp2p handshake if it's time
+// to check it again.
+func (s *crawlService) update(n nodeJSON, status int) {
+	var (
+		id   = n.N.ID()
+		dial bool
+	)
+	err := s.db.modify(id, func(e *crawlEntry) {
+		e.update(n)
+		dial = status != nodeRemoved && n.LastResponse.Equal(n.LastCheck) && time.Since(e.LastDial) >= s.dialInterval
+	})
+	if err != nil {
+		log.Error("Failed to store crawled node", "id", id, "err", err)
+		return
+	}
+	if !dial {
+		return
+	}
+	if _, ok := n.N.TCPEndpoint(); !ok {
+		return
+	}
+	hello, dialErr := rlpxHello(n.N, rlpxDialTimeout)
+	now := truncNow()
+
+	err = s.db.modify(id, func(e *crawlEntry) {
+		e.LastDial = now
+		if dialErr != nil {
+			e.DialError = dialErr.Error()
+			e.DialFailures++
+			return
+		}
+		e.LastDialOK, e.DialError, e.DialFailures = now, "", 0
+		e.Client = hello.Name
+		e.Caps = e.Caps[:0]
+		for _, cap := range hello.Caps {
+			e.Caps = append(e.Caps, cap.String())
+		}
+	})
+	if err != nil {
+		log.Error("Failed to store crawled node", "id", id, "err", err)
+	}
+}
+
+// handler returns the HTTP API of the crawler:
+//
+//	GET /nodes?filter=<filters>  live nodes matching the filters, as a node set
+//	GET /nodes/<id>              everything known about a node
+//	GET /stats                   statistics about the crawled nodes
+func (s *crawlService) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nodes", s.serveNodes)
+	mux.HandleFunc("/nodes/", s.serveNode)
+	mux.HandleFunc("/stats", s.serveStats)
+	return mux
+}
+
+func (s *crawlService) serveNodes(w http.ResponseWriter, r *http.Request) {
+	filter, limit, err := parseCrawlFilter(strings.Fields(r.URL.Query().Get("filter")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ns, err := s.db.nodeSet(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if limit >= 0 {
+		ns = ns.topN(limit)
+	}
+	writeJSONResponse(w, ns)
+}
+
+func (s *crawlService) serveNode(w http.ResponseWriter, r *http.Request) {
+	id, err := enode.ParseID(strings.TrimPrefix(r.URL.Path, "/nodes/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entry, err := s.db.get(id)
+	switch {
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case entry == nil:
+		http.Error(w, "unknown node", http.StatusNotFound)
+	default:
+		writeJSONResponse(w, entry)
+	}
+}
+
+// crawlStats summarizes the contents of the crawler database.
+type crawlStats struct {
+	Nodes     int            `json:"nodes"`     // Nodes ever seen
+	Live      int            `json:"live"`      // Nodes currently responding to discovery
+	Reachable int            `json:"reachable"` // Live nodes accepting devp2p connections
+	Clients   map[string]int `json:"clients"`   // Reachable nodes by client name
+	ForkIDs   map[string]int `json:"forkIDs"`   // Live nodes by fork ID
+}
+
+func (s *crawlService) serveStats(w http.ResponseWriter, r *http.Request) {
+	stats := crawlStats{Clients: make(map[string]int), ForkIDs: make(map[string]int)}
+	err := s.db.iterate(func(e *crawlEntry) bool {
+		stats.Nodes++
+		if e.Score <= 0 {
+			return true
+		}
+		stats.Live++
+		if e.ForkID != nil {
+			stats.ForkIDs[fmt.Sprintf("%v/%d", e.ForkID.Hash, e.ForkID.Next)]++
+		}
+		if e.reachable() {
+			stats.Reachable++
+			name, _, _ := strings.Cut(e.Client, "/")
+			stats.Clients[name]++
+		}
+		return true
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONResponse(w, stats)
+}
+
+func writeJSONResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", jsonIndent)
+	if err := enc.Encode(v); err != nil {
+		log.Debug("Failed to write API response", "err", err)
+	}
+}
@@ -19,12 +19,17 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/v5test"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/urfave/cli/v2"
 )
 
@@ -38,6 +43,8 @@ var (
 			discv5CrawlCommand,
 			discv5TestCommand,
 			discv5ListenCommand,
+			discv5RegisterCommand,
+			discv5TopicSearchCommand,
 		},
 	}
 	discv5PingCommand = &cli.Command{
@@ -77,8 +84,30 @@ var (
 		Action: discv5Listen,
 		Flags:  discoveryNodeFlags,
 	}
+	discv5RegisterCommand = &cli.Command{
+		Name:      "register",
+		Usage:     "Runs a node advertising itself for a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5Register,
+		Flags:     discoveryNodeFlags,
+	}
+	discv5TopicSearchCommand = &cli.Command{
+		Name:      "topic-search",
+		Usage:     "Finds the nodes advertised for a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5TopicSearch,
+		Flags: flags.Merge(discoveryNodeFlags, []cli.Flag{
+			topicSearchTimeoutFlag,
+		}),
+	}
 )
 
+var topicSearchTimeoutFlag = &cli.DurationFlag{
+	Name:  "timeout",
+	Usage: "Time limit for the search",
+	Value: 2 * time.Minute,
+}
+
 func discv5Ping(ctx *cli.Context) error {
 	n := getNodeArg(ctx)
 	disc, _ := startV5(ctx)
@@ -138,6 +167,53 @@ func discv5Listen(ctx *cli.Context) error {
 	select {}
 }
 
+func discv5Register(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need topic as argument")
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	topic := discover.NewTopic(ctx.Args().First())
+	reg := disc.RegisterTopic(topic)
+	defer reg.Stop()
+	fmt.Println(disc.Self())
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	status := time.NewTicker(30 * time.Second)
+	defer status.Stop()
+	for {
+		select {
+		case <-status.C:
+			log.Info("Advertising topic", "topic", topic, "registrars", reg.Registrars())
+		case <-sigc:
+			return nil
+		}
+	}
+}
+
+func discv5TopicSearch(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need topic as argument")
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	it := disc.TopicSearch(discover.NewTopic(ctx.Args().First()))
+	timeout := time.AfterFunc(ctx.Duration(topicSearchTimeoutFlag.Name), it.Close)
+	defer timeout.Stop()
+
+	seen := make(map[enode.ID]bool)
+	for it.Next() {
+		if n := it.Node(); !seen[n.ID()] {
+			seen[n.ID()] = true
+			fmt.Println(n)
+		}
+	}
+	return nil
+}
+
 // startV5 starts an ephemeral discovery v5 node.
 func startV5(ctx *cli.Context) (*discover.UDPv5, discover.Config) {
 	ln, config := makeDiscoveryConfig(ctx)
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -76,42 +77,55 @@ var (
 )
 
 func rlpxPing(ctx *cli.Context) error {
-	n := getNodeArg(ctx)
+	h, err := rlpxHello(getNodeArg(ctx), 0)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%+v\n", h)
+	return nil
+}
+
+// rlpxHello connects to a node and returns its devp2p handshake. A zero timeout
+// means no timeout.
+func rlpxHello(n *enode.Node, timeout time.Duration) (*ethtest.Hello, error) {
 	tcpEndpoint, ok := n.TCPEndpoint()
 	if !ok {
-		return fmt.Errorf("node has no TCP endpoint")
+		return nil, fmt.Errorf("node has no TCP endpoint")
 	}
-	fd, err := net.Dial("tcp", tcpEndpoint.String())
+	fd, err := net.DialTimeout("tcp", tcpEndpoint.String(), timeout)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer fd.Close()
+	if timeout > 0 {
+		fd.SetDeadline(time.Now().Add(timeout))
 	}
 	conn := rlpx.NewConn(fd, n.Pubkey())
 	ourKey, _ := crypto.GenerateKey()
 	_, err = conn.Handshake(ourKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	code, data, _, err := conn.Read()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	switch code {
 	case 0:
 		var h ethtest.Hello
 		if err := rlp.DecodeBytes(data, &h); err != nil {
-			return fmt.Errorf("invalid handshake: %v", err)
+			return nil, fmt.Errorf("invalid handshake: %v", err)
 		}
-		fmt.Printf("%+v\n", h)
+		return &h, nil
 	case 1:
 		var msg []p2p.DiscReason
 		if rlp.DecodeBytes(data, &msg); len(msg) == 0 {
-			return errors.New("invalid disconnect message")
+			return nil, errors.New("invalid disconnect message")
 		}
-		return fmt.Errorf("received disconnect message: %v", msg[0])
+		return nil, fmt.Errorf("received disconnect message: %v", msg[0])
 	default:
-		return fmt.Errorf("invalid message code %d, expected handshake (code zero) or disconnect (code one)", code)
+		return nil, fmt.Errorf("invalid message code %d, expected handshake (code zero) or disconnect (code one)", code)
 	}
-	return nil
 }
 
 // rlpxEthTest runs the eth protocol test suite.
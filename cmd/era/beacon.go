@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/urfave/cli/v2"
+)
+
+// beaconInfo prints some high-level information about a consensus-layer era file.
+func beaconInfo(ctx *cli.Context, number uint64) error {
+	e, err := openBeacon(ctx, number)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	summary, err := e.StateSummary()
+	if err != nil {
+		return fmt.Errorf("error reading beacon state: %w", err)
+	}
+	root, err := summary.Root()
+	if err != nil {
+		return fmt.Errorf("error computing historical root: %w", err)
+	}
+	info := struct {
+		Root       common.Hash `json:"root"`
+		StartSlot  uint64      `json:"startSlot"`
+		Count      uint64      `json:"count"`
+		StateSlot  uint64      `json:"stateSlot"`
+		FirstBlock *uint64     `json:"firstBlock,omitempty"`
+		LastBlock  *uint64     `json:"lastBlock,omitempty"`
+	}{
+		Root:      root,
+		StartSlot: e.Start(),
+		Count:     e.Count(),
+		StateSlot: e.StateSlot(),
+	}
+	// Find the first and last execution blocks, if the era is post-merge.
+	for slot := e.Start(); slot < e.Start()+e.Count(); slot++ {
+		block, err := executionBlock(e, slot)
+		if err != nil {
+			return err
+		}
+		if block != nil {
+			number := block.NumberU64()
+			info.FirstBlock = &number
+			break
+		}
+	}
+	for slot := e.Start() + e.Count(); info.FirstBlock != nil && slot > e.Start(); slot-- {
+		block, err := executionBlock(e, slot-1)
+		if err != nil {
+			return err
+		}
+		if block != nil {
+			number := block.NumberU64()
+			info.LastBlock = &number
+			break
+		}
+	}
+	b, _ := json.MarshalIndent(info, "", "  ")
+	fmt.Println(string(b))
+	return nil
+}
+
+// openBeacon opens a consensus-layer era file with a certain number.
+func openBeacon(ctx *cli.Context, number uint64) (*era.BeaconEra, error) {
+	var (
+		dir     = ctx.String(dirFlag.Name)
+		network = ctx.String(networkFlag.Name)
+	)
+	entries, err := era.ReadBeaconDir(dir, network)
+	if err != nil {
+		return nil, fmt.Errorf("error reading era dir: %w", err)
+	}
+	prefix := fmt.Sprintf("%s-%05d-", network, number)
+	for _, name := range entries {
+		if strings.HasPrefix(name, prefix) {
+			return era.OpenBeacon(filepath.Join(dir, name), network)
+		}
+	}
+	return nil, fmt.Errorf("era %d not found", number)
+}
+
+// verifyBeacon checks each consensus-layer era file in a directory to ensure it
+// is well-formed and that the historical root matches the expected value.
+func verifyBeacon(ctx *cli.Context, roots []common.Hash) error {
+	var (
+		dir      = ctx.String(dirFlag.Name)
+		network  = ctx.String(networkFlag.Name)
+		start    = time.Now()
+		reported = time.Now()
+	)
+	entries, err := era.ReadBeaconDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) != len(roots) {
+		return errors.New("number of era files should match the number of historical roots")
+	}
+	// Verify each era matches the expected root.
+	for i, want := range roots {
+		// Wrap in function so defers don't stack.
+		err := func() error {
+			name := entries[i]
+			e, err := era.OpenBeacon(filepath.Join(dir, name), network)
+			if err != nil {
+				return fmt.Errorf("error opening era file %s: %w", name, err)
+			}
+			defer e.Close()
+
+			got, err := e.Verify()
+			if err != nil {
+				return fmt.Errorf("error verifying era file %s: %w", name, err)
+			}
+			if got != want {
+				return fmt.Errorf("invalid root %s: got %s, want %s", name, got, want)
+			}
+			// The file name must match the contents.
+			number := int(e.StateSlot() / uint64(era.SlotsPerHistoricalRoot))
+			if expected := era.BeaconFilename(network, number, got); name != expected {
+				return fmt.Errorf("invalid file name %s: want %s", name, expected)
+			}
+			// Give the user some feedback that something is happening.
+			if time.Since(reported) >= 8*time.Second {
+				fmt.Printf("Verifying Era files \t\t verified=%d,\t elapsed=%s\n", i, common.PrettyDuration(time.Since(start)))
+				reported = time.Now()
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executionBlock returns the execution block of the beacon block at the given
+// slot, or nil if the slot is empty or predates the merge.
+func executionBlock(e *era.BeaconEra, slot uint64) (*types.Block, error) {
+	block, err := e.GetBlockBySlot(slot)
+	if err != nil {
+		return nil, fmt.Errorf("error reading block at slot %d: %w", slot, err)
+	}
+	if block == nil {
+		return nil, nil
+	}
+	payload, err := block.ExecutionPayload()
+	if err != nil {
+		return nil, fmt.Errorf("error reading execution payload at slot %d: %w", slot, err)
+	}
+	return payload, nil
+}
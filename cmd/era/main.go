@@ -42,14 +42,19 @@ var app = flags.NewApp("go-ethereum era tool")
 var (
 	dirFlag = &cli.StringFlag{
 		Name:  "dir",
-		Usage: "directory storing all relevant era1 files",
+		Usage: "directory storing all relevant era files",
 		Value: "eras",
 	}
 	networkFlag = &cli.StringFlag{
 		Name:  "network",
-		Usage: "network name associated with era1 files",
+		Usage: "network name associated with era files",
 		Value: "mainnet",
 	}
+	formatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "format of the era files: era1 (execution layer) or era (consensus layer)",
+		Value: "era1",
+	}
 	eraSizeFlag = &cli.IntFlag{
 		Name:  "size",
 		Usage: "number of blocks per era",
@@ -80,7 +85,7 @@ var (
 	verifyCommand = &cli.Command{
 		Name:      "verify",
 		ArgsUsage: "<expected>",
-		Usage:     "verifies each era1 against expected accumulator root",
+		Usage:     "verifies each era file against expected accumulator or historical root",
 		Action:    verify,
 	}
 )
@@ -95,6 +100,7 @@ func init() {
 		dirFlag,
 		networkFlag,
 		eraSizeFlag,
+		formatFlag,
 	}
 }
 
@@ -131,12 +137,19 @@ func block(ctx *cli.Context) error {
 	return nil
 }
 
-// info prints some high-level information about the era1 file.
+// info prints some high-level information about the era file.
 func info(ctx *cli.Context) error {
 	epoch, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid epoch number: %w", err)
 	}
+	switch ctx.String(formatFlag.Name) {
+	case "era1":
+	case "era":
+		return beaconInfo(ctx, epoch)
+	default:
+		return fmt.Errorf("unknown era format %q", ctx.String(formatFlag.Name))
+	}
 	e, err := open(ctx, epoch)
 	if err != nil {
 		return err
@@ -190,6 +203,13 @@ func verify(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("unable to read expected roots file: %w", err)
 	}
+	switch ctx.String(formatFlag.Name) {
+	case "era1":
+	case "era":
+		return verifyBeacon(ctx, roots)
+	default:
+		return fmt.Errorf("unknown era format %q", ctx.String(formatFlag.Name))
+	}
 
 	var (
 		dir      = ctx.String(dirFlag.Name)
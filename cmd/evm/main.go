@@ -138,6 +138,17 @@ var (
 		Usage:    "enable return data output",
 		Category: flags.VMCategory,
 	}
+	GasProfileFlag = &cli.StringFlag{
+		Name:     "gasprofile",
+		Usage:    "write a profile of the gas consumption by call stack and opcode to the given file",
+		Category: flags.VMCategory,
+	}
+	GasProfileFormatFlag = &cli.StringFlag{
+		Name:     "gasprofile.format",
+		Usage:    "gas profile format (pprof or folded)",
+		Value:    "pprof",
+		Category: flags.VMCategory,
+	}
 )
 
 var stateTransitionCommand = &cli.Command{
@@ -235,6 +246,7 @@ func init() {
 		stateTransitionCommand,
 		transactionCommand,
 		blockBuilderCommand,
+		statelessCommand,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		flags.MigrateGlobalFlags(ctx)
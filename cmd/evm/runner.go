@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -37,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/core/vm/runtime"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/eth/tracers/native"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -50,7 +52,7 @@ var runCommand = &cli.Command{
 	Usage:       "Run arbitrary evm binary",
 	ArgsUsage:   "<code>",
 	Description: `The run command runs arbitrary EVM code.`,
-	Flags:       flags.Merge(vmFlags, traceFlags),
+	Flags:       flags.Merge(vmFlags, traceFlags, []cli.Flag{GasProfileFlag, GasProfileFormatFlag}),
 }
 
 // readGenesis will read the given JSON format genesis file and return
@@ -135,6 +137,21 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		debugLogger = logger.NewStructLogger(logconfig)
 	}
+	// The return value is printed unless the trace is written to stdout.
+	printOutput := tracer == nil
+
+	var profiler *native.GasProfiler
+	if ctx.IsSet(GasProfileFlag.Name) {
+		if tracer != nil {
+			return errors.New("gas profiling can't be combined with other tracers")
+		}
+		config, _ := json.Marshal(map[string]string{"format": ctx.String(GasProfileFormatFlag.Name)})
+		var err error
+		if profiler, err = native.NewGasProfiler(config); err != nil {
+			return err
+		}
+		tracer = profiler.Hooks()
+	}
 
 	initialGas := ctx.Uint64(GasFlag.Name)
 	genesisConfig := new(core.Genesis)
@@ -301,7 +318,12 @@ allocations:     %d
 allocated bytes: %d
 `, initialGas-leftOverGas, stats.time, stats.allocs, stats.bytesAllocated)
 	}
-	if tracer == nil {
+	if profiler != nil {
+		if err := writeGasProfile(profiler, ctx.String(GasProfileFlag.Name), ctx.String(GasProfileFormatFlag.Name)); err != nil {
+			return err
+		}
+	}
+	if printOutput {
 		fmt.Printf("%#x\n", output)
 		if err != nil {
 			fmt.Printf(" error: %v\n", err)
@@ -310,3 +332,23 @@ allocated bytes: %d
 
 	return nil
 }
+
+// writeGasProfile writes the gas profile collected during the execution into
+// the given file.
+func writeGasProfile(profiler *native.GasProfiler, path string, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if format == native.GasProfileFormatFolded {
+		err = profiler.WriteFolded(f)
+	} else {
+		err = profiler.WriteProfile(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write gas profile: %v", err)
+	}
+	return nil
+}
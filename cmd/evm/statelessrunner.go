@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/t8ntool"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	StateRootFlag = &cli.StringFlag{
+		Name:  "stateroot",
+		Usage: "Expected post-state root of the block (default = root in the block header)",
+	}
+	ReceiptRootFlag = &cli.StringFlag{
+		Name:  "receiptroot",
+		Usage: "Expected receipt root of the block (default = root in the block header)",
+	}
+)
+
+var statelessCommand = &cli.Command{
+	Action:    statelessCmd,
+	Name:      "stateless",
+	Usage:     "Executes a block from its execution witness",
+	ArgsUsage: "<witness-file>",
+	Flags: []cli.Flag{
+		t8ntool.ForknameFlag,
+		t8ntool.ChainIDFlag,
+		StateRootFlag,
+		ReceiptRootFlag,
+	},
+}
+
+// statelessResult is the outcome of a stateless block execution.
+type statelessResult struct {
+	Number      uint64      `json:"number"`
+	StateRoot   common.Hash `json:"stateRoot"`
+	ReceiptRoot common.Hash `json:"receiptRoot"`
+	Error       string      `json:"error,omitempty"`
+}
+
+func statelessCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-witness argument required")
+	}
+	witness, err := utils.ReadWitness(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	config, _, err := tests.GetChainConfig(ctx.String(t8ntool.ForknameFlag.Name))
+	if err != nil {
+		return err
+	}
+	config.ChainID = big.NewInt(ctx.Int64(t8ntool.ChainIDFlag.Name))
+
+	// Check against the roots of the block, unless overridden
+	var (
+		stateRoot   = witness.Block.Root()
+		receiptRoot = witness.Block.ReceiptHash()
+	)
+	if ctx.IsSet(StateRootFlag.Name) {
+		stateRoot = common.HexToHash(ctx.String(StateRootFlag.Name))
+	}
+	if ctx.IsSet(ReceiptRootFlag.Name) {
+		receiptRoot = common.HexToHash(ctx.String(ReceiptRootFlag.Name))
+	}
+	result := &statelessResult{Number: witness.Block.NumberU64()}
+	result.StateRoot, result.ReceiptRoot, err = utils.VerifyWitness(config, witness, stateRoot, receiptRoot)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Fprintln(os.Stdout, string(out))
+	return err
+}
@@ -44,6 +44,11 @@ import (
 )
 
 var (
+	importExecuteFlag = &cli.BoolFlag{
+		Name:  "execute",
+		Usage: "Execute all imported blocks instead of importing their receipts (required for consensus-layer era files)",
+	}
+
 	initCommand = &cli.Command{
 		Action:    initGenesis,
 		Name:      "init",
@@ -133,13 +138,16 @@ be gzipped.`,
 		ArgsUsage: "<dir>",
 		Flags: flags.Merge([]cli.Flag{
 			utils.TxLookupLimitFlag,
+			importExecuteFlag,
 		},
 			utils.DatabaseFlags,
 			utils.NetworkFlags,
 		),
 		Description: `
 The import-history command will import blocks and their corresponding receipts
-from Era archives.
+from Era archives. Post-merge blocks are imported from consensus-layer era files
+if present, which requires the --execute flag: these files carry no receipts, so
+all blocks need to be executed instead.
 `,
 	}
 	exportHistoryCommand = &cli.Command{
@@ -437,12 +445,16 @@ func importHistory(ctx *cli.Context) error {
 			if err != nil {
 				return fmt.Errorf("error reading %s: %w", dir, err)
 			}
-			if len(entries) > 0 {
+			beacons, err := era.ReadBeaconDir(dir, n)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", dir, err)
+			}
+			if len(entries) > 0 || len(beacons) > 0 {
 				networks = append(networks, n)
 			}
 		}
 		if len(networks) == 0 {
-			return fmt.Errorf("no era1 files found in %s", dir)
+			return fmt.Errorf("no era files found in %s", dir)
 		}
 		if len(networks) > 1 {
 			return errors.New("multiple networks found, use a network flag to specify desired network")
@@ -450,7 +462,7 @@ func importHistory(ctx *cli.Context) error {
 		network = networks[0]
 	}
 
-	if err := utils.ImportHistory(chain, db, dir, network); err != nil {
+	if err := utils.ImportHistory(chain, db, dir, network, ctx.Bool(importExecuteFlag.Name)); err != nil {
 		return err
 	}
 	fmt.Printf("Import done in %v\n", time.Since(start))
@@ -232,7 +232,12 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		stack.RegisterLifecycle(blsyncer)
 	} else {
 		// Launch the engine API for interacting with external consensus client.
-		err := catalyst.Register(stack, eth)
+		var err error
+		if ctx.IsSet(utils.AuthRecordFlag.Name) {
+			err = catalyst.RegisterRecorded(stack, eth, ctx.String(utils.AuthRecordFlag.Name))
+		} else {
+			err = catalyst.Register(stack, eth)
+		}
 		if err != nil {
 			utils.Fatalf("failed to register catalyst service: %v", err)
 		}
@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	replayGenesisFlag = &cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Genesis file of the network the recording was made on (default = network flags)",
+	}
+	replayStopFlag = &cli.BoolFlag{
+		Name:  "stop",
+		Usage: "Stop replaying at the first divergence",
+	}
+
+	engineCommand = &cli.Command{
+		Name:  "engine",
+		Usage: "A set of commands for debugging the Engine API",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "replay",
+				Usage:     "Replay recorded Engine API calls against a fresh node",
+				ArgsUsage: "<recording-file>",
+				Action:    replayEngine,
+				Flags: flags.Merge([]cli.Flag{
+					replayGenesisFlag,
+					replayStopFlag,
+				}, utils.NetworkFlags),
+				Description: `
+geth engine replay <recording-file>
+This command drives a fresh in-memory node, started from the genesis of the
+network flags or the --genesis file, through the Engine API calls recorded
+with --authrpc.record. The calls are issued in the recorded order and each
+response and the resulting chain head are compared to the recorded ones, any
+divergence being reported.
+
+The recording must start from the genesis block. Payloads built by the node
+depend on its transaction pool and miner settings, so getPayload responses may
+legitimately diverge if the recording node had pending transactions.
+`,
+			},
+		},
+	}
+)
+
+// replayEngine replays the recorded Engine API calls against a fresh node and
+// reports the divergences.
+func replayEngine(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("need recording file as the only argument")
+	}
+	calls, err := catalyst.ReadRecording(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	genesis, err := loadGenesis(ctx, replayGenesisFlag)
+	if err != nil {
+		return err
+	}
+	// Create an ephemeral, disconnected node to replay the calls on
+	stack, err := node.New(&node.Config{
+		Name: clientIdentifier,
+		P2P: p2p.Config{
+			NoDiscovery: true,
+			MaxPeers:    0,
+			ListenAddr:  "",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+
+	config := ethconfig.Defaults
+	config.Genesis = genesis
+	config.NetworkId = genesis.Config.ChainID.Uint64()
+	config.SyncMode = downloader.FullSync
+
+	backend, err := eth.New(stack, &config)
+	if err != nil {
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	log.Info("Replaying engine API calls", "calls", len(calls), "genesis", backend.BlockChain().Genesis().Hash())
+
+	var (
+		api      = catalyst.NewConsensusAPI(backend)
+		stop     = ctx.Bool(replayStopFlag.Name)
+		replayed int
+	)
+	divergences, err := catalyst.Replay(api, calls, func(index int, div *catalyst.Divergence) bool {
+		replayed++
+		if div == nil {
+			return true
+		}
+		fmt.Println(div)
+		return !stop
+	})
+	if err != nil {
+		return err
+	}
+	head := backend.BlockChain().CurrentBlock()
+	fmt.Printf("Replayed %d of %d calls, %d diverged, head #%d %x\n", replayed, len(calls), len(divergences), head.Number, head.Hash())
+	if len(divergences) > 0 {
+		return fmt.Errorf("%d of %d replayed calls diverged", len(divergences), replayed)
+	}
+	return nil
+}
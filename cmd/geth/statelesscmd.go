@@ -0,0 +1,132 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	witnessGenesisFlag = &cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Genesis file of the network the witness belongs to (default = network flags)",
+	}
+	witnessStateRootFlag = &cli.StringFlag{
+		Name:  "stateroot",
+		Usage: "Expected post-state root of the block (default = root in the block header)",
+	}
+	witnessReceiptRootFlag = &cli.StringFlag{
+		Name:  "receiptroot",
+		Usage: "Expected receipt root of the block (default = root in the block header)",
+	}
+
+	statelessCommand = &cli.Command{
+		Name:  "stateless",
+		Usage: "A set of commands for stateless block execution",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "Execute a block from its witness and check the post-state",
+				ArgsUsage: "<witness-file>",
+				Action:    verifyWitness,
+				Flags: flags.Merge([]cli.Flag{
+					witnessGenesisFlag,
+					witnessStateRootFlag,
+					witnessReceiptRootFlag,
+				}, utils.NetworkFlags),
+				Description: `
+geth stateless verify <witness-file>
+This command re-executes the block in the witness file without any local state,
+using only the trie nodes, codes and headers contained in the witness, such as
+the one returned by debug_executionWitness. The computed post-state and receipt
+roots are checked against the ones in the block header, or the --stateroot and
+--receiptroot flags, if given.
+The chain configuration is taken from the network flags or the --genesis file.
+`,
+			},
+		},
+	}
+)
+
+// loadGenesis loads the genesis from the file given by the flag, falling back
+// to the one of the network flags, or mainnet if none is given.
+func loadGenesis(ctx *cli.Context, flag *cli.StringFlag) (*core.Genesis, error) {
+	if !ctx.IsSet(flag.Name) {
+		if genesis := utils.MakeGenesis(ctx); genesis != nil {
+			return genesis, nil
+		}
+		return core.DefaultGenesisBlock(), nil
+	}
+	file, err := os.Open(ctx.String(flag.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file: %v", err)
+	}
+	return genesis, nil
+}
+
+// verifyWitness executes a block statelessly based on the witness file and
+// checks the computed roots.
+func verifyWitness(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("need witness file as the only argument")
+	}
+	witness, err := utils.ReadWitness(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	genesis, err := loadGenesis(ctx, witnessGenesisFlag)
+	if err != nil {
+		return err
+	}
+	// Check against the roots of the block, unless overridden
+	var (
+		stateRoot   = witness.Block.Root()
+		receiptRoot = witness.Block.ReceiptHash()
+	)
+	if ctx.IsSet(witnessStateRootFlag.Name) {
+		stateRoot = common.HexToHash(ctx.String(witnessStateRootFlag.Name))
+	}
+	if ctx.IsSet(witnessReceiptRootFlag.Name) {
+		receiptRoot = common.HexToHash(ctx.String(witnessReceiptRootFlag.Name))
+	}
+	log.Info("Executing block statelessly", "number", witness.Block.Number(), "hash", witness.Block.Hash(),
+		"txs", len(witness.Block.Transactions()), "nodes", len(witness.State), "codes", len(witness.Codes), "headers", len(witness.Headers))
+
+	crossStateRoot, crossReceiptRoot, err := utils.VerifyWitness(genesis.Config, witness, stateRoot, receiptRoot)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("State root:   %#x\n", crossStateRoot)
+	fmt.Printf("Receipt root: %#x\n", crossReceiptRoot)
+	return nil
+}
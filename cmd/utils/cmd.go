@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -34,9 +35,11 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
@@ -242,8 +245,12 @@ func readList(filename string) ([]string, error) {
 }
 
 // ImportHistory imports Era1 files containing historical block information,
-// starting from genesis.
-func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, network string) error {
+// starting from genesis. Consensus-layer era files containing the post-merge
+// history are imported afterwards, if present.
+//
+// If execute is set, all blocks are executed instead of importing their receipts.
+// This is required for importing the post-merge history, which carries none.
+func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, network string, execute bool) error {
 	if chain.CurrentSnapBlock().Number.BitLen() != 0 {
 		return errors.New("history import only supported when starting from genesis")
 	}
@@ -251,12 +258,25 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 	if err != nil {
 		return fmt.Errorf("error reading %s: %w", dir, err)
 	}
-	checksums, err := readList(filepath.Join(dir, "checksums.txt"))
+	beacons, err := era.ReadBeaconDir(dir, network)
 	if err != nil {
-		return fmt.Errorf("unable to read checksums.txt: %w", err)
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	var checksums []string
+	if len(entries) > 0 {
+		checksums, err = readList(filepath.Join(dir, "checksums.txt"))
+		if err != nil {
+			return fmt.Errorf("unable to read checksums.txt: %w", err)
+		}
+		if len(checksums) != len(entries) {
+			return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+		}
+	}
+	if len(beacons) > 0 && !execute {
+		return fmt.Errorf("found %d consensus-layer era files, importing them requires executing all blocks", len(beacons))
 	}
-	if len(checksums) != len(entries) {
-		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	if execute {
+		log.Warn("Executing all imported blocks, this will take significantly longer than importing receipts", "eras", len(entries), "beacons", len(beacons))
 	}
 	var (
 		start    = time.Now()
@@ -265,7 +285,29 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 		forker   = core.NewForkChoice(chain, nil)
 		h        = sha256.New()
 		buf      = bytes.NewBuffer(nil)
+		pending  types.Blocks
+		head     uint64
 	)
+	// insertPending executes and inserts the blocks queued up for import.
+	insertPending := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if _, err := chain.InsertChain(pending); err != nil {
+			return fmt.Errorf("error inserting blocks %d-%d: %w", pending[0].NumberU64(), pending[len(pending)-1].NumberU64(), err)
+		}
+		pending = nil
+		return nil
+	}
+	// report gives the user some feedback that something is happening.
+	report := func() {
+		imported += 1
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing Era files", "head", head, "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+			imported = 0
+			reported = time.Now()
+		}
+	}
 	for i, filename := range entries {
 		err := func() error {
 			f, err := os.Open(filepath.Join(dir, filename))
@@ -301,26 +343,78 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 				if block.Number().BitLen() == 0 {
 					continue // skip genesis
 				}
-				receipts, err := it.Receipts()
+				if execute {
+					pending = append(pending, block)
+					if len(pending) >= importBatchSize {
+						if err := insertPending(); err != nil {
+							return err
+						}
+					}
+				} else {
+					receipts, err := it.Receipts()
+					if err != nil {
+						return fmt.Errorf("error reading receipts %d: %w", it.Number(), err)
+					}
+					if status, err := chain.HeaderChain().InsertHeaderChain([]*types.Header{block.Header()}, start, forker); err != nil {
+						return fmt.Errorf("error inserting header %d: %w", it.Number(), err)
+					} else if status != core.CanonStatTy {
+						return fmt.Errorf("error inserting header %d, not canon: %v", it.Number(), status)
+					}
+					if _, err := chain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{receipts}, 2^64-1); err != nil {
+						return fmt.Errorf("error inserting body %d: %w", it.Number(), err)
+					}
+				}
+				head = block.NumberU64()
+				report()
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	for _, filename := range beacons {
+		err := func() error {
+			e, err := era.OpenBeacon(filepath.Join(dir, filename), network)
+			if err != nil {
+				return fmt.Errorf("error opening era: %w", err)
+			}
+			defer e.Close()
+
+			// Authenticate the blocks against the stored beacon state, and the
+			// state against the root in the file name.
+			root, err := e.Verify()
+			if err != nil {
+				return fmt.Errorf("error verifying era %s: %w", filename, err)
+			}
+			number := int(e.StateSlot() / uint64(era.SlotsPerHistoricalRoot))
+			if want := era.BeaconFilename(network, number, root); filename != want {
+				return fmt.Errorf("era %s root mismatch, want %s", filename, want)
+			}
+			// Import the execution payloads of all blocks not yet imported.
+			for slot := e.Start(); slot < e.Start()+e.Count(); slot++ {
+				beaconBlock, err := e.GetBlockBySlot(slot)
 				if err != nil {
-					return fmt.Errorf("error reading receipts %d: %w", it.Number(), err)
+					return fmt.Errorf("error reading slot %d: %w", slot, err)
 				}
-				if status, err := chain.HeaderChain().InsertHeaderChain([]*types.Header{block.Header()}, start, forker); err != nil {
-					return fmt.Errorf("error inserting header %d: %w", it.Number(), err)
-				} else if status != core.CanonStatTy {
-					return fmt.Errorf("error inserting header %d, not canon: %v", it.Number(), status)
+				if beaconBlock == nil {
+					continue // skip empty slot
 				}
-				if _, err := chain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{receipts}, 2^64-1); err != nil {
-					return fmt.Errorf("error inserting body %d: %w", it.Number(), err)
+				block, err := beaconBlock.ExecutionPayload()
+				if err != nil {
+					return fmt.Errorf("error reading execution payload of slot %d: %w", slot, err)
 				}
-				imported += 1
-
-				// Give the user some feedback that something is happening.
-				if time.Since(reported) >= 8*time.Second {
-					log.Info("Importing Era files", "head", it.Number(), "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
-					imported = 0
-					reported = time.Now()
+				if block == nil || block.NumberU64() <= head {
+					continue // skip pre-merge or already imported
+				}
+				pending = append(pending, block)
+				if len(pending) >= importBatchSize {
+					if err := insertPending(); err != nil {
+						return err
+					}
 				}
+				head = block.NumberU64()
+				report()
 			}
 			return nil
 		}()
@@ -328,8 +422,7 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 			return err
 		}
 	}
-
-	return nil
+	return insertPending()
 }
 
 func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block {
@@ -864,3 +957,47 @@ func ExportChaindata(fn string, kind string, iter ChainDataIterator, interrupt c
 		"elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+// ReadWitness loads an execution witness from the given file. The witness is
+// either JSON encoded, as returned by debug_executionWitness, or RLP encoded in
+// binary or hex form.
+func ReadWitness(fn string) (*stateless.Witness, error) {
+	blob, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	witness := new(stateless.Witness)
+	switch trimmed := bytes.TrimSpace(blob); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, witness)
+	case bytes.HasPrefix(trimmed, []byte("0x")):
+		var enc []byte
+		if enc, err = hexutil.Decode(string(trimmed)); err == nil {
+			err = rlp.DecodeBytes(enc, witness)
+		}
+	default:
+		err = rlp.DecodeBytes(blob, witness)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness file %s: %v", fn, err)
+	}
+	return witness, nil
+}
+
+// VerifyWitness executes the block of the witness statelessly and checks the
+// computed post-state and receipt roots against the expected ones, which are
+// usually the ones in the header of the witness block. The computed roots are
+// returned.
+func VerifyWitness(config *params.ChainConfig, witness *stateless.Witness, stateRoot, receiptRoot common.Hash) (common.Hash, common.Hash, error) {
+	crossReceiptRoot, crossStateRoot, err := core.ExecuteStateless(config, witness)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("stateless execution failed: %v", err)
+	}
+	if crossStateRoot != stateRoot {
+		return crossStateRoot, crossReceiptRoot, fmt.Errorf("state root mismatch: have %x, want %x", crossStateRoot, stateRoot)
+	}
+	if crossReceiptRoot != receiptRoot {
+		return crossStateRoot, crossReceiptRoot, fmt.Errorf("receipt root mismatch: have %x, want %x", crossReceiptRoot, receiptRoot)
+	}
+	return crossStateRoot, crossReceiptRoot, nil
+}
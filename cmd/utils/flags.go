@@ -31,6 +31,7 @@ import (
 	"os"
 	"path/filepath"
 	godebug "runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/fdlimit"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -273,7 +275,7 @@ var (
 	}
 	StateHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.state",
-		Usage:    "Number of recent blocks to retain state history for (default = 90,000 blocks, 0 = entire chain)",
+		Usage:    "Number of recent blocks to retain state history for, historical states within the window are accessible in path scheme with a read cost linear in their age (default = 90,000 blocks, 0 = entire chain)",
 		Value:    ethconfig.Defaults.StateHistory,
 		Category: flags.StateCategory,
 	}
@@ -283,6 +285,32 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	HistoryArchiveFlag = &cli.StringFlag{
+		Name:     "history.archive",
+		Usage:    "Era1 directory or HTTP mirror URL to sync pre-merge block bodies and receipts from",
+		Category: flags.StateCategory,
+	}
+	HistoryExpiryFlag = &cli.Uint64Flag{
+		Name:     "history.expiry",
+		Usage:    "Block number below which block bodies and receipts are pruned from the ancient store, served from a local history archive afterwards (0 = keep all)",
+		Category: flags.StateCategory,
+	}
+	LogIndexFlag = &cli.BoolFlag{
+		Name:     "logindex",
+		Usage:    "Maintain a persistent log index to speed up log filtering (eth_getLogs)",
+		Category: flags.StateCategory,
+	}
+	LogHistoryFlag = &cli.Uint64Flag{
+		Name:     "history.logs",
+		Usage:    "Number of recent blocks to maintain the log index for (default = entire chain)",
+		Value:    ethconfig.Defaults.LogHistory,
+		Category: flags.StateCategory,
+	}
+	TraceIndexFlag = &cli.BoolFlag{
+		Name:     "traceindex",
+		Usage:    "Maintain an index of call trace senders and recipients for trace_filter, only the blocks imported afterwards are indexed",
+		Category: flags.StateCategory,
+	}
 	// Beacon client light sync settings
 	BeaconApiFlag = &cli.StringSliceFlag{
 		Name:     "beacon.api",
@@ -419,6 +447,30 @@ var (
 		Value:    ethconfig.Defaults.BlobPool.PriceBump,
 		Category: flags.BlobPoolCategory,
 	}
+	// Transaction bundle pool settings
+	BundlePoolEnabledFlag = &cli.BoolFlag{
+		Name:     "bundlepool",
+		Usage:    "Enable accepting private transaction bundles via eth_sendBundle",
+		Category: flags.BundlePoolCategory,
+	}
+	BundlePoolSlotsFlag = &cli.Uint64Flag{
+		Name:     "bundlepool.slots",
+		Usage:    "Maximum number of transaction bundles kept by the pool",
+		Value:    ethconfig.Defaults.BundlePool.Slots,
+		Category: flags.BundlePoolCategory,
+	}
+	BundlePoolMaxTxsFlag = &cli.Uint64Flag{
+		Name:     "bundlepool.maxtxs",
+		Usage:    "Maximum number of transactions in a single bundle",
+		Value:    ethconfig.Defaults.BundlePool.MaxTxs,
+		Category: flags.BundlePoolCategory,
+	}
+	BundlePoolAheadFlag = &cli.Uint64Flag{
+		Name:     "bundlepool.ahead",
+		Usage:    "Maximum number of blocks ahead of the chain head a bundle can target",
+		Value:    ethconfig.Defaults.BundlePool.Ahead,
+		Category: flags.BundlePoolCategory,
+	}
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
 		Name:     "cache",
@@ -502,6 +554,12 @@ var (
 		Value:    ethconfig.Defaults.Miner.Recommit,
 		Category: flags.MinerCategory,
 	}
+	MinerOrderingFlag = &cli.StringFlag{
+		Name:     "miner.ordering",
+		Usage:    "Transaction ordering strategy for block building (greedy, fifo, profit)",
+		Value:    ethconfig.Defaults.Miner.Ordering,
+		Category: flags.MinerCategory,
+	}
 	MinerPendingFeeRecipientFlag = &cli.StringFlag{
 		Name:     "miner.pending.feeRecipient",
 		Usage:    "0x prefixed public address for the pending block producer (not used for actual block production)",
@@ -568,6 +626,18 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	RPCTraceFilterRangeLimitFlag = &cli.Uint64Flag{
+		Name:     "rpc.tracefilter.rangelimit",
+		Usage:    "Sets a cap on the number of blocks a trace_filter query may span (0 = no cap)",
+		Value:    ethconfig.Defaults.RPCTraceFilterRangeLimit,
+		Category: flags.APICategory,
+	}
+	RPCTraceFilterResultLimitFlag = &cli.Uint64Flag{
+		Name:     "rpc.tracefilter.resultlimit",
+		Usage:    "Sets a cap on the number of traces a trace_filter query may return (0 = no cap)",
+		Value:    ethconfig.Defaults.RPCTraceFilterResultLimit,
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -587,6 +657,11 @@ var (
 		Value:    strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
 		Category: flags.APICategory,
 	}
+	AuthRecordFlag = &cli.StringFlag{
+		Name:     "authrpc.record",
+		Usage:    "File to journal all Engine API calls into, for replaying with 'geth engine replay'",
+		Category: flags.APICategory,
+	}
 	JWTSecretFlag = &flags.DirectoryFlag{
 		Name:     "authrpc.jwtsecret",
 		Usage:    "Path to a JWT secret to use for authenticated RPC endpoints",
@@ -749,6 +824,16 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Request units per second each HTTP/WS client may spend (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitBurstFlag = &cli.IntFlag{
+		Name:     "rpc.ratelimit.burst",
+		Usage:    "Request units each HTTP/WS client may spend at once (0 = rate limit)",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -768,6 +853,12 @@ var (
 		Value:    node.DefaultConfig.P2P.MaxPendingPeers,
 		Category: flags.NetworkingCategory,
 	}
+	SnapServeBudgetFlag = &cli.Uint64Flag{
+		Name:     "snap.serve.budget",
+		Usage:    "Disk-read budget for serving snap sync requests in KiB/s (0 = unlimited)",
+		Value:    ethconfig.Defaults.SnapServeBudget,
+		Category: flags.NetworkingCategory,
+	}
 	ListenPortFlag = &cli.IntFlag{
 		Name:     "port",
 		Usage:    "Network listening port",
@@ -830,6 +921,21 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	BandwidthUploadFlag = &cli.StringFlag{
+		Name:     "p2p.bandwidth.upload",
+		Usage:    "Upload quota in KiB/s, optionally followed by per-protocol quotas (e.g. 8192,snap=2048)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthDownloadFlag = &cli.StringFlag{
+		Name:     "p2p.bandwidth.download",
+		Usage:    "Download quota in KiB/s, optionally followed by per-protocol quotas (e.g. 8192,snap=2048)",
+		Category: flags.NetworkingCategory,
+	}
+	CaptureFileFlag = &cli.StringFlag{
+		Name:     "p2p.capture",
+		Usage:    "Records all sub-protocol messages exchanged with peers to the given file (debugging only)",
+		Category: flags.NetworkingCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -1190,6 +1296,18 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(RPCRateLimitFlag.Name) || ctx.IsSet(RPCRateLimitBurstFlag.Name) {
+		if cfg.RPCLimits == nil {
+			cfg.RPCLimits = new(node.RPCLimitConfig)
+		}
+		if ctx.IsSet(RPCRateLimitFlag.Name) {
+			cfg.RPCLimits.Rate = ctx.Float64(RPCRateLimitFlag.Name)
+		}
+		if ctx.IsSet(RPCRateLimitBurstFlag.Name) {
+			cfg.RPCLimits.Burst = ctx.Int(RPCRateLimitBurstFlag.Name)
+		}
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1388,6 +1506,23 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	if ctx.IsSet(BandwidthUploadFlag.Name) {
+		quota, protocols, err := p2p.ParseBandwidthQuotas(ctx.String(BandwidthUploadFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", BandwidthUploadFlag.Name, err)
+		}
+		cfg.Bandwidth.Upload, cfg.Bandwidth.ProtocolUpload = quota, protocols
+	}
+	if ctx.IsSet(BandwidthDownloadFlag.Name) {
+		quota, protocols, err := p2p.ParseBandwidthQuotas(ctx.String(BandwidthDownloadFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", BandwidthDownloadFlag.Name, err)
+		}
+		cfg.Bandwidth.Download, cfg.Bandwidth.ProtocolDownload = quota, protocols
+	}
+	if ctx.IsSet(CaptureFileFlag.Name) {
+		cfg.CaptureFile = ctx.String(CaptureFileFlag.Name)
+	}
 
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
@@ -1550,6 +1685,21 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	}
 }
 
+func setBundlePool(ctx *cli.Context, cfg *bundlepool.Config) {
+	if ctx.IsSet(BundlePoolEnabledFlag.Name) {
+		cfg.Enabled = ctx.Bool(BundlePoolEnabledFlag.Name)
+	}
+	if ctx.IsSet(BundlePoolSlotsFlag.Name) {
+		cfg.Slots = ctx.Uint64(BundlePoolSlotsFlag.Name)
+	}
+	if ctx.IsSet(BundlePoolMaxTxsFlag.Name) {
+		cfg.MaxTxs = ctx.Uint64(BundlePoolMaxTxsFlag.Name)
+	}
+	if ctx.IsSet(BundlePoolAheadFlag.Name) {
+		cfg.Ahead = ctx.Uint64(BundlePoolAheadFlag.Name)
+	}
+}
+
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.Bool(MiningEnabledFlag.Name) {
 		log.Warn("The flag --mine is deprecated and will be removed")
@@ -1566,6 +1716,13 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerRecommitIntervalFlag.Name) {
 		cfg.Recommit = ctx.Duration(MinerRecommitIntervalFlag.Name)
 	}
+	if ctx.IsSet(MinerOrderingFlag.Name) {
+		ordering := ctx.String(MinerOrderingFlag.Name)
+		if !slices.Contains(miner.Orderings, ordering) {
+			Fatalf("Invalid transaction ordering %q, supported: %v", ordering, miner.Orderings)
+		}
+		cfg.Ordering = ordering
+	}
 	if ctx.IsSet(MinerNewPayloadTimeoutFlag.Name) {
 		log.Warn("The flag --miner.newpayload-timeout is deprecated and will be removed, please use --miner.recommit")
 		cfg.Recommit = ctx.Duration(MinerNewPayloadTimeoutFlag.Name)
@@ -1651,6 +1808,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEtherbase(ctx, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	setBundlePool(ctx, &cfg.BundlePool)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
 	setLes(ctx, cfg)
@@ -1712,6 +1870,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
+	if ctx.IsSet(SnapServeBudgetFlag.Name) {
+		cfg.SnapServeBudget = ctx.Uint64(SnapServeBudgetFlag.Name)
+	}
+	if ctx.IsSet(HistoryArchiveFlag.Name) {
+		cfg.HistoryArchive = ctx.String(HistoryArchiveFlag.Name)
+	}
+	if ctx.IsSet(HistoryExpiryFlag.Name) {
+		cfg.HistoryExpiry = ctx.Uint64(HistoryExpiryFlag.Name)
+	}
 	// Parse transaction history flag, if user is still using legacy config
 	// file with 'TxLookupLimit' configured, copy the value to 'TransactionHistory'.
 	if cfg.TransactionHistory == ethconfig.Defaults.TransactionHistory && cfg.TxLookupLimit != ethconfig.Defaults.TxLookupLimit {
@@ -1724,6 +1891,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Warn("The flag --txlookuplimit is deprecated and will be removed, please use --history.transactions")
 		cfg.TransactionHistory = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(LogIndexFlag.Name) {
+		cfg.LogIndex = ctx.Bool(LogIndexFlag.Name)
+	}
+	if ctx.IsSet(LogHistoryFlag.Name) {
+		cfg.LogHistory = ctx.Uint64(LogHistoryFlag.Name)
+	}
+	if ctx.IsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.Bool(TraceIndexFlag.Name)
+	}
 	if ctx.String(GCModeFlag.Name) == "archive" && cfg.TransactionHistory != 0 {
 		cfg.TransactionHistory = 0
 		log.Warn("Disabled transaction unindexing for archive node")
@@ -1783,6 +1959,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(RPCTraceFilterRangeLimitFlag.Name) {
+		cfg.RPCTraceFilterRangeLimit = ctx.Uint64(RPCTraceFilterRangeLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCTraceFilterResultLimitFlag.Name) {
+		cfg.RPCTraceFilterResultLimit = ctx.Uint64(RPCTraceFilterResultLimitFlag.Name)
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -43,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/internal/syncx"
 	"github.com/ethereum/go-ethereum/internal/version"
 	"github.com/ethereum/go-ethereum/log"
@@ -140,6 +141,8 @@ type CacheConfig struct {
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
+	HistoryExpiry       uint64        // Block number below which bodies and receipts are pruned from the ancient store (0 = keep all)
+	HistoryArchive      *era.History  // Era1 archive to serve expired bodies and receipts from, owned by the caller
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
@@ -219,6 +222,7 @@ type BlockChain struct {
 	triedb        *triedb.Database                 // The database handler for maintaining trie nodes.
 	stateCache    state.Database                   // State database to reuse between imports (contains state cache)
 	txIndexer     *txIndexer                       // Transaction indexer, might be nil if not enabled
+	history       *era.History                     // Era1 archive serving expired chain history, might be nil if not configured
 
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
@@ -464,6 +468,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if txLookupLimit != nil {
 		bc.txIndexer = newTxIndexer(*txLookupLimit, bc)
 	}
+	// Start expiring history if it's enabled.
+	bc.history = bc.cacheConfig.HistoryArchive
+	if bc.cacheConfig.HistoryExpiry > 0 {
+		bc.wg.Add(1)
+		go bc.historyExpiryLoop()
+	}
 	return bc, nil
 }
 
@@ -2389,6 +2399,9 @@ func (bc *BlockChain) InsertBlockWithoutSetHead(block *types.Block) error {
 	}
 	defer bc.chainmu.Unlock()
 
+	bc.blockProcFeed.Send(true)
+	defer bc.blockProcFeed.Send(false)
+
 	_, err := bc.insertChain(types.Blocks{block}, false)
 	return err
 }
@@ -2539,6 +2552,13 @@ func (bc *BlockChain) SetBlockValidatorAndProcessorForTesting(v Validator, p Pro
 	bc.processor = p
 }
 
+// SetStateDatabase replaces the caching database underpinning the blockchain,
+// e.g. with one serving state not available locally, like that of a forked chain.
+// This method is unsafe and should only be used before block import starts.
+func (bc *BlockChain) SetStateDatabase(db state.Database) {
+	bc.stateCache = db
+}
+
 // SetTrieFlushInterval configures how often in-memory tries are persisted to disk.
 // The interval is in terms of block processing time, not wall clock.
 // It is thread-safe and can be called repeatedly without side effects.
@@ -106,7 +106,9 @@ func (bc *BlockChain) GetBody(hash common.Hash) *types.Body {
 	}
 	body := rawdb.ReadBody(bc.db, hash, *number)
 	if body == nil {
-		return nil
+		if body = bc.readExpiredBody(hash, *number); body == nil {
+			return nil
+		}
 	}
 	// Cache the found body for next time and return
 	bc.bodyCache.Add(hash, body)
@@ -126,7 +128,14 @@ func (bc *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 	}
 	body := rawdb.ReadBodyRLP(bc.db, hash, *number)
 	if len(body) == 0 {
-		return nil
+		expired := bc.readExpiredBody(hash, *number)
+		if expired == nil {
+			return nil
+		}
+		var err error
+		if body, err = rlp.EncodeToBytes(expired); err != nil {
+			return nil
+		}
 	}
 	// Cache the found body for next time and return
 	bc.bodyRLPCache.Add(hash, body)
@@ -164,7 +173,11 @@ func (bc *BlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	}
 	block := rawdb.ReadBlock(bc.db, hash, number)
 	if block == nil {
-		return nil
+		body := bc.readExpiredBody(hash, number)
+		if body == nil {
+			return nil
+		}
+		block = types.NewBlockWithHeader(bc.GetHeader(hash, number)).WithBody(*body)
 	}
 	// Cache the found block for next time and return
 	bc.blockCache.Add(block.Hash(), block)
@@ -224,7 +237,9 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	}
 	receipts := rawdb.ReadReceipts(bc.db, hash, *number, header.Time, bc.chainConfig)
 	if receipts == nil {
-		return nil
+		if receipts = bc.readExpiredReceipts(header); receipts == nil {
+			return nil
+		}
 	}
 	bc.receiptsCache.Add(hash, receipts)
 	return receipts
@@ -264,7 +279,8 @@ func (bc *BlockChain) GetAncestor(hash common.Hash, number, ancestor uint64, max
 //
 // A null will be returned in the transaction is not found and background
 // transaction indexing is already finished. The transaction is not existent
-// from the node's perspective.
+// from the node's perspective. If the index is cut short by the expired chain
+// history though, ErrHistoryPruned is returned instead.
 func (bc *BlockChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLookupEntry, *types.Transaction, error) {
 	bc.txLookupLock.RLock()
 	defer bc.txLookupLock.RUnlock()
@@ -273,7 +289,28 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLoo
 	if item, exist := bc.txLookupCache.Get(hash); exist {
 		return item.lookup, item.transaction, nil
 	}
-	tx, blockHash, blockNumber, txIndex := rawdb.ReadTransaction(bc.db, hash)
+	var (
+		tx          *types.Transaction
+		blockHash   common.Hash
+		blockNumber uint64
+		txIndex     uint64
+	)
+	if number := rawdb.ReadTxLookupEntry(bc.db, hash); number != nil && bc.HistoryPruned(*number) {
+		// The transaction is in the expired chain history, try the archive
+		blockHash, blockNumber = rawdb.ReadCanonicalHash(bc.db, *number), *number
+		body := bc.readExpiredBody(blockHash, blockNumber)
+		if body == nil {
+			return nil, nil, ErrHistoryPruned
+		}
+		for i, btx := range body.Transactions {
+			if btx.Hash() == hash {
+				tx, txIndex = btx, uint64(i)
+				break
+			}
+		}
+	} else {
+		tx, blockHash, blockNumber, txIndex = rawdb.ReadTransaction(bc.db, hash)
+	}
 	if tx == nil {
 		progress, err := bc.TxIndexProgress()
 		if err != nil {
@@ -284,6 +321,11 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLoo
 		if !progress.Done() {
 			return nil, nil, errors.New("transaction indexing still in progress")
 		}
+		// The index stops at the expired chain history, the transaction might
+		// be contained in it.
+		if tail := rawdb.ReadTxIndexTail(bc.db); tail != nil && *tail > 0 && bc.HistoryPruned(*tail-1) {
+			return nil, nil, ErrHistoryPruned
+		}
 		// The transaction is already indexed, the transaction is either
 		// not existent or not in the range of index, returning null.
 		return nil, nil, nil
@@ -359,6 +401,13 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache, bc.snaps)
 }
 
+// HistoricState returns a historic state specified by the given root. The state
+// is resolved by applying the retained state histories, therefore it's only
+// supported in path-based scheme. Live states should be accessed via StateAt.
+func (bc *BlockChain) HistoricState(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, state.NewHistoricDatabase(bc.stateCache), nil)
+}
+
 // Config retrieves the chain's fork configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
 
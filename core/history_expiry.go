@@ -0,0 +1,137 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrHistoryPruned is returned if the body or receipts of a block are requested
+// which were expired from the local database and no history archive is able to
+// serve them.
+var ErrHistoryPruned = errors.New("history pruned")
+
+// historyExpiryInterval is the frequency to check whether frozen chain segments
+// crossed the history expiry boundary and can be pruned.
+const historyExpiryInterval = time.Minute
+
+// HistoryPruned reports whether the body and receipts of the canonical block
+// with the given number were expired from the local database.
+func (bc *BlockChain) HistoryPruned(number uint64) bool {
+	tail, err := bc.db.Tail()
+	return err == nil && number < tail
+}
+
+// readExpiredBody retrieves the body of an expired block from the history
+// archive, returning nil if it's unavailable.
+func (bc *BlockChain) readExpiredBody(hash common.Hash, number uint64) *types.Body {
+	if bc.history == nil || !bc.HistoryPruned(number) {
+		return nil
+	}
+	header := bc.GetHeader(hash, number)
+	if header == nil {
+		return nil
+	}
+	body, err := bc.history.Body(header)
+	if err != nil {
+		log.Debug("Failed to serve expired block body", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return body
+}
+
+// readExpiredReceipts retrieves the receipts of an expired block from the history
+// archive, deriving all their metadata fields. Nil is returned if the receipts
+// are unavailable.
+func (bc *BlockChain) readExpiredReceipts(header *types.Header) types.Receipts {
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	body := bc.readExpiredBody(hash, number)
+	if body == nil {
+		return nil
+	}
+	receipts, err := bc.history.Receipts(header)
+	if err != nil {
+		log.Debug("Failed to serve expired block receipts", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	var blobGasPrice *big.Int
+	if header.ExcessBlobGas != nil {
+		blobGasPrice = eip4844.CalcBlobFee(*header.ExcessBlobGas)
+	}
+	if err := receipts.DeriveFields(bc.chainConfig, hash, number, header.Time, header.BaseFee, blobGasPrice, body.Transactions); err != nil {
+		log.Error("Failed to derive expired block receipts fields", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return receipts
+}
+
+// historyExpiryLoop periodically prunes the chain history crossing the configured
+// expiry boundary from the ancient store.
+func (bc *BlockChain) historyExpiryLoop() {
+	defer bc.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			// Failures are retried on the next tick, the node can keep running
+			// with a bit more history than configured.
+			if err := bc.expireHistory(); err != nil {
+				log.Error("Failed to expire chain history", "err", err)
+			}
+			timer.Reset(historyExpiryInterval)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// expireHistory prunes the block bodies and receipts below the configured expiry
+// boundary from the ancient store. Blocks are only pruned once frozen, headers
+// are always retained.
+func (bc *BlockChain) expireHistory() error {
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return err
+	}
+	tail, err := bc.db.Tail()
+	if err != nil {
+		return err
+	}
+	limit := min(bc.cacheConfig.HistoryExpiry, frozen)
+	if limit <= tail {
+		return nil
+	}
+	start := time.Now()
+	if _, err := bc.db.TruncateTail(limit); err != nil {
+		return err
+	}
+	log.Info("Expired chain history", "from", tail, "to", limit-1, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
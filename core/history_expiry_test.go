@@ -0,0 +1,209 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that expired chain history is pruned from the ancient store, and that
+// it's served from the era1 archive if available, or reported as pruned if not.
+func TestHistoryExpiry(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+
+		archived = uint64(16) // Blocks [0, archived) are available in the era1 archive
+		expiry   = uint64(32) // Blocks [0, expiry) are pruned from the freezer
+		frozen   = uint64(48) // Blocks [0, frozen] are moved into the freezer
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 64, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	// Write the first few blocks into an era1 archive
+	dir := t.TempDir()
+	f, err := os.CreateTemp(dir, "tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		builder = era.NewBuilder(f)
+		genesis = gspec.ToBlock()
+		td      = new(big.Int).Set(genesis.Difficulty())
+	)
+	if err := builder.Add(genesis, nil, new(big.Int).Set(td)); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < archived-1; i++ {
+		td.Add(td, blocks[i].Difficulty())
+		if err := builder.Add(blocks[i], receipts[i], new(big.Int).Set(td)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.Rename(f.Name(), filepath.Join(dir, era.Filename("mainnet", 0, root))); err != nil {
+		t.Fatal(err)
+	}
+	// Import the chain into the freezer and expire the old history
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+
+	history, err := era.NewHistory(dir, "mainnet")
+	if err != nil {
+		t.Fatalf("failed to open history archive: %v", err)
+	}
+	defer history.Close()
+
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.HistoryArchive = history
+
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, frozen); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	for _, block := range blocks {
+		rawdb.WriteTxLookupEntriesByBlock(db, block)
+	}
+	chain.cacheConfig.HistoryExpiry = expiry
+	if err := chain.expireHistory(); err != nil {
+		t.Fatalf("failed to expire history: %v", err)
+	}
+	if tail, _ := db.Tail(); tail != expiry {
+		t.Fatalf("freezer tail mismatch: have %d, want %d", tail, expiry)
+	}
+	// Check that the chain data is retrievable according to its expiry status
+	for i, block := range blocks {
+		var (
+			number = block.NumberU64()
+			hash   = block.Hash()
+			txhash = block.Transactions()[0].Hash()
+		)
+		if pruned := chain.HistoryPruned(number); pruned != (number < expiry) {
+			t.Errorf("block %d: pruned status mismatch: have %v, want %v", number, pruned, number < expiry)
+		}
+		if header := chain.GetHeaderByNumber(number); header == nil || header.Hash() != hash {
+			t.Errorf("block %d: header unavailable", number)
+		}
+		haveBlock := chain.GetBlockByNumber(number)
+		_, tx, err := chain.GetTransactionLookup(txhash)
+
+		if number >= archived && number < expiry {
+			if haveBlock != nil {
+				t.Errorf("block %d: expired block served", number)
+			}
+			if chain.GetReceiptsByHash(hash) != nil {
+				t.Errorf("block %d: expired receipts served", number)
+			}
+			if !errors.Is(err, ErrHistoryPruned) {
+				t.Errorf("block %d: transaction lookup error mismatch: have %v, want %v", number, err, ErrHistoryPruned)
+			}
+			continue
+		}
+		if haveBlock == nil || haveBlock.Hash() != hash || haveBlock.TxHash() != block.TxHash() || len(haveBlock.Transactions()) != 1 {
+			t.Errorf("block %d: block unavailable or mismatching", number)
+		}
+		if err != nil || tx == nil || tx.Hash() != txhash {
+			t.Errorf("block %d: transaction lookup failed: %v", number, err)
+		}
+		haveReceipts := chain.GetReceiptsByHash(hash)
+		if len(haveReceipts) != 1 || types.DeriveSha(haveReceipts, trie.NewStackTrie(nil)) != block.ReceiptHash() {
+			t.Errorf("block %d: receipts unavailable or mismatching", number)
+			continue
+		}
+		if haveReceipts[0].BlockNumber.Uint64() != number || haveReceipts[0].TxHash != txhash || haveReceipts[0].GasUsed != receipts[i][0].GasUsed {
+			t.Errorf("block %d: receipt metadata mismatch", number)
+		}
+	}
+	// The genesis block is retained in the key-value store
+	if block := chain.GetBlockByNumber(0); block == nil || block.Hash() != genesis.Hash() {
+		t.Errorf("genesis block unavailable")
+	}
+	// Reindex the transactions from scratch, checking that the indexer stops at
+	// the expired history and that its transactions are reported as pruned
+	for _, block := range blocks {
+		rawdb.DeleteTxLookupEntry(db, block.Transactions()[0].Hash())
+	}
+	chain.txLookupCache.Purge()
+	rawdb.WriteHeadBlockHash(db, blocks[len(blocks)-1].Hash())
+	chain.txIndexer = newTxIndexer(0, chain)
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if progress, err := chain.TxIndexProgress(); err == nil && progress.Done() {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("transaction indexing did not finish")
+		}
+	}
+	if tail := rawdb.ReadTxIndexTail(db); tail == nil || *tail != expiry {
+		t.Fatalf("tx index tail mismatch: have %v, want %d", tail, expiry)
+	}
+	for _, block := range blocks {
+		_, tx, err := chain.GetTransactionLookup(block.Transactions()[0].Hash())
+		if block.NumberU64() < expiry {
+			if !errors.Is(err, ErrHistoryPruned) {
+				t.Errorf("block %d: transaction lookup error mismatch: have %v, want %v", block.NumberU64(), err, ErrHistoryPruned)
+			}
+		} else if err != nil || tx == nil {
+			t.Errorf("block %d: transaction lookup failed: %v", block.NumberU64(), err)
+		}
+	}
+}
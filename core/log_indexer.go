@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// logIndexThrottling is the time to wait between processing two consecutive
+	// index sections. It's useful during chain upgrades to prevent disk overload.
+	logIndexThrottling = 100 * time.Millisecond
+)
+
+// logIndexEntry is the identifier of a log index entry, which is either the
+// address of the log emitter or a topic at a specific position.
+type logIndexEntry struct {
+	kind byte
+	key  string
+}
+
+// LogIndexer implements a core.ChainIndexer, building up a persistent index of
+// the emitted logs keyed by the emitter address and the positional topics. Each
+// index entry holds the numbers of the blocks in the section in which the logs
+// matching the entry were emitted, permitting fast filtering over wide ranges.
+type LogIndexer struct {
+	size    uint64                     // section size to generate the log index for
+	history uint64                     // number of recent blocks to index logs for, 0 means entire chain
+	db      ethdb.Database             // database instance to write index data and metadata into
+	section uint64                     // section number being processed currently
+	skip    bool                       // flag whether the section is out of the history window
+	entries map[logIndexEntry][]uint64 // index entries of the section being processed
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain. Logs of the blocks older than the specified history limit
+// are unindexed, 0 means the logs of the entire chain are indexed.
+func NewLogIndexer(db ethdb.Database, size, confirms, history uint64) *ChainIndexer {
+	backend := &LogIndexer{
+		db:      db,
+		size:    size,
+		history: history,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexPrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, logIndexThrottling, "logindex")
+}
+
+// outdated returns whether the given section is entirely out of the history
+// window, measured from the current chain head.
+func (b *LogIndexer) outdated(section uint64) bool {
+	if b.history == 0 {
+		return false
+	}
+	number := rawdb.ReadHeaderNumber(b.db, rawdb.ReadHeadHeaderHash(b.db))
+	if number == nil || *number < b.history {
+		return false
+	}
+	return (section+1)*b.size <= *number-b.history
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+func (b *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.section, b.skip = section, b.outdated(section)
+	b.entries = make(map[logIndexEntry][]uint64)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the logs emitted in the
+// given block into the index.
+func (b *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	// Short circuit if the section is not indexed or the block has no logs.
+	if b.skip || header.Bloom == (types.Bloom{}) {
+		return nil
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+	receipts := rawdb.ReadRawReceipts(b.db, hash, number)
+	if receipts == nil {
+		return fmt.Errorf("receipts of block %d (%x) not found", number, hash)
+	}
+	add := func(kind byte, key []byte) {
+		entry := logIndexEntry{kind: kind, key: string(key)}
+		if list := b.entries[entry]; len(list) == 0 || list[len(list)-1] != number {
+			b.entries[entry] = append(list, number)
+		}
+	}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			add(rawdb.LogIndexAddress, log.Address.Bytes())
+			for i, topic := range log.Topics {
+				add(rawdb.LogIndexTopic+byte(i), topic.Bytes())
+			}
+		}
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the log index section
+// and writing it out into the database. The stale entries of the section left
+// by a reorg are removed beforehand.
+func (b *LogIndexer) Commit() error {
+	// The section is out of the history window, move the tail over it.
+	if b.skip {
+		return b.Prune((b.section + 1) * b.size)
+	}
+	rawdb.DeleteLogIndex(b.db, b.section, b.section+1)
+
+	batch := b.db.NewBatch()
+	for entry, numbers := range b.entries {
+		rawdb.WriteLogIndex(batch, b.section, entry.kind, []byte(entry.key), numbers)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Unindex the sections which are out of the history window.
+	if b.history != 0 && (b.section+1)*b.size > b.history {
+		return b.Prune((b.section+1)*b.size - b.history)
+	}
+	return nil
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the log index of the
+// sections entirely below the given block number.
+func (b *LogIndexer) Prune(threshold uint64) error {
+	var (
+		tail  = rawdb.ReadLogIndexTail(b.db)
+		ntail = threshold / b.size
+	)
+	if ntail <= tail {
+		return nil
+	}
+	// Move the tail first for not serving the sections being removed.
+	rawdb.WriteLogIndexTail(b.db, ntail)
+	rawdb.DeleteLogIndex(b.db, tail, ntail)
+
+	log.Debug("Unindexed logs", "from", tail*b.size, "to", ntail*b.size)
+	return nil
+}
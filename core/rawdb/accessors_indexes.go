@@ -18,6 +18,8 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +181,179 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// Kinds of the log index entries. The topic entries are keyed by the position
+// of the topic as well, so the kind of a topic at position i is LogIndexTopic+i.
+const (
+	LogIndexAddress byte = iota // Entry keyed by the address of the log emitter
+	LogIndexTopic               // Entry keyed by the log topic at position 0
+)
+
+// readBlockNumbers retrieves the delta encoded block numbers stored under the
+// given key. Nil is returned if the entry is not present.
+func readBlockNumbers(db ethdb.KeyValueReader, key []byte) ([]uint64, error) {
+	blob, err := db.Get(key)
+	if err != nil {
+		return nil, nil // not present
+	}
+	var (
+		last    uint64
+		numbers []uint64
+	)
+	for len(blob) > 0 {
+		delta, n := binary.Uvarint(blob)
+		if n <= 0 {
+			return nil, errors.New("corrupted block number list")
+		}
+		last += delta
+		numbers = append(numbers, last)
+		blob = blob[n:]
+	}
+	return numbers, nil
+}
+
+// encodeBlockNumbers delta encodes the given sorted block numbers.
+func encodeBlockNumbers(numbers []uint64) []byte {
+	var (
+		last uint64
+		blob []byte
+	)
+	for _, number := range numbers {
+		blob = binary.AppendUvarint(blob, number-last)
+		last = number
+	}
+	return blob
+}
+
+// deleteSections removes all the entries with the given prefix belonging to
+// the sections in the range of [from, to), where the section number is encoded
+// right after the prefix.
+func deleteSections(db ethdb.Database, prefix []byte, from uint64, to uint64) error {
+	batch := db.NewBatch()
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	end := append(common.CopyBytes(prefix), encodeBlockNumber(to)...)
+	for it.Next() {
+		if bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		batch.Delete(it.Key())
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	return batch.Write()
+}
+
+// ReadLogIndex retrieves the numbers of the blocks within the given section in
+// which the logs with the specified address or topic were emitted.
+func ReadLogIndex(db ethdb.KeyValueReader, section uint64, kind byte, key []byte) ([]uint64, error) {
+	return readBlockNumbers(db, logIndexKey(section, kind, key))
+}
+
+// WriteLogIndex stores the numbers of the blocks within the given section in
+// which the logs with the specified address or topic were emitted. The block
+// numbers are expected to be sorted in ascending order.
+func WriteLogIndex(db ethdb.KeyValueWriter, section uint64, kind byte, key []byte, numbers []uint64) {
+	if err := db.Put(logIndexKey(section, kind, key), encodeBlockNumbers(numbers)); err != nil {
+		log.Crit("Failed to store log index", "err", err)
+	}
+}
+
+// DeleteLogIndex removes all log index entries belonging to the sections in
+// the range of [from, to).
+func DeleteLogIndex(db ethdb.Database, from uint64, to uint64) {
+	if err := deleteSections(db, logIndexPrefix, from, to); err != nil {
+		log.Crit("Failed to delete log index", "err", err)
+	}
+}
+
+// ReadLogIndexTail retrieves the number of the oldest section whose logs have
+// been indexed.
+func ReadLogIndexTail(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(logIndexTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteLogIndexTail stores the number of the oldest section whose logs have
+// been indexed into database.
+func WriteLogIndexTail(db ethdb.KeyValueWriter, section uint64) {
+	if err := db.Put(logIndexTailKey, encodeBlockNumber(section)); err != nil {
+		log.Crit("Failed to store the log index tail", "err", err)
+	}
+}
+
+// Kinds of the trace index entries.
+const (
+	TraceIndexFrom byte = iota // Entry keyed by the sender of a call frame
+	TraceIndexTo               // Entry keyed by the recipient of a call frame
+
+	traceIndexUnindexed // Marker of a section which couldn't be indexed
+)
+
+// ReadTraceIndex retrieves the numbers of the blocks within the given section
+// in which the specified address was the sender or recipient of a call frame.
+func ReadTraceIndex(db ethdb.KeyValueReader, section uint64, kind byte, address common.Address) ([]uint64, error) {
+	return readBlockNumbers(db, traceIndexKey(section, kind, address))
+}
+
+// WriteTraceIndex stores the numbers of the blocks within the given section in
+// which the specified address was the sender or recipient of a call frame. The
+// block numbers are expected to be sorted in ascending order.
+func WriteTraceIndex(db ethdb.KeyValueWriter, section uint64, kind byte, address common.Address, numbers []uint64) {
+	if err := db.Put(traceIndexKey(section, kind, address), encodeBlockNumbers(numbers)); err != nil {
+		log.Crit("Failed to store trace index", "err", err)
+	}
+}
+
+// DeleteTraceIndex removes all trace index entries belonging to the sections
+// in the range of [from, to).
+func DeleteTraceIndex(db ethdb.Database, from uint64, to uint64) {
+	if err := deleteSections(db, traceIndexPrefix, from, to); err != nil {
+		log.Crit("Failed to delete trace index", "err", err)
+	}
+}
+
+// ReadTraceIndexUnindexed reports whether the given section is marked as one
+// which couldn't be indexed, and must be served without the index.
+func ReadTraceIndexUnindexed(db ethdb.KeyValueReader, section uint64) bool {
+	has, _ := db.Has(traceIndexKey(section, traceIndexUnindexed, common.Address{}))
+	return has
+}
+
+// WriteTraceIndexUnindexed marks the given section as one which couldn't be
+// indexed. The marker is removed along with the section by DeleteTraceIndex.
+func WriteTraceIndexUnindexed(db ethdb.KeyValueWriter, section uint64) {
+	if err := db.Put(traceIndexKey(section, traceIndexUnindexed, common.Address{}), []byte{0x01}); err != nil {
+		log.Crit("Failed to store trace index marker", "err", err)
+	}
+}
+
+// ReadTraceIndexTail retrieves the number of the oldest section whose call
+// traces have been indexed. Nil is returned if the index is not initialized.
+func ReadTraceIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(traceIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	section := binary.BigEndian.Uint64(data)
+	return &section
+}
+
+// WriteTraceIndexTail stores the number of the oldest section whose call traces
+// have been indexed into database.
+func WriteTraceIndexTail(db ethdb.KeyValueWriter, section uint64) {
+	if err := db.Put(traceIndexTailKey, encodeBlockNumber(section)); err != nil {
+		log.Crit("Failed to store the trace index tail", "err", err)
+	}
+}
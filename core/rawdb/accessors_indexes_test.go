@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"math/big"
+	"slices"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -154,3 +155,42 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.SepoliaGenesisHash, true)
 }
+
+func TestLogIndex(t *testing.T) {
+	var (
+		db      = NewMemoryDatabase()
+		addr    = common.HexToAddress("0xdeadbeef")
+		numbers = []uint64{4096, 4097, 4200, 8191}
+	)
+	for s := uint64(1); s < 4; s++ {
+		WriteLogIndex(db, s, LogIndexAddress, addr.Bytes(), numbers)
+	}
+	check := func(section uint64, kind byte, exist bool) {
+		got, err := ReadLogIndex(db, section, kind, addr.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to read log index: %v", err)
+		}
+		if exist && !slices.Equal(got, numbers) {
+			t.Fatalf("Log index mismatch, want: %v, got: %v", numbers, got)
+		}
+		if !exist && len(got) > 0 {
+			t.Fatalf("Log index should be removed")
+		}
+	}
+	check(1, LogIndexAddress, true)
+	check(1, LogIndexTopic, false)
+
+	// Delete the sections in the middle, the others shouldn't be affected.
+	DeleteLogIndex(db, 2, 3)
+	check(1, LogIndexAddress, true)
+	check(2, LogIndexAddress, false)
+	check(3, LogIndexAddress, true)
+
+	if tail := ReadLogIndexTail(db); tail != 0 {
+		t.Fatalf("Unexpected log index tail %d", tail)
+	}
+	WriteLogIndexTail(db, 3)
+	if tail := ReadLogIndexTail(db); tail != 3 {
+		t.Fatalf("Log index tail mismatch, want: 3, got: %d", tail)
+	}
+}
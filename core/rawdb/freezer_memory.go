@@ -210,23 +210,31 @@ func (b *memoryBatch) commit(freezer *MemoryFreezer) (items uint64, writeSize in
 // interface and can be used along with ephemeral key-value store.
 type MemoryFreezer struct {
 	items      uint64                  // Number of items stored
-	tail       uint64                  // Number of the first stored item in the freezer
+	tail       uint64                  // Number of the first stored item in the prunable tables
 	readonly   bool                    // Flag if the freezer is only for reading
 	lock       sync.RWMutex            // Lock to protect fields
 	tables     map[string]*memoryTable // Tables for storing everything
+	prunable   map[string]*memoryTable // Subset of the tables truncated by TruncateTail
 	writeBatch *memoryBatch            // Pre-allocated write batch
 }
 
 // NewMemoryFreezer initializes an in-memory freezer instance.
-func NewMemoryFreezer(readonly bool, tableName map[string]bool) *MemoryFreezer {
-	tables := make(map[string]*memoryTable)
-	for name := range tableName {
+func NewMemoryFreezer(readonly bool, tableName map[string]freezerTableConfig) *MemoryFreezer {
+	var (
+		tables   = make(map[string]*memoryTable)
+		prunable = make(map[string]*memoryTable)
+	)
+	for name, config := range tableName {
 		tables[name] = newMemoryTable(name)
+		if config.prunable {
+			prunable[name] = tables[name]
+		}
 	}
 	return &MemoryFreezer{
 		writeBatch: newMemoryBatch(),
 		readonly:   readonly,
 		tables:     tables,
+		prunable:   prunable,
 	}
 }
 
@@ -282,8 +290,8 @@ func (f *MemoryFreezer) Ancients() (uint64, error) {
 	return f.items, nil
 }
 
-// Tail returns the number of first stored item in the freezer.
-// This number can also be interpreted as the total deleted item numbers.
+// Tail returns the number of first stored item in the prunable tables of the
+// freezer. This number can also be interpreted as the total deleted item numbers.
 func (f *MemoryFreezer) Tail() (uint64, error) {
 	f.lock.RLock()
 	defer f.lock.RUnlock()
@@ -369,6 +377,7 @@ func (f *MemoryFreezer) TruncateHead(items uint64) (uint64, error) {
 }
 
 // TruncateTail discards any recent data below the provided threshold number.
+// Only the tables configured as prunable are truncated.
 func (f *MemoryFreezer) TruncateTail(tail uint64) (uint64, error) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -380,7 +389,7 @@ func (f *MemoryFreezer) TruncateTail(tail uint64) (uint64, error) {
 	if old >= tail {
 		return old, nil
 	}
-	for _, table := range f.tables {
+	for _, table := range f.prunable {
 		if err := table.truncateTail(tail); err != nil {
 			return 0, err
 		}
@@ -408,6 +417,7 @@ func (f *MemoryFreezer) Close() error {
 	defer f.lock.Unlock()
 
 	f.tables = nil
+	f.prunable = nil
 	f.writeBatch = nil
 	return nil
 }
@@ -418,11 +428,17 @@ func (f *MemoryFreezer) Reset() error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	tables := make(map[string]*memoryTable)
+	var (
+		tables   = make(map[string]*memoryTable)
+		prunable = make(map[string]*memoryTable)
+	)
 	for name := range f.tables {
 		tables[name] = newMemoryTable(name)
+		if f.prunable[name] != nil {
+			prunable[name] = tables[name]
+		}
 	}
-	f.tables = tables
+	f.tables, f.prunable = tables, prunable
 	f.items, f.tail = 0, 0
 	return nil
 }
@@ -79,6 +79,12 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// logIndexTailKey tracks the oldest section whose logs have been indexed.
+	logIndexTailKey = []byte("LogIndexTail")
+
+	// traceIndexTailKey tracks the oldest section whose call traces have been indexed.
+	traceIndexTailKey = []byte("TraceIndexTail")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	// This flag is deprecated, it's kept to avoid reporting errors when inspect
 	// database.
@@ -116,6 +122,8 @@ var (
 	TrieNodeAccountPrefix = []byte("A") // TrieNodeAccountPrefix + hexPath -> trie node
 	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
+	logIndexPrefix        = []byte("g") // logIndexPrefix + section (uint64 big endian) + kind + key -> block numbers
+	traceIndexPrefix      = []byte("y") // traceIndexPrefix + section (uint64 big endian) + kind + address -> block numbers
 
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
@@ -124,6 +132,12 @@ var (
 	// BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 	BloomBitsIndexPrefix = []byte("iB")
 
+	// LogIndexPrefix is the data table of a chain indexer to track its progress
+	LogIndexPrefix = []byte("iL")
+
+	// TraceIndexPrefix is the data table of a chain indexer to track its progress
+	TraceIndexPrefix = []byte("iT")
+
 	ChtPrefix           = []byte("chtRootV2-") // ChtPrefix + chtNum (uint64 big endian) -> trie root hash
 	ChtTablePrefix      = []byte("cht-")
 	ChtIndexTablePrefix = []byte("chtIndexV2-")
@@ -226,6 +240,22 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// logIndexSectionKey = logIndexPrefix + section (uint64 big endian)
+func logIndexSectionKey(section uint64) []byte {
+	return append(logIndexPrefix, encodeBlockNumber(section)...)
+}
+
+// logIndexKey = logIndexPrefix + section (uint64 big endian) + kind + key
+func logIndexKey(section uint64, kind byte, key []byte) []byte {
+	return append(append(logIndexSectionKey(section), kind), key...)
+}
+
+// traceIndexKey = traceIndexPrefix + section (uint64 big endian) + kind + address
+func traceIndexKey(section uint64, kind byte, address common.Address) []byte {
+	key := append(append(traceIndexPrefix, encodeBlockNumber(section)...), kind)
+	return append(key, address.Bytes()...)
+}
+
 // skeletonHeaderKey = skeletonHeaderPrefix + num (uint64 big endian)
 func skeletonHeaderKey(number uint64) []byte {
 	return append(skeletonHeaderPrefix, encodeBlockNumber(number)...)
@@ -0,0 +1,217 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+// errHistoricTrie is returned by the unsupported operations of historic tries.
+var errHistoricTrie = errors.New("not supported by historic state")
+
+// historicDB is a state database which serves historic states from the state
+// histories maintained by the path-based trie database. It's only meant for
+// read-only usage, e.g. serving RPC requests at an old block.
+type historicDB struct {
+	Database
+}
+
+// NewHistoricDatabase wraps the given state database, opening all tries as read
+// only views of the historic state which are resolved with the state histories.
+// It's only supported with the path-based trie database.
+func NewHistoricDatabase(db Database) Database {
+	return &historicDB{Database: db}
+}
+
+// OpenTrie opens the main account trie of the historic state.
+func (db *historicDB) OpenTrie(root common.Hash) (Trie, error) {
+	reader, err := db.TrieDB().HistoricReader(root)
+	if err != nil {
+		return nil, err
+	}
+	return newHistoricTrie(reader, common.Address{}, false), nil
+}
+
+// OpenStorageTrie opens the storage trie of an account in the historic state.
+func (db *historicDB) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self Trie) (Trie, error) {
+	// Share the reader with the account trie if it's available.
+	if tr, ok := self.(*historicTrie); ok {
+		return newHistoricTrie(tr.reader, address, root == types.EmptyRootHash), nil
+	}
+	reader, err := db.TrieDB().HistoricReader(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newHistoricTrie(reader, address, root == types.EmptyRootHash), nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *historicDB) CopyTrie(t Trie) Trie {
+	if t, ok := t.(*historicTrie); ok {
+		return t.copy()
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// historicTrie implements the Trie interface on top of a historic state reader.
+// Mutations are only tracked in memory, allowing transactions to be executed
+// on top of the historic state, but the root hash is never recomputed.
+type historicTrie struct {
+	reader  *pathdb.HistoricalStateReader
+	address common.Address // The owner of the storage trie, empty for account trie
+	empty   bool           // Flag whether the storage trie is known to be empty
+
+	accounts map[common.Address]*types.StateAccount // Mutated accounts, nil means deleted
+	storages map[common.Hash][]byte                 // Mutated storage slots, keyed by raw slot key
+}
+
+// newHistoricTrie constructs a trie on top of the given historic state reader.
+func newHistoricTrie(reader *pathdb.HistoricalStateReader, address common.Address, empty bool) *historicTrie {
+	return &historicTrie{
+		reader:   reader,
+		address:  address,
+		empty:    empty,
+		accounts: make(map[common.Address]*types.StateAccount),
+		storages: make(map[common.Hash][]byte),
+	}
+}
+
+// GetKey implements Trie, preimages are not tracked by historic tries.
+func (t *historicTrie) GetKey([]byte) []byte {
+	return nil
+}
+
+// GetAccount implements Trie, retrieving the account at the historic state.
+func (t *historicTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	if account, ok := t.accounts[address]; ok {
+		if account == nil {
+			return nil, nil
+		}
+		return account.Copy(), nil
+	}
+	slim, err := t.reader.Account(address)
+	if err != nil || slim == nil {
+		return nil, err
+	}
+	account := &types.StateAccount{
+		Nonce:    slim.Nonce,
+		Balance:  slim.Balance,
+		Root:     types.EmptyRootHash,
+		CodeHash: types.EmptyCodeHash.Bytes(),
+	}
+	if len(slim.Root) != 0 {
+		account.Root = common.BytesToHash(slim.Root)
+	}
+	if len(slim.CodeHash) != 0 {
+		account.CodeHash = slim.CodeHash
+	}
+	return account, nil
+}
+
+// GetStorage implements Trie, retrieving the storage slot at the historic state.
+func (t *historicTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	if val, ok := t.storages[common.BytesToHash(key)]; ok {
+		return common.CopyBytes(val), nil
+	}
+	if t.empty {
+		return nil, nil
+	}
+	return t.reader.Storage(t.address, crypto.Keccak256Hash(key))
+}
+
+// UpdateAccount implements Trie, tracking the account mutation in memory.
+func (t *historicTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	t.accounts[address] = account.Copy()
+	return nil
+}
+
+// UpdateStorage implements Trie, tracking the storage mutation in memory.
+func (t *historicTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	t.storages[common.BytesToHash(key)] = common.CopyBytes(common.TrimLeftZeroes(value))
+	return nil
+}
+
+// DeleteAccount implements Trie, tracking the account deletion in memory.
+func (t *historicTrie) DeleteAccount(address common.Address) error {
+	t.accounts[address] = nil
+	return nil
+}
+
+// DeleteStorage implements Trie, tracking the storage deletion in memory.
+func (t *historicTrie) DeleteStorage(addr common.Address, key []byte) error {
+	t.storages[common.BytesToHash(key)] = nil
+	return nil
+}
+
+// UpdateContractCode implements Trie, it's a no-op for historic tries.
+func (t *historicTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return nil
+}
+
+// Hash implements Trie, returning the root of the historic state. Note the
+// in-memory mutations are not reflected in the returned hash.
+func (t *historicTrie) Hash() common.Hash {
+	return t.reader.Root()
+}
+
+// Commit implements Trie, historic tries can't be committed and the root of the
+// historic state is returned without any node.
+func (t *historicTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet) {
+	return t.reader.Root(), nil
+}
+
+// Witness implements Trie, historic tries don't track accessed trie nodes.
+func (t *historicTrie) Witness() map[string]struct{} {
+	return nil
+}
+
+// NodeIterator implements Trie, iteration is not supported by historic tries.
+func (t *historicTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errHistoricTrie
+}
+
+// Prove implements Trie, proofs are not supported by historic tries.
+func (t *historicTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errHistoricTrie
+}
+
+// IsVerkle implements Trie, historic tries are only supported for merkle tries.
+func (t *historicTrie) IsVerkle() bool {
+	return false
+}
+
+// copy returns an independent copy of the historic trie.
+func (t *historicTrie) copy() *historicTrie {
+	cpy := newHistoricTrie(t.reader, t.address, t.empty)
+	for addr, account := range t.accounts {
+		if account != nil {
+			account = account.Copy()
+		}
+		cpy.accounts[addr] = account
+	}
+	cpy.storages = maps.Clone(t.storages)
+	return cpy
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the historic states can be accessed via the state histories once
+// they are no longer maintained by the path-based trie database.
+func TestHistoricState(t *testing.T) {
+	var (
+		disk, _ = rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+		tdb     = triedb.NewDatabase(disk, &triedb.Config{PathDB: pathdb.Defaults})
+		sdb     = NewDatabaseWithNodeDB(disk, tdb)
+		root    = types.EmptyRootHash
+		roots   []common.Hash
+		addr    = common.HexToAddress("0xaaaa")
+		slot    = common.HexToHash("0x01")
+	)
+	defer tdb.Close()
+
+	for i := 1; i <= 5; i++ {
+		state, _ := New(root, sdb, nil)
+		state.SetBalance(addr, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+		state.SetState(addr, slot, common.BigToHash(big.NewInt(int64(i))))
+		if i == 3 {
+			state.SetState(addr, slot, common.Hash{})
+		}
+		var err error
+		root, err = state.Commit(uint64(i), false)
+		if err != nil {
+			t.Fatalf("Failed to commit state: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	// Flatten all the states into disk, leaving the older ones accessible
+	// only via the state histories.
+	if err := tdb.Commit(root, false); err != nil {
+		t.Fatalf("Failed to flatten states: %v", err)
+	}
+	if _, err := New(roots[0], sdb, nil); err == nil {
+		t.Fatal("Expected error for accessing historic state")
+	}
+	for i, root := range roots {
+		state, err := New(root, NewHistoricDatabase(sdb), nil)
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", i, err)
+		}
+		if balance := state.GetBalance(addr); balance.Uint64() != uint64(i+1) {
+			t.Fatalf("Unexpected balance %d, want: %d, got: %d", i, i+1, balance.Uint64())
+		}
+		want := common.BigToHash(big.NewInt(int64(i + 1)))
+		if i == 2 {
+			want = common.Hash{}
+		}
+		if got := state.GetState(addr, slot); got != want {
+			t.Fatalf("Unexpected slot %d, want: %x, got: %x", i, want, got)
+		}
+	}
+}
@@ -124,6 +124,29 @@ type extWitness struct {
 
 // extWitnessMarshalling defines the hex marshalling types for a witness.
 type extWitnessMarshalling struct {
+	Block *extBlock
 	Codes []hexutil.Bytes
 	State []hexutil.Bytes
 }
+
+// extBlock is a block marshalled into JSON as its hex encoded RLP, since blocks
+// have no JSON representation on their own.
+type extBlock types.Block
+
+// MarshalText implements encoding.TextMarshaler.
+func (b *extBlock) MarshalText() ([]byte, error) {
+	blob, err := rlp.EncodeToBytes((*types.Block)(b))
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(blob).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *extBlock) UnmarshalText(input []byte) error {
+	var blob hexutil.Bytes
+	if err := blob.UnmarshalText(input); err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(blob, (*types.Block)(b))
+}
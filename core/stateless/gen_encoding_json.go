@@ -15,13 +15,13 @@ var _ = (*extWitnessMarshalling)(nil)
 // MarshalJSON marshals as JSON.
 func (e extWitness) MarshalJSON() ([]byte, error) {
 	type extWitness struct {
-		Block   *types.Block    `json:"block"       gencodec:"required"`
+		Block   *extBlock       `json:"block"       gencodec:"required"`
 		Headers []*types.Header `json:"headers"       gencodec:"required"`
 		Codes   []hexutil.Bytes `json:"codes"`
 		State   []hexutil.Bytes `json:"state"`
 	}
 	var enc extWitness
-	enc.Block = e.Block
+	enc.Block = (*extBlock)(e.Block)
 	enc.Headers = e.Headers
 	if e.Codes != nil {
 		enc.Codes = make([]hexutil.Bytes, len(e.Codes))
@@ -41,7 +41,7 @@ func (e extWitness) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (e *extWitness) UnmarshalJSON(input []byte) error {
 	type extWitness struct {
-		Block   *types.Block    `json:"block"       gencodec:"required"`
+		Block   *extBlock       `json:"block"       gencodec:"required"`
 		Headers []*types.Header `json:"headers"       gencodec:"required"`
 		Codes   []hexutil.Bytes `json:"codes"`
 		State   []hexutil.Bytes `json:"state"`
@@ -53,7 +53,7 @@ func (e *extWitness) UnmarshalJSON(input []byte) error {
 	if dec.Block == nil {
 		return errors.New("missing required field 'block' for extWitness")
 	}
-	e.Block = dec.Block
+	e.Block = (*types.Block)(dec.Block)
 	if dec.Headers == nil {
 		return errors.New("missing required field 'headers' for extWitness")
 	}
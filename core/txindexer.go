@@ -86,18 +86,22 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 	// The tail flag is not existent, it means the node is just initialized
 	// and all blocks in the chain (part of them may from ancient store) are
 	// not indexed yet, index the chain according to the configured limit.
+	//
+	// Blocks below the history tail have had their bodies expired, so they can
+	// be neither indexed nor unindexed; clamp all ranges to it.
+	cutoff := indexer.historyTail()
 	if tail == nil {
 		from := uint64(0)
 		if indexer.limit != 0 && head >= indexer.limit {
 			from = head - indexer.limit + 1
 		}
-		rawdb.IndexTransactions(indexer.db, from, head+1, stop, true)
+		rawdb.IndexTransactions(indexer.db, max(from, cutoff), head+1, stop, true)
 		return
 	}
 	// The tail flag is existent (which means indexes in [tail, head] should be
 	// present), while the whole chain are requested for indexing.
 	if indexer.limit == 0 || head < indexer.limit {
-		if *tail > 0 {
+		if *tail > cutoff {
 			// It can happen when chain is rewound to a historical point which
 			// is even lower than the indexes tail, recap the indexing target
 			// to new head to avoid reading non-existent block bodies.
@@ -105,21 +109,33 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 			if end > head+1 {
 				end = head + 1
 			}
-			rawdb.IndexTransactions(indexer.db, 0, end, stop, true)
+			rawdb.IndexTransactions(indexer.db, cutoff, end, stop, true)
 		}
 		return
 	}
 	// The tail flag is existent, adjust the index range according to configured
 	// limit and the latest chain head.
-	if head-indexer.limit+1 < *tail {
+	if from := max(head-indexer.limit+1, cutoff); from < *tail {
 		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(indexer.db, head-indexer.limit+1, *tail, stop, true)
+		rawdb.IndexTransactions(indexer.db, from, *tail, stop, true)
 	} else {
-		// Unindex a part of stale indices and forward index tail to HEAD-limit
-		rawdb.UnindexTransactions(indexer.db, *tail, head-indexer.limit+1, stop, false)
+		// Unindex a part of stale indices and forward index tail to HEAD-limit.
+		// Indices of expired blocks are left in place, their lookups will report
+		// the history as pruned.
+		rawdb.UnindexTransactions(indexer.db, max(*tail, cutoff), from, stop, false)
 	}
 }
 
+// historyTail returns the number of the first block whose body is still stored
+// locally, i.e. the lowest block that can be indexed.
+func (indexer *txIndexer) historyTail() uint64 {
+	tail, err := indexer.db.Tail()
+	if err != nil {
+		return 0
+	}
+	return tail
+}
+
 // loop is the scheduler of the indexer, assigning indexing/unindexing tasks depending
 // on the received chain event.
 func (indexer *txIndexer) loop(chain *BlockChain) {
@@ -180,6 +196,10 @@ func (indexer *txIndexer) report(head uint64, tail *uint64) TxIndexProgress {
 	if indexer.limit == 0 || total > head {
 		total = head + 1 // genesis included
 	}
+	// Expired chain history can't be indexed, don't wait for it
+	if cutoff := indexer.historyTail(); cutoff > head+1-total {
+		total = head + 1 - min(cutoff, head+1)
+	}
 	var indexed uint64
 	if tail != nil {
 		indexed = head - *tail + 1
@@ -292,8 +292,9 @@ func newBlobTxMeta(id uint64, size uint32, tx *types.Transaction) *blobTxMeta {
 //     minimums will need to be done only starting at the swapped in/out nonce
 //     and leading up to the first no-change.
 type BlobPool struct {
-	config  Config                 // Pool configuration
-	reserve txpool.AddressReserver // Address reserver to ensure exclusivity across subpools
+	config    Config                 // Pool configuration
+	reserve   txpool.AddressReserver // Address reserver to ensure exclusivity across subpools
+	lifecycle *txpool.Lifecycle      // Lifecycle tracker to report the transaction events to
 
 	store  billy.Database // Persistent data store for the tx metadata and blobs
 	stored uint64         // Useful data size of all transactions on disk
@@ -339,6 +340,11 @@ func (p *BlobPool) Filter(tx *types.Transaction) bool {
 	return tx.Type() == types.BlobTxType
 }
 
+// SetLifecycle sets the lifecycle tracker to report the transaction events to.
+func (p *BlobPool) SetLifecycle(lifecycle *txpool.Lifecycle) {
+	p.lifecycle = lifecycle
+}
+
 // Init sets the gas price needed to keep a transaction in the pool and the chain
 // head to allow balance / nonce checks. The transaction journal will be loaded
 // from disk and filtered based on the provided starting settings.
@@ -533,6 +539,11 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.stored -= uint64(txs[i].size)
 			delete(p.lookup, txs[i].hash)
 
+			if gapped {
+				p.lifecycle.Dropped(txs[i].hash, addr, txpool.TxDropNonceGap)
+			} else {
+				p.lifecycle.Dropped(txs[i].hash, addr, txpool.TxDropStale)
+			}
 			// Included transactions blobs need to be moved to the limbo
 			if filled && inclusions != nil {
 				p.offload(addr, txs[i].nonce, txs[i].id, inclusions)
@@ -573,6 +584,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[0].costCap)
 			p.stored -= uint64(txs[0].size)
 			delete(p.lookup, txs[0].hash)
+			p.lifecycle.Dropped(txs[0].hash, addr, txpool.TxDropStale)
 
 			// Included transactions blobs need to be moved to the limbo
 			if inclusions != nil {
@@ -629,6 +641,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 			p.stored -= uint64(txs[i].size)
 			delete(p.lookup, txs[i].hash)
+			p.lifecycle.Dropped(txs[i].hash, addr, txpool.TxDropInvalid)
 
 			if err := p.store.Delete(id); err != nil {
 				log.Error("Failed to delete blob transaction", "from", addr, "id", id, "err", err)
@@ -651,6 +664,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[j].costCap)
 			p.stored -= uint64(txs[j].size)
 			delete(p.lookup, txs[j].hash)
+			p.lifecycle.Dropped(txs[j].hash, addr, txpool.TxDropNonceGap)
 		}
 		txs = txs[:i]
 
@@ -689,6 +703,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			delete(p.lookup, last.hash)
+			p.lifecycle.Dropped(last.hash, addr, txpool.TxDropUnpayable)
 		}
 		if len(txs) == 0 {
 			delete(p.index, addr)
@@ -729,6 +744,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			delete(p.lookup, last.hash)
+			p.lifecycle.Dropped(last.hash, addr, txpool.TxDropCapped)
 		}
 		p.index[addr] = txs
 
@@ -1035,6 +1051,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 					p.stored -= uint64(tx.size)
 					delete(p.lookup, tx.hash)
 					txs[i] = nil
+					p.lifecycle.Dropped(tx.hash, addr, txpool.TxDropUnderpriced)
 
 					// Drop everything afterwards, no gaps allowed
 					for j, tx := range txs[i+1:] {
@@ -1045,6 +1062,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 						p.stored -= uint64(tx.size)
 						delete(p.lookup, tx.hash)
 						txs[i+1+j] = nil
+						p.lifecycle.Dropped(tx.hash, addr, txpool.TxDropNonceGap)
 					}
 					// Clear out the dropped transactions from the index
 					if i > 0 {
@@ -1322,6 +1340,8 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		delete(p.lookup, prev.hash)
 		p.lookup[meta.hash] = meta.id
 		p.stored += uint64(meta.size) - uint64(prev.size)
+
+		p.lifecycle.Replaced(prev.hash, from, meta.hash)
 	} else {
 		// Transaction extends previously scheduled ones
 		p.index[from] = append(p.index[from], meta)
@@ -1380,6 +1400,11 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 			heap.Fix(p.evict, p.evict.index[from])
 		}
 	}
+	// Blob transactions are only accepted without nonce gaps, so they are
+	// executable right away
+	p.lifecycle.Received(meta.hash, from)
+	p.lifecycle.Promoted(meta.hash, from)
+
 	// If the pool went over the allowed data limit, evict transactions until
 	// we're again below the threshold
 	for p.stored > p.config.Datacap {
@@ -1420,6 +1445,7 @@ func (p *BlobPool) drop() {
 	}
 	p.stored -= uint64(drop.size)
 	delete(p.lookup, drop.hash)
+	p.lifecycle.Dropped(drop.hash, from, txpool.TxDropUnderpriced)
 
 	// Remove the transaction from the pool's eviction heap:
 	//   - If the entire account was dropped, pop off the address
@@ -0,0 +1,79 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Bundle is an ordered list of transactions to be included atomically into a
+// specific block: either all of them are included in order, or none of them.
+// Bundles are private to the node, they are never announced to the network.
+type Bundle struct {
+	Txs          []*types.Transaction // Transactions to include, in order
+	BlockNumber  uint64               // Number of the block the bundle targets
+	MinTimestamp uint64               // Minimum timestamp of the block, 0 if unbounded
+	MaxTimestamp uint64               // Maximum timestamp of the block, 0 if unbounded
+
+	RevertingTxHashes []common.Hash // Transactions allowed to revert without invalidating the bundle
+}
+
+// Hash returns the identifier of the bundle, which is the hash of the
+// concatenated hashes of its transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// Includable reports whether the bundle can be included in a block with the
+// given number and timestamp.
+func (b *Bundle) Includable(number uint64, time uint64) bool {
+	if b.BlockNumber != number {
+		return false
+	}
+	if b.MinTimestamp != 0 && time < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && time > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// MayRevert reports whether the transaction with the given hash is allowed to
+// revert without invalidating the bundle.
+func (b *Bundle) MayRevert(hash common.Hash) bool {
+	return slices.Contains(b.RevertingTxHashes, hash)
+}
+
+// bundler is implemented by the subpools accepting transaction bundles.
+type bundler interface {
+	// AddBundle validates a bundle and enqueues it for inclusion into the
+	// targeted block.
+	AddBundle(bundle *Bundle) error
+
+	// Bundles retrieves the bundles includable in a block with the given number
+	// and timestamp, in the order they were added.
+	Bundles(number uint64, time uint64) []*Bundle
+}
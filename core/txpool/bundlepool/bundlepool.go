@@ -0,0 +1,296 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bundlepool implements a private pool of atomic transaction bundles.
+package bundlepool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// txMaxSize is the maximum size a single transaction within a bundle can have.
+// It's the same limit the legacy pool enforces.
+const txMaxSize = 128 * 1024
+
+var (
+	// errEmptyBundle is returned if a bundle does not contain any transactions.
+	errEmptyBundle = errors.New("empty bundle")
+
+	// errBundleTooLarge is returned if a bundle contains more transactions than
+	// the pool is configured to accept.
+	errBundleTooLarge = errors.New("too many transactions in bundle")
+
+	// errBundleStale is returned if a bundle targets a block already on chain
+	// or a timestamp already passed.
+	errBundleStale = errors.New("bundle target already passed")
+
+	// errBundleTooFar is returned if a bundle targets a block too far ahead of
+	// the current head.
+	errBundleTooFar = errors.New("bundle target too far in the future")
+
+	// errInvalidTimestamps is returned if the minimum timestamp of a bundle is
+	// above its maximum.
+	errInvalidTimestamps = errors.New("bundle minimum timestamp above maximum")
+
+	// errPoolFull is returned if the pool has no room for further bundles.
+	errPoolFull = errors.New("bundle pool full")
+)
+
+// BlockChain defines the minimal set of methods needed to back a bundle pool
+// with a chain. Exists to allow mocking the live chain out of tests.
+type BlockChain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// CurrentBlock returns the current head of the chain.
+	CurrentBlock() *types.Header
+}
+
+// BundlePool is a subpool tracking bundles of transactions to be included
+// atomically into specific blocks. Contrary to the other subpools, it does not
+// accept individual transactions and its content is never announced to the
+// network: bundles can only be submitted locally and are only ever used when
+// building blocks.
+type BundlePool struct {
+	config Config     // Pool configuration
+	chain  BlockChain // Chain to validate the bundles against
+	signer types.Signer
+
+	head    *types.Header                  // Current head of the chain
+	bundles map[uint64][]*txpool.Bundle    // Bundles grouped by target block number
+	known   map[common.Hash]*txpool.Bundle // Bundles indexed by their hash for deduplication
+	lock    sync.RWMutex                   // Mutex protecting the pool fields
+}
+
+// New creates a new bundle pool to gather bundles of transactions submitted
+// for atomic inclusion.
+func New(config Config, chain BlockChain) *BundlePool {
+	return &BundlePool{
+		config:  config.sanitize(),
+		chain:   chain,
+		signer:  types.LatestSigner(chain.Config()),
+		bundles: make(map[uint64][]*txpool.Bundle),
+		known:   make(map[common.Hash]*txpool.Bundle),
+	}
+}
+
+// Filter returns whether the given transaction can be consumed by the bundle
+// pool, which is never the case: bundles are only added as a whole.
+func (p *BundlePool) Filter(tx *types.Transaction) bool {
+	return false
+}
+
+// Init sets the base parameters of the subpool.
+func (p *BundlePool) Init(gasTip uint64, head *types.Header, reserve txpool.AddressReserver) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = head
+	return nil
+}
+
+// Close terminates the bundle pool, discarding all tracked bundles.
+func (p *BundlePool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bundles = make(map[uint64][]*txpool.Bundle)
+	p.known = make(map[common.Hash]*txpool.Bundle)
+	return nil
+}
+
+// Reset drops all the bundles targeting blocks up to the new head, as they
+// cannot be included anymore.
+func (p *BundlePool) Reset(oldHead, newHead *types.Header) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = newHead
+	for number, bundles := range p.bundles {
+		if number > newHead.Number.Uint64() {
+			continue
+		}
+		for _, bundle := range bundles {
+			delete(p.known, bundle.Hash())
+		}
+		delete(p.bundles, number)
+		log.Trace("Dropped expired bundles", "number", number, "count", len(bundles))
+	}
+}
+
+// SetGasTip is a noop, bundles are not subject to the minimum gas tip as their
+// inclusion is decided on the profitability of the whole bundle.
+func (p *BundlePool) SetGasTip(tip *big.Int) {}
+
+// Has always returns false, the transactions of the bundles are not tracked
+// individually.
+func (p *BundlePool) Has(hash common.Hash) bool {
+	return false
+}
+
+// Get always returns nil, the transactions of the bundles are not tracked
+// individually.
+func (p *BundlePool) Get(hash common.Hash) *types.Transaction {
+	return nil
+}
+
+// Add rejects all transactions, bundles can only be added via AddBundle. It is
+// never called by the main pool as Filter accepts no transactions.
+func (p *BundlePool) Add(txs []*types.Transaction, local bool, sync bool) []error {
+	errs := make([]error, len(txs))
+	for i := range txs {
+		errs[i] = core.ErrTxTypeNotSupported
+	}
+	return errs
+}
+
+// Pending returns nothing, bundles are never mixed into the individual pending
+// transactions.
+func (p *BundlePool) Pending(filter txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction {
+	return nil
+}
+
+// SubscribeTransactions returns a subscription never firing, since bundles are
+// never announced.
+func (p *BundlePool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// Nonce returns 0, the bundle pool does not track account nonces.
+func (p *BundlePool) Nonce(addr common.Address) uint64 {
+	return 0
+}
+
+// Stats returns 0 for both counters, the bundled transactions are not counted
+// among the pooled ones.
+func (p *BundlePool) Stats() (int, int) {
+	return 0, 0
+}
+
+// Content returns empty maps, the bundled transactions are not listed among
+// the pooled ones.
+func (p *BundlePool) Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	return make(map[common.Address][]*types.Transaction), make(map[common.Address][]*types.Transaction)
+}
+
+// ContentFrom returns empty lists, the bundled transactions are not listed
+// among the pooled ones.
+func (p *BundlePool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
+	return []*types.Transaction{}, []*types.Transaction{}
+}
+
+// Locals returns nothing, the bundle pool has no notion of local accounts.
+func (p *BundlePool) Locals() []common.Address {
+	return nil
+}
+
+// Status returns unknown for all transactions, the transactions of the bundles
+// are not tracked individually.
+func (p *BundlePool) Status(hash common.Hash) txpool.TxStatus {
+	return txpool.TxStatusUnknown
+}
+
+// AddBundle validates a bundle and enqueues it for inclusion into the targeted
+// block.
+func (p *BundlePool) AddBundle(bundle *txpool.Bundle) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.validateBundle(bundle); err != nil {
+		return err
+	}
+	hash := bundle.Hash()
+	if _, ok := p.known[hash]; ok {
+		return txpool.ErrAlreadyKnown
+	}
+	if uint64(len(p.known)) >= p.config.Slots {
+		return errPoolFull
+	}
+	p.known[hash] = bundle
+	p.bundles[bundle.BlockNumber] = append(p.bundles[bundle.BlockNumber], bundle)
+
+	log.Debug("Added transaction bundle", "hash", hash, "number", bundle.BlockNumber, "txs", len(bundle.Txs))
+	return nil
+}
+
+// validateBundle checks whether a bundle is includable on top of the current
+// head and whether its transactions are statelessly valid. The stateful checks
+// are postponed until the bundle is simulated during block building.
+func (p *BundlePool) validateBundle(bundle *txpool.Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return errEmptyBundle
+	}
+	if uint64(len(bundle.Txs)) > p.config.MaxTxs {
+		return fmt.Errorf("%w: have %d, max %d", errBundleTooLarge, len(bundle.Txs), p.config.MaxTxs)
+	}
+	if bundle.MaxTimestamp != 0 && bundle.MinTimestamp > bundle.MaxTimestamp {
+		return errInvalidTimestamps
+	}
+	head := p.head.Number.Uint64()
+	if bundle.BlockNumber <= head {
+		return fmt.Errorf("%w: block %d, head %d", errBundleStale, bundle.BlockNumber, head)
+	}
+	if bundle.MaxTimestamp != 0 && bundle.MaxTimestamp <= p.head.Time {
+		return fmt.Errorf("%w: timestamp %d, head %d", errBundleStale, bundle.MaxTimestamp, p.head.Time)
+	}
+	if bundle.BlockNumber > head+p.config.Ahead {
+		return fmt.Errorf("%w: block %d, head %d", errBundleTooFar, bundle.BlockNumber, head)
+	}
+	opts := &txpool.ValidationOptions{
+		Config: p.chain.Config(),
+		Accept: 0 |
+			1<<types.LegacyTxType |
+			1<<types.AccessListTxType |
+			1<<types.DynamicFeeTxType,
+		MaxSize: txMaxSize,
+		MinTip:  new(big.Int),
+	}
+	for i, tx := range bundle.Txs {
+		if err := txpool.ValidateTransaction(tx, p.head, p.signer, opts); err != nil {
+			return fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+		}
+	}
+	return nil
+}
+
+// Bundles retrieves the bundles includable in a block with the given number
+// and timestamp, in the order they were added.
+func (p *BundlePool) Bundles(number uint64, time uint64) []*txpool.Bundle {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var bundles []*txpool.Bundle
+	for _, bundle := range p.bundles[number] {
+		if bundle.Includable(number, time) {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles
+}
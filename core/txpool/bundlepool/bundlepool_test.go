@@ -0,0 +1,120 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundlepool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testBlockChain is a mock of the live chain for testing the pool.
+type testBlockChain struct {
+	head *types.Header
+}
+
+func (bc *testBlockChain) Config() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (bc *testBlockChain) CurrentBlock() *types.Header {
+	return bc.head
+}
+
+func testHeader(number uint64, time uint64) *types.Header {
+	return &types.Header{
+		Number:   new(big.Int).SetUint64(number),
+		Time:     time,
+		GasLimit: params.GenesisGasLimit,
+		BaseFee:  big.NewInt(params.InitialBaseFee),
+	}
+}
+
+// Tests that bundles are validated on submission, retrieved by their target
+// block and dropped once the chain moves past it.
+func TestBundlePool(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		signer = types.LatestSigner(params.TestChainConfig)
+		chain  = &testBlockChain{head: testHeader(10, 100)}
+		pool   = New(Config{Slots: 3, MaxTxs: 2, Ahead: 5}, chain)
+	)
+	if err := pool.Init(0, chain.CurrentBlock(), nil); err != nil {
+		t.Fatalf("failed to init pool: %v", err)
+	}
+	defer pool.Close()
+
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &common.Address{},
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+	}
+	tests := []struct {
+		bundle *txpool.Bundle
+		err    error
+	}{
+		{bundle: &txpool.Bundle{BlockNumber: 11}, err: errEmptyBundle},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0), transfer(1), transfer(2)}, BlockNumber: 11}, err: errBundleTooLarge},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0)}, BlockNumber: 10}, err: errBundleStale},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0)}, BlockNumber: 11, MaxTimestamp: 100}, err: errBundleStale},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0)}, BlockNumber: 16}, err: errBundleTooFar},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0)}, BlockNumber: 11, MinTimestamp: 120, MaxTimestamp: 110}, err: errInvalidTimestamps},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0), transfer(1)}, BlockNumber: 11}},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0), transfer(1)}, BlockNumber: 11}, err: txpool.ErrAlreadyKnown},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(0)}, BlockNumber: 11, MinTimestamp: 120}},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(1)}, BlockNumber: 12}},
+		{bundle: &txpool.Bundle{Txs: []*types.Transaction{transfer(2)}, BlockNumber: 12}, err: errPoolFull},
+	}
+	for i, tt := range tests {
+		if err := pool.AddBundle(tt.bundle); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Bundles must only be returned for their target block and timestamps
+	if have := len(pool.Bundles(11, 110)); have != 1 {
+		t.Errorf("bundles mismatch at block 11, time 110: have %d, want %d", have, 1)
+	}
+	if have := len(pool.Bundles(11, 120)); have != 2 {
+		t.Errorf("bundles mismatch at block 11, time 120: have %d, want %d", have, 2)
+	}
+	if have := len(pool.Bundles(12, 120)); have != 1 {
+		t.Errorf("bundles mismatch at block 12: have %d, want %d", have, 1)
+	}
+	// Moving the head past a target block must drop its bundles and free up
+	// their slots
+	chain.head = testHeader(11, 112)
+	pool.Reset(nil, chain.head)
+
+	if have := len(pool.Bundles(11, 120)); have != 0 {
+		t.Errorf("bundles mismatch at block 11 after reset: have %d, want %d", have, 0)
+	}
+	if have := len(pool.Bundles(12, 120)); have != 1 {
+		t.Errorf("bundles mismatch at block 12 after reset: have %d, want %d", have, 1)
+	}
+	if err := pool.AddBundle(&txpool.Bundle{Txs: []*types.Transaction{transfer(2)}, BlockNumber: 12}); err != nil {
+		t.Errorf("failed to add bundle after reset: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundlepool
+
+import (
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Config are the configuration parameters of the bundle pool.
+type Config struct {
+	Enabled bool   // Whether transaction bundles are accepted at all
+	Slots   uint64 // Maximum number of bundles tracked by the pool
+	MaxTxs  uint64 // Maximum number of transactions in a single bundle
+	Ahead   uint64 // Maximum number of blocks ahead of the head a bundle can target
+}
+
+// DefaultConfig contains the default configurations for the bundle pool.
+var DefaultConfig = Config{
+	Slots:  1024,
+	MaxTxs: 64,
+	Ahead:  128,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.Slots < 1 {
+		log.Warn("Sanitizing invalid bundlepool slots", "provided", conf.Slots, "updated", DefaultConfig.Slots)
+		conf.Slots = DefaultConfig.Slots
+	}
+	if conf.MaxTxs < 1 {
+		log.Warn("Sanitizing invalid bundlepool transaction limit", "provided", conf.MaxTxs, "updated", DefaultConfig.MaxTxs)
+		conf.MaxTxs = DefaultConfig.MaxTxs
+	}
+	if conf.Ahead < 1 {
+		log.Warn("Sanitizing invalid bundlepool lookahead", "provided", conf.Ahead, "updated", DefaultConfig.Ahead)
+		conf.Ahead = DefaultConfig.Ahead
+	}
+	return conf
+}
@@ -60,4 +60,8 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrBundlesNotSupported is returned if a bundle is submitted to a pool not
+	// having any subpool accepting transaction bundles.
+	ErrBundlesNotSupported = errors.New("transaction bundles not supported")
 )
@@ -215,12 +215,13 @@ type LegacyPool struct {
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *journal    // Journal of local transaction to back up to disk
 
-	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
-	pending map[common.Address]*list     // All currently processable transactions
-	queue   map[common.Address]*list     // Queued but non-processable transactions
-	beats   map[common.Address]time.Time // Last heartbeat from each known account
-	all     *lookup                      // All transactions to allow lookups
-	priced  *pricedList                  // All transactions sorted by price
+	reserve   txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
+	lifecycle *txpool.Lifecycle            // Lifecycle tracker to report the transaction events to
+	pending   map[common.Address]*list     // All currently processable transactions
+	queue     map[common.Address]*list     // Queued but non-processable transactions
+	beats     map[common.Address]time.Time // Last heartbeat from each known account
+	all       *lookup                      // All transactions to allow lookups
+	priced    *pricedList                  // All transactions sorted by price
 
 	reqResetCh      chan *txpoolResetRequest
 	reqPromoteCh    chan *accountSet
@@ -284,6 +285,11 @@ func (pool *LegacyPool) Filter(tx *types.Transaction) bool {
 	}
 }
 
+// SetLifecycle sets the lifecycle tracker to report the transaction events to.
+func (pool *LegacyPool) SetLifecycle(lifecycle *txpool.Lifecycle) {
+	pool.lifecycle = lifecycle
+}
+
 // Init sets the gas price needed to keep a transaction in the pool and the chain
 // head to allow balance / nonce checks. The transaction journal will be loaded
 // from disk and filtered based on the provided starting settings. The internal
@@ -379,6 +385,7 @@ func (pool *LegacyPool) loop() {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true, true)
+						pool.lifecycle.Dropped(tx.Hash(), addr, txpool.TxDropLifetime)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
@@ -444,7 +451,9 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(tip)
 		for _, tx := range drop {
+			from, _ := types.Sender(pool.signer, tx)
 			pool.removeTx(tx.Hash(), false, true)
+			pool.lifecycle.Dropped(tx.Hash(), from, txpool.TxDropUnderpriced)
 		}
 		pool.priced.Removed(len(drop))
 	}
@@ -767,6 +776,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 
 			sender, _ := types.Sender(pool.signer, tx)
 			dropped := pool.removeTx(tx.Hash(), false, sender != from) // Don't unreserve the sender of the tx being added if last from the acc
+			pool.lifecycle.Dropped(tx.Hash(), sender, txpool.TxDropUnderpriced)
 
 			pool.changesSinceReorg += dropped
 		}
@@ -792,6 +802,12 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
+		pool.lifecycle.Received(hash, from)
+		if old != nil {
+			pool.lifecycle.Replaced(old.Hash(), from, hash)
+		}
+		pool.lifecycle.Promoted(hash, from)
+
 		// Successful promotion, bump the heartbeat
 		pool.beats[from] = time.Now()
 		return old != nil, nil
@@ -801,6 +817,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 	if err != nil {
 		return false, err
 	}
+	pool.lifecycle.Received(hash, from)
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -860,6 +877,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.lifecycle.Replaced(old.Hash(), from, hash)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -909,6 +927,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.lifecycle.Dropped(hash, addr, txpool.TxDropUnderpriced)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -916,6 +935,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.lifecycle.Replaced(old.Hash(), addr, hash)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -1455,6 +1475,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range forwards {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.lifecycle.Dropped(hash, addr, txpool.TxDropStale)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -1462,6 +1483,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.lifecycle.Dropped(hash, addr, txpool.TxDropUnpayable)
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
@@ -1472,6 +1494,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			hash := tx.Hash()
 			if pool.promoteTx(addr, hash, tx) {
 				promoted = append(promoted, tx)
+				pool.lifecycle.Promoted(hash, addr)
 			}
 		}
 		log.Trace("Promoted queued transactions", "count", len(promoted))
@@ -1484,6 +1507,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
+				pool.lifecycle.Dropped(hash, addr, txpool.TxDropCapped)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
@@ -1549,6 +1573,7 @@ func (pool *LegacyPool) truncatePending() {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
 						pool.all.Remove(hash)
+						pool.lifecycle.Dropped(hash, offenders[i], txpool.TxDropCapped)
 
 						// Update the account nonce to the dropped transaction
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
@@ -1576,6 +1601,7 @@ func (pool *LegacyPool) truncatePending() {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
 					pool.all.Remove(hash)
+					pool.lifecycle.Dropped(hash, addr, txpool.TxDropCapped)
 
 					// Update the account nonce to the dropped transaction
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1623,6 +1649,7 @@ func (pool *LegacyPool) truncateQueue() {
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
 				pool.removeTx(tx.Hash(), true, true)
+				pool.lifecycle.Dropped(tx.Hash(), addr.address, txpool.TxDropCapped)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1632,6 +1659,7 @@ func (pool *LegacyPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true, true)
+			pool.lifecycle.Dropped(txs[i].Hash(), addr.address, txpool.TxDropCapped)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1656,6 +1684,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 		for _, tx := range olds {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.lifecycle.Dropped(hash, addr, txpool.TxDropStale)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1664,6 +1693,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
+			pool.lifecycle.Dropped(hash, addr, txpool.TxDropUnpayable)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -2671,3 +2672,65 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that the lifecycle of the transactions is reported to the tracker.
+func TestTransactionLifecycle(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	lifecycle := txpool.NewLifecycle(16)
+	defer lifecycle.Close()
+
+	pool := New(testTxPoolConfig, blockchain)
+	pool.SetLifecycle(lifecycle)
+	pool.Init(testTxPoolConfig.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	stages := func(hash common.Hash) []txpool.TxStage {
+		var stages []txpool.TxStage
+		if record := lifecycle.Get(hash); record != nil {
+			for _, ev := range record.Events {
+				stages = append(stages, ev.Stage)
+			}
+		}
+		return stages
+	}
+	// Add an executable transaction and replace it with a better paying one
+	tx := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if have, want := stages(tx.Hash()), []txpool.TxStage{txpool.TxStageReceived, txpool.TxStagePromoted}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong lifecycle: have %v, want %v", have, want)
+	}
+	replacement := pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	record := lifecycle.Get(tx.Hash())
+	if last := record.Last(); last.Stage != txpool.TxStageReplaced || last.ReplacedBy != replacement.Hash() {
+		t.Fatalf("replacement not recorded: %+v", last)
+	}
+	if record.From != from {
+		t.Fatalf("wrong sender: have %x, want %x", record.From, from)
+	}
+	// Add a gapped transaction, which must stay queued, and drop it by raising
+	// the minimum tip
+	gapped := pricedTransaction(2, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(gapped); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if have, want := stages(gapped.Hash()), []txpool.TxStage{txpool.TxStageReceived}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong lifecycle: have %v, want %v", have, want)
+	}
+	pool.SetGasTip(big.NewInt(2))
+	if last := lifecycle.Get(gapped.Hash()).Last(); last.Stage != txpool.TxStageDropped || last.Reason != txpool.TxDropUnderpriced {
+		t.Fatalf("drop not recorded: %+v", last)
+	}
+}
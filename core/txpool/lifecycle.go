@@ -0,0 +1,275 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// lifecycleRecords is the number of transactions the lifecycle is tracked
+	// for, the least recently updated ones being forgotten first.
+	lifecycleRecords = 16384
+
+	// lifecycleEvents is the maximum number of events retained for a single
+	// transaction, the oldest ones being discarded first.
+	lifecycleEvents = 32
+
+	// lifecycleBacklog is the maximum number of events queued up for delivery
+	// to the subscribers, the oldest ones being discarded first.
+	lifecycleBacklog = 4096
+
+	// maxIncludedDepth is the maximum number of blocks the inclusion of the
+	// transactions is recorded for when the chain moves by more than a block.
+	maxIncludedDepth = 64
+)
+
+// TxStage is a step in the lifecycle of a transaction.
+type TxStage string
+
+const (
+	TxStageReceived TxStage = "received" // Transaction accepted into the pool
+	TxStagePromoted TxStage = "promoted" // Transaction became executable
+	TxStageReplaced TxStage = "replaced" // Transaction replaced by another with the same nonce
+	TxStageDropped  TxStage = "dropped"  // Transaction evicted from the pool
+	TxStageIncluded TxStage = "included" // Transaction included in a block
+)
+
+// TxDropReason is the reason a transaction was evicted from the pool.
+type TxDropReason string
+
+const (
+	TxDropUnderpriced TxDropReason = "underpriced"  // Outbid by better paying transactions or below the minimum tip
+	TxDropLifetime    TxDropReason = "lifetime"     // Non-executable for longer than the configured lifetime
+	TxDropNonceGap    TxDropReason = "nonce-gapped" // Preceding nonce missing from the pool and the chain
+	TxDropStale       TxDropReason = "stale"        // Nonce already used on chain
+	TxDropUnpayable   TxDropReason = "unpayable"    // Balance or block gas limit insufficient
+	TxDropCapped      TxDropReason = "capped"       // Account or pool capacity exceeded
+	TxDropInvalid     TxDropReason = "invalid"      // Transaction became invalid
+)
+
+// TxLifecycleEvent is a single step in the lifecycle of a transaction.
+type TxLifecycleEvent struct {
+	Hash  common.Hash    // Hash of the transaction
+	From  common.Address // Sender of the transaction
+	Stage TxStage        // Lifecycle step of the transaction
+	Time  time.Time      // Time when the step happened
+
+	Reason      TxDropReason // Reason of a drop
+	ReplacedBy  common.Hash  // Hash of the transaction replacing this one
+	BlockHash   common.Hash  // Hash of the block the transaction was included in
+	BlockNumber uint64       // Number of the block the transaction was included in
+}
+
+// TxLifecycle is the recorded lifecycle of a transaction.
+type TxLifecycle struct {
+	Hash   common.Hash
+	From   common.Address
+	Events []TxLifecycleEvent
+}
+
+// Last returns the most recent lifecycle event of the transaction.
+func (l *TxLifecycle) Last() *TxLifecycleEvent {
+	return &l.Events[len(l.Events)-1]
+}
+
+// Lifecycle tracks the lifecycle of the recently seen transactions from being
+// received, through promotion, until being replaced, dropped or included. The
+// number of tracked transactions is bounded, forgetting the least recently
+// updated ones first.
+//
+// All methods are safe to be called on a nil lifecycle, in which case they are
+// noops. This allows subpools to be used without the main transaction pool.
+type Lifecycle struct {
+	records lru.BasicLRU[common.Hash, *TxLifecycle]
+	backlog []TxLifecycleEvent // Events queued up for delivery to subscribers
+	lock    sync.Mutex
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+	wake  chan struct{}
+	quit  chan struct{}
+	term  chan struct{}
+}
+
+// NewLifecycle creates a lifecycle tracker retaining the given number of
+// transactions.
+func NewLifecycle(records int) *Lifecycle {
+	l := &Lifecycle{
+		records: lru.NewBasicLRU[common.Hash, *TxLifecycle](records),
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		term:    make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+// Close stops the event delivery and unsubscribes all subscribers.
+func (l *Lifecycle) Close() {
+	if l == nil {
+		return
+	}
+	// Unsubscribe first to unblock any pending delivery
+	l.scope.Close()
+	close(l.quit)
+	<-l.term
+}
+
+// loop delivers the queued events to the subscribers. Events are delivered
+// asynchronously to avoid blocking the pools on slow subscribers.
+func (l *Lifecycle) loop() {
+	defer close(l.term)
+
+	for {
+		select {
+		case <-l.wake:
+			l.lock.Lock()
+			events := l.backlog
+			l.backlog = nil
+			l.lock.Unlock()
+
+			if len(events) > 0 {
+				l.feed.Send(events)
+			}
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// Received records that a transaction was accepted into the pool.
+func (l *Lifecycle) Received(hash common.Hash, from common.Address) {
+	l.record(TxLifecycleEvent{Hash: hash, From: from, Stage: TxStageReceived})
+}
+
+// Promoted records that a transaction became executable.
+func (l *Lifecycle) Promoted(hash common.Hash, from common.Address) {
+	l.record(TxLifecycleEvent{Hash: hash, From: from, Stage: TxStagePromoted})
+}
+
+// Replaced records that a transaction was replaced by another one with the
+// same nonce.
+func (l *Lifecycle) Replaced(hash common.Hash, from common.Address, by common.Hash) {
+	l.record(TxLifecycleEvent{Hash: hash, From: from, Stage: TxStageReplaced, ReplacedBy: by})
+}
+
+// Dropped records that a transaction was evicted from the pool.
+func (l *Lifecycle) Dropped(hash common.Hash, from common.Address, reason TxDropReason) {
+	l.record(TxLifecycleEvent{Hash: hash, From: from, Stage: TxStageDropped, Reason: reason})
+}
+
+// Included records the inclusion of the tracked transactions of a block.
+// Transactions never seen by the pool are not tracked.
+func (l *Lifecycle) Included(block *types.Block) {
+	if l == nil {
+		return
+	}
+	for _, tx := range block.Transactions() {
+		l.lock.Lock()
+		record, ok := l.records.Peek(tx.Hash())
+		l.lock.Unlock()
+
+		if ok {
+			l.record(TxLifecycleEvent{
+				Hash:        tx.Hash(),
+				From:        record.From,
+				Stage:       TxStageIncluded,
+				BlockHash:   block.Hash(),
+				BlockNumber: block.NumberU64(),
+			})
+		}
+	}
+}
+
+// record appends an event to the lifecycle of a transaction and queues it up
+// for delivery to the subscribers.
+func (l *Lifecycle) record(ev TxLifecycleEvent) {
+	if l == nil {
+		return
+	}
+	ev.Time = time.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	record, ok := l.records.Get(ev.Hash)
+	if !ok {
+		record = &TxLifecycle{Hash: ev.Hash, From: ev.From}
+		l.records.Add(ev.Hash, record)
+	}
+	// An included transaction is dropped from the pool as stale once the pool
+	// catches up with the chain, which is not worth reporting.
+	if len(record.Events) > 0 && record.Last().Stage == TxStageIncluded && ev.Reason == TxDropStale {
+		return
+	}
+	if len(record.Events) >= lifecycleEvents {
+		record.Events = append(record.Events[:0], record.Events[1:]...)
+	}
+	record.Events = append(record.Events, ev)
+
+	if len(l.backlog) >= lifecycleBacklog {
+		log.Warn("Transaction lifecycle subscribers falling behind, discarding events", "backlog", len(l.backlog))
+		l.backlog = l.backlog[len(l.backlog)/2:]
+	}
+	l.backlog = append(l.backlog, ev)
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Get retrieves the recorded lifecycle of a transaction, or nil if it is not
+// tracked.
+func (l *Lifecycle) Get(hash common.Hash) *TxLifecycle {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	record, ok := l.records.Peek(hash)
+	if !ok {
+		return nil
+	}
+	return &TxLifecycle{
+		Hash:   record.Hash,
+		From:   record.From,
+		Events: append([]TxLifecycleEvent(nil), record.Events...),
+	}
+}
+
+// Subscribe registers a subscription for the lifecycle events of all tracked
+// transactions.
+func (l *Lifecycle) Subscribe(ch chan<- []TxLifecycleEvent) event.Subscription {
+	return l.scope.Track(l.feed.Subscribe(ch))
+}
+
+// lifecycleTracker is implemented by the subpools reporting the lifecycle of
+// their transactions.
+type lifecycleTracker interface {
+	// SetLifecycle sets the lifecycle tracker to report the transaction events
+	// to. It is called before the subpool is initialized.
+	SetLifecycle(lifecycle *Lifecycle)
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestLifecycle(t *testing.T) {
+	lifecycle := NewLifecycle(2)
+	defer lifecycle.Close()
+
+	events := make(chan []TxLifecycleEvent, 16)
+	sub := lifecycle.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	var (
+		from = common.HexToAddress("0xa11ce")
+		tx   = types.NewTx(&types.LegacyTx{Nonce: 1})
+		hash = tx.Hash()
+	)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(7)}, &types.Body{Transactions: []*types.Transaction{tx}}, nil, trie.NewStackTrie(nil))
+
+	lifecycle.Received(hash, from)
+	lifecycle.Promoted(hash, from)
+	lifecycle.Included(block)
+	lifecycle.Dropped(hash, from, TxDropStale) // Pool catching up with the chain
+
+	record := lifecycle.Get(hash)
+	if record == nil || record.From != from {
+		t.Fatalf("wrong record: %+v", record)
+	}
+	if len(record.Events) != 3 {
+		t.Fatalf("wrong number of events: have %d, want 3", len(record.Events))
+	}
+	if last := record.Last(); last.Stage != TxStageIncluded || last.BlockHash != block.Hash() || last.BlockNumber != 7 {
+		t.Fatalf("wrong inclusion event: %+v", last)
+	}
+	// Ensure the events are delivered to the subscribers in order
+	var delivered []TxLifecycleEvent
+	for len(delivered) < 3 {
+		select {
+		case evs := <-events:
+			delivered = append(delivered, evs...)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for events, have %d", len(delivered))
+		}
+	}
+	for i, stage := range []TxStage{TxStageReceived, TxStagePromoted, TxStageIncluded} {
+		if delivered[i].Stage != stage {
+			t.Errorf("event %d: wrong stage: have %s, want %s", i, delivered[i].Stage, stage)
+		}
+	}
+	// Inclusion of untracked transactions is ignored and the number of tracked
+	// transactions is bounded
+	lifecycle.Included(types.NewBlock(&types.Header{Number: big.NewInt(8)}, &types.Body{Transactions: []*types.Transaction{types.NewTx(&types.LegacyTx{Nonce: 2})}}, nil, trie.NewStackTrie(nil)))
+	lifecycle.Dropped(common.Hash{1}, from, TxDropLifetime)
+	lifecycle.Dropped(common.Hash{2}, from, TxDropCapped)
+
+	if lifecycle.Get(hash) != nil {
+		t.Fatal("least recently updated transaction not evicted")
+	}
+	if record := lifecycle.Get(common.Hash{1}); record == nil || record.Last().Reason != TxDropLifetime {
+		t.Fatalf("wrong drop record: %+v", record)
+	}
+}
+
+// testIncludedChain is a block chain serving blocks from memory for tracking
+// the transaction inclusions.
+type testIncludedChain map[common.Hash]*types.Block
+
+func (c testIncludedChain) CurrentBlock() *types.Header { return nil }
+
+func (c testIncludedChain) GetBlock(hash common.Hash, number uint64) *types.Block { return c[hash] }
+
+func (c testIncludedChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return nil
+}
+
+// Tests that the inclusion is recorded for the blocks skipped over by the head
+// events and for the new side of a reorg, but not for the reorged out blocks.
+func TestLifecycleIncluded(t *testing.T) {
+	var (
+		pool  = &TxPool{lifecycle: NewLifecycle(lifecycleRecords)}
+		chain = make(testIncludedChain)
+		nonce uint64
+	)
+	defer pool.lifecycle.Close()
+
+	extend := func(parent *types.Block, n int) []*types.Block {
+		var blocks []*types.Block
+		for i := 0; i < n; i++ {
+			nonce++
+			tx := types.NewTx(&types.LegacyTx{Nonce: nonce})
+			pool.lifecycle.Received(tx.Hash(), common.Address{})
+
+			header := &types.Header{Number: big.NewInt(1)}
+			if parent != nil {
+				header.ParentHash, header.Number = parent.Hash(), new(big.Int).Add(parent.Number(), common.Big1)
+			}
+			parent = types.NewBlock(header, &types.Body{Transactions: []*types.Transaction{tx}}, nil, trie.NewStackTrie(nil))
+			chain[parent.Hash()] = parent
+			blocks = append(blocks, parent)
+		}
+		return blocks
+	}
+	included := func(block *types.Block) bool {
+		return pool.lifecycle.Get(block.Transactions()[0].Hash()).Last().Stage == TxStageIncluded
+	}
+	main := extend(nil, 4)
+	side := extend(main[1], 3)
+
+	// Head moving by multiple blocks
+	pool.included(chain, main[0].Header(), main[3])
+	for i, block := range main {
+		if have := included(block); have != (i > 0) {
+			t.Errorf("main block %d: inclusion mismatch: have %v, want %v", i, have, i > 0)
+		}
+	}
+	// Head reorged to the side chain
+	pool.included(chain, main[3].Header(), side[2])
+	for i, block := range side {
+		if !included(block) {
+			t.Errorf("side block %d: inclusion not recorded", i)
+		}
+	}
+	if included(main[0]) {
+		t.Errorf("inclusion recorded below the old head")
+	}
+}
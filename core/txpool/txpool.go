@@ -55,6 +55,9 @@ type BlockChain interface {
 	// CurrentBlock returns the current head of the chain.
 	CurrentBlock() *types.Header
 
+	// GetBlock retrieves a specific block, used to track the included transactions.
+	GetBlock(hash common.Hash, number uint64) *types.Block
+
 	// SubscribeChainHeadEvent subscribes to new blocks being added to the chain.
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
@@ -70,6 +73,8 @@ type TxPool struct {
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
+	lifecycle *Lifecycle // Lifecycle tracker of the recently seen transactions
+
 	subs event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
 	term chan struct{}           // Termination channel to detect a closed pool
@@ -88,15 +93,22 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 	pool := &TxPool{
 		subpools:     subpools,
 		reservations: make(map[common.Address]SubPool),
+		lifecycle:    NewLifecycle(lifecycleRecords),
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
 	}
+	for _, subpool := range subpools {
+		if tracker, ok := subpool.(lifecycleTracker); ok {
+			tracker.SetLifecycle(pool.lifecycle)
+		}
+	}
 	for i, subpool := range subpools {
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
 			for j := i - 1; j >= 0; j-- {
 				subpools[j].Close()
 			}
+			pool.lifecycle.Close()
 			return nil, err
 		}
 	}
@@ -168,6 +180,7 @@ func (p *TxPool) Close() error {
 	}
 	// Unsubscribe anyone still listening for tx events
 	p.subs.Close()
+	p.lifecycle.Close()
 
 	if len(errs) > 0 {
 		return fmt.Errorf("subpool close errors: %v", errs)
@@ -194,6 +207,9 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 		oldHead = head
 		newHead = oldHead
 	)
+	// Track the last head whose transactions were recorded as included
+	lastIncluded := head
+
 	// Consume chain head events and start resets when none is running
 	var (
 		resetBusy = make(chan struct{}, 1) // Allow 1 reset to run concurrently
@@ -244,6 +260,8 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 		case event := <-newHeadCh:
 			// Chain moved forward, store the head for later consumption
 			newHead = event.Block.Header()
+			p.included(chain, lastIncluded, event.Block)
+			lastIncluded = newHead
 
 		case head := <-resetDone:
 			// Previous reset finished, update the old head and allow a new reset
@@ -276,6 +294,50 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 	errc <- nil
 }
 
+// included records the inclusion of the transactions of all the blocks the
+// chain moved forward by, including the ones skipped over by the head events
+// and the ones of the new side after a reorg. At most maxIncludedDepth blocks
+// are reported, the deeper ones are assumed to be long gone from the lifecycle
+// tracker anyway.
+func (p *TxPool) included(chain BlockChain, old *types.Header, head *types.Block) {
+	if p.lifecycle == nil {
+		return
+	}
+	var (
+		blocks []*types.Block
+		rem    = chain.GetBlock(old.Hash(), old.Number.Uint64())
+		add    = head
+	)
+	parent := func(block *types.Block) *types.Block {
+		if block.NumberU64() == 0 {
+			return nil
+		}
+		return chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	// Collect the blocks of the new side, up to the common ancestor
+	for rem != nil && add != nil && len(blocks) < maxIncludedDepth {
+		if rem.Hash() == add.Hash() {
+			break
+		}
+		if add.NumberU64() >= rem.NumberU64() {
+			blocks = append(blocks, add)
+			if add.NumberU64() == rem.NumberU64() {
+				rem = parent(rem)
+			}
+			add = parent(add)
+		} else {
+			rem = parent(rem)
+		}
+	}
+	// Old head unknown or reorg too deep, report the new head at least
+	if len(blocks) == 0 && (rem == nil || add == nil) {
+		blocks = append(blocks, head)
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		p.lifecycle.Included(blocks[i])
+	}
+}
+
 // SetGasTip updates the minimum gas tip required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (p *TxPool) SetGasTip(tip *big.Int) {
@@ -464,6 +526,41 @@ func (p *TxPool) Status(hash common.Hash) TxStatus {
 	return TxStatusUnknown
 }
 
+// Lifecycle retrieves the recorded lifecycle of a transaction, or nil if it is
+// not tracked.
+func (p *TxPool) Lifecycle(hash common.Hash) *TxLifecycle {
+	return p.lifecycle.Get(hash)
+}
+
+// SubscribeLifecycle registers a subscription for the lifecycle events of the
+// transactions seen by the pool.
+func (p *TxPool) SubscribeLifecycle(ch chan<- []TxLifecycleEvent) event.Subscription {
+	return p.lifecycle.Subscribe(ch)
+}
+
+// AddBundle validates a transaction bundle and enqueues it for inclusion into
+// the targeted block.
+func (p *TxPool) AddBundle(bundle *Bundle) error {
+	for _, subpool := range p.subpools {
+		if bundler, ok := subpool.(bundler); ok {
+			return bundler.AddBundle(bundle)
+		}
+	}
+	return ErrBundlesNotSupported
+}
+
+// Bundles retrieves the transaction bundles includable in a block with the
+// given number and timestamp, in the order they were added.
+func (p *TxPool) Bundles(number uint64, time uint64) []*Bundle {
+	var bundles []*Bundle
+	for _, subpool := range p.subpools {
+		if bundler, ok := subpool.(bundler); ok {
+			bundles = append(bundles, bundler.Bundles(number, time)...)
+		}
+	}
+	return bundles
+}
+
 // Sync is a helper method for unit tests or simulator runs where the chain events
 // are arriving in quick succession, without any time in between them to run the
 // internal background reset operations. This method will run an explicit reset
@@ -24,10 +24,14 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -443,3 +447,70 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// ExecutionWitness re-executes the given block on top of its parent state and
+// returns the witness needed to execute it statelessly: the block itself, the
+// trie nodes and codes accessed and the ancestor headers the block hashes are
+// proven with.
+func (api *DebugAPI) ExecutionWitness(bn rpc.BlockNumber) (*stateless.Witness, error) {
+	var header *types.Header
+	switch bn {
+	case rpc.PendingBlockNumber:
+		return nil, errors.New("witness of the pending block is not available")
+	case rpc.LatestBlockNumber:
+		header = api.eth.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		header = api.eth.blockchain.CurrentFinalBlock()
+	case rpc.SafeBlockNumber:
+		header = api.eth.blockchain.CurrentSafeBlock()
+	default:
+		header = api.eth.blockchain.GetHeaderByNumber(uint64(bn))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", bn)
+	}
+	block := api.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", bn)
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not executable")
+	}
+	return generateWitness(api.eth.blockchain, block)
+}
+
+// generateWitness executes the block with witness collection enabled.
+func generateWitness(chain *core.BlockChain, block *types.Block) (*stateless.Witness, error) {
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block #%d not found", block.NumberU64())
+	}
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := stateless.NewWitness(chain, block)
+	if err != nil {
+		return nil, err
+	}
+	statedb.StartPrefetcher("debug", witness)
+	defer statedb.StopPrefetcher()
+
+	receipts, _, usedGas, err := chain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process block #%d: %v", block.NumberU64(), err)
+	}
+	// The state validation hashes the post state, collecting the trie nodes
+	// touched by the updates.
+	if err := chain.Validator().ValidateState(block, statedb, receipts, usedGas, false); err != nil {
+		return nil, fmt.Errorf("failed to validate block #%d: %v", block.NumberU64(), err)
+	}
+	return witness, nil
+}
+
+// SyncStatus returns a detailed report on the running chain sync, including the
+// phase of the sync, the completion estimates of the state sync tasks, the depth
+// of the state healing queues and the slowest peers.
+func (api *DebugAPI) SyncStatus() *downloader.SyncStatus {
+	return api.eth.Downloader().SyncStatus()
+}
@@ -18,7 +18,9 @@ package eth
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"slices"
 	"strings"
@@ -26,11 +28,16 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
 )
@@ -221,3 +228,81 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestExecutionWitness(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Store the hash of the block two blocks before the current one
+				contract: {Code: []byte{
+					byte(vm.NUMBER), byte(vm.PUSH1), 2, byte(vm.SWAP1), byte(vm.SUB), byte(vm.BLOCKHASH),
+					byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP),
+				}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = ethash.NewFaker()
+	)
+	call := func(b *core.BlockGen) *types.Transaction {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    b.TxNonce(addr),
+			To:       &contract,
+			Gas:      100000,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+		return tx
+	}
+	// The chain generator can't resolve block hashes, the block calling the
+	// contract is generated on top of the imported chain.
+	db, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, 3, nil)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	blocks, _ = core.GenerateChain(gspec.Config, blocks[2], engine, db, 1, func(i int, b *core.BlockGen) {
+		b.AddTxWithChain(chain, call(b))
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[0]
+	witness, err := generateWitness(chain, block)
+	if err != nil {
+		t.Fatalf("failed to generate witness: %v", err)
+	}
+	// The headers back to the one accessed by BLOCKHASH are included.
+	if len(witness.Headers) != 2 {
+		t.Fatalf("wrong number of witness headers: have %d, want 2", len(witness.Headers))
+	}
+	if len(witness.Codes) != 1 {
+		t.Fatalf("wrong number of witness codes: have %d, want 1", len(witness.Codes))
+	}
+	// Execute the block from the witness transferred as JSON.
+	enc, err := json.Marshal(witness)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	dec := new(stateless.Witness)
+	if err := json.Unmarshal(enc, dec); err != nil {
+		t.Fatalf("failed to decode witness: %v", err)
+	}
+	receiptRoot, stateRoot, err := core.ExecuteStateless(gspec.Config, dec)
+	if err != nil {
+		t.Fatalf("failed to execute block statelessly: %v", err)
+	}
+	if stateRoot != block.Root() {
+		t.Errorf("state root mismatch: have %x, want %x", stateRoot, block.Root())
+	}
+	if receiptRoot != block.ReceiptHash() {
+		t.Errorf("receipt root mismatch: have %x, want %x", receiptRoot, block.ReceiptHash())
+	}
+}
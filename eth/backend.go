@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -45,6 +46,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/shutdowncheck"
 	"github.com/ethereum/go-ethereum/log"
@@ -84,6 +86,12 @@ type Ethereum struct {
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
+	logIndexer        *core.ChainIndexer // Log indexer operating during block imports, nil if disabled
+	traceIndexer      *core.ChainIndexer // Call trace indexer operating during block imports, nil if disabled
+
+	history *era.History // Era1 archive to sync pre-merge blocks from, nil if disabled
+
+	tracerAPIs []rpc.API // RPC APIs exposed by the live tracer, if any
 
 	APIBackend *EthAPIBackend
 
@@ -196,18 +204,38 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 			StateHistory:        config.StateHistory,
 			StateScheme:         scheme,
+			HistoryExpiry:       config.HistoryExpiry,
 		}
 	)
+	// Open the history archive to sync pre-merge blocks from, if configured. The
+	// same archive serves expired chain history over RPC if it's local, mirrors
+	// would need to download a whole era1 file for every cold request.
+	if config.HistoryArchive != "" {
+		network := params.NetworkNames[chainConfig.ChainID.String()]
+		if eth.history, err = era.NewHistory(config.HistoryArchive, network); err != nil {
+			return nil, fmt.Errorf("failed to open history archive: %w", err)
+		}
+		log.Info("Using era1 history archive", "location", config.HistoryArchive, "network", network)
+
+		if !eth.history.Remote() {
+			cacheConfig.HistoryArchive = eth.history
+		} else if config.HistoryExpiry > 0 {
+			log.Warn("Expired chain history not served over RPC from remote archives", "location", config.HistoryArchive)
+		} else {
+			log.Info("Expired chain history not served over RPC from remote archives", "location", config.HistoryArchive)
+		}
+	}
 	if config.VMTrace != "" {
 		var traceConfig json.RawMessage
 		if config.VMTraceJsonConfig != "" {
 			traceConfig = json.RawMessage(config.VMTraceJsonConfig)
 		}
-		t, err := tracers.LiveDirectory.New(config.VMTrace, traceConfig)
+		t, apis, err := tracers.LiveDirectory.NewWithAPIs(config.VMTrace, traceConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create tracer %s: %v", config.VMTrace, err)
 		}
 		vmConfig.Tracer = t
+		eth.tracerAPIs = apis
 	}
 	// Override the chain config with provided settings.
 	var overrides core.ChainOverrides
@@ -226,6 +254,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if config.LogIndex {
+		eth.logIndexer = core.NewLogIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms, config.LogHistory)
+		eth.logIndexer.Start(eth.blockchain)
+	}
 
 	if config.BlobPool.Datadir != "" {
 		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
@@ -237,7 +269,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
 
-	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool, blobPool})
+	subpools := []txpool.SubPool{legacyPool, blobPool}
+	if config.BundlePool.Enabled {
+		subpools = append(subpools, bundlepool.New(config.BundlePool, eth.blockchain))
+	}
+	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, subpools)
 	if err != nil {
 		return nil, err
 	}
@@ -253,6 +289,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		History:        eth.history,
+		SnapBudget:     config.SnapServeBudget * 1024,
 	}); err != nil {
 		return nil, err
 	}
@@ -270,6 +308,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
 
+	if config.TraceIndex {
+		eth.traceIndexer = tracers.NewTraceIndexer(eth.APIBackend, 0)
+		eth.traceIndexer.Start(eth.blockchain)
+	}
+
 	// Setup DNS discovery iterators.
 	dnsclient := dnsdisc.NewClient(dnsdisc.Config{})
 	eth.ethDialCandidates, err = dnsclient.NewIterator(eth.config.EthDiscoveryURLs...)
@@ -320,6 +363,9 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed by the live tracer
+	apis = append(apis, s.tracerAPIs...)
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -404,9 +450,18 @@ func (s *Ethereum) Stop() error {
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
+	if s.logIndexer != nil {
+		s.logIndexer.Close()
+	}
+	if s.traceIndexer != nil {
+		s.traceIndexer.Close()
+	}
 	close(s.closeBloomHandler)
 	s.txPool.Close()
 	s.blockchain.Stop()
+	if s.history != nil {
+		s.history.Close()
+	}
 	s.engine.Close()
 
 	// Clean shutdown marker as the last thing before closing db
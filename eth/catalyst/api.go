@@ -53,6 +53,28 @@ func Register(stack *node.Node, backend *eth.Ethereum) error {
 	return nil
 }
 
+// RegisterRecorded adds the engine API to the full node, journalling all the
+// served calls into the given file for later replay.
+func RegisterRecorded(stack *node.Node, backend *eth.Ethereum, path string) error {
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		return err
+	}
+	log.Warn("Engine API enabled", "protocol", "eth", "record", path)
+
+	api := NewConsensusAPI(backend)
+	api.SetRecorder(recorder)
+	stack.RegisterLifecycle(recorder)
+	stack.RegisterAPIs([]rpc.API{
+		{
+			Namespace:     "engine",
+			Service:       api,
+			Authenticated: true,
+		},
+	})
+	return nil
+}
+
 const (
 	// invalidBlockHitEviction is the number of times an invalid block can be
 	// referenced in forkchoice update or new payload before it is attempted
@@ -135,6 +157,8 @@ type ConsensusAPI struct {
 
 	forkchoiceLock sync.Mutex // Lock for the forkChoiceUpdated method
 	newPayloadLock sync.Mutex // Lock for the NewPayload method
+
+	recorder *Recorder // Journal of the served calls, nil if recording is disabled
 }
 
 // NewConsensusAPI creates a new consensus api for the given backend.
@@ -145,6 +169,18 @@ func NewConsensusAPI(eth *eth.Ethereum) *ConsensusAPI {
 	return api
 }
 
+// SetRecorder sets the recorder to journal the served Engine API calls into.
+func (api *ConsensusAPI) SetRecorder(recorder *Recorder) {
+	api.recorder = recorder
+}
+
+// record journals a served Engine API call if recording is enabled.
+func (api *ConsensusAPI) record(method string, result interface{}, err error, params ...interface{}) {
+	if api.recorder != nil {
+		api.recorder.record(method, params, result, err, api.eth.BlockChain().CurrentBlock())
+	}
+}
+
 // newConsensusAPIWithoutHeartbeat creates a new consensus api for the SimulatedBeacon Node.
 func newConsensusAPIWithoutHeartbeat(eth *eth.Ethereum) *ConsensusAPI {
 	if eth.BlockChain().Config().TerminalTotalDifficulty == nil {
@@ -175,7 +211,9 @@ func newConsensusAPIWithoutHeartbeat(eth *eth.Ethereum) *ConsensusAPI {
 //
 // If there are payloadAttributes: we try to assemble a block with the payloadAttributes
 // and return its payloadID.
-func (api *ConsensusAPI) ForkchoiceUpdatedV1(update engine.ForkchoiceStateV1, payloadAttributes *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+func (api *ConsensusAPI) ForkchoiceUpdatedV1(update engine.ForkchoiceStateV1, payloadAttributes *engine.PayloadAttributes) (res engine.ForkChoiceResponse, err error) {
+	defer func() { api.record("engine_forkchoiceUpdatedV1", res, err, update, payloadAttributes) }()
+
 	if payloadAttributes != nil {
 		if payloadAttributes.Withdrawals != nil || payloadAttributes.BeaconRoot != nil {
 			return engine.STATUS_INVALID, engine.InvalidParams.With(errors.New("withdrawals and beacon root not supported in V1"))
@@ -189,7 +227,9 @@ func (api *ConsensusAPI) ForkchoiceUpdatedV1(update engine.ForkchoiceStateV1, pa
 
 // ForkchoiceUpdatedV2 is equivalent to V1 with the addition of withdrawals in the payload
 // attributes. It supports both PayloadAttributesV1 and PayloadAttributesV2.
-func (api *ConsensusAPI) ForkchoiceUpdatedV2(update engine.ForkchoiceStateV1, params *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+func (api *ConsensusAPI) ForkchoiceUpdatedV2(update engine.ForkchoiceStateV1, params *engine.PayloadAttributes) (res engine.ForkChoiceResponse, err error) {
+	defer func() { api.record("engine_forkchoiceUpdatedV2", res, err, update, params) }()
+
 	if params != nil {
 		if params.BeaconRoot != nil {
 			return engine.STATUS_INVALID, engine.InvalidPayloadAttributes.With(errors.New("unexpected beacon root"))
@@ -212,7 +252,9 @@ func (api *ConsensusAPI) ForkchoiceUpdatedV2(update engine.ForkchoiceStateV1, pa
 
 // ForkchoiceUpdatedV3 is equivalent to V2 with the addition of parent beacon block root
 // in the payload attributes. It supports only PayloadAttributesV3.
-func (api *ConsensusAPI) ForkchoiceUpdatedV3(update engine.ForkchoiceStateV1, params *engine.PayloadAttributes) (engine.ForkChoiceResponse, error) {
+func (api *ConsensusAPI) ForkchoiceUpdatedV3(update engine.ForkchoiceStateV1, params *engine.PayloadAttributes) (res engine.ForkChoiceResponse, err error) {
+	defer func() { api.record("engine_forkchoiceUpdatedV3", res, err, update, params) }()
+
 	if params != nil {
 		if params.Withdrawals == nil {
 			return engine.STATUS_INVALID, engine.InvalidPayloadAttributes.With(errors.New("missing withdrawals"))
@@ -400,7 +442,9 @@ func (api *ConsensusAPI) forkchoiceUpdated(update engine.ForkchoiceStateV1, payl
 
 // ExchangeTransitionConfigurationV1 checks the given configuration against
 // the configuration of the node.
-func (api *ConsensusAPI) ExchangeTransitionConfigurationV1(config engine.TransitionConfigurationV1) (*engine.TransitionConfigurationV1, error) {
+func (api *ConsensusAPI) ExchangeTransitionConfigurationV1(config engine.TransitionConfigurationV1) (res *engine.TransitionConfigurationV1, err error) {
+	defer func() { api.record("engine_exchangeTransitionConfigurationV1", res, err, config) }()
+
 	log.Trace("Engine API request received", "method", "ExchangeTransitionConfiguration", "ttd", config.TerminalTotalDifficulty)
 	if config.TerminalTotalDifficulty == nil {
 		return nil, errors.New("invalid terminal total difficulty")
@@ -429,7 +473,9 @@ func (api *ConsensusAPI) ExchangeTransitionConfigurationV1(config engine.Transit
 }
 
 // GetPayloadV1 returns a cached payload by id.
-func (api *ConsensusAPI) GetPayloadV1(payloadID engine.PayloadID) (*engine.ExecutableData, error) {
+func (api *ConsensusAPI) GetPayloadV1(payloadID engine.PayloadID) (res *engine.ExecutableData, err error) {
+	defer func() { api.record("engine_getPayloadV1", res, err, payloadID) }()
+
 	if !payloadID.Is(engine.PayloadV1) {
 		return nil, engine.UnsupportedFork
 	}
@@ -441,7 +487,9 @@ func (api *ConsensusAPI) GetPayloadV1(payloadID engine.PayloadID) (*engine.Execu
 }
 
 // GetPayloadV2 returns a cached payload by id.
-func (api *ConsensusAPI) GetPayloadV2(payloadID engine.PayloadID) (*engine.ExecutionPayloadEnvelope, error) {
+func (api *ConsensusAPI) GetPayloadV2(payloadID engine.PayloadID) (res *engine.ExecutionPayloadEnvelope, err error) {
+	defer func() { api.record("engine_getPayloadV2", res, err, payloadID) }()
+
 	if !payloadID.Is(engine.PayloadV1, engine.PayloadV2) {
 		return nil, engine.UnsupportedFork
 	}
@@ -449,7 +497,9 @@ func (api *ConsensusAPI) GetPayloadV2(payloadID engine.PayloadID) (*engine.Execu
 }
 
 // GetPayloadV3 returns a cached payload by id.
-func (api *ConsensusAPI) GetPayloadV3(payloadID engine.PayloadID) (*engine.ExecutionPayloadEnvelope, error) {
+func (api *ConsensusAPI) GetPayloadV3(payloadID engine.PayloadID) (res *engine.ExecutionPayloadEnvelope, err error) {
+	defer func() { api.record("engine_getPayloadV3", res, err, payloadID) }()
+
 	if !payloadID.Is(engine.PayloadV3) {
 		return nil, engine.UnsupportedFork
 	}
@@ -466,7 +516,9 @@ func (api *ConsensusAPI) getPayload(payloadID engine.PayloadID, full bool) (*eng
 }
 
 // NewPayloadV1 creates an Eth1 block, inserts it in the chain, and returns the status of the chain.
-func (api *ConsensusAPI) NewPayloadV1(params engine.ExecutableData) (engine.PayloadStatusV1, error) {
+func (api *ConsensusAPI) NewPayloadV1(params engine.ExecutableData) (res engine.PayloadStatusV1, err error) {
+	defer func() { api.record("engine_newPayloadV1", res, err, params) }()
+
 	if params.Withdrawals != nil {
 		return engine.PayloadStatusV1{Status: engine.INVALID}, engine.InvalidParams.With(errors.New("withdrawals not supported in V1"))
 	}
@@ -474,7 +526,9 @@ func (api *ConsensusAPI) NewPayloadV1(params engine.ExecutableData) (engine.Payl
 }
 
 // NewPayloadV2 creates an Eth1 block, inserts it in the chain, and returns the status of the chain.
-func (api *ConsensusAPI) NewPayloadV2(params engine.ExecutableData) (engine.PayloadStatusV1, error) {
+func (api *ConsensusAPI) NewPayloadV2(params engine.ExecutableData) (res engine.PayloadStatusV1, err error) {
+	defer func() { api.record("engine_newPayloadV2", res, err, params) }()
+
 	if api.eth.BlockChain().Config().IsCancun(api.eth.BlockChain().Config().LondonBlock, params.Timestamp) {
 		return engine.PayloadStatusV1{Status: engine.INVALID}, engine.InvalidParams.With(errors.New("can't use newPayloadV2 post-cancun"))
 	}
@@ -497,7 +551,9 @@ func (api *ConsensusAPI) NewPayloadV2(params engine.ExecutableData) (engine.Payl
 }
 
 // NewPayloadV3 creates an Eth1 block, inserts it in the chain, and returns the status of the chain.
-func (api *ConsensusAPI) NewPayloadV3(params engine.ExecutableData, versionedHashes []common.Hash, beaconRoot *common.Hash) (engine.PayloadStatusV1, error) {
+func (api *ConsensusAPI) NewPayloadV3(params engine.ExecutableData, versionedHashes []common.Hash, beaconRoot *common.Hash) (res engine.PayloadStatusV1, err error) {
+	defer func() { api.record("engine_newPayloadV3", res, err, params, versionedHashes, beaconRoot) }()
+
 	if params.Withdrawals == nil {
 		return engine.PayloadStatusV1{Status: engine.INVALID}, engine.InvalidParams.With(errors.New("nil withdrawals post-shanghai"))
 	}
@@ -816,12 +872,16 @@ func (api *ConsensusAPI) heartbeat() {
 }
 
 // ExchangeCapabilities returns the current methods provided by this node.
-func (api *ConsensusAPI) ExchangeCapabilities([]string) []string {
+func (api *ConsensusAPI) ExchangeCapabilities(capabilities []string) (res []string) {
+	defer func() { api.record("engine_exchangeCapabilities", res, nil, capabilities) }()
+
 	return caps
 }
 
 // GetClientVersionV1 exchanges client version data of this node.
-func (api *ConsensusAPI) GetClientVersionV1(info engine.ClientVersionV1) []engine.ClientVersionV1 {
+func (api *ConsensusAPI) GetClientVersionV1(info engine.ClientVersionV1) (res []engine.ClientVersionV1) {
+	defer func() { api.record("engine_getClientVersionV1", res, nil, info) }()
+
 	log.Trace("Engine API request received", "method", "GetClientVersionV1", "info", info.String())
 	commit := make([]byte, 4)
 	if vcs, ok := version.VCS(); ok {
@@ -839,7 +899,9 @@ func (api *ConsensusAPI) GetClientVersionV1(info engine.ClientVersionV1) []engin
 
 // GetPayloadBodiesByHashV1 implements engine_getPayloadBodiesByHashV1 which allows for retrieval of a list
 // of block bodies by the engine api.
-func (api *ConsensusAPI) GetPayloadBodiesByHashV1(hashes []common.Hash) []*engine.ExecutionPayloadBodyV1 {
+func (api *ConsensusAPI) GetPayloadBodiesByHashV1(hashes []common.Hash) (res []*engine.ExecutionPayloadBodyV1) {
+	defer func() { api.record("engine_getPayloadBodiesByHashV1", res, nil, hashes) }()
+
 	bodies := make([]*engine.ExecutionPayloadBodyV1, len(hashes))
 	for i, hash := range hashes {
 		block := api.eth.BlockChain().GetBlockByHash(hash)
@@ -850,7 +912,9 @@ func (api *ConsensusAPI) GetPayloadBodiesByHashV1(hashes []common.Hash) []*engin
 
 // GetPayloadBodiesByRangeV1 implements engine_getPayloadBodiesByRangeV1 which allows for retrieval of a range
 // of block bodies by the engine api.
-func (api *ConsensusAPI) GetPayloadBodiesByRangeV1(start, count hexutil.Uint64) ([]*engine.ExecutionPayloadBodyV1, error) {
+func (api *ConsensusAPI) GetPayloadBodiesByRangeV1(start, count hexutil.Uint64) (res []*engine.ExecutionPayloadBodyV1, err error) {
+	defer func() { api.record("engine_getPayloadBodiesByRangeV1", res, err, start, count) }()
+
 	if start == 0 || count == 0 {
 		return nil, engine.InvalidParams.With(fmt.Errorf("invalid start or count, start: %v count: %v", start, count))
 	}
@@ -0,0 +1,261 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RecordedCall is an Engine API call journalled by the recorder, along with
+// the chain head right after the call was served.
+type RecordedCall struct {
+	Time   time.Time         `json:"time"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Head   RecordedHead      `json:"head"`
+}
+
+// RecordedHead is the chain head after an Engine API call.
+type RecordedHead struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+	Root   common.Hash    `json:"stateRoot"`
+}
+
+// newRecordedHead creates the recorded form of a chain head.
+func newRecordedHead(head *types.Header) RecordedHead {
+	return RecordedHead{
+		Number: hexutil.Uint64(head.Number.Uint64()),
+		Hash:   head.Hash(),
+		Root:   head.Root,
+	}
+}
+
+// Recorder journals the Engine API calls served by the node into a file, one
+// JSON encoded call per line, in the order they were served.
+type Recorder struct {
+	file *os.File
+	lock sync.Mutex
+}
+
+// NewRecorder creates an Engine API recorder appending to the given file.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{file: file}, nil
+}
+
+// Start implements node.Lifecycle, the recorder is active from its creation.
+func (r *Recorder) Start() error {
+	return nil
+}
+
+// Stop implements node.Lifecycle, closing the journal file.
+func (r *Recorder) Stop() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// record journals an Engine API call. Failures are only logged, as recording
+// must never interfere with serving the call.
+func (r *Recorder) record(method string, params []interface{}, result interface{}, err error, head *types.Header) {
+	if r == nil {
+		return
+	}
+	call := &RecordedCall{
+		Time:   time.Now(),
+		Method: method,
+		Params: make([]json.RawMessage, len(params)),
+		Head:   newRecordedHead(head),
+	}
+	for i, param := range params {
+		enc, err := json.Marshal(param)
+		if err != nil {
+			log.Warn("Failed to record engine API call", "method", method, "err", err)
+			return
+		}
+		call.Params[i] = enc
+	}
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		enc, err := json.Marshal(result)
+		if err != nil {
+			log.Warn("Failed to record engine API call", "method", method, "err", err)
+			return
+		}
+		call.Result = enc
+	}
+	enc, err := json.Marshal(call)
+	if err != nil {
+		log.Warn("Failed to record engine API call", "method", method, "err", err)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	if _, err := r.file.Write(append(enc, '\n')); err != nil {
+		log.Warn("Failed to record engine API call", "method", method, "err", err)
+	}
+}
+
+// ReadRecording reads the Engine API calls journalled by a recorder.
+func ReadRecording(path string) ([]*RecordedCall, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		calls   []*RecordedCall
+		scanner = bufio.NewScanner(file)
+	)
+	scanner.Buffer(nil, 128*1024*1024) // Payloads may be large, allow up to 128MB per call
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		call := new(RecordedCall)
+		if err := json.Unmarshal(scanner.Bytes(), call); err != nil {
+			return nil, fmt.Errorf("invalid recorded call on line %d: %v", line, err)
+		}
+		calls = append(calls, call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+// Divergence is a difference between a recorded Engine API call and its replay.
+type Divergence struct {
+	Index  int           // Index of the call in the recording
+	Call   *RecordedCall // Recorded call
+	Result json.RawMessage
+	Error  string
+	Head   RecordedHead
+}
+
+// String implements fmt.Stringer.
+func (d *Divergence) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "call #%d (%s, recorded at %v) diverged", d.Index, d.Call.Method, d.Call.Time.Format(time.RFC3339Nano))
+	if d.Error != d.Call.Error {
+		fmt.Fprintf(&buf, "\n  error:  have %q, want %q", d.Error, d.Call.Error)
+	}
+	if !equalJSON(d.Result, d.Call.Result) {
+		fmt.Fprintf(&buf, "\n  result: have %s\n          want %s", d.Result, d.Call.Result)
+	}
+	if d.Head != d.Call.Head {
+		fmt.Fprintf(&buf, "\n  head:   have #%d %x (root %x), want #%d %x (root %x)",
+			d.Head.Number, d.Head.Hash, d.Head.Root, d.Call.Head.Number, d.Call.Head.Hash, d.Call.Head.Root)
+	}
+	return buf.String()
+}
+
+// Replay drives the Engine API through the recorded calls in order, comparing
+// the responses and the resulting chain heads to the recorded ones. The calls
+// are issued through an in-process RPC server, decoding the parameters the same
+// way as the original calls were.
+//
+// The optional callback is invoked after each replayed call with its divergence,
+// or nil if it matched the recording. Returning false stops the replay.
+func Replay(api *ConsensusAPI, calls []*RecordedCall, onCall func(index int, div *Divergence) bool) ([]*Divergence, error) {
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	if err := srv.RegisterName("engine", api); err != nil {
+		return nil, err
+	}
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+
+	var divergences []*Divergence
+	for i, call := range calls {
+		params := make([]interface{}, len(call.Params))
+		for j, param := range call.Params {
+			params[j] = param
+		}
+		var (
+			result json.RawMessage
+			errstr string
+		)
+		if err := client.Call(&result, call.Method, params...); err != nil {
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) {
+				return divergences, fmt.Errorf("call #%d (%s) failed: %v", i, call.Method, err)
+			}
+			errstr, result = err.Error(), nil
+		}
+		div := &Divergence{
+			Index:  i,
+			Call:   call,
+			Result: result,
+			Error:  errstr,
+			Head:   newRecordedHead(api.eth.BlockChain().CurrentBlock()),
+		}
+		if div.Error == call.Error && equalJSON(div.Result, call.Result) && div.Head == call.Head {
+			div = nil
+		} else {
+			divergences = append(divergences, div)
+		}
+		if onCall != nil && !onCall(i, div) {
+			break
+		}
+	}
+	return divergences, nil
+}
+
+// equalJSON reports whether two JSON encoded values are semantically equal.
+func equalJSON(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package catalyst
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the recorded Engine API calls replay against a fresh node without
+// divergence, and that tampered recordings are reported as diverging.
+func TestRecordReplay(t *testing.T) {
+	genesis, _ := generateMergeChain(0, true)
+	n, ethservice := startEthService(t, genesis, nil)
+	defer n.Close()
+
+	path := filepath.Join(t.TempDir(), "engine.jsonl")
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	api := NewConsensusAPI(ethservice)
+	api.SetRecorder(recorder)
+
+	// Drive the recorded node through a few blocks and a failing call
+	for i := 0; i < 3; i++ {
+		parent := ethservice.BlockChain().CurrentBlock()
+		resp, err := api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: parent.Hash()}, &engine.PayloadAttributes{
+			Timestamp:             parent.Time + 1,
+			Random:                crypto.Keccak256Hash([]byte{byte(i)}),
+			SuggestedFeeRecipient: testAddr,
+		})
+		if err != nil || resp.PayloadID == nil {
+			t.Fatalf("block %d: failed to start building payload: %v", i, err)
+		}
+		payload, err := api.GetPayloadV1(*resp.PayloadID)
+		if err != nil {
+			t.Fatalf("block %d: failed to get payload: %v", i, err)
+		}
+		if status, err := api.NewPayloadV1(*payload); err != nil || status.Status != engine.VALID {
+			t.Fatalf("block %d: failed to import payload: %v %v", i, status.Status, err)
+		}
+		if _, err := api.ForkchoiceUpdatedV1(engine.ForkchoiceStateV1{HeadBlockHash: payload.BlockHash}, nil); err != nil {
+			t.Fatalf("block %d: failed to set head: %v", i, err)
+		}
+	}
+	if _, err := api.GetPayloadV1(engine.PayloadID{0x01}); err == nil {
+		t.Fatalf("unknown payload retrieved")
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("failed to close recorder: %v", err)
+	}
+	calls, err := ReadRecording(path)
+	if err != nil {
+		t.Fatalf("failed to read recording: %v", err)
+	}
+	if len(calls) != 13 {
+		t.Fatalf("recorded call count mismatch: have %d, want %d", len(calls), 13)
+	}
+	if calls[12].Method != "engine_getPayloadV1" || calls[12].Error == "" {
+		t.Fatalf("failing call not recorded: %+v", calls[12])
+	}
+	want := ethservice.BlockChain().CurrentBlock()
+	if head := calls[11].Head; uint64(head.Number) != want.Number.Uint64() || head.Hash != want.Hash() {
+		t.Fatalf("recorded head mismatch: have #%d %x, want #%d %x", head.Number, head.Hash, want.Number, want.Hash())
+	}
+	// Replay the recording on a fresh node, expecting no divergence
+	replay := func() []*Divergence {
+		n, ethservice := startEthService(t, genesis, nil)
+		defer n.Close()
+
+		divergences, err := Replay(NewConsensusAPI(ethservice), calls, nil)
+		if err != nil {
+			t.Fatalf("failed to replay: %v", err)
+		}
+		return divergences
+	}
+	if divergences := replay(); len(divergences) != 0 {
+		t.Fatalf("unexpected divergences: %v", divergences)
+	}
+	// Tamper with a recorded response and ensure it's reported
+	invalid, _ := json.Marshal(engine.PayloadStatusV1{Status: engine.INVALID})
+	calls[2].Result = invalid
+
+	divergences := replay()
+	if len(divergences) != 1 {
+		t.Fatalf("divergence count mismatch: have %d, want %d", len(divergences), 1)
+	}
+	if divergences[0].Index != 2 || divergences[0].Call.Method != "engine_newPayloadV1" {
+		t.Fatalf("unexpected divergence: %v", divergences[0])
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"time"
@@ -31,12 +32,16 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-const devEpochLength = 32
+const (
+	devEpochLength = 32
+	devSlotTime    = 12 // Slot time in seconds if blocks are produced on demand
+)
 
 // withdrawalQueue implements a FIFO queue which holds withdrawals that are
 // pending inclusion.
@@ -83,6 +88,9 @@ type SimulatedBeacon struct {
 	engineAPI          *ConsensusAPI
 	curForkchoiceState engine.ForkchoiceStateV1
 	lastBlockTime      uint64
+	skippedSlots       uint64     // Number of slots to leave empty before the next block
+	withholdFinality   bool       // Whether the finalized block is kept in place
+	lock               sync.Mutex // lock gates concurrent block production and forkchoice updates
 }
 
 // NewSimulatedBeacon constructs a new simulated beacon chain.
@@ -140,54 +148,78 @@ func (c *SimulatedBeacon) Stop() error {
 	return nil
 }
 
-// sealBlock initiates payload building for a new block and creates a new block
-// with the completed payload.
+// sealBlock initiates payload building for a new block on top of the current
+// head and creates a new block with the completed payload.
 func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, timestamp uint64) error {
-	if timestamp <= c.lastBlockTime {
-		timestamp = c.lastBlockTime + 1
-	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, err := c.seal(common.Hash{}, withdrawals, timestamp, false, true)
+	return err
+}
+
+// seal builds a new block on top of the given parent, or the current head if
+// none is given, and imports it into the chain. If forced, the timestamp is used
+// as is, otherwise it is pushed after the parent and any skipped slots. If head
+// is set, the new block is also made the chain head.
+//
+// The caller must hold the lock.
+func (c *SimulatedBeacon) seal(parentHash common.Hash, withdrawals []*types.Withdrawal, timestamp uint64, force bool, head bool) (common.Hash, error) {
 	c.feeRecipientLock.Lock()
 	feeRecipient := c.feeRecipient
 	c.feeRecipientLock.Unlock()
 
-	// Reset to CurrentBlock in case of the chain was rewound
+	// Reset to CurrentBlock in case of the chain was rewound. A withheld finalized
+	// block is kept in place, unless the chain was rewound below it.
 	if header := c.eth.BlockChain().CurrentBlock(); c.curForkchoiceState.HeadBlockHash != header.Hash() {
-		finalizedHash := c.finalizedBlockHash(header.Number.Uint64())
-		c.setCurrentState(header.Hash(), *finalizedHash)
+		finalizedHash := c.curForkchoiceState.FinalizedBlockHash
+		if !c.withholdFinality || !c.isAncestor(finalizedHash, header.Hash()) {
+			fh := c.finalizedBlockHash(header.Number.Uint64())
+			if fh == nil {
+				return common.Hash{}, errors.New("chain rewind interrupted calculation of finalized block hash")
+			}
+			finalizedHash = *fh
+		}
+		c.setCurrentState(header.Hash(), finalizedHash)
+	}
+	// Resolve the parent to build on and the timestamp of the new block
+	if parentHash == (common.Hash{}) {
+		parentHash = c.curForkchoiceState.HeadBlockHash
+	}
+	parent := c.eth.BlockChain().GetHeaderByHash(parentHash)
+	if parent == nil {
+		return common.Hash{}, errors.New("parent not found")
+	}
+	if force {
+		if timestamp <= parent.Time {
+			return common.Hash{}, fmt.Errorf("timestamp %d not after parent timestamp %d", timestamp, parent.Time)
+		}
+	} else {
+		minTime := parent.Time
+		if parentHash == c.curForkchoiceState.HeadBlockHash && c.lastBlockTime > minTime {
+			minTime = c.lastBlockTime
+		}
+		if timestamp <= minTime {
+			timestamp = minTime + 1
+		}
+		timestamp += c.skippedSlots * c.slotTime()
+		c.skippedSlots = 0
 	}
-
 	var random [32]byte
 	rand.Read(random[:])
-	fcResponse, err := c.engineAPI.forkchoiceUpdated(c.curForkchoiceState, &engine.PayloadAttributes{
+	attributes := &engine.PayloadAttributes{
 		Timestamp:             timestamp,
 		SuggestedFeeRecipient: feeRecipient,
 		Withdrawals:           withdrawals,
 		Random:                random,
 		BeaconRoot:            &common.Hash{},
-	}, engine.PayloadV3, true)
-	if err != nil {
-		return err
-	}
-	if fcResponse == engine.STATUS_SYNCING {
-		return errors.New("chain rewind prevented invocation of payload creation")
 	}
-	envelope, err := c.engineAPI.getPayload(*fcResponse.PayloadID, true)
+	envelope, err := c.buildPayload(parent, attributes)
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 	payload := envelope.ExecutionPayload
 
-	var finalizedHash common.Hash
-	if payload.Number%devEpochLength == 0 {
-		finalizedHash = payload.BlockHash
-	} else {
-		if fh := c.finalizedBlockHash(payload.Number); fh == nil {
-			return errors.New("chain rewind interrupted calculation of finalized block hash")
-		} else {
-			finalizedHash = *fh
-		}
-	}
-
 	// Independently calculate the blob hashes from sidecars.
 	blobHashes := make([]common.Hash, 0)
 	if envelope.BlobsBundle != nil {
@@ -195,26 +227,130 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, timestamp u
 		for _, commit := range envelope.BlobsBundle.Commitments {
 			var c kzg4844.Commitment
 			if len(commit) != len(c) {
-				return errors.New("invalid commitment length")
+				return common.Hash{}, errors.New("invalid commitment length")
 			}
 			copy(c[:], commit)
 			blobHashes = append(blobHashes, kzg4844.CalcBlobHashV1(hasher, &c))
 		}
 	}
-	// Mark the payload as canon
-	if _, err = c.engineAPI.NewPayloadV3(*payload, blobHashes, &common.Hash{}); err != nil {
-		return err
+	// Import the payload into the chain
+	status, err := c.engineAPI.NewPayloadV3(*payload, blobHashes, &common.Hash{})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if status.Status != engine.VALID {
+		return common.Hash{}, fmt.Errorf("payload rejected: %s", status.Status)
+	}
+	if !head {
+		return payload.BlockHash, nil
 	}
-	c.setCurrentState(payload.BlockHash, finalizedHash)
-
 	// Mark the block containing the payload as canonical
-	if _, err = c.engineAPI.ForkchoiceUpdatedV2(c.curForkchoiceState, nil); err != nil {
-		return err
+	if err := c.updateHead(payload.BlockHash, payload.Number); err != nil {
+		return common.Hash{}, err
 	}
 	c.lastBlockTime = payload.Timestamp
+	return payload.BlockHash, nil
+}
+
+// buildPayload builds a payload on top of the given parent. If the parent is the
+// current head, the payload is requested through a forkchoice update like from
+// a real consensus client. Otherwise the miner is invoked directly, since the
+// Engine API has no means to build on anything else than the head.
+func (c *SimulatedBeacon) buildPayload(parent *types.Header, attributes *engine.PayloadAttributes) (*engine.ExecutionPayloadEnvelope, error) {
+	if parent.Hash() == c.curForkchoiceState.HeadBlockHash {
+		fcResponse, err := c.engineAPI.forkchoiceUpdated(c.curForkchoiceState, attributes, engine.PayloadV3, true)
+		if err != nil {
+			return nil, err
+		}
+		if fcResponse == engine.STATUS_SYNCING {
+			return nil, errors.New("chain rewind prevented invocation of payload creation")
+		}
+		return c.engineAPI.getPayload(*fcResponse.PayloadID, true)
+	}
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return nil, err
+	}
+	payload, err := c.eth.Miner().BuildPayload(&miner.BuildPayloadArgs{
+		Parent:       parent.Hash(),
+		Timestamp:    attributes.Timestamp,
+		FeeRecipient: attributes.SuggestedFeeRecipient,
+		Random:       attributes.Random,
+		Withdrawals:  attributes.Withdrawals,
+		BeaconRoot:   attributes.BeaconRoot,
+		Version:      engine.PayloadV3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	envelope := payload.ResolveFull()
+	if envelope == nil {
+		return nil, errors.New("payload building interrupted")
+	}
+	return envelope, nil
+}
+
+// updateHead sends a forkchoice update making the given block the chain head,
+// along with the finalized block belonging to it.
+//
+// The caller must hold the lock.
+func (c *SimulatedBeacon) updateHead(hash common.Hash, number uint64) error {
+	finalizedHash := c.curForkchoiceState.FinalizedBlockHash
+	if !c.withholdFinality {
+		if number%devEpochLength == 0 {
+			finalizedHash = hash
+		} else if fh := c.finalizedBlockHash(number); fh == nil {
+			return errors.New("chain rewind interrupted calculation of finalized block hash")
+		} else if c.isAncestor(*fh, hash) {
+			finalizedHash = *fh
+		}
+	}
+	// Refuse to reorg out the finalized block, the forkchoice update would only
+	// reject the update after the reorg already happened
+	if !c.isAncestor(finalizedHash, hash) {
+		return errors.New("new head does not descend from the finalized block")
+	}
+	state := engine.ForkchoiceStateV1{
+		HeadBlockHash:      hash,
+		SafeBlockHash:      hash,
+		FinalizedBlockHash: finalizedHash,
+	}
+	res, err := c.engineAPI.ForkchoiceUpdatedV2(state, nil)
+	if err != nil {
+		return err
+	}
+	if res.PayloadStatus.Status != engine.VALID {
+		return fmt.Errorf("forkchoice update rejected: %s", res.PayloadStatus.Status)
+	}
+	if c.eth.BlockChain().CurrentBlock().Hash() != hash {
+		return errors.New("block is a canonical ancestor of the head, use Fork to rewind the chain")
+	}
+	c.curForkchoiceState = state
 	return nil
 }
 
+// isAncestor reports whether the block with the given hash is an ancestor of,
+// or the same as the descendant block.
+func (c *SimulatedBeacon) isAncestor(ancestor, descendant common.Hash) bool {
+	anc := c.eth.BlockChain().GetHeaderByHash(ancestor)
+	if anc == nil {
+		return false
+	}
+	header := c.eth.BlockChain().GetHeaderByHash(descendant)
+	for header != nil && header.Number.Uint64() > anc.Number.Uint64() {
+		header = c.eth.BlockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return header != nil && header.Hash() == ancestor
+}
+
+// slotTime returns the duration of a slot in seconds, used to space out the
+// blocks around skipped slots.
+func (c *SimulatedBeacon) slotTime() uint64 {
+	if c.period == 0 {
+		return devSlotTime
+	}
+	return c.period
+}
+
 // loop runs the block production loop for non-zero period configuration
 func (c *SimulatedBeacon) loop() {
 	timer := time.NewTimer(0)
@@ -279,6 +415,9 @@ func (c *SimulatedBeacon) Rollback() {
 
 // Fork sets the head to the provided hash.
 func (c *SimulatedBeacon) Fork(parentHash common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	// Ensure no pending transactions.
 	c.eth.TxPool().Sync()
 	if len(c.eth.TxPool().Pending(txpool.PendingFilter{})) != 0 {
@@ -305,6 +444,96 @@ func (c *SimulatedBeacon) AdjustTime(adjustment time.Duration) error {
 	return c.sealBlock(withdrawals, parent.Time+uint64(adjustment))
 }
 
+// SealOptions customizes a block sealed via Seal.
+type SealOptions struct {
+	Parent    common.Hash // Block to build on, the current head if zero
+	Timestamp uint64      // Timestamp of the block, derived from the clock and the parent if zero
+	NoHead    bool        // Whether to import the block without making it the chain head
+}
+
+// Seal builds and imports a block according to the given options, returning
+// its hash. Building on a block other than the head creates a side chain, which
+// can be made canonical either right away or later via SetHead.
+//
+// Note, the pending transactions are taken from the pool, which tracks the state
+// of the current head. Building on an older block might thus leave out some of
+// them due to nonce gaps. The queued withdrawals are only included in blocks made
+// the chain head.
+func (c *SimulatedBeacon) Seal(opts SealOptions) (common.Hash, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var (
+		withdrawals []*types.Withdrawal
+		timestamp   = opts.Timestamp
+		force       = timestamp != 0
+	)
+	if !force {
+		timestamp = uint64(time.Now().Unix())
+	}
+	// Blocks off the canonical chain leave the withdrawals queued for the head
+	if !opts.NoHead {
+		withdrawals = c.withdrawals.gatherPending(10)
+	}
+	return c.seal(opts.Parent, withdrawals, timestamp, force, !opts.NoHead)
+}
+
+// SetHead sends a forkchoice update making the given block the chain head,
+// reorging the chain if it's on a side chain. Unlike Fork, the blocks of the
+// previous canonical chain are kept and may be switched back to.
+func (c *SimulatedBeacon) SetHead(hash common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	header := c.eth.BlockChain().GetHeaderByHash(hash)
+	if header == nil {
+		return errors.New("block not found")
+	}
+	return c.updateHead(hash, header.Number.Uint64())
+}
+
+// SkipSlots leaves the given number of slots empty before the next block, as if
+// the proposers of those slots missed them. The timestamp of the next block is
+// pushed forward accordingly, unless set explicitly.
+func (c *SimulatedBeacon) SkipSlots(slots uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.skippedSlots += slots
+}
+
+// WithholdFinality stops or resumes advancing the finalized block along with the
+// epochs. While withheld, the finalized block only moves via Finalize, allowing
+// reorgs deeper than an epoch.
+func (c *SimulatedBeacon) WithholdFinality(withhold bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.withholdFinality = withhold
+}
+
+// Finalize marks the given canonical block as finalized.
+func (c *SimulatedBeacon) Finalize(hash common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.isAncestor(hash, c.curForkchoiceState.HeadBlockHash) {
+		return errors.New("block not in canonical chain")
+	}
+	state := c.curForkchoiceState
+	state.FinalizedBlockHash = hash
+
+	res, err := c.engineAPI.ForkchoiceUpdatedV2(state, nil)
+	if err != nil {
+		return err
+	}
+	if res.PayloadStatus.Status != engine.VALID {
+		return fmt.Errorf("forkchoice update rejected: %s", res.PayloadStatus.Status)
+	}
+	c.curForkchoiceState = state
+	return nil
+}
+
 func RegisterSimulatedBeaconAPIs(stack *node.Node, sim *SimulatedBeacon) {
 	api := &api{sim}
 	if sim.period == 0 {
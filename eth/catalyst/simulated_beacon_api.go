@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -59,3 +60,42 @@ func (a *api) AddWithdrawal(ctx context.Context, withdrawal *types.Withdrawal) e
 func (a *api) SetFeeRecipient(ctx context.Context, feeRecipient common.Address) {
 	a.sim.setFeeRecipient(feeRecipient)
 }
+
+// SealArgs represents the arguments to seal a block on demand.
+type SealArgs struct {
+	Parent    *common.Hash    `json:"parent"`
+	Timestamp *hexutil.Uint64 `json:"timestamp"`
+	NoHead    bool            `json:"noHead"`
+}
+
+// Seal builds and imports a block, optionally on top of an older block, with an
+// arbitrary timestamp, or without making it the chain head.
+func (a *api) Seal(ctx context.Context, args *SealArgs) (common.Hash, error) {
+	var opts SealOptions
+	if args != nil {
+		if args.Parent != nil {
+			opts.Parent = *args.Parent
+		}
+		if args.Timestamp != nil {
+			opts.Timestamp = uint64(*args.Timestamp)
+		}
+		opts.NoHead = args.NoHead
+	}
+	return a.sim.Seal(opts)
+}
+
+func (a *api) SetHead(ctx context.Context, hash common.Hash) error {
+	return a.sim.SetHead(hash)
+}
+
+func (a *api) SkipSlots(ctx context.Context, slots hexutil.Uint64) {
+	a.sim.SkipSlots(uint64(slots))
+}
+
+func (a *api) WithholdFinality(ctx context.Context, withhold bool) {
+	a.sim.WithholdFinality(withhold)
+}
+
+func (a *api) Finalize(ctx context.Context, hash common.Hash) error {
+	return a.sim.Finalize(hash)
+}
@@ -35,7 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
-func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.Node, *eth.Ethereum, *SimulatedBeacon) {
+func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis, period uint64) (*node.Node, *eth.Ethereum, *SimulatedBeacon) {
 	t.Helper()
 
 	n, err := node.New(&node.Config{
@@ -55,7 +55,7 @@ func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.
 		t.Fatal("can't create eth service:", err)
 	}
 
-	simBeacon, err := NewSimulatedBeacon(1, ethservice)
+	simBeacon, err := NewSimulatedBeacon(period, ethservice)
 	if err != nil {
 		t.Fatal("can't create simulated beacon:", err)
 	}
@@ -87,7 +87,7 @@ func TestSimulatedBeaconSendWithdrawals(t *testing.T) {
 	// short period (1 second) for testing purposes
 	var gasLimit uint64 = 10_000_000
 	genesis := core.DeveloperGenesisBlock(gasLimit, &testAddr)
-	node, ethService, mock := startSimulatedBeaconEthService(t, genesis)
+	node, ethService, mock := startSimulatedBeaconEthService(t, genesis, 1)
 	_ = mock
 	defer node.Close()
 
@@ -140,3 +140,102 @@ func TestSimulatedBeaconSendWithdrawals(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the simulated beacon can be scripted to build competing forks,
+// reorg between them, skip slots and withhold finality.
+func TestSimulatedBeaconScripting(t *testing.T) {
+	genesis := core.DeveloperGenesisBlock(30_000_000, &testAddr)
+	node, ethService, sim := startSimulatedBeaconEthService(t, genesis, 0)
+	defer node.Close()
+
+	chain := ethService.BlockChain()
+	seal := func(opts SealOptions) *types.Header {
+		t.Helper()
+		hash, err := sim.Seal(opts)
+		if err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		return chain.GetHeaderByHash(hash)
+	}
+	// Build a canonical chain, skipping a few slots before its last block
+	a1 := seal(SealOptions{})
+	a2 := seal(SealOptions{})
+	sim.SkipSlots(2)
+	a3 := seal(SealOptions{})
+	if chain.CurrentBlock().Hash() != a3.Hash() {
+		t.Fatalf("head mismatch: have %d, want %d", chain.CurrentBlock().Number, a3.Number)
+	}
+	if a3.Time < a2.Time+1+2*devSlotTime {
+		t.Fatalf("skipped slots not reflected in timestamp: parent %d, block %d", a2.Time, a3.Time)
+	}
+	// Build a longer competing fork from the first block without switching to it
+	b2 := seal(SealOptions{Parent: a1.Hash(), NoHead: true})
+	b3 := seal(SealOptions{Parent: b2.Hash(), NoHead: true})
+	b4 := seal(SealOptions{Parent: b3.Hash(), NoHead: true, Timestamp: b3.Time + 100})
+	if chain.CurrentBlock().Hash() != a3.Hash() {
+		t.Fatalf("head moved to side chain: have %d %x", chain.CurrentBlock().Number, chain.CurrentBlock().Hash())
+	}
+	if b4.Time != b3.Time+100 {
+		t.Fatalf("timestamp mismatch: have %d, want %d", b4.Time, b3.Time+100)
+	}
+	if _, err := sim.Seal(SealOptions{Parent: b4.Hash(), Timestamp: b4.Time}); err == nil {
+		t.Fatalf("block sealed with timestamp not after its parent")
+	}
+	// Reorg to the fork and back
+	if err := sim.SetHead(b4.Hash()); err != nil {
+		t.Fatalf("failed to reorg to fork: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != b4.Hash() || chain.GetCanonicalHash(2) != b2.Hash() {
+		t.Fatalf("reorg to fork failed: head %d %x", chain.CurrentBlock().Number, chain.CurrentBlock().Hash())
+	}
+	if err := sim.SetHead(b2.Hash()); err == nil {
+		t.Fatalf("head rewound to canonical ancestor")
+	}
+	if err := sim.SetHead(a3.Hash()); err != nil {
+		t.Fatalf("failed to reorg back: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != a3.Hash() {
+		t.Fatalf("reorg back failed: head %d %x", chain.CurrentBlock().Number, chain.CurrentBlock().Hash())
+	}
+	// Finalize a block and ensure reorgs below it are refused
+	sim.WithholdFinality(true)
+	if err := sim.Finalize(a2.Hash()); err != nil {
+		t.Fatalf("failed to finalize block: %v", err)
+	}
+	if final := chain.CurrentFinalBlock(); final == nil || final.Hash() != a2.Hash() {
+		t.Fatalf("finalized block mismatch: have %v, want %d", final, a2.Number)
+	}
+	if err := sim.Finalize(b2.Hash()); err == nil {
+		t.Fatalf("side chain block finalized")
+	}
+	if err := sim.SetHead(b4.Hash()); err == nil {
+		t.Fatalf("finalized block reorged out")
+	}
+	a4 := seal(SealOptions{})
+	if a4.ParentHash != a3.Hash() {
+		t.Fatalf("block not sealed on head")
+	}
+	if final := chain.CurrentFinalBlock(); final.Hash() != a2.Hash() {
+		t.Fatalf("withheld finality advanced to %d", final.Number)
+	}
+	// Rewinding the chain must not move the withheld finalized block either
+	if err := sim.Fork(a3.Hash()); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	seal(SealOptions{})
+	if final := chain.CurrentFinalBlock(); final.Hash() != a2.Hash() {
+		t.Fatalf("withheld finality moved by rewind to %d", final.Number)
+	}
+	// Withdrawals are left queued by the blocks not made the chain head
+	if err := sim.withdrawals.add(&types.Withdrawal{Index: 42}); err != nil {
+		t.Fatalf("failed to queue withdrawal: %v", err)
+	}
+	side := seal(SealOptions{Parent: a3.Hash(), NoHead: true})
+	if block := chain.GetBlockByHash(side.Hash()); len(block.Withdrawals()) != 0 {
+		t.Fatalf("withdrawals included in side block: %d", len(block.Withdrawals()))
+	}
+	head := seal(SealOptions{})
+	if block := chain.GetBlockByHash(head.Hash()); len(block.Withdrawals()) != 1 || block.Withdrawals()[0].Index != 42 {
+		t.Fatalf("queued withdrawal not included in head block: %v", block.Withdrawals())
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -100,8 +101,9 @@ type Downloader struct {
 	blockchain BlockChain
 
 	// Callbacks
-	dropPeer peerDropFn // Drops a peer for misbehaving
-	badBlock badBlockFn // Reports a block as rejected by the chain
+	dropPeer   peerDropFn // Drops a peer for misbehaving
+	punishPeer peerDropFn // Drops and penalizes a peer for delivering invalid data
+	badBlock   badBlockFn // Reports a block as rejected by the chain
 
 	// Status
 	synchronising atomic.Bool
@@ -115,6 +117,9 @@ type Downloader struct {
 	// Skeleton sync
 	skeleton *skeleton // Header skeleton to backfill the chain with (eth2 mode)
 
+	// History archive
+	history *era.History // Local Era1 archive to fill pre-merge blocks from, nil if unused
+
 	// State sync
 	pivotHeader *types.Header // Pivot block header to dynamically push the syncing state root
 	pivotLock   sync.RWMutex  // Lock protecting pivot header reads from updates
@@ -193,23 +198,33 @@ type BlockChain interface {
 	TrieDB() *triedb.Database
 }
 
-// New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, dropPeer peerDropFn, success func()) *Downloader {
+// New creates a new downloader to fetch hashes and blocks from remote peers. If
+// a history archive is given, pre-merge blocks are filled from it when possible.
+//
+// Peers are dropped via dropPeer if they stall the sync, and via punishPeer if
+// they deliver data which doesn't match the requested headers.
+func New(stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, history *era.History, dropPeer peerDropFn, punishPeer peerDropFn, success func()) *Downloader {
 	dl := &Downloader{
 		stateDB:        stateDb,
 		mux:            mux,
 		queue:          newQueue(blockCacheMaxItems, blockCacheInitialItems),
 		peers:          newPeerSet(),
 		blockchain:     chain,
+		history:        history,
 		dropPeer:       dropPeer,
+		punishPeer:     punishPeer,
 		headerProcCh:   make(chan *headerTask, 1),
 		quitCh:         make(chan struct{}),
 		SnapSyncer:     snap.NewSyncer(stateDb, chain.TrieDB().Scheme()),
 		stateSyncStart: make(chan *stateSync),
 		syncStartBlock: chain.CurrentSnapBlock().Number.Uint64(),
 	}
+	if history != nil {
+		dl.queue.history = history
+		dl.queue.historyPeer = newPeerConnection("history", 0, nil, log.New("peer", "history"))
+	}
 	// Create the post-merge skeleton syncer and start the process
-	dl.skeleton = newSkeleton(stateDb, dl.peers, dropPeer, newBeaconBackfiller(dl, success))
+	dl.skeleton = newSkeleton(stateDb, dl.peers, dropPeer, punishPeer, newBeaconBackfiller(dl, success))
 
 	go dl.stateFetcher()
 	return dl
@@ -258,6 +273,59 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	}
 }
 
+// Phases of a chain sync, complemented by the phases of the snap state sync.
+const (
+	SyncPhaseIdle     = "idle"     // No sync is running
+	SyncPhaseSkeleton = "skeleton" // Headers are being downloaded backwards from the beacon head
+	SyncPhaseChain    = "chain"    // Blocks (and receipts) are being downloaded and imported
+)
+
+// SyncStatus is a detailed report on the running chain sync, meant to help
+// diagnosing which phase of the sync is stalling and how long it has left.
+type SyncStatus struct {
+	Phase string   `json:"phase"` // Current phase of the sync, either a chain or a snap phase
+	Mode  SyncMode `json:"mode"`  // Sync mode the chain is synced with
+
+	StartingBlock uint64 `json:"startingBlock"` // Block number where sync began
+	CurrentBlock  uint64 `json:"currentBlock"`  // Current block number where sync is at
+	HighestBlock  uint64 `json:"highestBlock"`  // Highest alleged block number in the chain
+
+	SkeletonHead *uint64 `json:"skeletonHead,omitempty"` // Head of the beacon header skeleton
+	SkeletonTail *uint64 `json:"skeletonTail,omitempty"` // Tail of the beacon header skeleton
+
+	State *snap.SyncStatus `json:"state,omitempty"` // Detailed status of the running state sync
+}
+
+// SyncStatus retrieves a detailed report on the running chain sync, including
+// the status of the snap state sync if one is running.
+func (d *Downloader) SyncStatus() *SyncStatus {
+	progress := d.Progress()
+	status := &SyncStatus{
+		Phase:         SyncPhaseIdle,
+		Mode:          d.getMode(),
+		StartingBlock: progress.StartingBlock,
+		CurrentBlock:  progress.CurrentBlock,
+		HighestBlock:  progress.HighestBlock,
+		State:         d.SnapSyncer.Status(),
+	}
+	head, tail, _, err := d.skeleton.Bounds()
+	if err == nil {
+		headNumber, tailNumber := head.Number.Uint64(), tail.Number.Uint64()
+		status.SkeletonHead, status.SkeletonTail = &headNumber, &tailNumber
+	}
+	switch {
+	case status.State != nil:
+		status.Phase = status.State.Phase
+	case d.synchronising.Load():
+		status.Phase = SyncPhaseChain
+	case err == nil && head.Number.Cmp(d.blockchain.CurrentHeader().Number) > 0:
+		// The skeleton is ahead of the local chain, but the chain filling has not
+		// started yet, so the skeleton must still be linking up
+		status.Phase = SyncPhaseSkeleton
+	}
+	return status
+}
+
 // RegisterPeer injects a new download peer into the set of block source to be
 // used for fetching hashes and blocks from.
 func (d *Downloader) RegisterPeer(id string, version uint, peer Peer) error {
@@ -523,6 +591,9 @@ func (d *Downloader) syncToHead() (err error) {
 		func() error { return d.fetchReceipts(origin + 1) }, // Receipts are retrieved during snap sync
 		func() error { return d.processHeaders(origin + 1) },
 	}
+	if d.history != nil {
+		fetchers = append(fetchers, d.fetchHistory) // Bodies and receipts are filled from the local archive
+	}
 	if mode == SnapSync {
 		d.pivotLock.Lock()
 		d.pivotHeader = pivot
@@ -636,11 +707,16 @@ func (d *Downloader) fetchReceipts(from uint64) error {
 // queue until the stream ends or a failure occurs.
 func (d *Downloader) processHeaders(origin uint64) error {
 	var (
-		mode  = d.getMode()
-		timer = time.NewTimer(time.Second)
+		mode    = d.getMode()
+		timer   = time.NewTimer(time.Second)
+		fetches = []chan bool{d.queue.blockWakeCh, d.queue.receiptWakeCh}
 	)
 	defer timer.Stop()
 
+	if d.history != nil {
+		fetches = append(fetches, d.queue.historyWakeCh)
+	}
+
 	for {
 		select {
 		case <-d.cancelCh:
@@ -650,7 +726,7 @@ func (d *Downloader) processHeaders(origin uint64) error {
 			// Terminate header processing if we synced up
 			if task == nil || len(task.headers) == 0 {
 				// Notify everyone that headers are fully processed
-				for _, ch := range []chan bool{d.queue.blockWakeCh, d.queue.receiptWakeCh} {
+				for _, ch := range fetches {
 					select {
 					case ch <- false:
 					case <-d.cancelCh:
@@ -715,7 +791,7 @@ func (d *Downloader) processHeaders(origin uint64) error {
 			d.syncStatsLock.Unlock()
 
 			// Signal the content downloaders of the availability of new tasks
-			for _, ch := range []chan bool{d.queue.blockWakeCh, d.queue.receiptWakeCh} {
+			for _, ch := range fetches {
 				select {
 				case ch <- true:
 				default:
@@ -18,7 +18,12 @@ package downloader
 
 import (
 	"fmt"
+	"math"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -34,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -45,8 +51,9 @@ type downloadTester struct {
 	chain      *core.BlockChain
 	downloader *Downloader
 
-	peers map[string]*downloadTesterPeer
-	lock  sync.RWMutex
+	peers    map[string]*downloadTesterPeer
+	punished map[string]int
+	lock     sync.RWMutex
 }
 
 // newTester creates a new downloader test mocker.
@@ -56,6 +63,12 @@ func newTester(t *testing.T) *downloadTester {
 
 // newTesterWithNotification creates a new downloader test mocker.
 func newTesterWithNotification(t *testing.T, success func()) *downloadTester {
+	return newTesterWithHistory(t, nil, success)
+}
+
+// newTesterWithHistory creates a new downloader test mocker using a history
+// archive.
+func newTesterWithHistory(t *testing.T, history *era.History, success func()) *downloadTester {
 	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false)
 	if err != nil {
 		panic(err)
@@ -73,10 +86,11 @@ func newTesterWithNotification(t *testing.T, success func()) *downloadTester {
 		panic(err)
 	}
 	tester := &downloadTester{
-		chain: chain,
-		peers: make(map[string]*downloadTesterPeer),
+		chain:    chain,
+		peers:    make(map[string]*downloadTesterPeer),
+		punished: make(map[string]int),
 	}
-	tester.downloader = New(db, new(event.TypeMux), tester.chain, tester.dropPeer, success)
+	tester.downloader = New(db, new(event.TypeMux), tester.chain, history, tester.dropPeer, tester.punishPeer, success)
 	return tester
 }
 
@@ -119,9 +133,20 @@ func (dl *downloadTester) dropPeer(id string) {
 	dl.downloader.UnregisterPeer(id)
 }
 
+// punishPeer simulates a peer removal for delivering invalid data, tracking the
+// penalty to check against.
+func (dl *downloadTester) punishPeer(id string) {
+	dl.lock.Lock()
+	dl.punished[id]++
+	dl.lock.Unlock()
+
+	dl.dropPeer(id)
+}
+
 type downloadTesterPeer struct {
 	dl             *downloadTester
 	withholdBodies map[common.Hash]struct{}
+	corruptBodies  bool // Strip the uncles and transactions from delivered bodies
 	id             string
 	chain          *core.BlockChain
 }
@@ -225,6 +250,9 @@ func (dlp *downloadTesterPeer) RequestBodies(hashes []common.Hash, sink chan *et
 	for i, blob := range blobs {
 		bodies[i] = new(eth.BlockBody)
 		rlp.DecodeBytes(blob, bodies[i])
+		if dlp.corruptBodies {
+			bodies[i].Transactions, bodies[i].Uncles = nil, nil
+		}
 	}
 	var (
 		txsHashes        = make([]common.Hash, len(bodies))
@@ -685,6 +713,56 @@ func testBeaconSync(t *testing.T, protocol uint, mode SyncMode) {
 	}
 }
 
+// Tests that peers delivering block bodies not matching the requested headers
+// are punished instead of just dropped, and that the sync recovers afterwards.
+func TestInvalidBodyPunishment68Full(t *testing.T) { testInvalidBodyPunishment(t, eth.ETH68, FullSync) }
+func TestInvalidBodyPunishment68Snap(t *testing.T) { testInvalidBodyPunishment(t, eth.ETH68, SnapSync) }
+
+func testInvalidBodyPunishment(t *testing.T, protocol uint, mode SyncMode) {
+	success := make(chan struct{})
+	tester := newTesterWithNotification(t, func() {
+		close(success)
+	})
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+
+	// Sync from a peer serving the correct headers, but junk bodies
+	faultyPeer := tester.newPeer("peer-faulty", protocol, chain.blocks[1:])
+	faultyPeer.corruptBodies = true
+
+	if err := tester.downloader.BeaconSync(mode, chain.blocks[len(chain.blocks)-1].Header(), nil); err != nil {
+		t.Fatalf("failed to beacon-sync chain: %v", err)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		tester.lock.RLock()
+		punished := tester.punished["peer-faulty"]
+		tester.lock.RUnlock()
+
+		if punished > 0 {
+			break
+		}
+		if time.Since(start) > 3*time.Second {
+			t.Fatalf("faulty peer not punished in three seconds")
+		}
+	}
+	// Connect an honest peer and ensure the sync completes without penalties
+	tester.newPeer("peer-full", protocol, chain.blocks[1:])
+
+	select {
+	case <-success:
+	case <-time.NewTimer(time.Second * 3).C:
+		t.Fatalf("Failed to sync chain in three seconds")
+	}
+	assertOwnChain(t, tester, len(chain.blocks))
+
+	tester.lock.RLock()
+	defer tester.lock.RUnlock()
+	if n := tester.punished["peer-full"]; n != 0 {
+		t.Fatalf("honest peer punished %d times", n)
+	}
+}
+
 // Tests that synchronisation progress (origin block number, current block number
 // and highest block number) is tracked and updated correctly.
 func TestSyncProgress68Full(t *testing.T) { testSyncProgress(t, eth.ETH68, FullSync) }
@@ -740,3 +818,155 @@ func testSyncProgress(t *testing.T, protocol uint, mode SyncMode) {
 		t.Fatalf("Failed to sync chain in three seconds")
 	}
 }
+
+// Tests that block bodies and receipts are filled from a history archive, and
+// that anything missing from it, or failing verification, is retrieved from
+// the network instead.
+func TestHistoryArchiveSync68Full(t *testing.T) { testHistoryArchiveSync(t, eth.ETH68, FullSync) }
+func TestHistoryArchiveSync68Snap(t *testing.T) { testHistoryArchiveSync(t, eth.ETH68, SnapSync) }
+
+func testHistoryArchiveSync(t *testing.T, protocol uint, mode SyncMode) {
+	t.Run("partial", func(t *testing.T) { testHistoryArchive(t, protocol, mode, false) })
+	t.Run("corrupt", func(t *testing.T) { testHistoryArchive(t, protocol, mode, true) })
+}
+
+func testHistoryArchive(t *testing.T, protocol uint, mode SyncMode, corrupt bool) {
+	var (
+		chain   = testChainBase.shorten(blockCacheMaxItems - 15)
+		archive = len(chain.blocks) / 2 // Blocks contained in the archive
+		dir     = t.TempDir()
+	)
+	// Write the first half of the chain into an era1 file
+	writeTestEra(t, dir, chain, archive, corrupt)
+
+	history, err := era.NewHistory(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	success := make(chan struct{})
+	tester := newTesterWithHistory(t, history, func() {
+		close(success)
+	})
+	defer tester.terminate()
+
+	tester.newPeer("peer", protocol, chain.blocks[1:])
+
+	// Track the lowest block retrieved from the network
+	var fetched atomic.Uint64
+	fetched.Store(math.MaxUint64)
+	hook := func(headers []*types.Header) {
+		for _, header := range headers {
+			for old := fetched.Load(); header.Number.Uint64() < old; old = fetched.Load() {
+				if fetched.CompareAndSwap(old, header.Number.Uint64()) {
+					break
+				}
+			}
+		}
+	}
+	tester.downloader.bodyFetchHook = hook
+	tester.downloader.receiptFetchHook = hook
+
+	if err := tester.downloader.BeaconSync(mode, chain.blocks[len(chain.blocks)-1].Header(), nil); err != nil {
+		t.Fatalf("failed to beacon-sync chain: %v", err)
+	}
+	select {
+	case <-success:
+		assertOwnChain(t, tester, len(chain.blocks))
+	case <-time.NewTimer(time.Second * 10).C:
+		t.Fatalf("Failed to sync chain in ten seconds")
+	}
+	if corrupt {
+		if fetched.Load() != 1 {
+			t.Errorf("corrupt archive used: lowest fetched block %d", fetched.Load())
+		}
+	} else if fetched.Load() < uint64(archive) {
+		t.Errorf("archived block %d fetched from network", fetched.Load())
+	}
+}
+
+// writeTestEra writes the first blocks of a test chain into an era1 file in the
+// given directory, returning the name of the file.
+func writeTestEra(t *testing.T, dir string, chain *testChain, blocks int, corrupt bool) string {
+	source := newTestBlockchain(chain.blocks[1:])
+
+	f, err := os.Create(filepath.Join(dir, "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := era.NewBuilder(f)
+	for _, block := range chain.blocks[:blocks] {
+		receipts := source.GetReceiptsByHash(block.Hash())
+		if err := builder.Add(block, receipts, source.GetTd(block.Hash(), block.NumberU64())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if corrupt {
+		root = common.Hash{0xde, 0xad, 0xbe, 0xef}
+	}
+	name := era.Filename("test", 0, root)
+	if err := os.Rename(f.Name(), filepath.Join(dir, name)); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// Tests that a slow history mirror download does not block availability checks,
+// which the downloader performs while holding its queue lock.
+func TestHistoryArchiveRemoteNonBlocking(t *testing.T) {
+	var (
+		chain   = testChainBase.shorten(blockCacheMaxItems - 15)
+		dir     = t.TempDir()
+		name    = writeTestEra(t, dir, chain, len(chain.blocks), false)
+		release = make(chan struct{})
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprintf(w, `<a href="%s">%s</a>`, name, name)
+			return
+		}
+		<-release
+		http.ServeFile(w, r, filepath.Join(dir, name))
+	}))
+	defer server.Close()
+
+	history, err := era.NewHistory(server.URL, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	defer unblock()
+
+	header := chain.blocks[10].Header()
+	done := make(chan error, 1)
+	go func() {
+		_, err := history.Body(header)
+		done <- err
+	}()
+	checked := make(chan bool)
+	go func() {
+		time.Sleep(100 * time.Millisecond) // Give the download a head start
+		checked <- history.Available(header.Number.Uint64())
+	}()
+	select {
+	case available := <-checked:
+		if !available {
+			t.Error("block not available while downloading")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("availability check blocked by download")
+	}
+	unblock()
+	if err := <-done; err != nil {
+		t.Fatalf("failed to retrieve body: %v", err)
+	}
+}
@@ -336,6 +336,11 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 				if errors.Is(err, errInvalidChain) {
 					return err
 				}
+				// Data not matching the requested headers can't be caused by
+				// the network, drop the peer as faulty or malicious.
+				if errors.Is(err, errInvalidBody) || errors.Is(err, errInvalidReceipt) {
+					d.punishPeer(peer.id)
+				}
 				// Unless a peer delivered something completely else than requested (usually
 				// caused by a timed out request which came through in the end), set it to
 				// idle. If the delivery's stale, the peer should have already been idled.
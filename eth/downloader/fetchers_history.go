@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	historyFetchBatch = 256                    // Number of blocks to fill from the history archive at once
+	historyWaitPeriod = 100 * time.Millisecond // Time to wait for result slots to free up
+)
+
+// fetchHistory iteratively fills the scheduled block bodies and receipts from
+// the local history archive. Fetches the archive can't serve are left to the
+// remote peers.
+func (d *Downloader) fetchHistory() error {
+	var (
+		finished bool
+		filled   int
+		timer    = time.NewTimer(0)
+	)
+	<-timer.C
+	defer timer.Stop()
+
+	for {
+		bodies := d.fillHistoryBodies()
+		receipts := d.fillHistoryReceipts()
+		if bodies || receipts {
+			// Blocks the archive failed to serve need to be fetched from peers
+			for _, ch := range []chan bool{d.queue.blockWakeCh, d.queue.receiptWakeCh} {
+				select {
+				case ch <- true:
+				default:
+				}
+			}
+			filled++
+			continue
+		}
+		if finished && !d.queue.HistoryPending() {
+			log.Debug("Finished filling history from archive", "batches", filled)
+			return nil
+		}
+		// Nothing to do until new headers are scheduled or results are consumed
+		timer.Reset(historyWaitPeriod)
+		select {
+		case <-d.cancelCh:
+			return errCanceled
+		case cont := <-d.queue.historyWakeCh:
+			if !cont {
+				finished = true
+			}
+		case <-timer.C:
+		}
+	}
+}
+
+// fillHistoryBodies reserves a batch of body fetches for the history archive and
+// delivers them to the queue, returning whether anything was reserved.
+func (d *Downloader) fillHistoryBodies() bool {
+	q := d.queue
+	req, _, _ := q.ReserveBodies(q.historyPeer, historyFetchBatch)
+	if req == nil {
+		return false
+	}
+	var (
+		txs              [][]*types.Transaction
+		txHashes         []common.Hash
+		uncles           [][]*types.Header
+		uncleHashes      []common.Hash
+		withdrawals      [][]*types.Withdrawal
+		withdrawalHashes []common.Hash
+		hasher           = trie.NewStackTrie(nil)
+	)
+	for _, header := range req.Headers {
+		body, err := d.history.Body(header)
+		if err != nil {
+			break // the rest are returned to the queue on delivery
+		}
+		txs = append(txs, body.Transactions)
+		txHashes = append(txHashes, types.DeriveSha(types.Transactions(body.Transactions), hasher))
+		uncles = append(uncles, body.Uncles)
+		uncleHashes = append(uncleHashes, types.CalcUncleHash(body.Uncles))
+		withdrawals = append(withdrawals, body.Withdrawals)
+		if body.Withdrawals != nil {
+			withdrawalHashes = append(withdrawalHashes, types.DeriveSha(types.Withdrawals(body.Withdrawals), hasher))
+		} else {
+			withdrawalHashes = append(withdrawalHashes, common.Hash{})
+		}
+	}
+	accepted, err := q.DeliverBodies(q.historyPeer.id, txs, txHashes, uncles, uncleHashes, withdrawals, withdrawalHashes)
+	if err != nil {
+		d.history.Reject(req.Headers[accepted].Number.Uint64(), err)
+	}
+	log.Trace("Filled bodies from history archive", "count", accepted, "from", req.Headers[0].Number)
+	return true
+}
+
+// fillHistoryReceipts reserves a batch of receipt fetches for the history archive
+// and delivers them to the queue, returning whether anything was reserved.
+func (d *Downloader) fillHistoryReceipts() bool {
+	q := d.queue
+	req, _, _ := q.ReserveReceipts(q.historyPeer, historyFetchBatch)
+	if req == nil {
+		return false
+	}
+	var (
+		receipts [][]*types.Receipt
+		hashes   []common.Hash
+		hasher   = trie.NewStackTrie(nil)
+	)
+	for _, header := range req.Headers {
+		list, err := d.history.Receipts(header)
+		if err != nil {
+			break // the rest are returned to the queue on delivery
+		}
+		receipts = append(receipts, list)
+		hashes = append(hashes, types.DeriveSha(list, hasher))
+	}
+	accepted, err := q.DeliverReceipts(q.historyPeer.id, receipts, hashes)
+	if err != nil {
+		d.history.Reject(req.Headers[accepted].Number.Uint64(), err)
+	}
+	log.Trace("Filled receipts from history archive", "count", accepted, "from", req.Headers[0].Number)
+	return true
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
@@ -151,6 +152,10 @@ type queue struct {
 	resultCache *resultStore       // Downloaded but not yet delivered fetch results
 	resultSize  common.StorageSize // Approximate size of a block (exponential moving average)
 
+	history       *era.History    // Local history archive to fill pre-merge blocks from
+	historyPeer   *peerConnection // Pseudo-peer reserving the fetches of the history archive
+	historyWakeCh chan bool       // Channel to notify the history fetcher of new tasks
+
 	lock   *sync.RWMutex
 	active *sync.Cond
 	closed bool
@@ -167,6 +172,7 @@ func newQueue(blockCacheLimit int, thresholdInitialSize int) *queue {
 		blockWakeCh:      make(chan bool, 1),
 		receiptTaskQueue: prque.New[int64, *types.Header](nil),
 		receiptWakeCh:    make(chan bool, 1),
+		historyWakeCh:    make(chan bool, 1),
 		active:           sync.NewCond(lock),
 		lock:             lock,
 	}
@@ -215,19 +221,23 @@ func (q *queue) PendingHeaders() int {
 }
 
 // PendingBodies retrieves the number of block body requests pending for retrieval.
+// Requests being filled from the history archive are counted too, since they may
+// be handed back to the remote peers.
 func (q *queue) PendingBodies() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	return q.blockTaskQueue.Size()
+	return q.blockTaskQueue.Size() + q.historyFetching(q.blockPendPool)
 }
 
 // PendingReceipts retrieves the number of block receipts pending for retrieval.
+// Requests being filled from the history archive are counted too, since they may
+// be handed back to the remote peers.
 func (q *queue) PendingReceipts() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	return q.receiptTaskQueue.Size()
+	return q.receiptTaskQueue.Size() + q.historyFetching(q.receiptPendPool)
 }
 
 // InFlightBlocks retrieves whether there are block fetch requests currently in
@@ -558,7 +568,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 		// Remove it from the task queue
 		taskQueue.PopItem()
 		// Otherwise unless the peer is known not to have the data, add to the retrieve list
-		if p.Lacks(header.Hash()) {
+		if q.historySkips(p, header) || p.Lacks(header.Hash()) {
 			skip = append(skip, header)
 		} else {
 			send = append(send, header)
@@ -585,6 +595,44 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 	return request, progress, throttled
 }
 
+// historySkips reports whether a fetch must be left to someone else than the
+// given peer: fetches available from the local history archive are reserved
+// for it, while all other fetches are left to the remote peers.
+func (q *queue) historySkips(p *peerConnection, header *types.Header) bool {
+	if q.history == nil {
+		return false
+	}
+	return q.history.Available(header.Number.Uint64()) != (p == q.historyPeer)
+}
+
+// historyFetching returns the number of fetches in the given pending pool that
+// are currently being filled from the local history archive.
+func (q *queue) historyFetching(pendPool map[string]*fetchRequest) int {
+	if q.historyPeer == nil {
+		return 0
+	}
+	if request, ok := pendPool[q.historyPeer.id]; ok {
+		return len(request.Headers)
+	}
+	return 0
+}
+
+// HistoryPending reports whether any of the queued fetches are available from
+// the local history archive.
+func (q *queue) HistoryPending() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, pool := range []map[common.Hash]*types.Header{q.blockTaskPool, q.receiptTaskPool} {
+		for _, header := range pool {
+			if q.history.Available(header.Number.Uint64()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Revoke cancels all pending requests belonging to a given peer. This method is
 // meant to be called during a peer drop to quickly reassign owned data fetches
 // to remaining nodes.
@@ -893,8 +941,10 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header,
 	reqTimer.UpdateSince(request.Time)
 	resInMeter.Mark(int64(results))
 
-	// If no data items were retrieved, mark them as unavailable for the origin peer
-	if results == 0 {
+	// If no data items were retrieved, mark them as unavailable for the origin peer.
+	// The history archive tracks its own availability, which the remote peers also
+	// rely on to decide what to fetch, so it must not be marked lacking.
+	if results == 0 && request.Peer != q.historyPeer {
 		for _, header := range request.Headers {
 			request.Peer.MarkLacking(header.Hash())
 		}
@@ -949,7 +999,7 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header,
 	}
 	// If none of the data was good, it's a stale delivery
 	if accepted > 0 {
-		return accepted, fmt.Errorf("partial failure: %v", failure)
+		return accepted, fmt.Errorf("partial failure: %w", failure)
 	}
 	return accepted, fmt.Errorf("%w: %v", failure, errStaleDelivery)
 }
@@ -202,9 +202,10 @@ type skeleton struct {
 	db     ethdb.Database // Database backing the skeleton
 	filler backfiller     // Chain syncer suspended/resumed by head events
 
-	peers *peerSet                   // Set of peers we can sync from
-	idles map[string]*peerConnection // Set of idle peers in the current sync cycle
-	drop  peerDropFn                 // Drops a peer for misbehaving
+	peers  *peerSet                   // Set of peers we can sync from
+	idles  map[string]*peerConnection // Set of idle peers in the current sync cycle
+	drop   peerDropFn                 // Drops a peer for misbehaving
+	punish peerDropFn                 // Drops and penalizes a peer for delivering invalid data
 
 	progress *skeletonProgress // Sync progress tracker for resumption and metrics
 	started  time.Time         // Timestamp when the skeleton syncer was created
@@ -227,12 +228,13 @@ type skeleton struct {
 
 // newSkeleton creates a new sync skeleton that tracks a potentially dangling
 // header chain until it's linked into an existing set of blocks.
-func newSkeleton(db ethdb.Database, peers *peerSet, drop peerDropFn, filler backfiller) *skeleton {
+func newSkeleton(db ethdb.Database, peers *peerSet, drop peerDropFn, punish peerDropFn, filler backfiller) *skeleton {
 	sk := &skeleton{
 		db:         db,
 		filler:     filler,
 		peers:      peers,
 		drop:       drop,
+		punish:     punish,
 		requests:   make(map[uint64]*headerRequest),
 		headEvents: make(chan *headUpdate),
 		terminate:  make(chan chan error),
@@ -955,7 +957,7 @@ func (s *skeleton) processResponse(res *headerResponse) (linked bool, merged boo
 			for i := 0; i < requestHeaders; i++ {
 				s.scratchSpace[i] = nil
 			}
-			s.drop(s.scratchOwners[0])
+			s.punish(s.scratchOwners[0])
 			s.scratchOwners[0] = ""
 			break
 		}
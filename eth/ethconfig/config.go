@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -63,10 +64,14 @@ var Defaults = Config{
 	Miner:              miner.DefaultConfig,
 	TxPool:             legacypool.DefaultConfig,
 	BlobPool:           blobpool.DefaultConfig,
+	BundlePool:         bundlepool.DefaultConfig,
 	RPCGasCap:          50000000,
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
+
+	RPCTraceFilterRangeLimit:  10000,
+	RPCTraceFilterResultLimit: 10000,
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -87,6 +92,10 @@ type Config struct {
 	EthDiscoveryURLs  []string
 	SnapDiscoveryURLs []string
 
+	// SnapServeBudget is the disk-read budget for serving snap sync requests
+	// to remote peers, in KiB per second (0 = unlimited).
+	SnapServeBudget uint64 `toml:",omitempty"`
+
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
@@ -95,6 +104,25 @@ type Config struct {
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
 
+	// HistoryArchive is an Era1 directory or HTTP mirror to fill in pre-merge
+	// block bodies and receipts from during sync, instead of fetching them
+	// from peers.
+	HistoryArchive string `toml:",omitempty"`
+
+	// HistoryExpiry is the block number below which block bodies and receipts
+	// are pruned from the ancient store. Expired history is still served from
+	// the HistoryArchive if it's a local directory. Zero retains all history.
+	HistoryExpiry uint64 `toml:",omitempty"`
+
+	// Log index options, the persistent log index speeds up log filtering over
+	// wide block ranges compared to the bloombits index.
+	LogIndex   bool   `toml:",omitempty"` // Whether to maintain the persistent log index
+	LogHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose logs are indexed, 0 means entire chain
+
+	// TraceIndex enables the index of call frame senders and recipients, which
+	// is used by trace_filter. Only the blocks imported afterwards are indexed.
+	TraceIndex bool `toml:",omitempty"`
+
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
 	// consistent with persistent state.
@@ -132,8 +160,9 @@ type Config struct {
 	Miner miner.Config
 
 	// Transaction pool options
-	TxPool   legacypool.Config
-	BlobPool blobpool.Config
+	TxPool     legacypool.Config
+	BlobPool   blobpool.Config
+	BundlePool bundlepool.Config
 
 	// Gas Price Oracle options
 	GPO gasprice.Config
@@ -161,6 +190,14 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// RPCTraceFilterRangeLimit is the maximum number of blocks a single
+	// trace_filter query may span (0 = unlimited).
+	RPCTraceFilterRangeLimit uint64
+
+	// RPCTraceFilterResultLimit is the maximum number of traces a single
+	// trace_filter query may return (0 = unlimited).
+	RPCTraceFilterResultLimit uint64
+
 	// OverrideCancun (TODO: remove after the fork)
 	OverrideCancun *uint64 `toml:",omitempty"`
 
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -17,48 +18,57 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64                 `toml:",omitempty"`
-		TransactionHistory      uint64                 `toml:",omitempty"`
-		StateHistory            uint64                 `toml:",omitempty"`
-		StateScheme             string                 `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightIngress            int                    `toml:",omitempty"`
-		LightEgress             int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		LightNoPrune            bool                   `toml:",omitempty"`
-		LightNoSyncServe        bool                   `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		TrieCleanCache          int
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Preimages               bool
-		FilterLogCacheSize      int
-		Miner                   miner.Config
-		TxPool                  legacypool.Config
-		BlobPool                blobpool.Config
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		EnableWitnessCollection bool `toml:"-"`
-		VMTrace                 string
-		VMTraceJsonConfig       string
-		DocRoot                 string `toml:"-"`
-		RPCGasCap               uint64
-		RPCEVMTimeout           time.Duration
-		RPCTxFeeCap             float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
-		OverrideVerkle          *uint64 `toml:",omitempty"`
+		Genesis                   *core.Genesis `toml:",omitempty"`
+		NetworkId                 uint64
+		SyncMode                  downloader.SyncMode
+		EthDiscoveryURLs          []string
+		SnapDiscoveryURLs         []string
+		SnapServeBudget           uint64 `toml:",omitempty"`
+		NoPruning                 bool
+		NoPrefetch                bool
+		TxLookupLimit             uint64                 `toml:",omitempty"`
+		TransactionHistory        uint64                 `toml:",omitempty"`
+		StateHistory              uint64                 `toml:",omitempty"`
+		HistoryArchive            string                 `toml:",omitempty"`
+		HistoryExpiry             uint64                 `toml:",omitempty"`
+		LogIndex                  bool                   `toml:",omitempty"`
+		LogHistory                uint64                 `toml:",omitempty"`
+		TraceIndex                bool                   `toml:",omitempty"`
+		StateScheme               string                 `toml:",omitempty"`
+		RequiredBlocks            map[uint64]common.Hash `toml:"-"`
+		LightServ                 int                    `toml:",omitempty"`
+		LightIngress              int                    `toml:",omitempty"`
+		LightEgress               int                    `toml:",omitempty"`
+		LightPeers                int                    `toml:",omitempty"`
+		LightNoPrune              bool                   `toml:",omitempty"`
+		LightNoSyncServe          bool                   `toml:",omitempty"`
+		SkipBcVersionCheck        bool                   `toml:"-"`
+		DatabaseHandles           int                    `toml:"-"`
+		DatabaseCache             int
+		DatabaseFreezer           string
+		TrieCleanCache            int
+		TrieDirtyCache            int
+		TrieTimeout               time.Duration
+		SnapshotCache             int
+		Preimages                 bool
+		FilterLogCacheSize        int
+		Miner                     miner.Config
+		TxPool                    legacypool.Config
+		BlobPool                  blobpool.Config
+		BundlePool                bundlepool.Config
+		GPO                       gasprice.Config
+		EnablePreimageRecording   bool
+		EnableWitnessCollection   bool `toml:"-"`
+		VMTrace                   string
+		VMTraceJsonConfig         string
+		DocRoot                   string `toml:"-"`
+		RPCGasCap                 uint64
+		RPCEVMTimeout             time.Duration
+		RPCTxFeeCap               float64
+		RPCTraceFilterRangeLimit  uint64
+		RPCTraceFilterResultLimit uint64
+		OverrideCancun            *uint64 `toml:",omitempty"`
+		OverrideVerkle            *uint64 `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -66,11 +76,17 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.EthDiscoveryURLs = c.EthDiscoveryURLs
 	enc.SnapDiscoveryURLs = c.SnapDiscoveryURLs
+	enc.SnapServeBudget = c.SnapServeBudget
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
 	enc.TransactionHistory = c.TransactionHistory
 	enc.StateHistory = c.StateHistory
+	enc.HistoryArchive = c.HistoryArchive
+	enc.HistoryExpiry = c.HistoryExpiry
+	enc.LogIndex = c.LogIndex
+	enc.LogHistory = c.LogHistory
+	enc.TraceIndex = c.TraceIndex
 	enc.StateScheme = c.StateScheme
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.LightServ = c.LightServ
@@ -92,6 +108,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
+	enc.BundlePool = c.BundlePool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.EnableWitnessCollection = c.EnableWitnessCollection
@@ -101,6 +118,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.RPCTraceFilterRangeLimit = c.RPCTraceFilterRangeLimit
+	enc.RPCTraceFilterResultLimit = c.RPCTraceFilterResultLimit
 	enc.OverrideCancun = c.OverrideCancun
 	enc.OverrideVerkle = c.OverrideVerkle
 	return &enc, nil
@@ -109,48 +128,57 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64                `toml:",omitempty"`
-		TransactionHistory      *uint64                `toml:",omitempty"`
-		StateHistory            *uint64                `toml:",omitempty"`
-		StateScheme             *string                `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightIngress            *int                   `toml:",omitempty"`
-		LightEgress             *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		LightNoPrune            *bool                  `toml:",omitempty"`
-		LightNoSyncServe        *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		TrieCleanCache          *int
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Preimages               *bool
-		FilterLogCacheSize      *int
-		Miner                   *miner.Config
-		TxPool                  *legacypool.Config
-		BlobPool                *blobpool.Config
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		EnableWitnessCollection *bool `toml:"-"`
-		VMTrace                 *string
-		VMTraceJsonConfig       *string
-		DocRoot                 *string `toml:"-"`
-		RPCGasCap               *uint64
-		RPCEVMTimeout           *time.Duration
-		RPCTxFeeCap             *float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
-		OverrideVerkle          *uint64 `toml:",omitempty"`
+		Genesis                   *core.Genesis `toml:",omitempty"`
+		NetworkId                 *uint64
+		SyncMode                  *downloader.SyncMode
+		EthDiscoveryURLs          []string
+		SnapDiscoveryURLs         []string
+		SnapServeBudget           *uint64 `toml:",omitempty"`
+		NoPruning                 *bool
+		NoPrefetch                *bool
+		TxLookupLimit             *uint64                `toml:",omitempty"`
+		TransactionHistory        *uint64                `toml:",omitempty"`
+		StateHistory              *uint64                `toml:",omitempty"`
+		HistoryArchive            *string                `toml:",omitempty"`
+		HistoryExpiry             *uint64                `toml:",omitempty"`
+		LogIndex                  *bool                  `toml:",omitempty"`
+		LogHistory                *uint64                `toml:",omitempty"`
+		TraceIndex                *bool                  `toml:",omitempty"`
+		StateScheme               *string                `toml:",omitempty"`
+		RequiredBlocks            map[uint64]common.Hash `toml:"-"`
+		LightServ                 *int                   `toml:",omitempty"`
+		LightIngress              *int                   `toml:",omitempty"`
+		LightEgress               *int                   `toml:",omitempty"`
+		LightPeers                *int                   `toml:",omitempty"`
+		LightNoPrune              *bool                  `toml:",omitempty"`
+		LightNoSyncServe          *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck        *bool                  `toml:"-"`
+		DatabaseHandles           *int                   `toml:"-"`
+		DatabaseCache             *int
+		DatabaseFreezer           *string
+		TrieCleanCache            *int
+		TrieDirtyCache            *int
+		TrieTimeout               *time.Duration
+		SnapshotCache             *int
+		Preimages                 *bool
+		FilterLogCacheSize        *int
+		Miner                     *miner.Config
+		TxPool                    *legacypool.Config
+		BlobPool                  *blobpool.Config
+		BundlePool                *bundlepool.Config
+		GPO                       *gasprice.Config
+		EnablePreimageRecording   *bool
+		EnableWitnessCollection   *bool `toml:"-"`
+		VMTrace                   *string
+		VMTraceJsonConfig         *string
+		DocRoot                   *string `toml:"-"`
+		RPCGasCap                 *uint64
+		RPCEVMTimeout             *time.Duration
+		RPCTxFeeCap               *float64
+		RPCTraceFilterRangeLimit  *uint64
+		RPCTraceFilterResultLimit *uint64
+		OverrideCancun            *uint64 `toml:",omitempty"`
+		OverrideVerkle            *uint64 `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -171,6 +199,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SnapDiscoveryURLs != nil {
 		c.SnapDiscoveryURLs = dec.SnapDiscoveryURLs
 	}
+	if dec.SnapServeBudget != nil {
+		c.SnapServeBudget = *dec.SnapServeBudget
+	}
 	if dec.NoPruning != nil {
 		c.NoPruning = *dec.NoPruning
 	}
@@ -186,6 +217,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateHistory != nil {
 		c.StateHistory = *dec.StateHistory
 	}
+	if dec.HistoryArchive != nil {
+		c.HistoryArchive = *dec.HistoryArchive
+	}
+	if dec.HistoryExpiry != nil {
+		c.HistoryExpiry = *dec.HistoryExpiry
+	}
+	if dec.LogIndex != nil {
+		c.LogIndex = *dec.LogIndex
+	}
+	if dec.LogHistory != nil {
+		c.LogHistory = *dec.LogHistory
+	}
+	if dec.TraceIndex != nil {
+		c.TraceIndex = *dec.TraceIndex
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
@@ -249,6 +295,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.BlobPool != nil {
 		c.BlobPool = *dec.BlobPool
 	}
+	if dec.BundlePool != nil {
+		c.BundlePool = *dec.BundlePool
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
@@ -276,6 +325,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.RPCTraceFilterRangeLimit != nil {
+		c.RPCTraceFilterRangeLimit = *dec.RPCTraceFilterRangeLimit
+	}
+	if dec.RPCTraceFilterResultLimit != nil {
+		c.RPCTraceFilterResultLimit = *dec.RPCTraceFilterResultLimit
+	}
 	if dec.OverrideCancun != nil {
 		c.OverrideCancun = dec.OverrideCancun
 	}
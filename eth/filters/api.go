@@ -22,12 +22,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -190,6 +192,60 @@ func (api *FilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool)
 	return rpcSub, nil
 }
 
+// TransactionLifecycle creates a subscription that is triggered each time a pooled
+// transaction matching the given criteria is received, promoted, replaced, dropped
+// or included in a block.
+func (api *FilterAPI) TransactionLifecycle(ctx context.Context, crit *TxLifecycleCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit == nil {
+		crit = new(TxLifecycleCriteria)
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan []txpool.TxLifecycleEvent, 128)
+		lifecycleSub := api.events.SubscribeTxLifecycle(*crit, events)
+		defer lifecycleSub.Unsubscribe()
+
+		for {
+			select {
+			case events := <-events:
+				for i := range events {
+					notifier.Notify(rpcSub.ID, ethapi.NewRPCTxLifecycleEvent(&events[i]))
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// TxLifecycleCriteria selects the pooled transactions to deliver the lifecycle
+// events of, either by hash or by sender. Empty criteria select all transactions.
+type TxLifecycleCriteria struct {
+	Hashes []common.Hash    `json:"hashes"`
+	From   []common.Address `json:"from"`
+}
+
+// filterTxLifecycle returns the lifecycle events matching the given criteria.
+func filterTxLifecycle(events []txpool.TxLifecycleEvent, crit *TxLifecycleCriteria) []txpool.TxLifecycleEvent {
+	if len(crit.Hashes) == 0 && len(crit.From) == 0 {
+		return events
+	}
+	var matched []txpool.TxLifecycleEvent
+	for _, ev := range events {
+		if slices.Contains(crit.Hashes, ev.Hash) || slices.Contains(crit.From, ev.From) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 func (api *FilterAPI) NewBlockFilter() rpc.ID {
@@ -251,7 +307,15 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+//
+// If the criteria start at a historical block or carry the cursor of a log delivered
+// before, the matching logs from there on are delivered first, followed by the new
+// logs without gaps or duplicates. Every log is then delivered along with its cursor,
+// which can be used to resume the subscription. If the block of the cursor has been
+// reorged out meanwhile, the logs of the dropped blocks are reported as removed first.
+// Should the historical logs fail to be delivered, a last notification carrying the
+// error is sent, after which the subscription is to be resumed from the last cursor.
+func (api *FilterAPI) Logs(ctx context.Context, crit LogsCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -262,29 +326,101 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 		matchedLogs = make(chan []*types.Log)
 	)
 
-	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
+	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit.FilterCriteria), matchedLogs)
 	if err != nil {
 		return nil, err
 	}
 
-	go func() {
-		defer logsSub.Unsubscribe()
-		for {
-			select {
-			case logs := <-matchedLogs:
-				for _, log := range logs {
-					log := log
-					notifier.Notify(rpcSub.ID, &log)
+	if !crit.resumable() {
+		go func() {
+			defer logsSub.Unsubscribe()
+			for {
+				select {
+				case logs := <-matchedLogs:
+					for _, log := range logs {
+						log := log
+						notifier.Notify(rpcSub.ID, &log)
+					}
+				case <-rpcSub.Err(): // client send an unsubscribe request
+					return
 				}
-			case <-rpcSub.Err(): // client send an unsubscribe request
-				return
 			}
-		}
-	}()
+		}()
+		return rpcSub, nil
+	}
+	// The live logs are already subscribed to, so the backfill can't miss any
+	// block ending up between the head retrieved below and the first live logs.
+	backfill, err := newLogsBackfill(ctx, api.sys, &crit)
+	if err != nil {
+		logsSub.Unsubscribe()
+		return nil, err
+	}
+	go backfill.run(notifier, rpcSub, logsSub, matchedLogs)
 
 	return rpcSub, nil
 }
 
+// LogsCriteria represents the criteria of a logs subscription, optionally
+// resuming a previous one from a given cursor.
+type LogsCriteria struct {
+	FilterCriteria
+	Cursor *LogCursor
+}
+
+// UnmarshalJSON sets *args fields with given data.
+func (args *LogsCriteria) UnmarshalJSON(data []byte) error {
+	if err := args.FilterCriteria.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	var raw struct {
+		Cursor *LogCursor `json:"cursor"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Cursor != nil && args.BlockHash != nil {
+		return errors.New("cannot specify both BlockHash and Cursor, choose one or the other")
+	}
+	args.Cursor = raw.Cursor
+	return nil
+}
+
+// resumable reports whether the subscription delivers historical logs along
+// with the cursors, or the new logs only.
+func (args *LogsCriteria) resumable() bool {
+	return args.Cursor != nil || (args.FromBlock != nil && args.FromBlock.Sign() >= 0)
+}
+
+// LogCursor identifies the position of a log delivered by a logs subscription.
+type LogCursor struct {
+	BlockHash common.Hash  `json:"blockHash"`
+	LogIndex  hexutil.Uint `json:"logIndex"`
+}
+
+// cursorLog is a log delivered by a resumable logs subscription, which is
+// encoded along with its cursor.
+type cursorLog struct {
+	*types.Log
+}
+
+// MarshalJSON encodes the log with an additional cursor field.
+func (l cursorLog) MarshalJSON() ([]byte, error) {
+	enc, err := json.Marshal(l.Log)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		return nil, err
+	}
+	cursor, err := json.Marshal(&LogCursor{BlockHash: l.BlockHash, LogIndex: hexutil.Uint(l.Index)})
+	if err != nil {
+		return nil, err
+	}
+	fields["cursor"] = cursor
+	return json.Marshal(fields)
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
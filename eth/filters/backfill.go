@@ -0,0 +1,265 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// backfillChunkSize is the number of blocks filtered at once when
+	// delivering the historical logs of a subscription.
+	backfillChunkSize = 2048
+
+	// backfillReorgWindow is the number of blocks below the backfill head
+	// the delivered blocks are tracked for, in order to filter the live
+	// logs of blocks already delivered by the backfill.
+	backfillReorgWindow = 1024
+
+	// maxCursorReorgDepth is the maximum number of reorged out blocks a
+	// subscription can be resumed across.
+	maxCursorReorgDepth = 1024
+
+	// maxBackfillQueue is the maximum number of live logs queued while the
+	// backfill is running. The subscription is ended if it's exceeded.
+	maxBackfillQueue = 10000
+)
+
+var (
+	errUnknownCursor   = errors.New("unknown cursor block")
+	errCursorTooDeep   = errors.New("cursor block reorged out too deep")
+	errMissingAncestor = errors.New("missing ancestor of cursor block")
+	errBackfillQueue   = errors.New("too many live logs queued during backfill")
+)
+
+// backfillFailure is the last notification of a resumable logs subscription
+// which couldn't deliver its logs. The subscription can be resumed from the
+// cursor of the last delivered log.
+type backfillFailure struct {
+	Error string `json:"error"`
+}
+
+// logsBackfill delivers the historical logs of a resumable logs subscription
+// and switches over to the live logs once done.
+type logsBackfill struct {
+	sys  *FilterSystem
+	crit FilterCriteria
+
+	removed    []*types.Log // Logs of reorged out blocks delivered before the cursor
+	begin, end uint64       // Range of canonical blocks to deliver the logs of
+	cursor     *LogCursor   // Cursor within the first block, if it's canonical
+
+	delivered map[common.Hash]struct{} // Recent blocks the logs were delivered of
+}
+
+// newLogsBackfill creates a backfill up to the current head for the given
+// criteria, resolving the position of the cursor if any.
+func newLogsBackfill(ctx context.Context, sys *FilterSystem, crit *LogsCriteria) (*logsBackfill, error) {
+	head := sys.backend.CurrentHeader()
+	if head == nil {
+		return nil, errors.New("latest header not found")
+	}
+	b := &logsBackfill{
+		sys:       sys,
+		crit:      crit.FilterCriteria,
+		end:       head.Number.Uint64(),
+		delivered: make(map[common.Hash]struct{}),
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < b.end {
+		b.end = crit.ToBlock.Uint64()
+	}
+	if crit.Cursor != nil {
+		if err := b.resume(ctx, crit.Cursor); err != nil {
+			return nil, err
+		}
+	} else {
+		b.begin = crit.FromBlock.Uint64()
+	}
+	return b, nil
+}
+
+// resume sets the backfill to start after the given cursor. If the block of
+// the cursor is not canonical anymore, the logs delivered of it and of its
+// non-canonical ancestors are collected to be reported as removed.
+func (b *logsBackfill) resume(ctx context.Context, cursor *LogCursor) error {
+	header, err := b.sys.backend.HeaderByHash(ctx, cursor.BlockHash)
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return errUnknownCursor
+	}
+	for depth := 0; ; depth++ {
+		canon, err := b.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return err
+		}
+		if canon != nil && canon.Hash() == header.Hash() {
+			break
+		}
+		if depth >= maxCursorReorgDepth {
+			return errCursorTooDeep
+		}
+		logs, err := b.sys.NewBlockFilter(header.Hash(), b.crit.Addresses, b.crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		// Report the logs in the reverse order of their delivery.
+		for i := len(logs) - 1; i >= 0; i-- {
+			if header.Hash() == cursor.BlockHash && logs[i].Index > uint(cursor.LogIndex) {
+				continue
+			}
+			removed := *logs[i]
+			removed.Removed = true
+			b.removed = append(b.removed, &removed)
+		}
+		if header, err = b.sys.backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+			return err
+		}
+		if header == nil {
+			return errMissingAncestor
+		}
+	}
+	if header.Hash() == cursor.BlockHash {
+		b.begin, b.cursor = header.Number.Uint64(), cursor
+	} else {
+		b.begin = header.Number.Uint64() + 1
+	}
+	return nil
+}
+
+// run delivers the historical logs to the subscriber, queueing the live logs
+// meanwhile. Once the backfill is done, the queued logs not delivered by it
+// are flushed and the live logs are passed through. If the backfill fails or
+// too many live logs pile up meanwhile, the error is sent to the subscriber
+// and no further logs are delivered.
+func (b *logsBackfill) run(notifier *rpc.Notifier, rpcSub *rpc.Subscription, logsSub *Subscription, live chan []*types.Log) {
+	defer logsSub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		history = make(chan *types.Log)
+		done    = make(chan error, 1)
+		queue   []*types.Log
+	)
+	go func(done chan<- error) { done <- b.fetch(ctx, history) }(done)
+
+	for {
+		select {
+		case log := <-history:
+			b.track(log)
+			notifier.Notify(rpcSub.ID, cursorLog{log})
+
+		case err := <-done:
+			if err != nil {
+				log.Debug("Failed to backfill subscribed logs", "begin", b.begin, "end", b.end, "err", err)
+				notifier.Notify(rpcSub.ID, &backfillFailure{Error: err.Error()})
+				return
+			}
+			for _, log := range queue {
+				if b.deliverable(log) {
+					notifier.Notify(rpcSub.ID, cursorLog{log})
+				}
+			}
+			queue, done = nil, nil
+
+		case logs := <-live:
+			if done != nil {
+				if len(queue)+len(logs) > maxBackfillQueue {
+					log.Debug("Failed to backfill subscribed logs", "begin", b.begin, "end", b.end, "err", errBackfillQueue)
+					notifier.Notify(rpcSub.ID, &backfillFailure{Error: errBackfillQueue.Error()})
+					return
+				}
+				queue = append(queue, logs...)
+				continue
+			}
+			for _, log := range logs {
+				if b.deliverable(log) {
+					notifier.Notify(rpcSub.ID, cursorLog{log})
+				}
+			}
+
+		case <-rpcSub.Err(): // client send an unsubscribe request
+			return
+		}
+	}
+}
+
+// fetch retrieves the historical logs in chunks and sends them to the given
+// channel, preceded by the removed logs of the reorged out blocks.
+func (b *logsBackfill) fetch(ctx context.Context, out chan<- *types.Log) error {
+	send := func(log *types.Log) error {
+		select {
+		case out <- log:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, log := range b.removed {
+		if err := send(log); err != nil {
+			return err
+		}
+	}
+	for begin := b.begin; begin <= b.end; begin += backfillChunkSize {
+		end := min(begin+backfillChunkSize-1, b.end)
+
+		logs, err := b.sys.NewRangeFilter(int64(begin), int64(end), b.crit.Addresses, b.crit.Topics).Logs(ctx)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if b.cursor != nil && log.BlockHash == b.cursor.BlockHash && log.Index <= uint(b.cursor.LogIndex) {
+				continue
+			}
+			if err := send(log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// track records the block of a delivered historical log if it's recent enough
+// to be also delivered by the live logs.
+func (b *logsBackfill) track(log *types.Log) {
+	if !log.Removed && log.BlockNumber+backfillReorgWindow > b.end {
+		b.delivered[log.BlockHash] = struct{}{}
+	}
+}
+
+// deliverable reports whether a live log is to be delivered, filtering the
+// ones already delivered by the backfill and the removal of the ones never
+// delivered.
+func (b *logsBackfill) deliverable(log *types.Log) bool {
+	if log.BlockNumber > b.end || log.BlockNumber < b.begin || log.BlockNumber+backfillReorgWindow <= b.end {
+		return true
+	}
+	_, seen := b.delivered[log.BlockHash]
+	if log.Removed {
+		return seen
+	}
+	return !seen
+}
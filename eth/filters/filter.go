@@ -24,7 +24,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -174,33 +176,168 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 			close(logChan)
 		}()
 
-		// Gather all indexed logs, and finish with non indexed ones
-		var (
-			end            = uint64(f.end)
-			size, sections = f.sys.backend.BloomStatus()
-			err            error
-		)
-		if indexed := sections * size; indexed > uint64(f.begin) {
-			if indexed > end {
-				indexed = end + 1
+		// Gather the logs covered by the persistent log index if it's available,
+		// falling back to the bloombits index and raw block iteration otherwise.
+		end := uint64(f.end)
+		if size, tail, sections := f.sys.backend.LogIndexStatus(); size > 0 && sections > tail && f.indexable() {
+			if first := tail * size; first > uint64(f.begin) {
+				if err := f.bloomLogs(ctx, min(first-1, end), logChan); err != nil {
+					errChan <- err
+					return
+				}
 			}
-			if err = f.indexedLogs(ctx, indexed-1, logChan); err != nil {
-				errChan <- err
-				return
+			if next := sections * size; next > uint64(f.begin) && uint64(f.begin) <= end {
+				if err := f.logIndexLogs(ctx, size, min(next-1, end), logChan); err != nil {
+					errChan <- err
+					return
+				}
 			}
 		}
-
-		if err := f.unindexedLogs(ctx, end, logChan); err != nil {
+		if err := f.bloomLogs(ctx, end, logChan); err != nil {
 			errChan <- err
 			return
 		}
-
 		errChan <- nil
 	}()
 
 	return logChan, errChan
 }
 
+// bloomLogs returns the logs matching the filter criteria up to the given block,
+// gathering the logs covered by the bloombits index first and finishing with the
+// non indexed ones.
+func (f *Filter) bloomLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	size, sections := f.sys.backend.BloomStatus()
+	if indexed := sections * size; indexed > uint64(f.begin) && uint64(f.begin) <= end {
+		if indexed > end {
+			indexed = end + 1
+		}
+		if err := f.indexedLogs(ctx, indexed-1, logChan); err != nil {
+			return err
+		}
+	}
+	return f.unindexedLogs(ctx, end, logChan)
+}
+
+// indexable returns whether the filter has any criteria which can be looked up
+// in the persistent log index.
+func (f *Filter) indexable() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logIndexLogs returns the logs matching the filter criteria based on the
+// persistent log index, which must cover all the blocks up to the given one.
+func (f *Filter) logIndexLogs(ctx context.Context, size uint64, end uint64, logChan chan *types.Log) error {
+	db := f.sys.backend.ChainDb()
+	for section := uint64(f.begin) / size; section <= end/size; section++ {
+		numbers, err := f.sectionMatches(db, section)
+		if err != nil {
+			return err
+		}
+		for _, number := range numbers {
+			if number < uint64(f.begin) || number > end {
+				continue
+			}
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return err
+			}
+			f.begin = int64(number) + 1
+
+			for _, log := range found {
+				select {
+				case logChan <- log:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		f.begin = int64(min((section+1)*size, end+1))
+	}
+	return nil
+}
+
+// sectionMatches returns the numbers of the blocks within the given section of
+// the persistent log index which potentially contain the logs matching the
+// filter criteria. Each clause matches the union of its alternatives, while
+// the result is the intersection of all the clauses.
+func (f *Filter) sectionMatches(db ethdb.KeyValueReader, section uint64) ([]uint64, error) {
+	var (
+		result []uint64
+		first  = true
+	)
+	match := func(kind byte, keys [][]byte) error {
+		var union []uint64
+		for _, key := range keys {
+			numbers, err := rawdb.ReadLogIndex(db, section, kind, key)
+			if err != nil {
+				return err
+			}
+			union = append(union, numbers...)
+		}
+		slices.Sort(union)
+		union = slices.Compact(union)
+
+		if first {
+			result, first = union, false
+			return nil
+		}
+		result = intersectSorted(result, union)
+		return nil
+	}
+	if len(f.addresses) > 0 {
+		keys := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			keys[i] = address.Bytes()
+		}
+		if err := match(rawdb.LogIndexAddress, keys); err != nil {
+			return nil, err
+		}
+	}
+	for i, sub := range f.topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		keys := make([][]byte, len(sub))
+		for j, topic := range sub {
+			keys[j] = topic.Bytes()
+		}
+		if err := match(rawdb.LogIndexTopic+byte(i), keys); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// intersectSorted returns the common elements of the two sorted lists.
+func intersectSorted(a, b []uint64) []uint64 {
+	var result []uint64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		}
+	}
+	return result
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
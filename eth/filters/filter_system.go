@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -68,9 +69,14 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeTxLifecycleEvent(ch chan<- []txpool.TxLifecycleEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+
+	// LogIndexStatus returns the section size, the first and the next section
+	// of the persistent log index. Zero size means the log index is disabled.
+	LogIndexStatus() (uint64, uint64, uint64)
 }
 
 // FilterSystem holds resources shared by all filters.
@@ -154,6 +160,8 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// TxLifecycleSubscription queries for lifecycle events of pooled transactions
+	TxLifecycleSubscription
 	// LastIndexSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -168,6 +176,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// lifecycleChanSize is the size of channel listening to TxLifecycleEvent.
+	lifecycleChanSize = 128
 )
 
 type subscription struct {
@@ -178,6 +188,8 @@ type subscription struct {
 	logs      chan []*types.Log
 	txs       chan []*types.Transaction
 	headers   chan *types.Header
+	lifeCrit  TxLifecycleCriteria
+	lifecycle chan []txpool.TxLifecycleEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -189,18 +201,20 @@ type EventSystem struct {
 	sys     *FilterSystem
 
 	// Subscriptions
-	txsSub    event.Subscription // Subscription for new transaction event
-	logsSub   event.Subscription // Subscription for new log event
-	rmLogsSub event.Subscription // Subscription for removed log event
-	chainSub  event.Subscription // Subscription for new chain event
+	txsSub       event.Subscription // Subscription for new transaction event
+	logsSub      event.Subscription // Subscription for new log event
+	rmLogsSub    event.Subscription // Subscription for removed log event
+	chainSub     event.Subscription // Subscription for new chain event
+	lifecycleSub event.Subscription // Subscription for transaction lifecycle event
 
 	// Channels
-	install   chan *subscription         // install filter for event notification
-	uninstall chan *subscription         // remove filter for event notification
-	txsCh     chan core.NewTxsEvent      // Channel to receive new transactions event
-	logsCh    chan []*types.Log          // Channel to receive new log event
-	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
-	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	install     chan *subscription             // install filter for event notification
+	uninstall   chan *subscription             // remove filter for event notification
+	txsCh       chan core.NewTxsEvent          // Channel to receive new transactions event
+	logsCh      chan []*types.Log              // Channel to receive new log event
+	rmLogsCh    chan core.RemovedLogsEvent     // Channel to receive removed log event
+	chainCh     chan core.ChainEvent           // Channel to receive new chain event
+	lifecycleCh chan []txpool.TxLifecycleEvent // Channel to receive transaction lifecycle event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -219,6 +233,8 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 		logsCh:    make(chan []*types.Log, logsChanSize),
 		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+
+		lifecycleCh: make(chan []txpool.TxLifecycleEvent, lifecycleChanSize),
 	}
 
 	// Subscribe events
@@ -226,9 +242,10 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.lifecycleSub = m.backend.SubscribeTxLifecycleEvent(m.lifecycleCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.lifecycleSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -264,6 +281,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.txs:
 			case <-sub.f.headers:
+			case <-sub.f.lifecycle:
 			}
 		}
 
@@ -369,6 +387,21 @@ func (es *EventSystem) SubscribePendingTxs(txs chan []*types.Transaction) *Subsc
 	return es.subscribe(sub)
 }
 
+// SubscribeTxLifecycle creates a subscription that writes the lifecycle events
+// of the pooled transactions matching the given criteria.
+func (es *EventSystem) SubscribeTxLifecycle(crit TxLifecycleCriteria, events chan []txpool.TxLifecycleEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       TxLifecycleSubscription,
+		created:   time.Now(),
+		lifeCrit:  crit,
+		lifecycle: events,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 func (es *EventSystem) handleLogs(filters filterIndex, ev []*types.Log) {
@@ -395,6 +428,14 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 }
 
+func (es *EventSystem) handleTxLifecycle(filters filterIndex, ev []txpool.TxLifecycleEvent) {
+	for _, f := range filters[TxLifecycleSubscription] {
+		if matched := filterTxLifecycle(ev, &f.lifeCrit); len(matched) > 0 {
+			f.lifecycle <- matched
+		}
+	}
+}
+
 // eventLoop (un)installs filters and processes mux events.
 func (es *EventSystem) eventLoop() {
 	// Ensure all subscriptions get cleaned up
@@ -403,6 +444,7 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.lifecycleSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -420,6 +462,8 @@ func (es *EventSystem) eventLoop() {
 			es.handleLogs(index, ev.Logs)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.lifecycleCh:
+			es.handleTxLifecycle(index, ev)
 
 		case f := <-es.install:
 			index[f.typ][f.id] = f
@@ -438,6 +482,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.lifecycleSub.Err():
+			return
 		}
 	}
 }
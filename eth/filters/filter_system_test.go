@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"math/big"
 	"math/rand"
@@ -31,12 +32,14 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/triedb"
 )
 
 type testBackend struct {
@@ -46,8 +49,11 @@ type testBackend struct {
 	logsFeed        event.Feed
 	rmLogsFeed      event.Feed
 	chainFeed       event.Feed
+	lifecycleFeed   event.Feed
 	pendingBlock    *types.Block
 	pendingReceipts types.Receipts
+	logIndexer      *core.ChainIndexer
+	logIndexSize    uint64
 }
 
 func (b *testBackend) ChainConfig() *params.ChainConfig {
@@ -137,10 +143,22 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeTxLifecycleEvent(ch chan<- []txpool.TxLifecycleEvent) event.Subscription {
+	return b.lifecycleFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
 
+func (b *testBackend) LogIndexStatus() (uint64, uint64, uint64) {
+	if b.logIndexer == nil {
+		return 0, 0, 0
+	}
+	sections, _, _ := b.logIndexer.Sections()
+	return b.logIndexSize, rawdb.ReadLogIndexTail(b.db), sections
+}
+
 func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	requests := make(chan chan *bloombits.Retrieval)
 
@@ -628,3 +646,130 @@ func TestPendingTxFilterDeadlock(t *testing.T) {
 		}
 	}
 }
+
+// TestResumableLogsSubscription tests that a logs subscription starting from a
+// historical block delivers the stored logs followed by the live ones without
+// duplicates, and that it can be resumed from the cursor of a delivered log,
+// also across a reorg.
+func TestResumableLogsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+		addr         = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		gspec        = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+
+	generate := func(parent *types.Block, n int, extra []byte) ([]*types.Block, []types.Receipts) {
+		return core.GenerateChain(gspec.Config, parent, ethash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+			gen.SetExtra(extra)
+			gen.AddUncheckedReceipt(makeReceipt(addr))
+			gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		})
+	}
+	chain, receipts := generate(gspec.ToBlock(), 10, nil)
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Store a sibling of the head block, which is not canonical.
+	side, sideReceipts := generate(chain[8], 1, []byte("side"))
+	rawdb.WriteBlock(db, side[0])
+	rawdb.WriteReceipts(db, side[0].Hash(), side[0].NumberU64(), sideReceipts[0])
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type event struct {
+		log    types.Log
+		cursor LogCursor
+	}
+	subscribe := func(crit map[string]interface{}) (chan json.RawMessage, *rpc.ClientSubscription) {
+		ch := make(chan json.RawMessage, 64)
+		sub, err := client.EthSubscribe(context.Background(), ch, "logs", crit)
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		return ch, sub
+	}
+	next := func(ch chan json.RawMessage) event {
+		t.Helper()
+		select {
+		case raw := <-ch:
+			var ev event
+			if err := json.Unmarshal(raw, &ev.log); err != nil {
+				t.Fatalf("invalid log: %v", err)
+			}
+			var enc struct {
+				Cursor *LogCursor `json:"cursor"`
+			}
+			if err := json.Unmarshal(raw, &enc); err != nil || enc.Cursor == nil {
+				t.Fatalf("missing cursor: %s", raw)
+			}
+			ev.cursor = *enc.Cursor
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for log")
+		}
+		return event{}
+	}
+	expect := func(ch chan json.RawMessage, block *types.Block, removed bool) event {
+		t.Helper()
+		ev := next(ch)
+		if ev.log.BlockHash != block.Hash() || ev.log.Removed != removed {
+			t.Fatalf("wrong log: have block %d (%x, removed %v), want %d (%x, removed %v)",
+				ev.log.BlockNumber, ev.log.BlockHash, ev.log.Removed, block.NumberU64(), block.Hash(), removed)
+		}
+		if ev.cursor.BlockHash != ev.log.BlockHash || uint(ev.cursor.LogIndex) != ev.log.Index {
+			t.Fatalf("wrong cursor: %+v", ev.cursor)
+		}
+		return ev
+	}
+	// Backfill from block 3, then deliver the live logs.
+	ch, sub := subscribe(map[string]interface{}{"fromBlock": "0x3", "address": addr})
+	var cursor LogCursor
+	for _, block := range chain[2:] {
+		ev := expect(ch, block, false)
+		if block.NumberU64() == 5 {
+			cursor = ev.cursor
+		}
+	}
+	// Posting the logs of the head block again must not deliver them twice.
+	newBlocks, _ := generate(chain[9], 1, nil)
+	headLog := &types.Log{Address: addr, Topics: []common.Hash{}, BlockNumber: 10, BlockHash: chain[9].Hash()}
+	nextLog := &types.Log{Address: addr, Topics: []common.Hash{}, BlockNumber: 11, BlockHash: newBlocks[0].Hash()}
+	backend.logsFeed.Send([]*types.Log{headLog, nextLog})
+	expect(ch, newBlocks[0], false)
+	sub.Unsubscribe()
+
+	// Resume after the log of block 5.
+	ch, sub = subscribe(map[string]interface{}{"cursor": cursor, "address": addr})
+	for _, block := range chain[5:] {
+		expect(ch, block, false)
+	}
+	sub.Unsubscribe()
+
+	// Resume from the reorged out sibling, its log must be removed first.
+	ch, sub = subscribe(map[string]interface{}{"cursor": LogCursor{BlockHash: side[0].Hash()}, "address": addr})
+	expect(ch, side[0], true)
+	expect(ch, chain[9], false)
+	sub.Unsubscribe()
+
+	// Unknown cursors are rejected.
+	if _, err := client.EthSubscribe(context.Background(), make(chan json.RawMessage), "logs", map[string]interface{}{"cursor": LogCursor{}}); err == nil {
+		t.Fatal("expected error for unknown cursor")
+	}
+}
@@ -108,6 +108,14 @@ func BenchmarkFilters(b *testing.B) {
 }
 
 func TestFilters(t *testing.T) {
+	testFilters(t, false)
+}
+
+func TestFiltersLogIndex(t *testing.T) {
+	testFilters(t, true)
+}
+
+func testFilters(t *testing.T, logIndex bool) {
 	var (
 		db           = rawdb.NewMemoryDatabase()
 		backend, sys = newTestFilterSystem(t, db, Config{})
@@ -259,6 +267,26 @@ func TestFilters(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if logIndex {
+		// Index the logs of the recent 500 blocks, leaving the older ones
+		// to be served via the fallback.
+		backend.logIndexSize = 100
+		backend.logIndexer = core.NewLogIndexer(db, backend.logIndexSize, 0, 500)
+		backend.logIndexer.Start(bc)
+		defer backend.logIndexer.Close()
+
+		for {
+			if size, tail, sections := backend.LogIndexStatus(); sections == 10 {
+				if tail != 5 {
+					t.Fatalf("log index tail mismatch, want: 5, got: %d", tail)
+				}
+				break
+			} else if size != backend.logIndexSize {
+				t.Fatalf("log index section size mismatch, want: %d, got: %d", backend.logIndexSize, size)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
 	// Set block 998 as Finalized (-3)
 	bc.SetFinalized(chain[998].Header())
 
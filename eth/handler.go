@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -93,6 +94,8 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+	History        *era.History           // Local history archive to sync pre-merge blocks from, nil if unused
+	SnapBudget     uint64                 // Disk-read budget for serving snap requests in bytes per second (0 = unlimited)
 }
 
 type handler struct {
@@ -108,9 +111,10 @@ type handler struct {
 	chain    *core.BlockChain
 	maxPeers int
 
-	downloader *downloader.Downloader
-	txFetcher  *fetcher.TxFetcher
-	peers      *peerSet
+	downloader    *downloader.Downloader
+	txFetcher     *fetcher.TxFetcher
+	snapScheduler *snap.ServingScheduler
+	peers         *peerSet
 
 	eventMux *event.TypeMux
 	txsCh    chan core.NewTxsEvent
@@ -180,7 +184,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return nil, errors.New("snap sync not supported with snapshots disabled")
 	}
 	// Construct the downloader (long sync)
-	h.downloader = downloader.New(config.Database, h.eventMux, h.chain, h.removePeer, h.enableSyncedFeatures)
+	h.downloader = downloader.New(config.Database, h.eventMux, h.chain, config.History, h.removePeer, h.punishPeer, h.enableSyncedFeatures)
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -192,7 +196,8 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	addTxs := func(txs []*types.Transaction) []error {
 		return h.txpool.Add(txs, false, false)
 	}
-	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.punishPeer)
+	h.snapScheduler = snap.NewServingScheduler(config.SnapBudget, h.chain)
 	return h, nil
 }
 
@@ -388,6 +393,17 @@ func (h *handler) removePeer(id string) {
 	}
 }
 
+// punishPeer requests disconnection of a peer that violated the protocol and
+// lowers its reputation accordingly. Timeouts and stalls should go through
+// removePeer instead, as those are not necessarily malicious.
+func (h *handler) punishPeer(id string) {
+	peer := h.peers.peer(id)
+	if peer != nil {
+		peer.Peer.Report(p2p.ReputationInvalid, "dropped for protocol violation")
+		peer.Peer.Disconnect(p2p.DiscUselessPeer)
+	}
+}
+
 // unregisterPeer removes a peer from the downloader, fetchers and main peer set.
 func (h *handler) unregisterPeer(id string) {
 	// Create a custom logger to avoid printing the entire id
@@ -430,6 +446,7 @@ func (h *handler) Start(maxPeers int) {
 
 	// start sync handlers
 	h.txFetcher.Start()
+	h.snapScheduler.Start()
 
 	// start peer handler tracker
 	h.wg.Add(1)
@@ -439,6 +456,7 @@ func (h *handler) Start(maxPeers int) {
 func (h *handler) Stop() {
 	h.txsSub.Unsubscribe() // quits txBroadcastLoop
 	h.txFetcher.Stop()
+	h.snapScheduler.Stop()
 	h.downloader.Terminate()
 
 	// Quit chainSync and txsync64.
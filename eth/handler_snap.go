@@ -19,6 +19,7 @@ package eth
 import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
@@ -28,6 +29,10 @@ type snapHandler handler
 
 func (h *snapHandler) Chain() *core.BlockChain { return h.chain }
 
+// ServingScheduler retrieves the scheduler rationing the disk reads spent on
+// serving `snap` requests.
+func (h *snapHandler) ServingScheduler() *snap.ServingScheduler { return h.snapScheduler }
+
 // RunPeer is invoked when a peer joins on the `snap` protocol.
 func (h *snapHandler) RunPeer(peer *snap.Peer, hand snap.Handler) error {
 	return (*handler)(h).runSnapExtension(peer, hand)
@@ -46,5 +51,10 @@ func (h *snapHandler) PeerInfo(id enode.ID) interface{} {
 // Handle is invoked from a peer's message handler when it receives a new remote
 // message that the handler couldn't consume and serve itself.
 func (h *snapHandler) Handle(peer *snap.Peer, packet snap.Packet) error {
-	return h.downloader.DeliverSnapPacket(peer, packet)
+	if err := h.downloader.DeliverSnapPacket(peer, packet); err != nil {
+		peer.Report(p2p.ReputationInvalid, "invalid snap response")
+		return err
+	}
+	peer.Report(p2p.ReputationUseful, "snap response delivered")
+	return nil
 }
@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -115,11 +116,22 @@ func MakeProtocols(backend Backend, network uint64, dnsdisc enode.Iterator) []p2
 			},
 			Attributes:     []enr.Entry{currentENREntry(backend.Chain())},
 			DialCandidates: dnsdisc,
+			PriorityMsg:    isPropagationMsg,
 		})
 	}
 	return protocols
 }
 
+// isPropagationMsg reports whether a message propagates new blocks or transactions
+// across the network, which takes priority over serving bulk data to syncing peers.
+func isPropagationMsg(code uint64) bool {
+	switch code {
+	case NewBlockHashesMsg, NewBlockMsg, TransactionsMsg, NewPooledTransactionHashesMsg:
+		return true
+	}
+	return false
+}
+
 // NodeInfo represents a short summary of the `eth` sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
@@ -186,6 +198,7 @@ func handleMessage(backend Backend, peer *Peer) error {
 		return err
 	}
 	if msg.Size > maxMessageSize {
+		peer.Report(p2p.ReputationInvalid, "oversized eth message")
 		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
 	}
 	defer msg.Discard()
@@ -205,7 +218,15 @@ func handleMessage(backend Backend, peer *Peer) error {
 		}(time.Now())
 	}
 	if handler := handlers[msg.Code]; handler != nil {
-		return handler(backend, msg, peer)
+		// Any failure past this point is caused by the contents of the message
+		// (undecodable, unsolicited or rejected by the consumer), penalize the
+		// peer unless it's just going away.
+		err := handler(backend, msg, peer)
+		if err != nil && !errors.Is(err, errDisconnected) {
+			peer.Report(p2p.ReputationInvalid, "invalid eth message")
+		}
+		return err
 	}
+	peer.Report(p2p.ReputationInvalid, "unknown eth message")
 	return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
 }
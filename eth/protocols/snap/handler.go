@@ -66,6 +66,10 @@ type Backend interface {
 	// Chain retrieves the blockchain object to serve data.
 	Chain() *core.BlockChain
 
+	// ServingScheduler retrieves the scheduler rationing the disk reads spent
+	// on serving remote requests. Nil means requests are served unrestricted.
+	ServingScheduler() *ServingScheduler
+
 	// RunPeer is invoked when a peer joins on the `eth` protocol. The handler
 	// should do any peer maintenance work, handshakes and validations. If all
 	// is passed, control should be given back to the `handler` to process the
@@ -160,8 +164,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		if err := msg.Decode(&req); err != nil {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
+		// Wait for a disk-read allowance, which might shrink the response
+		req.Bytes = backend.ServingScheduler().acquire(peer.id, min(req.Bytes, softResponseLimit))
+
 		// Service the request, potentially returning nothing in case of errors
 		accounts, proofs := ServiceGetAccountRangeQuery(backend.Chain(), &req)
+		backend.ServingScheduler().release(req.Bytes, accountRangeSize(accounts, proofs))
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, AccountRangeMsg, &AccountRangePacket{
@@ -192,8 +200,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		if err := msg.Decode(&req); err != nil {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
+		// Wait for a disk-read allowance, which might shrink the response
+		req.Bytes = backend.ServingScheduler().acquire(peer.id, min(req.Bytes, softResponseLimit))
+
 		// Service the request, potentially returning nothing in case of errors
 		slots, proofs := ServiceGetStorageRangesQuery(backend.Chain(), &req)
+		backend.ServingScheduler().release(req.Bytes, storageRangesSize(slots, proofs))
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, StorageRangesMsg, &StorageRangesPacket{
@@ -226,8 +238,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		if err := msg.Decode(&req); err != nil {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
+		// Wait for a disk-read allowance, which might shrink the response
+		req.Bytes = backend.ServingScheduler().acquire(peer.id, min(req.Bytes, softResponseLimit))
+
 		// Service the request, potentially returning nothing in case of errors
 		codes := ServiceGetByteCodesQuery(backend.Chain(), &req)
+		backend.ServingScheduler().release(req.Bytes, blobsSize(codes))
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, ByteCodesMsg, &ByteCodesPacket{
@@ -251,8 +267,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		if err := msg.Decode(&req); err != nil {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
+		// Wait for a disk-read allowance, which might shrink the response
+		req.Bytes = backend.ServingScheduler().acquire(peer.id, min(req.Bytes, softResponseLimit))
+
 		// Service the request, potentially returning nothing in case of errors
 		nodes, err := ServiceGetTrieNodesQuery(backend.Chain(), &req, start)
+		backend.ServingScheduler().release(req.Bytes, blobsSize(nodes))
 		if err != nil {
 			return err
 		}
@@ -561,6 +581,35 @@ func ServiceGetTrieNodesQuery(chain *core.BlockChain, req *GetTrieNodesPacket, s
 	return nodes, nil
 }
 
+// accountRangeSize estimates the disk reads spent on serving an account range.
+func accountRangeSize(accounts []*AccountData, proofs [][]byte) uint64 {
+	size := blobsSize(proofs)
+	for _, account := range accounts {
+		size += uint64(common.HashLength + len(account.Body))
+	}
+	return size
+}
+
+// storageRangesSize estimates the disk reads spent on serving storage ranges.
+func storageRangesSize(slots [][]*StorageData, proofs [][]byte) uint64 {
+	size := blobsSize(proofs)
+	for _, storage := range slots {
+		for _, slot := range storage {
+			size += uint64(common.HashLength + len(slot.Body))
+		}
+	}
+	return size
+}
+
+// blobsSize estimates the disk reads spent on serving a list of blobs.
+func blobsSize(blobs [][]byte) uint64 {
+	var size uint64
+	for _, blob := range blobs {
+		size += uint64(len(blob))
+	}
+	return size
+}
+
 // NodeInfo represents a short summary of the `snap` sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct{}
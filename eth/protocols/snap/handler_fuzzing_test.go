@@ -137,10 +137,11 @@ type dummyBackend struct {
 	chain *core.BlockChain
 }
 
-func (d *dummyBackend) Chain() *core.BlockChain       { return d.chain }
-func (d *dummyBackend) RunPeer(*Peer, Handler) error  { return nil }
-func (d *dummyBackend) PeerInfo(enode.ID) interface{} { return "Foo" }
-func (d *dummyBackend) Handle(*Peer, Packet) error    { return nil }
+func (d *dummyBackend) Chain() *core.BlockChain             { return d.chain }
+func (d *dummyBackend) ServingScheduler() *ServingScheduler { return nil }
+func (d *dummyBackend) RunPeer(*Peer, Handler) error        { return nil }
+func (d *dummyBackend) PeerInfo(enode.ID) interface{}       { return "Foo" }
+func (d *dummyBackend) Handle(*Peer, Packet) error          { return nil }
 
 type dummyRW struct {
 	code       uint64
@@ -66,4 +66,16 @@ var (
 	// discarded during the snap sync.
 	largeStorageDiscardGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/discard", nil)
 	largeStorageResumedGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/resume", nil)
+
+	// servingWaitTimer is the metric to track how long requests wait for a
+	// disk-read allowance before being served.
+	servingWaitTimer = metrics.NewRegisteredTimer("eth/protocols/snap/serve/wait", nil)
+
+	// servingPartialMeter is the metric to track how many requests are served
+	// with a reduced allowance due to the disk-read budget.
+	servingPartialMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/partial", nil)
+
+	// servingDeferredMeter is the metric to track how many requests are held
+	// back due to a block import.
+	servingDeferredMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/deferred", nil)
 )
@@ -0,0 +1,260 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	// maxServingDefer is the maximum time to hold back serving a request while
+	// the local chain is importing blocks. Requests are served afterwards even
+	// if the import is still running, to avoid the remote side timing out.
+	maxServingDefer = time.Second
+
+	// minServingAllowance is the smallest disk-read allowance granted to serve
+	// a request. If the budget is drained below it, requests wait for it to be
+	// refilled instead of being served uselessly small responses.
+	minServingAllowance = 64 * 1024
+
+	// servingActivityWindow is the time span within which a peer is considered
+	// to be actively requesting data, and thus entitled to a share of the budget.
+	servingActivityWindow = time.Second
+)
+
+// blockProcessingFeed is the part of the chain the serving scheduler needs to
+// track block imports.
+type blockProcessingFeed interface {
+	SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription
+}
+
+// ServingScheduler rations the disk reads spent on serving snap requests. A
+// budget of bytes per second is shared fairly among the peers requesting data,
+// requests are held back while the local chain is importing blocks, and
+// responses are cut short instead of stalled when the budget runs low.
+type ServingScheduler struct {
+	budget uint64              // Disk-read budget in bytes per second, 0 = unlimited
+	feed   blockProcessingFeed // Block import notifications to defer serving on
+	clock  mclock.Clock        // Clock to refill the budget with
+
+	reqCh     chan *servingTicket
+	releaseCh chan servingUsage
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// servingTicket is a request waiting for a disk-read allowance.
+type servingTicket struct {
+	peer  string      // Peer requesting the data
+	want  uint64      // Number of bytes requested
+	grant chan uint64 // Channel to deliver the allowance on
+	time  mclock.AbsTime
+}
+
+// servingUsage is the number of bytes read to serve a request, reported back
+// to the scheduler to correct the allowance charged to the budget.
+type servingUsage struct {
+	granted uint64
+	used    uint64
+}
+
+// NewServingScheduler creates a scheduler for serving snap requests with the
+// given disk-read budget in bytes per second. A zero budget disables the rate
+// limiting, but requests are still held back during block imports.
+func NewServingScheduler(budget uint64, chain *core.BlockChain) *ServingScheduler {
+	return newServingScheduler(budget, chain, mclock.System{})
+}
+
+func newServingScheduler(budget uint64, feed blockProcessingFeed, clock mclock.Clock) *ServingScheduler {
+	return &ServingScheduler{
+		budget:    budget,
+		feed:      feed,
+		clock:     clock,
+		reqCh:     make(chan *servingTicket),
+		releaseCh: make(chan servingUsage),
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start boots up the scheduling loop.
+func (s *ServingScheduler) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop terminates the scheduling loop, releasing all waiting requests with an
+// empty allowance.
+func (s *ServingScheduler) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// acquire waits for a disk-read allowance to serve a request of the given peer
+// asking for the given number of bytes. The allowance may be smaller than asked
+// for, in which case a partial response should be served.
+func (s *ServingScheduler) acquire(peer string, want uint64) uint64 {
+	if s == nil {
+		return want
+	}
+	ticket := &servingTicket{
+		peer:  peer,
+		want:  want,
+		grant: make(chan uint64, 1),
+		time:  s.clock.Now(),
+	}
+	select {
+	case s.reqCh <- ticket:
+	case <-s.quit:
+		return 0
+	}
+	select {
+	case allowance := <-ticket.grant:
+		return allowance
+	case <-s.quit:
+		return 0
+	}
+}
+
+// release reports the number of bytes actually read to serve a request with the
+// given allowance, refunding or charging the difference to the budget.
+func (s *ServingScheduler) release(granted, used uint64) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.releaseCh <- servingUsage{granted: granted, used: used}:
+	case <-s.quit:
+	}
+}
+
+// loop is the scheduling loop, granting disk-read allowances to the waiting
+// requests in arrival order. Since every peer has at most one request served
+// at a time, this results in round-robin serving of the requesting peers.
+func (s *ServingScheduler) loop() {
+	defer s.wg.Done()
+
+	procCh := make(chan bool, 16)
+	if s.feed != nil {
+		sub := s.feed.SubscribeBlockProcessingEvent(procCh)
+		defer sub.Unsubscribe()
+	}
+	var (
+		queue     []*servingTicket
+		active    = make(map[string]mclock.AbsTime) // Peers requesting data recently
+		tokens    = float64(s.budget)               // Bytes available to read (negative if overspent)
+		refilled  = s.clock.Now()                   // Last time the tokens were refilled
+		importing bool                              // Whether a block import is in progress
+		imported  mclock.AbsTime                    // Time the running block import started
+		timeout   <-chan mclock.AbsTime             // Timer to reschedule waiting requests on
+	)
+	// schedule grants allowances to as many waiting requests as possible, and
+	// arms a timer to retry if requests remain waiting.
+	schedule := func() {
+		now := s.clock.Now()
+		timeout = nil
+
+		if s.budget > 0 {
+			tokens += now.Sub(refilled).Seconds() * float64(s.budget)
+			if tokens > float64(s.budget) {
+				tokens = float64(s.budget)
+			}
+		}
+		refilled = now
+
+		for len(queue) > 0 {
+			// Hold back serving while a block is being imported, unless it's
+			// taking too long
+			if importing {
+				if wait := maxServingDefer - now.Sub(imported); wait > 0 {
+					timeout = s.clock.After(wait)
+					return
+				}
+			}
+			ticket := queue[0]
+			if s.budget == 0 {
+				ticket.grant <- ticket.want
+				queue = queue[1:]
+				servingWaitTimer.Update(now.Sub(ticket.time))
+				continue
+			}
+			// Share the budget among the active peers, but don't go below the
+			// minimum allowance, or the responses become too small to be useful
+			for peer, last := range active {
+				if now.Sub(last) > servingActivityWindow {
+					delete(active, peer)
+				}
+			}
+			floor := min(uint64(minServingAllowance), s.budget)
+			share := max(s.budget/uint64(max(len(active), 1)), floor)
+			allowance := min(ticket.want, share)
+
+			// If not enough budget is available, serve a partial response if
+			// it's still meaningful, otherwise wait for the budget to refill
+			if tokens < float64(allowance) {
+				if need := min(allowance, floor); tokens < float64(need) {
+					wait := time.Duration((float64(need) - tokens) / float64(s.budget) * float64(time.Second))
+					timeout = s.clock.After(wait)
+					return
+				}
+				allowance = uint64(tokens)
+			}
+			if allowance < ticket.want {
+				servingPartialMeter.Mark(1)
+			}
+			tokens -= float64(allowance)
+			ticket.grant <- allowance
+			queue = queue[1:]
+			servingWaitTimer.Update(now.Sub(ticket.time))
+		}
+	}
+	for {
+		select {
+		case ticket := <-s.reqCh:
+			if importing {
+				servingDeferredMeter.Mark(1)
+			}
+			active[ticket.peer] = ticket.time
+			queue = append(queue, ticket)
+			schedule()
+
+		case usage := <-s.releaseCh:
+			tokens += float64(usage.granted) - float64(usage.used)
+			schedule()
+
+		case running := <-procCh:
+			if running && !importing {
+				imported = s.clock.Now()
+			}
+			importing = running
+			if importing && len(queue) > 0 {
+				servingDeferredMeter.Mark(int64(len(queue)))
+			}
+			schedule()
+
+		case <-timeout:
+			schedule()
+
+		case <-s.quit:
+			return
+		}
+	}
+}
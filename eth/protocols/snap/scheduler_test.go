@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// testProcessingFeed is a block processing feed handing out the subscribed
+// channel, so tests can inject import events and wait for their consumption.
+type testProcessingFeed struct {
+	subCh chan chan<- bool
+}
+
+func newTestProcessingFeed() *testProcessingFeed {
+	return &testProcessingFeed{subCh: make(chan chan<- bool, 1)}
+}
+
+func (f *testProcessingFeed) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
+	f.subCh <- ch
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// send delivers an import event to the scheduler and waits until it's consumed.
+func send(ch chan<- bool, running bool) {
+	ch <- running
+	for len(ch) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// acquireAsync requests an allowance in the background, delivering it on the
+// returned channel.
+func acquireAsync(s *ServingScheduler, peer string, want uint64) chan uint64 {
+	res := make(chan uint64, 1)
+	go func() { res <- s.acquire(peer, want) }()
+	return res
+}
+
+// Tests that a nil scheduler serves requests unrestricted.
+func TestServingSchedulerNil(t *testing.T) {
+	var s *ServingScheduler
+	if have := s.acquire("peer", 1024); have != 1024 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, 1024)
+	}
+	s.release(1024, 512)
+}
+
+// Tests that a scheduler without budget grants requests in full.
+func TestServingSchedulerUnlimited(t *testing.T) {
+	s := newServingScheduler(0, nil, new(mclock.Simulated))
+	s.Start()
+	defer s.Stop()
+
+	for i := 0; i < 10; i++ {
+		if have := s.acquire("peer", softResponseLimit); have != softResponseLimit {
+			t.Fatalf("request %d: allowance mismatch: have %d, want %d", i, have, softResponseLimit)
+		}
+		s.release(softResponseLimit, softResponseLimit)
+	}
+}
+
+// Tests that the budget is shared among the requesting peers, and that requests
+// are cut short or held back when it runs low.
+func TestServingSchedulerBudget(t *testing.T) {
+	var (
+		budget = uint64(1024 * 1024)
+		clock  = new(mclock.Simulated)
+		s      = newServingScheduler(budget, nil, clock)
+	)
+	s.Start()
+	defer s.Stop()
+
+	// A single peer may use the entire budget
+	if have := s.acquire("a", 256*1024); have != 256*1024 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, 256*1024)
+	}
+	// A second peer gets only half of the budget
+	if have := s.acquire("b", budget); have != budget/2 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, budget/2)
+	}
+	// Unused allowances are refunded, overspent ones are charged
+	s.release(budget/2, 0)
+	s.release(256*1024, 832*1024)
+
+	// Only 192KiB remain, which is less than the fair share: partial response
+	if have := s.acquire("a", budget); have != 192*1024 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, 192*1024)
+	}
+	// The budget is drained, requests wait until the minimum allowance refills
+	res := acquireAsync(s, "b", budget)
+	clock.WaitForTimers(1)
+	select {
+	case have := <-res:
+		t.Fatalf("request served with drained budget: %d", have)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Run(time.Second * minServingAllowance / time.Duration(budget))
+	if have := <-res; have != minServingAllowance {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, minServingAllowance)
+	}
+}
+
+// Tests that serving is held back while the chain is importing blocks, but not
+// for longer than the maximum defer time.
+func TestServingSchedulerImport(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		feed  = newTestProcessingFeed()
+		s     = newServingScheduler(0, feed, clock)
+	)
+	s.Start()
+	defer s.Stop()
+	procCh := <-feed.subCh
+
+	// Requests are served once the import finishes
+	send(procCh, true)
+	res := acquireAsync(s, "peer", 1024)
+	clock.WaitForTimers(1)
+	select {
+	case have := <-res:
+		t.Fatalf("request served during import: %d", have)
+	case <-time.After(50 * time.Millisecond):
+	}
+	send(procCh, false)
+	if have := <-res; have != 1024 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, 1024)
+	}
+	// Requests are served if the import takes too long
+	clock.Run(maxServingDefer) // drop the stale timer
+
+	send(procCh, true)
+	res = acquireAsync(s, "peer", 1024)
+	clock.WaitForTimers(1)
+	clock.Run(maxServingDefer / 2)
+	select {
+	case have := <-res:
+		t.Fatalf("request served during import: %d", have)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.WaitForTimers(1)
+	clock.Run(maxServingDefer / 2)
+	if have := <-res; have != 1024 {
+		t.Fatalf("allowance mismatch: have %d, want %d", have, 1024)
+	}
+	send(procCh, false)
+}
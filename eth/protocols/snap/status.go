@@ -0,0 +1,367 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"cmp"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// statusRefreshInterval is the minimum time between two refreshes of the
+	// externally visible sync status.
+	statusRefreshInterval = time.Second
+
+	// statusLogInterval is the time between two detailed sync status reports
+	// written to the log.
+	statusLogInterval = time.Minute
+
+	// statusSlowPeers is the number of slowest peers to include in the status.
+	statusSlowPeers = 5
+)
+
+// Phases of a snap sync cycle. Since the account, storage and bytecode data is
+// retrieved concurrently, the phase is the earliest one with data outstanding.
+const (
+	PhaseAccounts  = "accounts"  // Account ranges are being downloaded
+	PhaseStorage   = "storage"   // Only contract storage is being downloaded
+	PhaseBytecodes = "bytecodes" // Only contract bytecodes are being downloaded
+	PhaseHealing   = "healing"   // The downloaded state is being healed
+)
+
+// SyncStatus is a detailed report on a running snap sync cycle, meant to help
+// diagnosing which phase of the sync is stalling and how long it has left.
+type SyncStatus struct {
+	Phase string      `json:"phase"` // Current phase of the sync cycle
+	Root  common.Hash `json:"root"`  // State root being synced
+
+	Elapsed    float64  `json:"elapsed"`    // Seconds spent syncing state since startup
+	Progress   float64  `json:"progress"`   // Estimated completion of the state download, in [0, 1]
+	ETA        float64  `json:"eta"`        // Estimated seconds left of the state sync including healing, 0 if unknown
+	PhaseETA   PhaseETA `json:"phaseEta"`   // Estimated seconds left of each phase
+	Throughput float64  `json:"throughput"` // Average state bytes persisted per second
+
+	Accounts       []*RangeStatus `json:"accounts"`       // Account ranges still being downloaded
+	Storage        []*RangeStatus `json:"storage"`        // Large contract storages downloaded in chunks
+	PendingStorage uint64         `json:"pendingStorage"` // Accounts whose storage is yet to be requested
+	PendingCodes   uint64         `json:"pendingCodes"`   // Bytecodes yet to be requested
+
+	Heal      HealStatus    `json:"heal"`      // Progress of the state healing
+	SlowPeers []*PeerStatus `json:"slowPeers"` // Peers with the highest round trip times
+}
+
+// RangeStatus is the progress of a task downloading a range of hashed keys.
+type RangeStatus struct {
+	Account  *common.Hash `json:"account,omitempty"` // Owner of the storage, nil for account ranges
+	Next     common.Hash  `json:"next"`              // Next key to download
+	Last     common.Hash  `json:"last"`              // Last key of the range
+	Progress float64      `json:"progress"`          // Estimated completion, in [0, 1]
+}
+
+// PhaseETA is the estimated seconds left of each phase of the sync cycle, at the
+// rate it progressed so far. Phases without outstanding data, or without enough
+// progress to extrapolate from, report 0.
+type PhaseETA struct {
+	Accounts  float64 `json:"accounts"`
+	Storage   float64 `json:"storage"`
+	Bytecodes float64 `json:"bytecodes"`
+	Healing   float64 `json:"healing"`
+}
+
+// HealStatus is the progress of the state healing, derived from the heal counters
+// of the sync progress.
+type HealStatus struct {
+	Trienodes        uint64  `json:"trienodes"`        // Trie nodes healed
+	Bytecodes        uint64  `json:"bytecodes"`        // Bytecodes healed
+	PendingTrienodes uint64  `json:"pendingTrienodes"` // Trie nodes queued for retrieval
+	PendingBytecodes uint64  `json:"pendingBytecodes"` // Bytecodes queued for retrieval
+	Progress         float64 `json:"progress"`         // Estimated completion of the healing, in [0, 1]
+}
+
+// PeerStatus is the responsiveness of a peer serving the sync.
+type PeerStatus struct {
+	ID        string  `json:"id"`        // Unique identifier of the peer
+	RoundTrip float64 `json:"roundTrip"` // Measured round trip time in seconds
+	Stateless bool    `json:"stateless"` // Whether the peer failed to deliver state data
+}
+
+// Status returns a detailed report on the running snap sync cycle, or nil if no
+// sync is running.
+func (s *Syncer) Status() *SyncStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.extStatus
+}
+
+// updateStatus refreshes the externally visible sync status, and occasionally
+// reports it to the user.
+func (s *Syncer) updateStatus(force bool) {
+	// Don't refresh on all the events, just occasionally
+	if !force && time.Since(s.statusTime) < statusRefreshInterval {
+		return
+	}
+	s.statusTime = time.Now()
+
+	status := s.buildStatus(s.Progress())
+	s.lock.Lock()
+	s.extStatus = status
+	s.lock.Unlock()
+
+	if time.Since(s.statusLogTime) >= statusLogInterval {
+		s.statusLogTime = time.Now()
+		s.logStatus(status)
+	}
+}
+
+// buildStatus assembles a sync status report from the current task set and the
+// given sync progress counters.
+func (s *Syncer) buildStatus(progress *SyncProgress, pending *SyncPending) *SyncStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	status := &SyncStatus{
+		Phase:     s.phase(),
+		Root:      s.root,
+		Elapsed:   time.Since(s.startTime).Seconds(),
+		Accounts:  []*RangeStatus{},
+		Storage:   []*RangeStatus{},
+		SlowPeers: []*PeerStatus{},
+	}
+	// Estimate the progress of the state download from the covered hash space
+	synced, estimate := s.estimateStateSize()
+	if estimate >= 1.0 {
+		status.Progress = min(float64(synced)/estimate, 1)
+		status.PhaseETA.Storage = status.Elapsed/float64(synced)*estimate - status.Elapsed
+	}
+	if len(s.tasks) == 0 {
+		status.Progress, status.PhaseETA.Storage = 1, 0
+	}
+	if status.Elapsed > 0 {
+		persisted := synced + s.trienodeHealBytes + s.bytecodeHealBytes
+		status.Throughput = float64(persisted) / status.Elapsed
+	}
+	// Report the progress of each range task from its remaining key space
+	chunk := new(big.Int).Div(hashSpace, big.NewInt(int64(accountConcurrency)))
+	for _, task := range s.tasks {
+		if !task.done && task.res == nil {
+			status.Accounts = append(status.Accounts, &RangeStatus{
+				Next:     task.Next,
+				Last:     task.Last,
+				Progress: rangeProgress(rangeRemaining(task.Next, task.Last), chunk),
+			})
+		}
+		for account, subtasks := range task.SubTasks {
+			var (
+				next      common.Hash
+				pending   bool
+				remaining = new(big.Int)
+			)
+			for _, subtask := range subtasks {
+				if subtask.done {
+					continue
+				}
+				if !pending {
+					next, pending = subtask.Next, true
+				}
+				remaining.Add(remaining, rangeRemaining(subtask.Next, subtask.Last))
+			}
+			if !pending {
+				continue
+			}
+			account := account // avoid aliasing the loop variable
+			status.Storage = append(status.Storage, &RangeStatus{
+				Account:  &account,
+				Next:     next,
+				Last:     common.MaxHash,
+				Progress: rangeProgress(remaining, hashSpace),
+			})
+		}
+		status.PendingStorage += uint64(len(task.stateTasks))
+		status.PendingCodes += uint64(len(task.codeTasks))
+	}
+	slices.SortFunc(status.Storage, func(a, b *RangeStatus) int {
+		return a.Account.Cmp(*b.Account)
+	})
+	s.estimatePhases(status, progress, pending)
+
+	// Report the peers lagging behind the others
+	for id, rtt := range s.rates.RoundTrips() {
+		if _, ok := s.peers[id]; !ok {
+			continue
+		}
+		_, stateless := s.statelessPeers[id]
+		status.SlowPeers = append(status.SlowPeers, &PeerStatus{
+			ID:        id,
+			RoundTrip: rtt.Seconds(),
+			Stateless: stateless,
+		})
+	}
+	slices.SortFunc(status.SlowPeers, func(a, b *PeerStatus) int {
+		if c := cmp.Compare(b.RoundTrip, a.RoundTrip); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(status.SlowPeers) > statusSlowPeers {
+		status.SlowPeers = status.SlowPeers[:statusSlowPeers]
+	}
+	return status
+}
+
+// estimatePhases fills in the completion estimates of the individual phases. The
+// download phases are extrapolated from the data retrieved so far, the healing
+// from the heal counters of the sync progress.
+//
+// Note, this method expects the syncer lock to be held for reading.
+func (s *Syncer) estimatePhases(status *SyncStatus, progress *SyncProgress, pending *SyncPending) {
+	// The account ranges are done once the account hash space is covered
+	if len(status.Accounts) > 0 {
+		remaining := new(big.Int)
+		for _, task := range s.tasks {
+			if !task.done && task.res == nil {
+				remaining.Add(remaining, rangeRemaining(task.Next, task.Last))
+			}
+		}
+		if done := rangeProgress(remaining, hashSpace); done > 0 {
+			status.PhaseETA.Accounts = status.Elapsed * (1 - done) / done
+		}
+	}
+	// The storage is gating the state download until all of it is retrieved,
+	// after that only the queued bytecodes are left
+	if status.Phase != PhaseAccounts && status.Phase != PhaseStorage {
+		status.PhaseETA.Storage = 0
+	}
+	if status.PendingCodes > 0 && progress.BytecodeSynced > 0 {
+		rate := float64(progress.BytecodeSynced) / status.Elapsed
+		status.PhaseETA.Bytecodes = float64(status.PendingCodes) / rate
+	}
+	// Estimate the healing from the items healed versus the ones still queued
+	var (
+		healed = progress.TrienodeHealSynced + progress.BytecodeHealSynced
+		queued = pending.TrienodeHeal + pending.BytecodeHeal
+	)
+	status.Heal = HealStatus{
+		Trienodes:        progress.TrienodeHealSynced,
+		Bytecodes:        progress.BytecodeHealSynced,
+		PendingTrienodes: pending.TrienodeHeal,
+		PendingBytecodes: pending.BytecodeHeal,
+	}
+	if healed+queued > 0 {
+		status.Heal.Progress = float64(healed) / float64(healed+queued)
+	}
+	if status.Phase == PhaseHealing {
+		// The heal rate is measured from the first report of the healing phase,
+		// the counters themselves include the items healed in previous runs.
+		if s.healTime.IsZero() || healed < s.healBase {
+			s.healTime, s.healBase = time.Now(), healed
+		}
+		if elapsed := time.Since(s.healTime).Seconds(); elapsed > 0 && healed > s.healBase {
+			status.PhaseETA.Healing = float64(queued) / (float64(healed-s.healBase) / elapsed)
+		}
+	}
+	phases := status.PhaseETA
+	status.ETA = max(phases.Accounts, phases.Storage, phases.Bytecodes) + phases.Healing
+}
+
+// phase determines the current phase of the sync cycle. Although account,
+// storage and bytecode retrievals run concurrently, the earliest phase with
+// data outstanding is the one gating the sync.
+func (s *Syncer) phase() string {
+	if len(s.tasks) == 0 {
+		return PhaseHealing
+	}
+	storage := len(s.storageReqs)
+	for _, task := range s.tasks {
+		if !task.done && task.res == nil {
+			return PhaseAccounts
+		}
+		storage += len(task.stateTasks) + len(task.SubTasks)
+	}
+	if storage > 0 {
+		return PhaseStorage
+	}
+	return PhaseBytecodes
+}
+
+// logStatus reports the detailed sync status to the user.
+func (s *Syncer) logStatus(status *SyncStatus) {
+	ctx := []interface{}{
+		"phase", status.Phase, "progress", fmt.Sprintf("%.2f%%", status.Progress*100),
+		"rate", fmt.Sprintf("%v/s", common.StorageSize(status.Throughput).TerminalString()),
+	}
+	if status.ETA > 0 {
+		ctx = append(ctx, "eta", common.PrettyDuration(status.ETA*float64(time.Second)))
+	}
+	switch status.Phase {
+	case PhaseHealing:
+		ctx = append(ctx, "healed", fmt.Sprintf("%.2f%%", status.Heal.Progress*100),
+			"nodes", status.Heal.Trienodes, "codes", status.Heal.Bytecodes,
+			"pendingnodes", status.Heal.PendingTrienodes, "pendingcodes", status.Heal.PendingBytecodes)
+	default:
+		ctx = append(ctx, "ranges", len(status.Accounts), "contracts", len(status.Storage),
+			"pendingslots", status.PendingStorage, "pendingcodes", status.PendingCodes)
+
+		// Surface the range lagging the most, it's the one holding the sync up
+		var laggard *RangeStatus
+		for _, tasks := range [][]*RangeStatus{status.Accounts, status.Storage} {
+			for _, task := range tasks {
+				if laggard == nil || task.Progress < laggard.Progress {
+					laggard = task
+				}
+			}
+		}
+		if laggard != nil {
+			ctx = append(ctx, "slowest", fmt.Sprintf("%x@%.2f%%", laggard.Next[:4], laggard.Progress*100))
+		}
+	}
+	if len(status.SlowPeers) > 0 {
+		peer := status.SlowPeers[0]
+		ctx = append(ctx, "slowpeer", peer.ID, "rtt", common.PrettyDuration(peer.RoundTrip*float64(time.Second)))
+	}
+	log.Info("Syncing: state sync status", ctx...)
+
+	for _, task := range status.Accounts {
+		log.Debug("Account range sync status", "next", task.Next, "last", task.Last, "progress", fmt.Sprintf("%.2f%%", task.Progress*100))
+	}
+	for _, task := range status.Storage {
+		log.Debug("Storage range sync status", "account", task.Account, "next", task.Next, "progress", fmt.Sprintf("%.2f%%", task.Progress*100))
+	}
+}
+
+// rangeRemaining returns the number of keys left in the range [next, last].
+func rangeRemaining(next, last common.Hash) *big.Int {
+	if next.Cmp(last) > 0 {
+		return new(big.Int)
+	}
+	remaining := new(big.Int).Sub(last.Big(), next.Big())
+	return remaining.Add(remaining, common.Big1)
+}
+
+// rangeProgress estimates the completion of a range of the given size, which
+// has the given number of keys remaining.
+func rangeProgress(remaining *big.Int, size *big.Int) float64 {
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(remaining), new(big.Float).SetInt(size)).Float64()
+	return max(1-ratio, 0)
+}
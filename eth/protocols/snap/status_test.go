@@ -0,0 +1,122 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the sync status reports the phase of the sync and estimates the
+// completion of the range tasks from the remaining hashed key space.
+func TestSyncStatus(t *testing.T) {
+	syncer := NewSyncer(rawdb.NewMemoryDatabase(), rawdb.HashScheme)
+	syncer.startTime = time.Now().Add(-time.Minute)
+	syncer.accountBytes = 1024
+
+	progress, pending := new(SyncProgress), new(SyncPending)
+
+	var (
+		account = common.HexToHash("0x01")
+		half    = common.HexToHash("0x8000000000000000000000000000000000000000000000000000000000000000")
+		quarter = common.HexToHash("0x4000000000000000000000000000000000000000000000000000000000000000")
+	)
+	// The first task (first 1/16th of the key space) is half way through its
+	// account range, the second is waiting for a large contract's storage,
+	// which is a quarter done.
+	syncer.tasks = []*accountTask{
+		{
+			Next: common.HexToHash("0x0800000000000000000000000000000000000000000000000000000000000000"),
+			Last: common.HexToHash("0x0fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+		},
+		{
+			Next: common.HexToHash("0x1000000000000000000000000000000000000000000000000000000000000000"),
+			Last: common.HexToHash("0x1fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+			SubTasks: map[common.Hash][]*storageTask{
+				account: {
+					{Next: common.Hash{}, Last: quarter, done: true},
+					{Next: quarter, Last: common.HexToHash("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+					{Next: half, Last: common.MaxHash},
+				},
+			},
+			res: new(accountResponse),
+		},
+	}
+	status := syncer.buildStatus(progress, pending)
+	if status.Phase != PhaseAccounts {
+		t.Fatalf("phase mismatch: have %s, want %s", status.Phase, PhaseAccounts)
+	}
+	if len(status.Accounts) != 1 {
+		t.Fatalf("account range count mismatch: have %d, want %d", len(status.Accounts), 1)
+	}
+	if have := status.Accounts[0].Progress; math.Abs(have-0.5) > 1e-9 {
+		t.Fatalf("account range progress mismatch: have %f, want %f", have, 0.5)
+	}
+	if len(status.Storage) != 1 {
+		t.Fatalf("storage range count mismatch: have %d, want %d", len(status.Storage), 1)
+	}
+	if have := *status.Storage[0].Account; have != account {
+		t.Fatalf("storage account mismatch: have %x, want %x", have, account)
+	}
+	if have := status.Storage[0].Next; have != quarter {
+		t.Fatalf("storage next mismatch: have %x, want %x", have, quarter)
+	}
+	if have := status.Storage[0].Progress; math.Abs(have-0.25) > 1e-9 {
+		t.Fatalf("storage range progress mismatch: have %f, want %f", have, 0.25)
+	}
+	if status.ETA <= 0 || status.Throughput <= 0 {
+		t.Fatalf("missing estimates: eta %f, throughput %f", status.ETA, status.Throughput)
+	}
+	if status.PhaseETA.Accounts <= 0 || status.PhaseETA.Storage <= 0 || status.PhaseETA.Healing != 0 {
+		t.Fatalf("phase estimates mismatch: %+v", status.PhaseETA)
+	}
+	// Once the account ranges are done, the storage is gating the sync
+	syncer.tasks = syncer.tasks[1:]
+	if status = syncer.buildStatus(progress, pending); status.Phase != PhaseStorage {
+		t.Fatalf("phase mismatch: have %s, want %s", status.Phase, PhaseStorage)
+	}
+	// Once the storage is done, the bytecodes are gating the sync
+	syncer.tasks[0].SubTasks = nil
+	if status = syncer.buildStatus(progress, pending); status.Phase != PhaseBytecodes {
+		t.Fatalf("phase mismatch: have %s, want %s", status.Phase, PhaseBytecodes)
+	}
+	// Once all the tasks are done, the state is being healed
+	syncer.tasks = nil
+	if status = syncer.buildStatus(progress, pending); status.Phase != PhaseHealing || status.Progress != 1 {
+		t.Fatalf("phase mismatch: have %s (progress %f), want %s", status.Phase, status.Progress, PhaseHealing)
+	}
+	// The healing is estimated from the heal counters, 100 items healed in 10
+	// seconds with 50 more queued leaves 5 seconds
+	syncer.healTime = time.Now().Add(-10 * time.Second)
+	progress.TrienodeHealSynced, progress.BytecodeHealSynced = 90, 10
+	pending.TrienodeHeal, pending.BytecodeHeal = 40, 10
+
+	status = syncer.buildStatus(progress, pending)
+	if have, want := status.Heal.Progress, 100.0/150; math.Abs(have-want) > 1e-9 {
+		t.Fatalf("heal progress mismatch: have %f, want %f", have, want)
+	}
+	if have := status.PhaseETA.Healing; math.Abs(have-5) > 0.1 {
+		t.Fatalf("heal eta mismatch: have %f, want %f", have, 5.0)
+	}
+	if status.ETA != status.PhaseETA.Healing {
+		t.Fatalf("eta mismatch: have %f, want %f", status.ETA, status.PhaseETA.Healing)
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/msgrate"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -431,6 +432,14 @@ type SyncPeer interface {
 	Log() log.Logger
 }
 
+// reportPeer adjusts the reputation of a sync peer, if it's backed by a live
+// p2p connection.
+func reportPeer(peer SyncPeer, delta float64, reason string) {
+	if p, ok := peer.(interface{ Report(float64, string) }); ok {
+		p.Report(delta, reason)
+	}
+}
+
 // Syncer is an Ethereum account and storage trie syncer based on snapshots and
 // the  snap protocol. It's purpose is to download all the accounts and storage
 // slots from remote peers and reassemble chunks of the state trie, on top of
@@ -475,6 +484,7 @@ type Syncer struct {
 	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
 
 	extProgress *SyncProgress // progress that can be exposed to external caller.
+	extStatus   *SyncStatus   // Detailed status of the running sync cycle, nil if not running
 
 	// Request tracking during healing phase
 	trienodeHealIdlers map[string]struct{} // Peers that aren't serving trie node requests
@@ -506,6 +516,11 @@ type Syncer struct {
 	startTime time.Time // Time instance when snapshot sync started
 	logTime   time.Time // Time instance when status was last reported
 
+	statusTime    time.Time // Time instance when the external status was last refreshed
+	statusLogTime time.Time // Time instance when the detailed status was last reported
+	healTime      time.Time // Time instance when the healing was first reported (sync goroutine only)
+	healBase      uint64    // Number of items healed before healTime, to measure the heal rate
+
 	pend sync.WaitGroup // Tracks network request goroutines for graceful shutdown
 	lock sync.RWMutex   // Protects fields that can change outside of sync (peers, reqs, root)
 }
@@ -655,6 +670,7 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 		s.bytecodeReqs = make(map[uint64]*bytecodeRequest)
 		s.trienodeHealReqs = make(map[uint64]*trienodeHealRequest)
 		s.bytecodeHealReqs = make(map[uint64]*bytecodeHealRequest)
+		s.extStatus = nil
 		s.lock.Unlock()
 	}()
 	// Keep scheduling sync tasks
@@ -713,6 +729,8 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 			BytecodeHealBytes:  s.bytecodeHealBytes,
 		}
 		s.lock.Unlock()
+		s.updateStatus(false)
+
 		// Wait for something to happen
 		select {
 		case <-s.update:
@@ -2533,6 +2551,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 		logger.Debug("Peer rejected account range request", "root", s.root)
 		s.statelessPeers[peer.ID()] = struct{}{}
 		s.lock.Unlock()
+		reportPeer(peer, p2p.ReputationUseless, "stateless snap peer")
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertAccountRequest(req)
@@ -2643,6 +2662,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 		logger.Debug("Peer rejected bytecode request")
 		s.statelessPeers[peer.ID()] = struct{}{}
 		s.lock.Unlock()
+		reportPeer(peer, p2p.ReputationUseless, "stateless snap peer")
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeRequest(req)
@@ -2771,6 +2791,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 		logger.Debug("Peer rejected storage request")
 		s.statelessPeers[peer.ID()] = struct{}{}
 		s.lock.Unlock()
+		reportPeer(peer, p2p.ReputationUseless, "stateless snap peer")
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		return nil
 	}
@@ -2890,6 +2911,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 		logger.Debug("Peer rejected trienode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
 		s.lock.Unlock()
+		reportPeer(peer, p2p.ReputationUseless, "stateless snap peer")
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertTrienodeHealRequest(req)
@@ -2997,6 +3019,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 		logger.Debug("Peer rejected bytecode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
 		s.lock.Unlock()
+		reportPeer(peer, p2p.ReputationUseless, "stateless snap peer")
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeHealRequest(req)
@@ -3090,27 +3113,12 @@ func (s *Syncer) reportSyncProgress(force bool) {
 		return
 	}
 	// Don't report anything until we have a meaningful progress
-	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
-	if synced == 0 {
-		return
-	}
-	accountGaps := new(big.Int)
-	for _, task := range s.tasks {
-		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
-	}
-	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
-	if accountFills.BitLen() == 0 {
-		return
-	}
-	s.logTime = time.Now()
-	estBytes := float64(new(big.Int).Div(
-		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), hashSpace),
-		accountFills,
-	).Uint64())
-	// Don't report anything until we have a meaningful progress
+	synced, estBytes := s.estimateStateSize()
 	if estBytes < 1.0 {
 		return
 	}
+	s.logTime = time.Now()
+
 	elapsed := time.Since(s.startTime)
 	estTime := elapsed / time.Duration(synced) * time.Duration(estBytes)
 
@@ -3125,6 +3133,29 @@ func (s *Syncer) reportSyncProgress(force bool) {
 		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(estTime-elapsed))
 }
 
+// estimateStateSize returns the number of state bytes synced, along with the
+// total size of the state extrapolated from the account hash space covered. The
+// estimate is zero until a meaningful progress is made.
+func (s *Syncer) estimateStateSize() (common.StorageSize, float64) {
+	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
+	if synced == 0 {
+		return 0, 0
+	}
+	accountGaps := new(big.Int)
+	for _, task := range s.tasks {
+		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
+	}
+	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
+	if accountFills.BitLen() == 0 {
+		return synced, 0
+	}
+	estBytes := float64(new(big.Int).Div(
+		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), hashSpace),
+		accountFills,
+	).Uint64())
+	return synced, estBytes
+}
+
 // reportHealProgress calculates various status reports and provides it to the user.
 func (s *Syncer) reportHealProgress(force bool) {
 	// Don't report all the events, just occasionally
@@ -181,10 +181,12 @@ func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), erro
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
-	// TODO historic state is not supported in path-based scheme.
-	// Fully archive node in pbss will be implemented by relying
-	// on state history, but needs more work on top.
-	return nil, nil, errors.New("historical state not available in path scheme yet")
+	// Otherwise resolve the historic state from the retained state histories.
+	statedb, err = eth.blockchain.HistoricState(block.Root())
+	if err != nil {
+		return nil, nil, fmt.Errorf("historical state not available: %w", err)
+	}
+	return statedb, noopReleaser, nil
 }
 
 // stateAtBlock retrieves the state database associated with a certain block.
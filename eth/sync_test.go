@@ -17,14 +17,23 @@
 package eth
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Tests that snap sync is disabled after a successful sync cycle.
@@ -94,3 +103,117 @@ func testSnapSyncDisabling(t *testing.T, ethVer uint, snapVer uint) {
 		t.Fatalf("snap sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that a peer delivering invalid blocks during sync loses reputation in
+// the p2p server, instead of just being disconnected.
+func TestInvalidBlockPenalty68(t *testing.T) { testInvalidBlockPenalty(t, eth.ETH68) }
+
+func testInvalidBlockPenalty(t *testing.T, protocol uint) {
+	t.Parallel()
+
+	// Create an empty handler and a chain with non-empty blocks to sync from
+	empty := newTestHandler()
+	defer empty.close()
+
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+	}
+	chain, _ := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 16, func(i int, block *core.BlockGen) {
+		if i > 1 {
+			block.AddUncle(&types.Header{
+				ParentHash: block.PrevBlock(i - 2).Hash(),
+				Number:     big.NewInt(block.Number().Int64() - 1),
+			})
+		}
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Serve the headers correctly, but strip the uncles from all the bodies
+	protocols := eth.MakeProtocols((*ethHandler)(empty.handler), 1, nil)
+
+	faulty := protocols[0]
+	faulty.NodeInfo, faulty.PeerInfo = nil, nil
+	faulty.Run = func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+		peer := eth.NewPeer(protocol, p, rw, newTestTxPool())
+		defer peer.Close()
+
+		var (
+			head = chain.CurrentBlock()
+			td   = chain.GetTd(head.Hash(), head.Number.Uint64())
+		)
+		if err := peer.Handshake(1, td, head.Hash(), chain.Genesis().Hash(), forkid.NewIDWithChain(chain), forkid.NewFilter(chain)); err != nil {
+			return err
+		}
+		for {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			switch msg.Code {
+			case eth.GetBlockHeadersMsg:
+				var query eth.GetBlockHeadersPacket
+				if err := msg.Decode(&query); err != nil {
+					return err
+				}
+				peer.ReplyBlockHeadersRLP(query.RequestId, eth.ServiceGetBlockHeadersQuery(chain, query.GetBlockHeadersRequest, peer))
+
+			case eth.GetBlockBodiesMsg:
+				var query eth.GetBlockBodiesPacket
+				if err := msg.Decode(&query); err != nil {
+					return err
+				}
+				bodies := make(eth.BlockBodiesResponse, len(query.GetBlockBodiesRequest))
+				for i, hash := range query.GetBlockBodiesRequest {
+					bodies[i] = &eth.BlockBody{Transactions: chain.GetBlockByHash(hash).Transactions()}
+				}
+				p2p.Send(rw, eth.BlockBodiesMsg, &eth.BlockBodiesPacket{RequestId: query.RequestId, BlockBodiesResponse: bodies})
+			}
+			msg.Discard()
+		}
+	}
+	// Connect the two nodes via real p2p servers to track reputations
+	newServer := func(protocols []p2p.Protocol) *p2p.Server {
+		key, _ := crypto.GenerateKey()
+		srv := &p2p.Server{Config: p2p.Config{
+			PrivateKey:  key,
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			Protocols:   protocols,
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("failed to start p2p server: %v", err)
+		}
+		return srv
+	}
+	local := newServer(protocols)
+	defer local.Stop()
+	remote := newServer([]p2p.Protocol{faulty})
+	defer remote.Stop()
+
+	local.AddPeer(remote.Self())
+	for start := time.Now(); empty.handler.peers.len() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 3*time.Second {
+			t.Fatalf("faulty peer not connected in three seconds")
+		}
+	}
+	if err := empty.handler.downloader.BeaconSync(downloader.FullSync, chain.CurrentBlock(), nil); err != nil {
+		t.Fatalf("failed to start sync: %v", err)
+	}
+	// Ensure the faulty peer is penalized for the invalid bodies
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		for _, rep := range local.PeerReputations() {
+			if rep.ID == remote.Self().ID() && rep.Score < 0 {
+				return
+			}
+		}
+		if time.Since(start) > 3*time.Second {
+			t.Fatalf("faulty peer not penalized in three seconds: %v", local.PeerReputations())
+		}
+	}
+}
@@ -87,6 +87,15 @@ type Backend interface {
 	ChainDb() ethdb.Database
 	StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, StateReleaseFunc, error)
 	StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*types.Transaction, vm.BlockContext, *state.StateDB, StateReleaseFunc, error)
+
+	// TraceIndexStatus returns the section size, the first and the next section
+	// of the call trace index. Zero size means the trace index is disabled.
+	TraceIndexStatus() (uint64, uint64, uint64)
+
+	// TraceFilterLimits returns the maximum number of blocks a trace_filter
+	// query may span and the maximum number of traces it may return. Zero
+	// means unlimited.
+	TraceFilterLimits() (uint64, uint64)
 }
 
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
@@ -1023,6 +1032,10 @@ func APIs(backend Backend) []rpc.API {
 			Namespace: "debug",
 			Service:   NewAPI(backend),
 		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(backend),
+		},
 	}
 }
 
@@ -60,6 +60,9 @@ type testBackend struct {
 
 	refHook func() // Hook is invoked when the requested state is referenced
 	relHook func() // Hook is invoked when the requested state is released
+
+	traceFilterRangeLimit  uint64 // Maximum block range of trace_filter queries
+	traceFilterResultLimit uint64 // Maximum result count of trace_filter queries
 }
 
 // newTestBackend creates a new test backend. OBS: After test is done, teardown must be
@@ -140,6 +143,14 @@ func (b *testBackend) teardown() {
 	b.chain.Stop()
 }
 
+func (b *testBackend) TraceIndexStatus() (uint64, uint64, uint64) {
+	return 0, 0, 0
+}
+
+func (b *testBackend) TraceFilterLimits() (uint64, uint64) {
+	return b.traceFilterRangeLimit, b.traceFilterResultLimit
+}
+
 func (b *testBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, StateReleaseFunc, error) {
 	statedb, err := b.chain.StateAt(block.Root())
 	if err != nil {
@@ -5,27 +5,48 @@ import (
 	"errors"
 
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type ctorFunc func(config json.RawMessage) (*tracing.Hooks, error)
 
+// ctorWithAPIsFunc is the constructor of a live tracer which also exposes
+// RPC APIs, e.g. for querying the data collected by the tracer.
+type ctorWithAPIsFunc func(config json.RawMessage) (*tracing.Hooks, []rpc.API, error)
+
 // LiveDirectory is the collection of tracers which can be used
 // during normal block import operations.
-var LiveDirectory = liveDirectory{elems: make(map[string]ctorFunc)}
+var LiveDirectory = liveDirectory{elems: make(map[string]ctorWithAPIsFunc)}
 
 type liveDirectory struct {
-	elems map[string]ctorFunc
+	elems map[string]ctorWithAPIsFunc
 }
 
 // Register registers a tracer constructor by name.
 func (d *liveDirectory) Register(name string, f ctorFunc) {
+	d.elems[name] = func(config json.RawMessage) (*tracing.Hooks, []rpc.API, error) {
+		hooks, err := f(config)
+		return hooks, nil, err
+	}
+}
+
+// RegisterWithAPIs registers a tracer constructor by name, which returns the
+// RPC APIs of the tracer along with the hooks.
+func (d *liveDirectory) RegisterWithAPIs(name string, f ctorWithAPIsFunc) {
 	d.elems[name] = f
 }
 
 // New instantiates a tracer by name.
 func (d *liveDirectory) New(name string, config json.RawMessage) (*tracing.Hooks, error) {
+	hooks, _, err := d.NewWithAPIs(name, config)
+	return hooks, err
+}
+
+// NewWithAPIs instantiates a tracer by name, returning the RPC APIs exposed
+// by the tracer too.
+func (d *liveDirectory) NewWithAPIs(name string, config json.RawMessage) (*tracing.Hooks, []rpc.API, error) {
 	if f, ok := d.elems[name]; ok {
 		return f(config)
 	}
-	return nil, errors.New("not found")
+	return nil, nil, errors.New("not found")
 }
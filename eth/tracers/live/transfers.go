@@ -0,0 +1,569 @@
+package live
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func init() {
+	tracers.LiveDirectory.RegisterWithAPIs("transfers", newTransfersTracer)
+}
+
+// The kinds of the recorded transfers.
+const (
+	TransferKindCall    = "call"    // Ether transferred by a call frame
+	TransferKindERC20   = "erc20"   // ERC-20 Transfer event
+	TransferKindERC721  = "erc721"  // ERC-721 Transfer event
+	TransferKindERC1155 = "erc1155" // ERC-1155 TransferSingle or TransferBatch event
+)
+
+var (
+	transferTopic       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// Database schema of the transfers tracer:
+//
+//	transfersAddressPrefix + address (20 bytes) + number (8 bytes) + index (4 bytes) -> RLP(transfer)
+//	transfersCanonicalPrefix + number (8 bytes) -> block hash
+//	transfersBlockPrefix + number (8 bytes) + hash -> RLP([]transfer)
+var (
+	transfersAddressPrefix   = []byte("a")
+	transfersCanonicalPrefix = []byte("n")
+	transfersBlockPrefix     = []byte("b")
+)
+
+const (
+	// transfersBlockRetention is the number of recent blocks whose transfers are
+	// kept by block hash, for restoring them when a previously executed block is
+	// reinserted as canonical without execution (OnSkippedBlock).
+	transfersBlockRetention = 1024
+
+	// transfersDefaultLimit is the default and maximum number of transfers
+	// returned by a single query.
+	transfersDefaultLimit = 1000
+)
+
+// Transfer is a value transfer of ether or tokens between two addresses.
+type Transfer struct {
+	Kind        string         `json:"kind"`
+	Token       common.Address `json:"token"` // Token contract, zero for ether transfers
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *big.Int       `json:"value"`
+	TokenID     *big.Int       `json:"tokenId"` // Token identifier of ERC-721 and ERC-1155 transfers
+	BlockNumber uint64         `json:"-"`
+	BlockHash   common.Hash    `json:"-"`
+	TxHash      common.Hash    `json:"-"`
+	TxIndex     uint64         `json:"-"`
+}
+
+// MarshalJSON marshals the transfer as JSON.
+func (t *Transfer) MarshalJSON() ([]byte, error) {
+	enc := struct {
+		Kind        string         `json:"kind"`
+		Token       common.Address `json:"token"`
+		From        common.Address `json:"from"`
+		To          common.Address `json:"to"`
+		Value       *hexutil.Big   `json:"value"`
+		TokenID     *hexutil.Big   `json:"tokenId,omitempty"`
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		BlockHash   common.Hash    `json:"blockHash"`
+		TxHash      common.Hash    `json:"transactionHash"`
+		TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	}{
+		Kind:        t.Kind,
+		Token:       t.Token,
+		From:        t.From,
+		To:          t.To,
+		Value:       (*hexutil.Big)(t.Value),
+		BlockNumber: hexutil.Uint64(t.BlockNumber),
+		BlockHash:   t.BlockHash,
+		TxHash:      t.TxHash,
+		TxIndex:     hexutil.Uint64(t.TxIndex),
+	}
+	if t.Kind == TransferKindERC721 || t.Kind == TransferKindERC1155 {
+		enc.TokenID = (*hexutil.Big)(t.TokenID)
+	}
+	return json.Marshal(&enc)
+}
+
+type transfersTracerConfig struct {
+	Path  string `json:"path"`  // Path to the directory where the transfer database will be stored
+	Cache int    `json:"cache"` // Megabytes of memory allocated to the database cache, defaults to 16
+}
+
+// transfersTracer is a live tracer recording the ether transferred by call
+// frames and the ERC-20, ERC-721 and ERC-1155 token transfers of the imported
+// blocks into its own database, indexed by the addresses involved.
+//
+// The transfers of a block are buffered until the block is processed, then
+// written out along with removing the transfers of the same or higher blocks
+// recorded earlier, which were reorged out.
+type transfersTracer struct {
+	db ethdb.KeyValueStore
+
+	block     *types.Block       // Block being processed
+	tx        *types.Transaction // Transaction being processed
+	txIndex   uint64             // Index of the transaction being processed
+	txs       uint64             // Number of transactions started in the block being processed
+	frames    [][]Transfer       // Transfers of the active call frames, dropped on revert
+	transfers []Transfer         // Transfers of the block being processed
+}
+
+func newTransfersTracer(cfg json.RawMessage) (*tracing.Hooks, []rpc.API, error) {
+	var config transfersTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config: %v", err)
+		}
+	}
+	if config.Path == "" {
+		return nil, nil, errors.New("transfers tracer database path is required")
+	}
+	if config.Cache <= 0 {
+		config.Cache = 16
+	}
+	db, err := pebble.New(config.Path, config.Cache, 16, "eth/tracers/transfers", false, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open transfers database: %v", err)
+	}
+	t := newTransfers(db)
+	apis := []rpc.API{{
+		Namespace: "transfers",
+		Service:   &TransfersAPI{db: db},
+	}}
+	return t.hooks(), apis, nil
+}
+
+func newTransfers(db ethdb.KeyValueStore) *transfersTracer {
+	return &transfersTracer{db: db}
+}
+
+func (t *transfersTracer) hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnBlockStart:   t.OnBlockStart,
+		OnBlockEnd:     t.OnBlockEnd,
+		OnSkippedBlock: t.OnSkippedBlock,
+		OnTxStart:      t.OnTxStart,
+		OnEnter:        t.OnEnter,
+		OnExit:         t.OnExit,
+		OnLog:          t.OnLog,
+		OnClose:        t.OnClose,
+	}
+}
+
+func (t *transfersTracer) OnBlockStart(ev tracing.BlockEvent) {
+	t.block = ev.Block
+	t.tx, t.txIndex, t.txs = nil, 0, 0
+	t.frames, t.transfers = nil, nil
+}
+
+func (t *transfersTracer) OnBlockEnd(err error) {
+	if t.block == nil {
+		return
+	}
+	// The transfers are dropped if the block failed to be processed.
+	if err == nil {
+		if err := t.commit(t.block.NumberU64(), t.block.Hash(), t.transfers); err != nil {
+			log.Warn("Failed to write block transfers", "number", t.block.NumberU64(), "hash", t.block.Hash(), "err", err)
+		}
+	}
+	t.block, t.tx, t.frames, t.transfers = nil, nil, nil, nil
+}
+
+// OnSkippedBlock is called for the blocks which are inserted into the chain
+// without execution, as they have been executed before. The transfers recorded
+// at the time are restored if still available.
+func (t *transfersTracer) OnSkippedBlock(ev tracing.BlockEvent) {
+	var (
+		number = ev.Block.NumberU64()
+		hash   = ev.Block.Hash()
+	)
+	var transfers []Transfer
+	if blob, err := t.db.Get(transfersBlockKey(number, hash)); err == nil && len(blob) > 0 {
+		if err := rlp.DecodeBytes(blob, &transfers); err != nil {
+			log.Warn("Failed to decode block transfers", "number", number, "hash", hash, "err", err)
+			return
+		}
+	}
+	if err := t.commit(number, hash, transfers); err != nil {
+		log.Warn("Failed to write block transfers", "number", number, "hash", hash, "err", err)
+	}
+}
+
+func (t *transfersTracer) OnTxStart(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.tx, t.txIndex = tx, t.txs
+	t.txs++
+	t.frames = t.frames[:0]
+}
+
+func (t *transfersTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	var frame []Transfer
+	if value != nil && value.Sign() > 0 {
+		frame = append(frame, t.newTransfer(TransferKindCall, common.Address{}, from, to, value, nil))
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *transfersTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	size := len(t.frames)
+	if size == 0 {
+		return
+	}
+	frame := t.frames[size-1]
+	t.frames = t.frames[:size-1]
+
+	// In case of a revert, the transfers of the frame and all its subcalls
+	// are dropped.
+	if reverted {
+		return
+	}
+	if size == 1 {
+		t.transfers = append(t.transfers, frame...)
+		return
+	}
+	t.frames[size-2] = append(t.frames[size-2], frame...)
+}
+
+func (t *transfersTracer) OnLog(l *types.Log) {
+	transfers := t.decodeLog(l)
+	if len(transfers) == 0 {
+		return
+	}
+	if size := len(t.frames); size > 0 {
+		t.frames[size-1] = append(t.frames[size-1], transfers...)
+		return
+	}
+	t.transfers = append(t.transfers, transfers...)
+}
+
+func (t *transfersTracer) OnClose() {
+	if err := t.db.Close(); err != nil {
+		log.Warn("Failed to close transfers database", "err", err)
+	}
+}
+
+// newTransfer creates a transfer of the transaction being processed.
+func (t *transfersTracer) newTransfer(kind string, token, from, to common.Address, value, id *big.Int) Transfer {
+	tr := Transfer{
+		Kind:    kind,
+		Token:   token,
+		From:    from,
+		To:      to,
+		Value:   new(big.Int).Set(value),
+		TokenID: new(big.Int),
+		TxIndex: t.txIndex,
+	}
+	if id != nil {
+		tr.TokenID.Set(id)
+	}
+	if t.block != nil {
+		tr.BlockNumber, tr.BlockHash = t.block.NumberU64(), t.block.Hash()
+	}
+	if t.tx != nil {
+		tr.TxHash = t.tx.Hash()
+	}
+	return tr
+}
+
+// decodeLog returns the token transfers described by the log, if it's an
+// ERC-20, ERC-721 or ERC-1155 transfer event.
+func (t *transfersTracer) decodeLog(l *types.Log) []Transfer {
+	if len(l.Topics) == 0 {
+		return nil
+	}
+	switch l.Topics[0] {
+	case transferTopic:
+		// ERC-20 has the value in the data, ERC-721 has the token id indexed.
+		switch {
+		case len(l.Topics) == 3 && len(l.Data) == 32:
+			from, to := common.BytesToAddress(l.Topics[1][:]), common.BytesToAddress(l.Topics[2][:])
+			return []Transfer{t.newTransfer(TransferKindERC20, l.Address, from, to, new(big.Int).SetBytes(l.Data), nil)}
+		case len(l.Topics) == 4 && len(l.Data) == 0:
+			from, to := common.BytesToAddress(l.Topics[1][:]), common.BytesToAddress(l.Topics[2][:])
+			return []Transfer{t.newTransfer(TransferKindERC721, l.Address, from, to, common.Big1, l.Topics[3].Big())}
+		}
+	case transferSingleTopic:
+		if len(l.Topics) != 4 || len(l.Data) != 64 {
+			return nil
+		}
+		var (
+			from, to = common.BytesToAddress(l.Topics[2][:]), common.BytesToAddress(l.Topics[3][:])
+			id       = new(big.Int).SetBytes(l.Data[:32])
+			value    = new(big.Int).SetBytes(l.Data[32:])
+		)
+		return []Transfer{t.newTransfer(TransferKindERC1155, l.Address, from, to, value, id)}
+	case transferBatchTopic:
+		if len(l.Topics) != 4 {
+			return nil
+		}
+		ids, values, ok := decodeTransferBatch(l.Data)
+		if !ok {
+			return nil
+		}
+		from, to := common.BytesToAddress(l.Topics[2][:]), common.BytesToAddress(l.Topics[3][:])
+		transfers := make([]Transfer, len(ids))
+		for i := range ids {
+			transfers[i] = t.newTransfer(TransferKindERC1155, l.Address, from, to, values[i], ids[i])
+		}
+		return transfers
+	}
+	return nil
+}
+
+// decodeTransferBatch decodes the ABI-encoded id and value arrays of an ERC-1155
+// TransferBatch event.
+func decodeTransferBatch(data []byte) ([]*big.Int, []*big.Int, bool) {
+	word := func(offset uint64) (uint64, bool) {
+		if offset+32 < offset || offset+32 > uint64(len(data)) {
+			return 0, false
+		}
+		n := new(big.Int).SetBytes(data[offset : offset+32])
+		if !n.IsUint64() {
+			return 0, false
+		}
+		return n.Uint64(), true
+	}
+	array := func(head uint64) ([]*big.Int, bool) {
+		offset, ok := word(head)
+		if !ok {
+			return nil, false
+		}
+		length, ok := word(offset)
+		if !ok || length > uint64(len(data))/32 {
+			return nil, false
+		}
+		items := make([]*big.Int, length)
+		for i := uint64(0); i < length; i++ {
+			start := offset + 32*(i+1)
+			if start+32 > uint64(len(data)) {
+				return nil, false
+			}
+			items[i] = new(big.Int).SetBytes(data[start : start+32])
+		}
+		return items, true
+	}
+	ids, ok := array(0)
+	if !ok {
+		return nil, nil, false
+	}
+	values, ok := array(32)
+	if !ok || len(values) != len(ids) {
+		return nil, nil, false
+	}
+	return ids, values, true
+}
+
+// commit writes the transfers of the given block into the database, removing
+// the transfers of the same and higher blocks recorded earlier.
+func (t *transfersTracer) commit(number uint64, hash common.Hash, transfers []Transfer) error {
+	batch := t.db.NewBatch()
+	if err := t.rollback(batch, number); err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(transfers)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(transfersBlockKey(number, hash), blob); err != nil {
+		return err
+	}
+	if err := batch.Put(transfersCanonicalKey(number), hash.Bytes()); err != nil {
+		return err
+	}
+	for i, tr := range transfers {
+		blob, err := rlp.EncodeToBytes(&tr)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(transfersAddressKey(tr.From, number, uint32(i)), blob); err != nil {
+			return err
+		}
+		if tr.To != tr.From {
+			if err := batch.Put(transfersAddressKey(tr.To, number, uint32(i)), blob); err != nil {
+				return err
+			}
+		}
+	}
+	// Drop the by-hash transfers of the blocks too old to be reinserted.
+	if number >= transfersBlockRetention {
+		prefix := append(bytes.Clone(transfersBlockPrefix), encodeBlockNumber(number-transfersBlockRetention)...)
+		if err := deletePrefix(t.db, batch, prefix); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// rollback removes the address entries of the blocks recorded at or above the
+// given number.
+func (t *transfersTracer) rollback(batch ethdb.Batch, number uint64) error {
+	it := t.db.NewIterator(transfersCanonicalPrefix, encodeBlockNumber(number))
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(transfersCanonicalPrefix)+8 {
+			continue
+		}
+		n := binary.BigEndian.Uint64(key[len(transfersCanonicalPrefix):])
+		hash := common.BytesToHash(it.Value())
+
+		var transfers []Transfer
+		if blob, err := t.db.Get(transfersBlockKey(n, hash)); err == nil && len(blob) > 0 {
+			if err := rlp.DecodeBytes(blob, &transfers); err != nil {
+				return err
+			}
+		}
+		for i, tr := range transfers {
+			if err := batch.Delete(transfersAddressKey(tr.From, n, uint32(i))); err != nil {
+				return err
+			}
+			if err := batch.Delete(transfersAddressKey(tr.To, n, uint32(i))); err != nil {
+				return err
+			}
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// deletePrefix removes all the database entries with the given prefix.
+func deletePrefix(db ethdb.Iteratee, batch ethdb.KeyValueWriter, prefix []byte) error {
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// encodeBlockNumber encodes a block number as big endian uint64.
+func encodeBlockNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+// transfersAddressKey = transfersAddressPrefix + address + number (uint64 big endian) + index (uint32 big endian)
+func transfersAddressKey(address common.Address, number uint64, index uint32) []byte {
+	key := make([]byte, len(transfersAddressPrefix)+common.AddressLength+12)
+	copy(key, transfersAddressPrefix)
+	copy(key[len(transfersAddressPrefix):], address.Bytes())
+	binary.BigEndian.PutUint64(key[len(transfersAddressPrefix)+common.AddressLength:], number)
+	binary.BigEndian.PutUint32(key[len(transfersAddressPrefix)+common.AddressLength+8:], index)
+	return key
+}
+
+// transfersCanonicalKey = transfersCanonicalPrefix + number (uint64 big endian)
+func transfersCanonicalKey(number uint64) []byte {
+	return append(bytes.Clone(transfersCanonicalPrefix), encodeBlockNumber(number)...)
+}
+
+// transfersBlockKey = transfersBlockPrefix + number (uint64 big endian) + hash
+func transfersBlockKey(number uint64, hash common.Hash) []byte {
+	key := append(bytes.Clone(transfersBlockPrefix), encodeBlockNumber(number)...)
+	return append(key, hash.Bytes()...)
+}
+
+// TransfersAPI provides an API to query the transfers recorded by the live
+// transfers tracer.
+type TransfersAPI struct {
+	db ethdb.KeyValueStore
+}
+
+// TransfersQuery is the pagination and range options of a transfer history
+// query.
+type TransfersQuery struct {
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	Cursor    hexutil.Bytes   `json:"cursor"` // Position to continue from, as returned by the previous page
+	Limit     *hexutil.Uint64 `json:"limit"`
+}
+
+// TransfersPage is a page of the transfer history of an address.
+type TransfersPage struct {
+	Transfers []*Transfer   `json:"transfers"`
+	Cursor    hexutil.Bytes `json:"cursor,omitempty"` // Position of the next page, omitted if there are no more transfers
+}
+
+// GetTransfers returns the ether and token transfers sent or received by the
+// given address, in ascending block order. At most limit transfers are returned
+// at once, the cursor of the result is to be passed for retrieving the next
+// page.
+func (api *TransfersAPI) GetTransfers(address common.Address, query *TransfersQuery) (*TransfersPage, error) {
+	var (
+		from  uint64
+		to    = uint64(math.MaxUint64)
+		limit = uint64(transfersDefaultLimit)
+	)
+	if query == nil {
+		query = new(TransfersQuery)
+	}
+	if query.FromBlock != nil {
+		from = uint64(*query.FromBlock)
+	}
+	if query.ToBlock != nil {
+		to = uint64(*query.ToBlock)
+	}
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	if query.Limit != nil && *query.Limit > 0 && uint64(*query.Limit) < limit {
+		limit = uint64(*query.Limit)
+	}
+	prefix := append(bytes.Clone(transfersAddressPrefix), address.Bytes()...)
+	start := encodeBlockNumber(from)
+	if query.Cursor != nil {
+		if len(query.Cursor) != 12 {
+			return nil, errors.New("invalid cursor")
+		}
+		if bytes.Compare(query.Cursor, start) > 0 {
+			start = query.Cursor
+		}
+	}
+	it := api.db.NewIterator(prefix, start)
+	defer it.Release()
+
+	page := &TransfersPage{Transfers: []*Transfer{}}
+	for it.Next() {
+		pos := it.Key()[len(prefix):]
+		if len(pos) != 12 || binary.BigEndian.Uint64(pos) > to {
+			break
+		}
+		if uint64(len(page.Transfers)) == limit {
+			page.Cursor = common.CopyBytes(pos)
+			break
+		}
+		tr := new(Transfer)
+		if err := rlp.DecodeBytes(it.Value(), tr); err != nil {
+			return nil, err
+		}
+		page.Transfers = append(page.Transfers, tr)
+	}
+	return page, it.Error()
+}
@@ -0,0 +1,162 @@
+package live
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+var (
+	transferAlice = common.HexToAddress("0xa11ce")
+	transferBob   = common.HexToAddress("0xb0b")
+	transferCarol = common.HexToAddress("0xca401")
+	transferToken = common.HexToAddress("0x70ce7")
+)
+
+// erc20Log creates an ERC-20 Transfer event.
+func erc20Log(from, to common.Address, value int64) *types.Log {
+	return &types.Log{
+		Address: transferToken,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.BigToHash(big.NewInt(value)).Bytes(),
+	}
+}
+
+// runTransferBlock feeds a block with a single transaction into the tracer. The
+// transaction sends value from alice to to, which calls carol with a reverted
+// subcall and emits an ERC-20 transfer.
+func runTransferBlock(tracer *transfersTracer, number int64, extra byte, to common.Address, value int64) *types.Block {
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Extra: []byte{extra}})
+	hooks := tracer.hooks()
+
+	hooks.OnBlockStart(tracing.BlockEvent{Block: block})
+	hooks.OnTxStart(nil, types.NewTx(&types.LegacyTx{Nonce: uint64(number)}), transferAlice)
+	hooks.OnEnter(0, byte(vm.CALL), transferAlice, to, nil, 0, big.NewInt(value))
+	hooks.OnEnter(1, byte(vm.CALL), to, transferCarol, nil, 0, big.NewInt(1))
+	hooks.OnLog(erc20Log(to, transferCarol, 100))
+	hooks.OnExit(1, nil, 0, vm.ErrExecutionReverted, true)
+	hooks.OnLog(erc20Log(to, transferBob, 5))
+	hooks.OnExit(0, nil, 0, nil, false)
+	hooks.OnBlockEnd(nil)
+	return block
+}
+
+func queryTransfers(t *testing.T, api *TransfersAPI, address common.Address, query *TransfersQuery) *TransfersPage {
+	t.Helper()
+	page, err := api.GetTransfers(address, query)
+	if err != nil {
+		t.Fatalf("failed to query transfers: %v", err)
+	}
+	return page
+}
+
+func TestTransfersTracer(t *testing.T) {
+	var (
+		db     = memorydb.New()
+		tracer = newTransfers(db)
+		api    = &TransfersAPI{db: db}
+	)
+	block1 := runTransferBlock(tracer, 1, 0, transferBob, 10)
+	runTransferBlock(tracer, 2, 0, transferBob, 20)
+
+	// The reverted subcall and its log must not be recorded.
+	if page := queryTransfers(t, api, transferCarol, nil); len(page.Transfers) != 0 {
+		t.Fatalf("reverted transfers recorded: %v", page.Transfers)
+	}
+	page := queryTransfers(t, api, transferAlice, nil)
+	if len(page.Transfers) != 2 {
+		t.Fatalf("wrong number of transfers: have %d, want 2", len(page.Transfers))
+	}
+	if tr := page.Transfers[0]; tr.Kind != TransferKindCall || tr.To != transferBob || tr.Value.Int64() != 10 || tr.BlockHash != block1.Hash() {
+		t.Fatalf("wrong transfer: %+v", tr)
+	}
+	page = queryTransfers(t, api, transferBob, nil)
+	if len(page.Transfers) != 4 {
+		t.Fatalf("wrong number of transfers: have %d, want 4", len(page.Transfers))
+	}
+	if tr := page.Transfers[1]; tr.Kind != TransferKindERC20 || tr.Token != transferToken || tr.Value.Int64() != 5 {
+		t.Fatalf("wrong token transfer: %+v", tr)
+	}
+	// Paginate over the transfers of bob.
+	limit := hexutil.Uint64(3)
+	page = queryTransfers(t, api, transferBob, &TransfersQuery{Limit: &limit})
+	if len(page.Transfers) != 3 || page.Cursor == nil {
+		t.Fatalf("wrong first page: %d transfers, cursor %v", len(page.Transfers), page.Cursor)
+	}
+	page = queryTransfers(t, api, transferBob, &TransfersQuery{Limit: &limit, Cursor: page.Cursor})
+	if len(page.Transfers) != 1 || page.Cursor != nil || page.Transfers[0].BlockNumber != 2 {
+		t.Fatalf("wrong second page: %d transfers, cursor %v", len(page.Transfers), page.Cursor)
+	}
+	// Reorg block 2 with one sending to carol instead, the transfers of the
+	// old block must be removed.
+	runTransferBlock(tracer, 2, 1, transferCarol, 30)
+
+	page = queryTransfers(t, api, transferBob, nil)
+	if len(page.Transfers) != 3 || page.Transfers[2].From != transferCarol {
+		t.Fatalf("reorged transfers not removed: %v", page.Transfers)
+	}
+	if page = queryTransfers(t, api, transferCarol, nil); len(page.Transfers) != 2 || page.Transfers[0].Value.Int64() != 30 {
+		t.Fatalf("wrong transfers after reorg: %v", page.Transfers)
+	}
+	// Reinsert block 1 without execution, the transfers of block 2 must be
+	// removed and the ones of block 1 kept.
+	tracer.hooks().OnSkippedBlock(tracing.BlockEvent{Block: block1})
+
+	if page = queryTransfers(t, api, transferCarol, nil); len(page.Transfers) != 0 {
+		t.Fatalf("transfers above skipped block not removed: %v", page.Transfers)
+	}
+	if page = queryTransfers(t, api, transferBob, nil); len(page.Transfers) != 2 {
+		t.Fatalf("transfers of skipped block not restored: %v", page.Transfers)
+	}
+	// Query a block range.
+	from := hexutil.Uint64(2)
+	if page = queryTransfers(t, api, transferBob, &TransfersQuery{FromBlock: &from}); len(page.Transfers) != 0 {
+		t.Fatalf("transfers out of range returned: %v", page.Transfers)
+	}
+}
+
+func TestTransfersDecodeLog(t *testing.T) {
+	tracer := newTransfers(memorydb.New())
+
+	// ERC-721 transfer with the token id indexed.
+	transfers := tracer.decodeLog(&types.Log{
+		Address: transferToken,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(transferAlice.Bytes()), common.BytesToHash(transferBob.Bytes()), common.BigToHash(big.NewInt(7))},
+	})
+	if len(transfers) != 1 || transfers[0].Kind != TransferKindERC721 || transfers[0].TokenID.Int64() != 7 {
+		t.Fatalf("wrong erc721 transfers: %+v", transfers)
+	}
+	// ERC-1155 batch transfer of two tokens.
+	var data []byte
+	for _, word := range []int64{64, 160, 2, 1, 2, 2, 10, 20} {
+		data = append(data, common.BigToHash(big.NewInt(word)).Bytes()...)
+	}
+	transfers = tracer.decodeLog(&types.Log{
+		Address: transferToken,
+		Topics:  []common.Hash{transferBatchTopic, common.BytesToHash(transferCarol.Bytes()), common.BytesToHash(transferAlice.Bytes()), common.BytesToHash(transferBob.Bytes())},
+		Data:    data,
+	})
+	if len(transfers) != 2 {
+		t.Fatalf("wrong number of erc1155 transfers: have %d, want 2", len(transfers))
+	}
+	for i, tr := range transfers {
+		if tr.Kind != TransferKindERC1155 || tr.From != transferAlice || tr.To != transferBob || tr.TokenID.Int64() != int64(i+1) || tr.Value.Int64() != int64(10*(i+1)) {
+			t.Fatalf("wrong erc1155 transfer %d: %+v", i, tr)
+		}
+	}
+	// Malformed batch transfer.
+	transfers = tracer.decodeLog(&types.Log{
+		Address: transferToken,
+		Topics:  []common.Hash{transferBatchTopic, {}, {}, {}},
+		Data:    data[:200],
+	})
+	if len(transfers) != 0 {
+		t.Fatalf("malformed erc1155 batch decoded: %+v", transfers)
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/google/pprof/profile"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("gasProfiler", newGasProfilerTracer, false)
+}
+
+// The output formats of the gas profiler.
+const (
+	GasProfileFormatPprof  = "pprof"  // gzipped pprof protobuf profile, hex encoded in the json result
+	GasProfileFormatFolded = "folded" // folded stacks, as consumed by flamegraph tools
+)
+
+// gasProfileIntrinsic is the pseudo instruction the intrinsic gas of the
+// transaction is accounted to.
+const gasProfileIntrinsic = "INTRINSIC"
+
+// gasProfileLoc is an element of a gas profile call stack. It's either a call
+// frame identified by the contract address and the called function selector,
+// or an instruction executed by the innermost frame.
+type gasProfileLoc struct {
+	addr     common.Address
+	selector string // function selector, "create" or "fallback" for call frames
+	op       string // instruction name, empty for call frames
+	pc       uint64 // instruction code offset
+}
+
+// String returns the label of the location in folded stacks.
+func (l gasProfileLoc) String() string {
+	if l.op != "" {
+		if l.op == gasProfileIntrinsic {
+			return l.op
+		}
+		return fmt.Sprintf("%s@%#x", l.op, l.pc)
+	}
+	return fmt.Sprintf("%s:%s", l.addr.Hex(), l.selector)
+}
+
+// gasProfileSample is the gas consumed at a unique call stack.
+type gasProfileSample struct {
+	stack []gasProfileLoc // root first
+	gas   uint64
+	count uint64
+}
+
+// gasProfileFrame is an active call frame along with the instruction whose gas
+// consumption is pending, which is only known once the next instruction in the
+// same frame is reached.
+type gasProfileFrame struct {
+	loc     gasProfileLoc
+	gas     uint64 // gas available for the frame at the beginning
+	ops     bool   // whether the frame executed any instructions
+	pending *gasProfileLoc
+	pgas    uint64 // gas available before the pending instruction
+	child   uint64 // gas used by the subcalls of the pending instruction
+}
+
+type gasProfilerConfig struct {
+	Format string `json:"format"` // output format, pprof (default) or folded
+}
+
+// GasProfiler is a native go tracer aggregating the gas consumed by a
+// transaction by call stack, made up of the called contracts and functions,
+// and the instructions executed. The result is a pprof profile or folded
+// stacks for flamegraph tools.
+type GasProfiler struct {
+	config    gasProfilerConfig
+	tx        *types.Transaction
+	frames    []*gasProfileFrame
+	samples   map[string]*gasProfileSample
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newGasProfilerTracer returns a native go tracer which profiles the gas
+// consumption of a transaction.
+func newGasProfilerTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	t, err := NewGasProfiler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tracers.Tracer{
+		Hooks:     t.Hooks(),
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+// NewGasProfiler creates a gas profiler, which can be attached to the EVM with
+// its hooks directly.
+func NewGasProfiler(cfg json.RawMessage) (*GasProfiler, error) {
+	var config gasProfilerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Format {
+	case "":
+		config.Format = GasProfileFormatPprof
+	case GasProfileFormatPprof, GasProfileFormatFolded:
+	default:
+		return nil, fmt.Errorf("unknown gas profile format %q", config.Format)
+	}
+	return &GasProfiler{config: config, samples: make(map[string]*gasProfileSample)}, nil
+}
+
+// Hooks returns the tracing hooks of the profiler.
+func (t *GasProfiler) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
+	}
+}
+
+func (t *GasProfiler) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.tx = tx
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *GasProfiler) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	loc := gasProfileLoc{addr: to, selector: "fallback"}
+	switch op := vm.OpCode(typ); {
+	case op == vm.CREATE || op == vm.CREATE2:
+		loc.selector = "create"
+	case len(input) >= 4:
+		loc.selector = hexutil.Encode(input[:4])
+	}
+	t.frames = append(t.frames, &gasProfileFrame{loc: loc, gas: gas})
+
+	// Account the gas consumed before the execution to the root frame.
+	if len(t.frames) == 1 && t.tx != nil && t.tx.Gas() > gas {
+		t.record(gasProfileLoc{op: gasProfileIntrinsic}, t.tx.Gas()-gas)
+	}
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *GasProfiler) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+
+	// The last instruction of the frame consumed all the gas not left over.
+	if frame.pending != nil {
+		var remaining uint64
+		if gasUsed < frame.gas {
+			remaining = frame.gas - gasUsed
+		}
+		t.record(*frame.pending, gasUsedBy(frame.pgas, remaining, frame.child))
+		frame.pending = nil
+	} else if !frame.ops && gasUsed > 0 {
+		// Precompiles consume gas without executing instructions, account
+		// it to the frame itself.
+		t.record(gasProfileLoc{}, gasUsed)
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].child += gasUsed
+	}
+}
+
+// OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
+func (t *GasProfiler) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.pending != nil {
+		t.record(*frame.pending, gasUsedBy(frame.pgas, gas, frame.child))
+	}
+	frame.ops = true
+	frame.pending = &gasProfileLoc{addr: frame.loc.addr, op: vm.OpCode(opcode).String(), pc: pc}
+	frame.pgas, frame.child = gas, 0
+}
+
+// gasUsedBy returns the gas consumed by an instruction itself, excluding the
+// gas used by the subcalls made by it.
+func gasUsedBy(before, after, child uint64) uint64 {
+	if after+child >= before {
+		return 0
+	}
+	return before - after - child
+}
+
+// record accounts the gas to the current call stack, with the given location
+// on top of it. The location is omitted if zero.
+func (t *GasProfiler) record(leaf gasProfileLoc, gas uint64) {
+	stack := make([]gasProfileLoc, 0, len(t.frames)+1)
+	for _, frame := range t.frames {
+		stack = append(stack, frame.loc)
+	}
+	if leaf != (gasProfileLoc{}) {
+		stack = append(stack, leaf)
+	}
+	labels := make([]string, len(stack))
+	for i, loc := range stack {
+		labels[i] = loc.String()
+	}
+	key := strings.Join(labels, ";")
+
+	sample := t.samples[key]
+	if sample == nil {
+		sample = &gasProfileSample{stack: stack}
+		t.samples[key] = sample
+	}
+	sample.gas += gas
+	sample.count++
+}
+
+// GetResult returns the json-encoded gas profile in the configured format, and
+// any error arising from the encoding or forceful termination (via `Stop`).
+func (t *GasProfiler) GetResult() (json.RawMessage, error) {
+	var (
+		res []byte
+		err error
+	)
+	switch t.config.Format {
+	case GasProfileFormatFolded:
+		var buf bytes.Buffer
+		if err = t.WriteFolded(&buf); err == nil {
+			res, err = json.Marshal(buf.String())
+		}
+	default:
+		var buf bytes.Buffer
+		if err = t.WriteProfile(&buf); err == nil {
+			res, err = json.Marshal(hexutil.Bytes(buf.Bytes()))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *GasProfiler) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// sortedSamples returns the samples ordered by their call stacks.
+func (t *GasProfiler) sortedSamples() ([]string, []*gasProfileSample) {
+	keys := make([]string, 0, len(t.samples))
+	for key := range t.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	samples := make([]*gasProfileSample, len(keys))
+	for i, key := range keys {
+		samples[i] = t.samples[key]
+	}
+	return keys, samples
+}
+
+// WriteFolded writes the gas profile as folded stacks, one line for each call
+// stack with the consumed gas.
+func (t *GasProfiler) WriteFolded(w io.Writer) error {
+	keys, samples := t.sortedSamples()
+	for i, key := range keys {
+		if samples[i].gas == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", key, samples[i].gas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProfile writes the gas profile as a gzipped pprof protobuf profile. The
+// call frames are represented by functions named after the contract address
+// and the function selector, the instructions by functions named after the
+// opcode, with the code offset as the line number.
+func (t *GasProfiler) WriteProfile(w io.Writer) error {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "gas", Unit: "gas"},
+			{Type: "count", Unit: "count"},
+		},
+		DefaultSampleType: "gas",
+	}
+	var (
+		functions = make(map[string]*profile.Function)
+		locations = make(map[gasProfileLoc]*profile.Location)
+	)
+	function := func(name, filename string) *profile.Function {
+		key := filename + "/" + name
+		if fn, ok := functions[key]; ok {
+			return fn
+		}
+		fn := &profile.Function{
+			ID:         uint64(len(p.Function) + 1),
+			Name:       name,
+			SystemName: name,
+			Filename:   filename,
+		}
+		functions[key] = fn
+		p.Function = append(p.Function, fn)
+		return fn
+	}
+	location := func(loc gasProfileLoc) *profile.Location {
+		if l, ok := locations[loc]; ok {
+			return l
+		}
+		var line profile.Line
+		if loc.op != "" {
+			line = profile.Line{Function: function(loc.op, ""), Line: int64(loc.pc)}
+		} else {
+			line = profile.Line{Function: function(loc.String(), loc.addr.Hex())}
+		}
+		l := &profile.Location{
+			ID:   uint64(len(p.Location) + 1),
+			Line: []profile.Line{line},
+		}
+		locations[loc] = l
+		p.Location = append(p.Location, l)
+		return l
+	}
+	_, samples := t.sortedSamples()
+	for _, sample := range samples {
+		s := &profile.Sample{Value: []int64{int64(sample.gas), int64(sample.count)}}
+		for i := len(sample.stack) - 1; i >= 0; i-- {
+			s.Location = append(s.Location, location(sample.stack[i]))
+		}
+		p.Sample = append(p.Sample, s)
+	}
+	if err := p.CheckValid(); err != nil {
+		return errors.New("invalid gas profile: " + err.Error())
+	}
+	return p.Write(w)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasProfiler(t *testing.T) {
+	var (
+		caller = common.HexToAddress("0xc0de")
+		callee = common.HexToAddress("0xca11")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(callee, []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.STOP),
+	})
+	// Call the callee with the function selector 0xdeadbeef, storing it in
+	// memory first.
+	statedb.SetCode(caller, []byte{
+		byte(vm.PUSH4), 0xde, 0xad, 0xbe, 0xef, byte(vm.PUSH1), 0xe0, byte(vm.SHL), byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x04, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00,
+		byte(vm.PUSH2), 0xca, 0x11, byte(vm.GAS), byte(vm.CALL),
+		byte(vm.STOP),
+	})
+	for _, format := range []string{"folded", "pprof"} {
+		tracer, err := tracers.DefaultDirectory.New("gasProfiler", &tracers.Context{}, json.RawMessage(`{"format":"`+format+`"}`))
+		require.NoError(t, err)
+
+		_, used, err := runtime.Call(caller, nil, &runtime.Config{
+			GasLimit:  100000,
+			State:     statedb.Copy(),
+			EVMConfig: vm.Config{Tracer: tracer.Hooks},
+		})
+		require.NoError(t, err)
+		gasUsed := 100000 - used
+
+		res, err := tracer.GetResult()
+		require.NoError(t, err)
+
+		switch format {
+		case "folded":
+			var folded string
+			require.NoError(t, json.Unmarshal(res, &folded))
+
+			root := caller.Hex() + ":fallback"
+			require.Contains(t, folded, root+";"+callee.Hex()+":0xdeadbeef;SSTORE@0x4 22100\n")
+
+			// The gas of the call excludes the one used by the callee.
+			require.Contains(t, folded, root+";CALL@0x19 2600\n")
+
+			// All the gas used is accounted.
+			var total uint64
+			for _, line := range strings.Split(strings.TrimSpace(folded), "\n") {
+				var gas uint64
+				require.NoError(t, json.Unmarshal([]byte(line[strings.LastIndexByte(line, ' ')+1:]), &gas))
+				total += gas
+			}
+			require.Equal(t, gasUsed, total)
+
+		case "pprof":
+			var enc hexutil.Bytes
+			require.NoError(t, json.Unmarshal(res, &enc))
+			p, err := profile.Parse(bytes.NewReader(enc))
+			require.NoError(t, err)
+			require.Equal(t, "gas", p.SampleType[0].Type)
+
+			var total int64
+			for _, sample := range p.Sample {
+				total += sample.Value[0]
+			}
+			require.Equal(t, int64(gasUsed), total)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("vmTracer", newVmTracer, false)
+}
+
+// vmTrace is the Parity-style trace of the code executed in a call frame.
+type vmTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*vmTraceOp  `json:"ops"`
+}
+
+// vmTraceOp is a single executed instruction. The execution result is nil if
+// the instruction failed.
+type vmTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *vmTraceEx `json:"ex"`
+	Pc   uint64     `json:"pc"`
+	Sub  *vmTrace   `json:"sub"`
+	Op   string     `json:"op"`
+}
+
+// vmTraceEx is the execution result of an instruction, holding the remaining
+// gas, the pushed stack items and the memory and storage writes.
+type vmTraceEx struct {
+	Mem   *vmTraceMem   `json:"mem"`
+	Push  []string      `json:"push"`
+	Store *vmTraceStore `json:"store"`
+	Used  uint64        `json:"used"`
+}
+
+// vmTraceMem is a memory write of an instruction.
+type vmTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// vmTraceStore is a storage write of an instruction.
+type vmTraceStore struct {
+	Key string `json:"key"`
+	Val string `json:"val"`
+}
+
+// vmTraceFrame is an active call frame along with the instruction whose
+// execution result is pending, which is only known once the next instruction
+// in the same frame is reached.
+type vmTraceFrame struct {
+	trace *vmTrace // nil for the frames not executing any code (selfdestruct)
+	gas   uint64   // gas available for the frame at the beginning
+
+	pending *vmTraceOp    // instruction waiting for the execution result
+	push    int           // number of stack items pushed by the pending instruction
+	memOff  uint64        // offset of the memory written by the pending instruction
+	memSize uint64        // size of the memory written by the pending instruction
+	store   *vmTraceStore // storage written by the pending instruction
+}
+
+// vmTracer is a native go tracer producing the Parity-style VM trace of a
+// transaction, as returned by the vmTrace type of trace_replay* and trace_call.
+type vmTracer struct {
+	env       *tracing.VMContext
+	root      *vmTrace
+	frames    []*vmTraceFrame
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newVmTracer returns a native go tracer which produces Parity-style VM traces.
+func newVmTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	t := &vmTracer{}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+			OnFault:   t.OnFault,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *vmTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *vmTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	frame := &vmTraceFrame{gas: gas}
+	if op := vm.OpCode(typ); op != vm.SELFDESTRUCT {
+		frame.trace = &vmTrace{Ops: []*vmTraceOp{}}
+		if op == vm.CREATE || op == vm.CREATE2 {
+			frame.trace.Code = common.CopyBytes(input)
+		} else if t.env != nil {
+			frame.trace.Code = common.CopyBytes(t.env.StateDB.GetCode(to))
+		}
+		if len(t.frames) == 0 {
+			t.root = frame.trace
+		} else if parent := t.frames[len(t.frames)-1]; parent.pending != nil {
+			parent.pending.Sub = frame.trace
+		}
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *vmTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	// The last instruction of the frame (e.g. STOP, RETURN) doesn't push any
+	// stack items nor write memory, only the remaining gas is reported.
+	if frame.pending != nil {
+		var remaining uint64
+		if gasUsed < frame.gas {
+			remaining = frame.gas - gasUsed
+		}
+		frame.pending.Ex = &vmTraceEx{Push: []string{}, Store: frame.store, Used: remaining}
+		frame.pending = nil
+	}
+}
+
+// OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
+func (t *vmTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.trace == nil {
+		return
+	}
+	stack := scope.StackData()
+
+	// Resolve the execution result of the previous instruction.
+	if frame.pending != nil {
+		ex := &vmTraceEx{Push: []string{}, Store: frame.store, Used: gas}
+		push := frame.push
+		if push > len(stack) {
+			push = len(stack)
+		}
+		for _, item := range stack[len(stack)-push:] {
+			ex.Push = append(ex.Push, item.Hex())
+		}
+		if frame.memSize > 0 {
+			memory := scope.MemoryData()
+			if end := frame.memOff + frame.memSize; end >= frame.memOff && end <= uint64(len(memory)) {
+				ex.Mem = &vmTraceMem{Off: frame.memOff, Data: common.CopyBytes(memory[frame.memOff:end])}
+			}
+		}
+		frame.pending.Ex = ex
+	}
+	op := vm.OpCode(opcode)
+	frame.pending = &vmTraceOp{Cost: cost, Pc: pc, Op: op.String()}
+	frame.trace.Ops = append(frame.trace.Ops, frame.pending)
+	frame.push, frame.memOff, frame.memSize, frame.store = vmTracePushes(op), 0, 0, nil
+
+	// Track the memory and storage written by the instruction, the locations
+	// are only available before the execution.
+	peek := func(n int) *uint256.Int {
+		if n >= len(stack) {
+			return new(uint256.Int)
+		}
+		return &stack[len(stack)-1-n]
+	}
+	memory := func(off, size *uint256.Int) {
+		if off.IsUint64() && size.IsUint64() {
+			frame.memOff, frame.memSize = off.Uint64(), size.Uint64()
+		}
+	}
+	switch op {
+	case vm.MSTORE:
+		memory(peek(0), uint256.NewInt(32))
+	case vm.MSTORE8:
+		memory(peek(0), uint256.NewInt(1))
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		memory(peek(0), peek(2))
+	case vm.EXTCODECOPY:
+		memory(peek(1), peek(3))
+	case vm.CALL, vm.CALLCODE:
+		memory(peek(5), peek(6))
+	case vm.DELEGATECALL, vm.STATICCALL:
+		memory(peek(4), peek(5))
+	case vm.SSTORE:
+		frame.store = &vmTraceStore{Key: peek(0).Hex(), Val: peek(1).Hex()}
+	}
+}
+
+// OnFault is called when the instruction fails, leaving it without execution
+// result.
+func (t *vmTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	frame.pending, frame.store = nil, nil
+}
+
+// GetResult returns the json-encoded VM trace, and any error arising from the
+// encoding or forceful termination (via `Stop`).
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.root)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// vmTracePushes returns the number of stack items reported as pushed by the
+// given instruction. Following Parity, the duplicated and swapped items are
+// all reported for DUP and SWAP instructions.
+func vmTracePushes(op vm.OpCode) int {
+	switch {
+	case op >= vm.PUSH0 && op <= vm.PUSH32:
+		return 1
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.MCOPY, vm.JUMP,
+		vm.JUMPI, vm.JUMPDEST, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return 0
+	}
+	return 1
+}
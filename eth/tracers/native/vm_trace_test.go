@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVmTracer(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("vmTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+
+	code := []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.STOP),
+	}
+	_, _, err = runtime.Execute(code, nil, &runtime.Config{
+		GasLimit:  100000,
+		EVMConfig: vm.Config{Tracer: tracer.Hooks},
+	})
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var trace struct {
+		Code string `json:"code"`
+		Ops  []struct {
+			Cost uint64 `json:"cost"`
+			Pc   uint64 `json:"pc"`
+			Op   string `json:"op"`
+			Ex   *struct {
+				Mem *struct {
+					Data string `json:"data"`
+					Off  uint64 `json:"off"`
+				} `json:"mem"`
+				Push  []string `json:"push"`
+				Store *struct {
+					Key string `json:"key"`
+					Val string `json:"val"`
+				} `json:"store"`
+				Used uint64 `json:"used"`
+			} `json:"ex"`
+		} `json:"ops"`
+	}
+	require.NoError(t, json.Unmarshal(res, &trace))
+	require.Equal(t, "0x602a600055602a60005200", trace.Code)
+	require.Len(t, trace.Ops, 7)
+
+	// The pushed item and the remaining gas are reported.
+	require.Equal(t, "PUSH1", trace.Ops[0].Op)
+	require.Equal(t, []string{"0x2a"}, trace.Ops[0].Ex.Push)
+	require.Equal(t, trace.Ops[0].Ex.Used-trace.Ops[1].Cost, trace.Ops[1].Ex.Used)
+
+	// The storage write is reported.
+	require.Equal(t, "SSTORE", trace.Ops[2].Op)
+	require.Equal(t, "0x0", trace.Ops[2].Ex.Store.Key)
+	require.Equal(t, "0x2a", trace.Ops[2].Ex.Store.Val)
+
+	// The memory write is reported.
+	require.Equal(t, "MSTORE", trace.Ops[5].Op)
+	require.Equal(t, uint64(0), trace.Ops[5].Ex.Mem.Off)
+	require.Equal(t, common.BytesToHash([]byte{0x2a}).Hex(), trace.Ops[5].Ex.Mem.Data)
+
+	// The last instruction reports the gas remaining at the end.
+	require.Equal(t, uint64(10), trace.Ops[6].Pc)
+	require.Equal(t, trace.Ops[5].Ex.Used, trace.Ops[6].Ex.Used)
+}
@@ -0,0 +1,566 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// flatCallTracerName is the native tracer producing Parity-style call traces.
+	flatCallTracerName = "flatCallTracer"
+
+	// Supported trace types of trace_call and trace_replayBlockTransactions.
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVmTrace   = "vmTrace"
+)
+
+// flatCallTracerConfig is the flat call tracer configuration which reports the
+// errors in the Parity format.
+var flatCallTracerConfig = json.RawMessage(`{"convertParityErrors":true}`)
+
+// traceContextFields are the fields of the flat call traces which are omitted
+// by the replay APIs, as they refer to the block the traces are included in.
+var traceContextFields = []string{"blockHash", "blockNumber", "transactionHash", "transactionPosition"}
+
+// TraceAPI is the collection of Parity-style tracing APIs exposed over the trace
+// namespace, offering compatibility with the tooling written for OpenEthereum
+// and Erigon.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the Parity-style tracing methods.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceResults is the result of replaying a transaction with the requested
+// trace types.
+type TraceResults struct {
+	Output          hexutil.Bytes                   `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []json.RawMessage               `json:"trace"`
+	VmTrace         json.RawMessage                 `json:"vmTrace"`
+	TransactionHash *common.Hash                    `json:"transactionHash,omitempty"`
+}
+
+// AccountDiff is the Parity-style state difference of a single account. Each
+// field is either "=" if unchanged, {"+": value} if created, {"-": value} if
+// removed or {"*": {"from": old, "to": new}} if changed.
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Nonce   interface{}                 `json:"nonce"`
+	Code    interface{}                 `json:"code"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// Block returns the call traces of all the transactions in the given block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.api.blockTraces(ctx, block)
+}
+
+// Transaction returns the call traces of the given transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) (interface{}, error) {
+	tracer := flatCallTracerName
+	return api.api.TraceTransaction(ctx, hash, &TraceConfig{Tracer: &tracer, TracerConfig: flatCallTracerConfig})
+}
+
+// ReplayBlockTransactions replays all the transactions in the given block,
+// returning the results of the requested trace types for each of them.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceResults, error) {
+	config, err := replayConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, config)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, result := range results {
+		if result.Error != "" {
+			return nil, errors.New(result.Error)
+		}
+		if replays[i], err = newTraceResults(result.Result); err != nil {
+			return nil, err
+		}
+		hash := result.TxHash
+		replays[i].TransactionHash = &hash
+	}
+	return replays, nil
+}
+
+// Call executes the given call on top of the specified block, returning the
+// results of the requested trace types.
+func (api *TraceAPI) Call(ctx context.Context, args ethapi.TransactionArgs, traceTypes []string, blockNrOrHash *rpc.BlockNumberOrHash) (*TraceResults, error) {
+	config, err := replayConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	result, err := api.api.TraceCall(ctx, args, *blockNrOrHash, &TraceCallConfig{TraceConfig: *config})
+	if err != nil {
+		return nil, err
+	}
+	return newTraceResults(result)
+}
+
+// Filter returns the call traces matching the given criteria. A trace matches
+// if its sender is in the from addresses and its recipient is in the to
+// addresses, an empty address list matching everything. The trace index is
+// used for skipping the blocks without matching traces if it's available.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	begin, err := api.resolveNumber(ctx, args.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	end, err := api.resolveNumber(ctx, args.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if begin > end {
+		return nil, errors.New("invalid block range")
+	}
+	rangeLimit, resultLimit := api.api.backend.TraceFilterLimits()
+	if rangeLimit > 0 && end-begin >= rangeLimit {
+		return nil, fmt.Errorf("block range too large: %d blocks, limit %d", end-begin+1, rangeLimit)
+	}
+	var (
+		size, tail, sections = api.api.backend.TraceIndexStatus()
+		indexable            = size > 0 && (len(args.FromAddress) > 0 || len(args.ToAddress) > 0)
+
+		section    = uint64(0)
+		candidates []uint64
+		indexed    bool
+		loaded     bool
+
+		skipped uint64
+		traces  = []json.RawMessage{}
+	)
+	for number := begin; number <= end; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Skip the blocks without matching traces if they are indexed.
+		if indexable && number/size >= tail && number/size < sections {
+			if !loaded || number/size != section {
+				section, loaded = number/size, true
+
+				// Sections which couldn't be indexed are served by tracing
+				indexed = !rawdb.ReadTraceIndexUnindexed(api.api.backend.ChainDb(), section)
+				if indexed {
+					if candidates, err = traceIndexMatches(api.api.backend.ChainDb(), section, args.FromAddress, args.ToAddress); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if _, found := slices.BinarySearch(candidates, number); indexed && !found {
+				continue
+			}
+		}
+		block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if len(block.Transactions()) == 0 {
+			continue
+		}
+		blockTraces, err := api.api.blockTraces(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range blockTraces {
+			from, to, err := traceAddresses(trace)
+			if err != nil {
+				return nil, err
+			}
+			if !matchAddress(args.FromAddress, from) || !matchAddress(args.ToAddress, to) {
+				continue
+			}
+			if args.After != nil && skipped < *args.After {
+				skipped++
+				continue
+			}
+			if resultLimit > 0 && uint64(len(traces)) >= resultLimit {
+				return nil, fmt.Errorf("too many traces, limit %d", resultLimit)
+			}
+			traces = append(traces, trace)
+			if args.Count != nil && uint64(len(traces)) >= *args.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// resolveNumber resolves the given block number into an absolute one, the
+// latest block is used if it's not specified.
+func (api *TraceAPI) resolveNumber(ctx context.Context, number *rpc.BlockNumber) (uint64, error) {
+	if number != nil && *number >= 0 {
+		return uint64(*number), nil
+	}
+	latest := rpc.LatestBlockNumber
+	if number != nil {
+		latest = *number
+	}
+	header, err := api.api.backend.HeaderByNumber(ctx, latest)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("block #%d not found", latest)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// blockTraces returns the flat call traces of all the transactions in the
+// given block.
+func (api *API) blockTraces(ctx context.Context, block *types.Block) ([]json.RawMessage, error) {
+	if len(block.Transactions()) == 0 {
+		return []json.RawMessage{}, nil
+	}
+	tracer := flatCallTracerName
+	results, err := api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer, TracerConfig: flatCallTracerConfig})
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for _, result := range results {
+		if result.Error != "" {
+			return nil, errors.New(result.Error)
+		}
+		var txTraces []json.RawMessage
+		if err := unmarshalResult(result.Result, &txTraces); err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	return traces, nil
+}
+
+// unmarshalResult decodes the JSON result returned by a tracer.
+func unmarshalResult(result interface{}, v interface{}) error {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		var err error
+		if blob, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(blob, v)
+}
+
+// replayConfig constructs the configuration of the mux tracer producing the
+// results of the requested trace types. The call tracer is always included to
+// report the output of the transaction.
+func replayConfig(traceTypes []string) (*TraceConfig, error) {
+	config := map[string]json.RawMessage{
+		"callTracer": json.RawMessage(`{"onlyTopCall":true}`),
+	}
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+			config[flatCallTracerName] = flatCallTracerConfig
+		case traceTypeStateDiff:
+			config["prestateTracer"] = json.RawMessage(`{"diffMode":true}`)
+		case traceTypeVmTrace:
+			config["vmTracer"] = nil
+		default:
+			return nil, fmt.Errorf("invalid trace type %q", typ)
+		}
+	}
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	tracer := "muxTracer"
+	return &TraceConfig{Tracer: &tracer, TracerConfig: blob}, nil
+}
+
+// newTraceResults converts the result of the mux tracer configured by
+// replayConfig into the Parity-style replay result.
+func newTraceResults(result interface{}) (*TraceResults, error) {
+	var results map[string]json.RawMessage
+	if err := unmarshalResult(result, &results); err != nil {
+		return nil, err
+	}
+	var (
+		call struct {
+			Output hexutil.Bytes `json:"output"`
+		}
+		res = &TraceResults{Trace: []json.RawMessage{}}
+	)
+	if err := json.Unmarshal(results["callTracer"], &call); err != nil {
+		return nil, err
+	}
+	res.Output = call.Output
+	if res.Output == nil {
+		res.Output = hexutil.Bytes{}
+	}
+	if blob, ok := results[flatCallTracerName]; ok {
+		var traces []map[string]json.RawMessage
+		if err := json.Unmarshal(blob, &traces); err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			for _, field := range traceContextFields {
+				delete(trace, field)
+			}
+			blob, err := json.Marshal(trace)
+			if err != nil {
+				return nil, err
+			}
+			res.Trace = append(res.Trace, blob)
+		}
+	}
+	if blob, ok := results["prestateTracer"]; ok {
+		var diff prestateDiff
+		if err := json.Unmarshal(blob, &diff); err != nil {
+			return nil, err
+		}
+		res.StateDiff = diff.stateDiff()
+	}
+	if blob, ok := results["vmTracer"]; ok {
+		res.VmTrace = blob
+	}
+	return res, nil
+}
+
+// prestateAccount is an account reported by the prestate tracer.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateDiff is the result of the prestate tracer in diff mode. The pre state
+// holds the modified accounts before the transaction, the post state holds the
+// modified fields after the transaction. The created accounts are absent in the
+// pre state and the deleted accounts are absent in the post state.
+type prestateDiff struct {
+	Pre  map[common.Address]*prestateAccount `json:"pre"`
+	Post map[common.Address]*prestateAccount `json:"post"`
+}
+
+// Parity-style value differences.
+func diffBorn(v interface{}) interface{} { return map[string]interface{}{"+": v} }
+func diffDied(v interface{}) interface{} { return map[string]interface{}{"-": v} }
+func diffChanged(from, to interface{}) interface{} {
+	return map[string]interface{}{"*": map[string]interface{}{"from": from, "to": to}}
+}
+
+// stateDiff converts the prestate diff into the Parity-style state difference.
+func (d *prestateDiff) stateDiff() map[common.Address]*AccountDiff {
+	balance := func(b *hexutil.Big) *hexutil.Big {
+		if b == nil {
+			return (*hexutil.Big)(new(big.Int))
+		}
+		return b
+	}
+	code := func(c hexutil.Bytes) hexutil.Bytes {
+		if c == nil {
+			return hexutil.Bytes{}
+		}
+		return c
+	}
+	diffs := make(map[common.Address]*AccountDiff)
+	for addr, post := range d.Post {
+		pre, ok := d.Pre[addr]
+		if !ok {
+			// The account is created by the transaction.
+			diff := &AccountDiff{
+				Balance: diffBorn(balance(post.Balance)),
+				Nonce:   diffBorn(hexutil.Uint64(post.Nonce)),
+				Code:    diffBorn(code(post.Code)),
+				Storage: make(map[common.Hash]interface{}),
+			}
+			for key, val := range post.Storage {
+				diff.Storage[key] = diffBorn(val)
+			}
+			diffs[addr] = diff
+			continue
+		}
+		// The account is modified by the transaction, the fields absent in
+		// the post state are unchanged.
+		diff := &AccountDiff{Balance: "=", Nonce: "=", Code: "=", Storage: make(map[common.Hash]interface{})}
+		if post.Balance != nil {
+			diff.Balance = diffChanged(balance(pre.Balance), post.Balance)
+		}
+		if post.Nonce != 0 && post.Nonce != pre.Nonce {
+			diff.Nonce = diffChanged(hexutil.Uint64(pre.Nonce), hexutil.Uint64(post.Nonce))
+		}
+		if len(post.Code) != 0 {
+			diff.Code = diffChanged(code(pre.Code), post.Code)
+		}
+		// The pre state only holds the changed slots with non-zero original
+		// values, the post state only holds the slots with non-zero values.
+		for key, val := range pre.Storage {
+			diff.Storage[key] = diffChanged(val, post.Storage[key])
+		}
+		for key, val := range post.Storage {
+			if _, ok := pre.Storage[key]; !ok {
+				diff.Storage[key] = diffChanged(common.Hash{}, val)
+			}
+		}
+		diffs[addr] = diff
+	}
+	for addr, pre := range d.Pre {
+		if _, ok := d.Post[addr]; ok {
+			continue
+		}
+		// The account is deleted by the transaction.
+		diff := &AccountDiff{
+			Balance: diffDied(balance(pre.Balance)),
+			Nonce:   diffDied(hexutil.Uint64(pre.Nonce)),
+			Code:    diffDied(code(pre.Code)),
+			Storage: make(map[common.Hash]interface{}),
+		}
+		for key, val := range pre.Storage {
+			diff.Storage[key] = diffDied(val)
+		}
+		diffs[addr] = diff
+	}
+	return diffs
+}
+
+// traceAddresses returns the sender and the recipient of the given flat call
+// trace, following the semantics of trace_filter in OpenEthereum.
+func traceAddresses(trace json.RawMessage) (*common.Address, *common.Address, error) {
+	var dec struct {
+		Type   string `json:"type"`
+		Action struct {
+			From          *common.Address `json:"from"`
+			To            *common.Address `json:"to"`
+			Address       *common.Address `json:"address"`
+			RefundAddress *common.Address `json:"refundAddress"`
+			Author        *common.Address `json:"author"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(trace, &dec); err != nil {
+		return nil, nil, err
+	}
+	switch dec.Type {
+	case "create":
+		var to *common.Address
+		if dec.Result != nil {
+			to = dec.Result.Address
+		}
+		return dec.Action.From, to, nil
+	case "suicide":
+		return dec.Action.Address, dec.Action.RefundAddress, nil
+	case "reward":
+		return nil, dec.Action.Author, nil
+	default:
+		return dec.Action.From, dec.Action.To, nil
+	}
+}
+
+// matchAddress reports whether the address is in the given list, an empty list
+// matching every address.
+func matchAddress(list []common.Address, address *common.Address) bool {
+	if len(list) == 0 {
+		return true
+	}
+	return address != nil && slices.Contains(list, *address)
+}
+
+// traceIndexMatches returns the numbers of the blocks within the given section
+// of the trace index which potentially contain matching traces.
+func traceIndexMatches(db ethdb.KeyValueReader, section uint64, from, to []common.Address) ([]uint64, error) {
+	lookup := func(kind byte, addresses []common.Address) ([]uint64, error) {
+		var union []uint64
+		for _, address := range addresses {
+			numbers, err := rawdb.ReadTraceIndex(db, section, kind, address)
+			if err != nil {
+				return nil, err
+			}
+			union = append(union, numbers...)
+		}
+		slices.Sort(union)
+		return slices.Compact(union), nil
+	}
+	var senders, recipients []uint64
+	if len(from) > 0 {
+		numbers, err := lookup(rawdb.TraceIndexFrom, from)
+		if err != nil {
+			return nil, err
+		}
+		senders = numbers
+	}
+	if len(to) > 0 {
+		numbers, err := lookup(rawdb.TraceIndexTo, to)
+		if err != nil {
+			return nil, err
+		}
+		recipients = numbers
+	}
+	switch {
+	case len(from) == 0:
+		return recipients, nil
+	case len(to) == 0:
+		return senders, nil
+	}
+	// Both the sender and the recipient of a trace must match, the block must
+	// be present in both lists.
+	var result []uint64
+	for _, number := range senders {
+		if _, found := slices.BinarySearch(recipients, number); found {
+			result = append(result, number)
+		}
+	}
+	return result, nil
+}
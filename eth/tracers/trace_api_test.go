@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestTraceStateDiff(t *testing.T) {
+	var (
+		created  = common.HexToAddress("0x01")
+		modified = common.HexToAddress("0x02")
+		deleted  = common.HexToAddress("0x03")
+	)
+	blob := `{
+		"pre": {
+			"0x0000000000000000000000000000000000000002": {"balance": "0x10", "nonce": 1, "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000001"}},
+			"0x0000000000000000000000000000000000000003": {"balance": "0x5", "code": "0x00"}
+		},
+		"post": {
+			"0x0000000000000000000000000000000000000001": {"balance": "0x1", "code": "0x6000", "nonce": 1},
+			"0x0000000000000000000000000000000000000002": {"nonce": 2, "storage": {"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000002"}}
+		}
+	}`
+	var diff prestateDiff
+	if err := json.Unmarshal([]byte(blob), &diff); err != nil {
+		t.Fatalf("Failed to decode prestate diff: %v", err)
+	}
+	have, err := json.Marshal(diff.stateDiff())
+	if err != nil {
+		t.Fatalf("Failed to encode state diff: %v", err)
+	}
+	want := map[common.Address]string{
+		created:  `{"balance":{"+":"0x1"},"nonce":{"+":"0x1"},"code":{"+":"0x6000"},"storage":{}}`,
+		modified: `{"balance":"=","nonce":{"*":{"from":"0x1","to":"0x2"}},"code":"=","storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000000000000000000000000000000"}},"0x0000000000000000000000000000000000000000000000000000000000000002":{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}}`,
+		deleted:  `{"balance":{"-":"0x5"},"nonce":{"-":"0x0"},"code":{"-":"0x00"},"storage":{}}`,
+	}
+	var got map[common.Address]json.RawMessage
+	if err := json.Unmarshal(have, &got); err != nil {
+		t.Fatalf("Failed to decode state diff: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Account count mismatch, want: %d, got: %d", len(want), len(got))
+	}
+	for addr, diff := range want {
+		if string(got[addr]) != diff {
+			t.Fatalf("State diff mismatch %x\nwant: %s\ngot:  %s", addr, diff, got[addr])
+		}
+	}
+}
+
+func TestTraceAddresses(t *testing.T) {
+	var (
+		a = common.HexToAddress("0xa")
+		b = common.HexToAddress("0xb")
+	)
+	tests := []struct {
+		trace    string
+		from, to *common.Address
+	}{
+		{`{"type":"call","action":{"from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000b"}}`, &a, &b},
+		{`{"type":"create","action":{"from":"0x000000000000000000000000000000000000000a"},"result":{"address":"0x000000000000000000000000000000000000000b"}}`, &a, &b},
+		{`{"type":"create","action":{"from":"0x000000000000000000000000000000000000000a"},"error":"Reverted"}`, &a, nil},
+		{`{"type":"suicide","action":{"address":"0x000000000000000000000000000000000000000a","refundAddress":"0x000000000000000000000000000000000000000b"}}`, &a, &b},
+	}
+	for i, test := range tests {
+		from, to, err := traceAddresses(json.RawMessage(test.trace))
+		if err != nil {
+			t.Fatalf("test %d: failed to decode trace: %v", i, err)
+		}
+		if !reflect.DeepEqual(from, test.from) || !reflect.DeepEqual(to, test.to) {
+			t.Fatalf("test %d: address mismatch, want: %v -> %v, got: %v -> %v", i, test.from, test.to, from, to)
+		}
+	}
+}
+
+func TestTraceIndexMatches(t *testing.T) {
+	var (
+		db = rawdb.NewMemoryDatabase()
+		a  = common.HexToAddress("0xa")
+		b  = common.HexToAddress("0xb")
+		c  = common.HexToAddress("0xc")
+	)
+	rawdb.WriteTraceIndex(db, 1, rawdb.TraceIndexFrom, a, []uint64{64, 70, 100})
+	rawdb.WriteTraceIndex(db, 1, rawdb.TraceIndexFrom, b, []uint64{65, 70})
+	rawdb.WriteTraceIndex(db, 1, rawdb.TraceIndexTo, c, []uint64{65, 100})
+
+	tests := []struct {
+		from, to []common.Address
+		want     []uint64
+	}{
+		{[]common.Address{a}, nil, []uint64{64, 70, 100}},
+		{[]common.Address{a, b}, nil, []uint64{64, 65, 70, 100}},
+		{nil, []common.Address{c}, []uint64{65, 100}},
+		{[]common.Address{a}, []common.Address{c}, []uint64{100}},
+		{[]common.Address{c}, []common.Address{c}, nil},
+	}
+	for i, test := range tests {
+		have, err := traceIndexMatches(db, 1, test.from, test.to)
+		if err != nil {
+			t.Fatalf("test %d: failed to match index: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Fatalf("test %d: candidates mismatch, want: %v, got: %v", i, test.want, have)
+		}
+	}
+}
+
+func TestTraceIndexUnindexed(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteTraceIndex(db, 1, rawdb.TraceIndexFrom, common.Address{}, []uint64{64})
+	rawdb.WriteTraceIndexUnindexed(db, 2)
+
+	if rawdb.ReadTraceIndexUnindexed(db, 1) {
+		t.Fatalf("indexed section marked as unindexed")
+	}
+	if !rawdb.ReadTraceIndexUnindexed(db, 2) {
+		t.Fatalf("unindexed section not marked")
+	}
+	// The marker must not be mistaken for index entries of the zero address
+	if have, _ := traceIndexMatches(db, 2, []common.Address{{}}, nil); len(have) != 0 {
+		t.Fatalf("marker matched as index entry: %v", have)
+	}
+	rawdb.DeleteTraceIndex(db, 2, 3)
+	if rawdb.ReadTraceIndexUnindexed(db, 2) {
+		t.Fatalf("marker not removed with the section")
+	}
+}
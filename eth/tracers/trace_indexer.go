@@ -0,0 +1,179 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// TraceIndexSectionSize is the number of blocks in a trace index section. It's
+// kept small, so that the blocks of a section are indexed while their states
+// are still available for re-execution.
+const TraceIndexSectionSize = 64
+
+// traceIndexEntry is the identifier of a trace index entry.
+type traceIndexEntry struct {
+	kind    byte
+	address common.Address
+}
+
+// TraceIndexer implements a core.ChainIndexer, building up a persistent index
+// of the senders and recipients of all call frames keyed by address, which is
+// used to speed up trace_filter. The blocks are re-executed with the flat call
+// tracer, so the indexer needs the state of the recent blocks being available.
+// The sections before the chain head at the time of enabling the index are left
+// unindexed by moving the tail of the index over them, the ones whose states
+// went missing later on are marked as unindexed individually.
+type TraceIndexer struct {
+	api     *API
+	db      ethdb.Database // database instance to write index data and metadata into
+	size    uint64         // section size to generate the trace index for
+	section uint64         // section number being processed currently
+	skip    bool           // flag whether the section can't be indexed
+	entries map[traceIndexEntry][]uint64
+}
+
+// NewTraceIndexer returns a chain indexer that generates the call trace index
+// for the canonical chain, starting from the current chain head if the index
+// is not initialized yet.
+func NewTraceIndexer(backend Backend, confirms uint64) *core.ChainIndexer {
+	db := backend.ChainDb()
+	if rawdb.ReadTraceIndexTail(db) == nil {
+		var tail uint64
+		if number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db)); number != nil {
+			tail = *number / TraceIndexSectionSize
+		}
+		rawdb.WriteTraceIndexTail(db, tail)
+	}
+	indexer := &TraceIndexer{
+		api:  NewAPI(backend),
+		db:   db,
+		size: TraceIndexSectionSize,
+	}
+	table := rawdb.NewTable(db, string(rawdb.TraceIndexPrefix))
+
+	// The sections are not throttled, the re-execution of blocks is slow enough
+	// and the sections below the tail are skipped quickly.
+	return core.NewChainIndexer(db, table, indexer, TraceIndexSectionSize, confirms, 0, "traceindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new trace index section.
+func (b *TraceIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.section, b.skip = section, false
+	if tail := rawdb.ReadTraceIndexTail(b.db); tail != nil && section < *tail {
+		b.skip = true
+	}
+	b.entries = make(map[traceIndexEntry][]uint64)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the senders and recipients
+// of the call frames in the given block into the index.
+func (b *TraceIndexer) Process(ctx context.Context, header *types.Header) error {
+	if b.skip || header.TxHash == types.EmptyTxsHash {
+		return nil
+	}
+	block, err := b.api.blockByHash(ctx, header.Hash())
+	if err != nil {
+		return err
+	}
+	traces, err := b.api.blockTraces(ctx, block)
+	if err != nil {
+		// Don't give up on the section if the indexer is being shut down.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Debug("Skipping unindexable trace section", "section", b.section, "number", header.Number, "err", err)
+		b.skip = true
+		return nil
+	}
+	number := header.Number.Uint64()
+	add := func(kind byte, address *common.Address) {
+		if address == nil {
+			return
+		}
+		entry := traceIndexEntry{kind: kind, address: *address}
+		if list := b.entries[entry]; len(list) == 0 || list[len(list)-1] != number {
+			b.entries[entry] = append(list, number)
+		}
+	}
+	for _, trace := range traces {
+		from, to, err := traceAddresses(trace)
+		if err != nil {
+			return err
+		}
+		add(rawdb.TraceIndexFrom, from)
+		add(rawdb.TraceIndexTo, to)
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the trace index section
+// and writing it out into the database. The stale entries of the section left
+// by a reorg are removed beforehand.
+func (b *TraceIndexer) Commit() error {
+	// The section couldn't be indexed. Move the tail over it if it's the oldest
+	// one, otherwise mark it alone to not lose the indexed sections below.
+	if b.skip {
+		tail := rawdb.ReadTraceIndexTail(b.db)
+		if tail == nil || b.section <= *tail {
+			return b.Prune((b.section + 1) * b.size)
+		}
+		rawdb.DeleteTraceIndex(b.db, b.section, b.section+1)
+		rawdb.WriteTraceIndexUnindexed(b.db, b.section)
+		return nil
+	}
+	rawdb.DeleteTraceIndex(b.db, b.section, b.section+1)
+
+	batch := b.db.NewBatch()
+	for entry, numbers := range b.entries {
+		rawdb.WriteTraceIndex(batch, b.section, entry.kind, entry.address, numbers)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the trace index of the
+// sections entirely below the given block number.
+func (b *TraceIndexer) Prune(threshold uint64) error {
+	var (
+		tail  uint64
+		ntail = threshold / b.size
+	)
+	if stored := rawdb.ReadTraceIndexTail(b.db); stored != nil {
+		tail = *stored
+	}
+	if ntail <= tail {
+		return nil
+	}
+	// Move the tail first for not serving the sections being removed.
+	rawdb.WriteTraceIndexTail(b.db, ntail)
+	rawdb.DeleteTraceIndex(b.db, tail, ntail)
+	return nil
+}
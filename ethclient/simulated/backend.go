@@ -96,20 +96,30 @@ func NewBackend(alloc types.GenesisAlloc, options ...func(nodeConf *node.Config,
 	if err != nil {
 		panic(err) // this should never happen
 	}
-	sim, err := newWithNode(stack, &ethConf, 0)
+	sim, err := newWithNode(stack, &ethConf, 0, takeForkSource(&ethConf))
 	if err != nil {
 		panic(err) // this should never happen
 	}
 	return sim
 }
 
-// newWithNode sets up a simulated backend on an existing node. The provided node
-// must not be started and will be started by this method.
-func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backend, error) {
+// newWithNode sets up a simulated backend on an existing node, optionally forking
+// the state of the given source. The provided node must not be started and will
+// be started by this method.
+func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64, fork ForkSource) (*Backend, error) {
 	backend, err := eth.New(stack, conf)
 	if err != nil {
 		return nil, err
 	}
+	// Fall back to the fork source for the state missing locally. The pools
+	// already track the state of the head, so reset them to pick it up.
+	if fork != nil {
+		chain := backend.BlockChain()
+		chain.SetStateDatabase(newForkDatabase(chain.StateCache(), fork))
+		if err := backend.TxPool().Sync(); err != nil {
+			return nil, err
+		}
+	}
 	// Register the filter system
 	filterSystem := filters.NewFilterSystem(backend.APIBackend, filters.Config{})
 	stack.RegisterAPIs([]rpc.API{{
@@ -187,6 +197,12 @@ func (n *Backend) AdjustTime(adjustment time.Duration) error {
 	return n.beacon.AdjustTime(adjustment)
 }
 
+// Beacon returns the simulated beacon driving the chain, which allows scripting
+// competing forks, reorgs, missed slots and delayed finality.
+func (n *Backend) Beacon() *catalyst.SimulatedBeacon {
+	return n.beacon
+}
+
 // Client returns a client that accesses the simulated chain.
 func (n *Backend) Client() Client {
 	return n.client
@@ -0,0 +1,465 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+// ForkAccount is an account of the state a simulated backend is forked from.
+type ForkAccount struct {
+	Nonce   uint64
+	Balance *uint256.Int
+	Code    []byte
+}
+
+// ForkSource provides the state a simulated backend is forked from. The state
+// is expected to be immutable, its contents being retrieved once and cached.
+type ForkSource interface {
+	// Account retrieves an account, or nil if it does not exist.
+	Account(addr common.Address) (*ForkAccount, error)
+
+	// Storage retrieves a storage slot of an account, or the zero hash if it
+	// does not exist.
+	Storage(addr common.Address, slot common.Hash) (common.Hash, error)
+}
+
+// rpcSource is a fork source retrieving the state of a given block from a remote
+// node via the standard eth RPC methods.
+type rpcSource struct {
+	client *ethclient.Client
+	number *big.Int
+}
+
+// NewRPCSource creates a fork source retrieving the state of the given block
+// from a remote node. If no block number is given, the state is pinned to the
+// latest block at the time of creation.
+func NewRPCSource(client *rpc.Client, number *big.Int) (ForkSource, error) {
+	ec := ethclient.NewClient(client)
+	if number == nil {
+		head, err := ec.BlockNumber(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		number = new(big.Int).SetUint64(head)
+	}
+	return &rpcSource{client: ec, number: new(big.Int).Set(number)}, nil
+}
+
+// Account implements ForkSource, retrieving an account from the remote node.
+func (s *rpcSource) Account(addr common.Address) (*ForkAccount, error) {
+	ctx := context.Background()
+
+	balance, err := s.client.BalanceAt(ctx, addr, s.number)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := s.client.NonceAt(ctx, addr, s.number)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.client.CodeAt(ctx, addr, s.number)
+	if err != nil {
+		return nil, err
+	}
+	// Remote nodes do not distinguish between empty and missing accounts, but
+	// empty accounts are deleted since Spurious Dragon anyway
+	if balance.Sign() == 0 && nonce == 0 && len(code) == 0 {
+		return nil, nil
+	}
+	return &ForkAccount{Nonce: nonce, Balance: uint256.MustFromBig(balance), Code: code}, nil
+}
+
+// Storage implements ForkSource, retrieving a storage slot from the remote node.
+func (s *rpcSource) Storage(addr common.Address, slot common.Hash) (common.Hash, error) {
+	value, err := s.client.StorageAt(context.Background(), addr, slot, s.number)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// DumpFormat is the format of a state dump to fork from, determining how the
+// storage slots are keyed and their values encoded.
+type DumpFormat int
+
+const (
+	// StateDump is the output of 'geth dump', either collected or iterative.
+	// The storage slots are keyed by their preimages and hold the raw values.
+	StateDump DumpFormat = iota
+
+	// SnapshotDump is the output of 'geth snapshot dump'. The storage slots are
+	// keyed by their hashes and hold the RLP encoded values.
+	SnapshotDump
+)
+
+// dumpSource is a fork source serving the state of a state dump.
+type dumpSource struct {
+	format   DumpFormat
+	accounts map[common.Hash]*ForkAccount
+	storage  map[common.Hash]map[common.Hash]common.Hash
+}
+
+// NewDumpSource creates a fork source from a state dump of the given format,
+// either the collected JSON object produced by 'geth dump', or the line-by-line
+// iterative output of 'geth dump --iterative' or 'geth snapshot dump'.
+//
+// Accounts of the iterative dumps are identified by their hashes if the address
+// is missing, and can be served regardless.
+func NewDumpSource(r io.Reader, format DumpFormat) (ForkSource, error) {
+	if format != StateDump && format != SnapshotDump {
+		return nil, fmt.Errorf("unknown dump format %d", format)
+	}
+	src := &dumpSource{
+		format:   format,
+		accounts: make(map[common.Hash]*ForkAccount),
+		storage:  make(map[common.Hash]map[common.Hash]common.Hash),
+	}
+	dec := json.NewDecoder(r)
+
+	// The first object is either a collected dump, or the state root heading
+	// an iterative dump
+	var head map[string]json.RawMessage
+	if err := dec.Decode(&head); err != nil {
+		return nil, fmt.Errorf("invalid dump: %v", err)
+	}
+	if _, ok := head["accounts"]; ok {
+		var accounts map[string]state.DumpAccount
+		if err := json.Unmarshal(head["accounts"], &accounts); err != nil {
+			return nil, fmt.Errorf("invalid dump: %v", err)
+		}
+		for id, account := range accounts {
+			if !common.IsHexAddress(id) {
+				return nil, fmt.Errorf("account %q without address", id)
+			}
+			addr := common.HexToAddress(id)
+			account.Address = &addr
+			if err := src.add(&account); err != nil {
+				return nil, err
+			}
+		}
+		return src, nil
+	}
+	if _, ok := head["balance"]; ok {
+		return nil, errors.New("iterative dump without state root")
+	}
+	for line := 2; ; line++ {
+		var account state.DumpAccount
+		if err := dec.Decode(&account); err == io.EOF {
+			return src, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid dump entry %d: %v", line, err)
+		}
+		if err := src.add(&account); err != nil {
+			return nil, fmt.Errorf("invalid dump entry %d: %v", line, err)
+		}
+	}
+}
+
+// add inserts a dumped account into the index of the source.
+func (s *dumpSource) add(account *state.DumpAccount) error {
+	// Index the account by its address hash, this is the only identifier of
+	// the accounts whose preimages are missing
+	var hash common.Hash
+	switch {
+	case account.Address != nil:
+		hash = crypto.Keccak256Hash(account.Address.Bytes())
+	case len(account.AddressHash) == common.HashLength:
+		hash = common.BytesToHash(account.AddressHash)
+	default:
+		return errors.New("account without address or address hash")
+	}
+	balance, err := uint256.FromDecimal(account.Balance)
+	if err != nil {
+		return fmt.Errorf("invalid balance %q: %v", account.Balance, err)
+	}
+	s.accounts[hash] = &ForkAccount{Nonce: account.Nonce, Balance: balance, Code: account.Code}
+
+	// Index the storage by the slot hashes, decoding the values based on the
+	// format of the dump
+	if len(account.Storage) == 0 {
+		return nil
+	}
+	slots := make(map[common.Hash]common.Hash, len(account.Storage))
+	for key, val := range account.Storage {
+		blob := common.FromHex(val)
+		switch s.format {
+		case SnapshotDump:
+			_, content, _, err := rlp.Split(blob)
+			if err != nil {
+				return fmt.Errorf("invalid storage slot %x: %v", key, err)
+			}
+			blob = content
+		default:
+			key = crypto.Keccak256Hash(key.Bytes())
+		}
+		slots[key] = common.BytesToHash(blob)
+	}
+	s.storage[hash] = slots
+	return nil
+}
+
+// Account implements ForkSource, retrieving an account from the dump.
+func (s *dumpSource) Account(addr common.Address) (*ForkAccount, error) {
+	return s.accounts[crypto.Keccak256Hash(addr.Bytes())], nil
+}
+
+// Storage implements ForkSource, retrieving a storage slot from the dump.
+func (s *dumpSource) Storage(addr common.Address, slot common.Hash) (common.Hash, error) {
+	return s.storage[crypto.Keccak256Hash(addr.Bytes())][crypto.Keccak256Hash(slot.Bytes())], nil
+}
+
+// forkState caches the state retrieved from a fork source.
+type forkState struct {
+	source   ForkSource
+	diskdb   ethdb.KeyValueWriter                           // Database to store the forked contract codes into
+	accounts map[common.Address]*types.StateAccount         // Accounts retrieved from the source, nil if missing
+	storage  map[common.Address]map[common.Hash]common.Hash // Storage slots retrieved from the source
+	lock     sync.Mutex
+}
+
+// account retrieves an account from the fork source. The storage root of the
+// account is reported as empty, the slots being retrieved one by one on demand.
+func (f *forkState) account(addr common.Address) (*types.StateAccount, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if acc, ok := f.accounts[addr]; ok {
+		if acc == nil {
+			return nil, nil
+		}
+		return acc.Copy(), nil
+	}
+	acc, err := f.source.Account(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve forked account %x: %v", addr, err)
+	}
+	if acc == nil {
+		f.accounts[addr] = nil
+		return nil, nil
+	}
+	data := &types.StateAccount{
+		Nonce:    acc.Nonce,
+		Balance:  acc.Balance,
+		Root:     types.EmptyRootHash,
+		CodeHash: types.EmptyCodeHash.Bytes(),
+	}
+	if data.Balance == nil {
+		data.Balance = new(uint256.Int)
+	}
+	if len(acc.Code) > 0 {
+		hash := crypto.Keccak256Hash(acc.Code)
+		data.CodeHash = hash.Bytes()
+		rawdb.WriteCode(f.diskdb, hash, acc.Code)
+	}
+	f.accounts[addr] = data
+	return data.Copy(), nil
+}
+
+// slot retrieves a storage slot from the fork source.
+func (f *forkState) slot(addr common.Address, key common.Hash) (common.Hash, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if val, ok := f.storage[addr][key]; ok {
+		return val, nil
+	}
+	val, err := f.source.Storage(addr, key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to retrieve forked slot %x of %x: %v", key, addr, err)
+	}
+	if f.storage[addr] == nil {
+		f.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	f.storage[addr][key] = val
+	return val, nil
+}
+
+// forkDatabase is a state database serving the accounts and storage slots not
+// available locally from a fork source.
+type forkDatabase struct {
+	state.Database
+	fork *forkState
+}
+
+// newForkDatabase wraps a state database to fall back to a fork source.
+func newForkDatabase(db state.Database, source ForkSource) state.Database {
+	return &forkDatabase{
+		Database: db,
+		fork: &forkState{
+			source:   source,
+			diskdb:   db.DiskDB(),
+			accounts: make(map[common.Address]*types.StateAccount),
+			storage:  make(map[common.Address]map[common.Hash]common.Hash),
+		},
+	}
+}
+
+// OpenTrie opens the main account trie, falling back to the fork source for the
+// accounts missing from it.
+func (db *forkDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, fork: db.fork}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, falling back to the fork
+// source for the slots missing from it, unless the account was destroyed locally.
+func (db *forkDatabase) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self state.Trie) (state.Trie, error) {
+	if ft, ok := self.(*forkTrie); ok {
+		self = ft.Trie
+	}
+	tr, err := db.Database.OpenStorageTrie(stateRoot, address, root, self)
+	if err != nil {
+		return nil, err
+	}
+	// The account trie is not handed over by the prefetcher, open it to look
+	// for the destruction marker of the account
+	if self == nil {
+		if self, err = db.Database.OpenTrie(stateRoot); err != nil {
+			return nil, err
+		}
+	}
+	marker, err := self.GetAccount(destructedMarker(address))
+	if err != nil {
+		return nil, err
+	}
+	if marker != nil {
+		return tr, nil
+	}
+	return &forkTrie{Trie: tr, fork: db.fork}, nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *forkDatabase) CopyTrie(t state.Trie) state.Trie {
+	if ft, ok := t.(*forkTrie); ok {
+		return &forkTrie{Trie: db.Database.CopyTrie(ft.Trie), fork: ft.fork}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+var (
+	// deletedSlot is the tombstone stored in place of the deleted storage slots,
+	// preventing the fallback to their forked values. It never occurs otherwise,
+	// as slot values are stored without leading zeroes.
+	deletedSlot = []byte{0x00}
+
+	// deletedCodeHash is the code hash of the tombstone account stored in place
+	// of the deleted accounts, preventing the fallback to their forked values.
+	deletedCodeHash = crypto.Keccak256([]byte("simulated fork deleted account"))
+)
+
+// destructedMarker returns the address of the marker stored along with the
+// tombstone of a deleted account. Unlike the tombstone, the marker outlives the
+// account being recreated, preventing the fallback to its forked storage.
+//
+// Note, accounts destroyed and recreated within the same block, which is only
+// possible before Cancun, are not detected.
+func destructedMarker(addr common.Address) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte("simulated fork destructed account"), addr.Bytes()))
+}
+
+// forkTrie is a state trie falling back to a fork source for the accounts and
+// storage slots missing from it. Deletions are stored as tombstones, so that
+// the fallback only happens for state never touched locally.
+type forkTrie struct {
+	state.Trie
+	fork *forkState
+}
+
+// GetAccount implements state.Trie, retrieving the account from the fork source
+// if it is not present locally.
+func (t *forkTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	acc, err := t.Trie.GetAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc != nil {
+		if bytes.Equal(acc.CodeHash, deletedCodeHash) {
+			return nil, nil
+		}
+		return acc, nil
+	}
+	return t.fork.account(address)
+}
+
+// GetStorage implements state.Trie, retrieving the slot from the fork source if
+// it is not present locally.
+func (t *forkTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	val, err := t.Trie.GetStorage(addr, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(val) > 0 {
+		if bytes.Equal(val, deletedSlot) {
+			return nil, nil
+		}
+		return val, nil
+	}
+	slot, err := t.fork.slot(addr, common.BytesToHash(key))
+	if err != nil {
+		return nil, err
+	}
+	return common.TrimLeftZeroes(slot[:]), nil
+}
+
+// UpdateStorage implements state.Trie, storing a tombstone for deleted slots.
+func (t *forkTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	if len(value) == 0 {
+		value = deletedSlot
+	}
+	return t.Trie.UpdateStorage(addr, key, value)
+}
+
+// DeleteStorage implements state.Trie, storing a tombstone for the slot.
+func (t *forkTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return t.Trie.UpdateStorage(addr, key, deletedSlot)
+}
+
+// DeleteAccount implements state.Trie, storing a tombstone for the account and
+// marking its storage as destructed.
+func (t *forkTrie) DeleteAccount(address common.Address) error {
+	tombstone := &types.StateAccount{
+		Balance:  new(uint256.Int),
+		Root:     types.EmptyRootHash,
+		CodeHash: deletedCodeHash,
+	}
+	if err := t.Trie.UpdateAccount(address, tombstone); err != nil {
+		return err
+	}
+	return t.Trie.UpdateAccount(destructedMarker(address), tombstone)
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	forkAccount  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	forkContract = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	forkCode     = common.FromHex("0x600060005500") // sstore(0, 0)
+)
+
+// forkTestService is a fake remote node serving the state to fork from.
+type forkTestService struct {
+	storage map[common.Hash]common.Hash
+}
+
+func (s *forkTestService) BlockNumber() hexutil.Uint64 {
+	return 100
+}
+
+func (s *forkTestService) GetBalance(addr common.Address, block rpc.BlockNumberOrHash) *hexutil.Big {
+	if addr == forkAccount {
+		return (*hexutil.Big)(big.NewInt(params.Ether))
+	}
+	return new(hexutil.Big)
+}
+
+func (s *forkTestService) GetTransactionCount(addr common.Address, block rpc.BlockNumberOrHash) hexutil.Uint64 {
+	if addr == forkAccount {
+		return 3
+	}
+	return 0
+}
+
+func (s *forkTestService) GetCode(addr common.Address, block rpc.BlockNumberOrHash) hexutil.Bytes {
+	if addr == forkContract {
+		return forkCode
+	}
+	return nil
+}
+
+func (s *forkTestService) GetStorageAt(addr common.Address, key common.Hash, block rpc.BlockNumberOrHash) hexutil.Bytes {
+	if addr != forkContract {
+		return common.Hash{}.Bytes()
+	}
+	return s.storage[key].Bytes()
+}
+
+// Tests that a simulated backend forked from a remote node serves the remote
+// state, and applies the local modifications on top.
+func TestForkRPC(t *testing.T) {
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	srv.RegisterName("eth", &forkTestService{
+		storage: map[common.Hash]common.Hash{
+			common.HexToHash("0x00"): common.HexToHash("0x01"),
+			common.HexToHash("0x01"): common.HexToHash("0x02"),
+		},
+	})
+	src, err := NewRPCSource(rpc.DialInProc(srv), nil)
+	if err != nil {
+		t.Fatalf("failed to create fork source: %v", err)
+	}
+	sim := NewBackend(types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}}, WithFork(src))
+	defer sim.Close()
+
+	var (
+		ctx    = context.Background()
+		client = sim.Client()
+	)
+	// Ensure the remote state is served
+	if balance, err := client.BalanceAt(ctx, forkAccount, nil); err != nil || balance.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("forked balance mismatch: have %v, want %v (err %v)", balance, params.Ether, err)
+	}
+	if nonce, err := client.NonceAt(ctx, forkAccount, nil); err != nil || nonce != 3 {
+		t.Fatalf("forked nonce mismatch: have %d, want %d (err %v)", nonce, 3, err)
+	}
+	if code, err := client.CodeAt(ctx, forkContract, nil); err != nil || !bytes.Equal(code, forkCode) {
+		t.Fatalf("forked code mismatch: have %x, want %x (err %v)", code, forkCode, err)
+	}
+	if val, err := client.StorageAt(ctx, forkContract, common.HexToHash("0x00"), nil); err != nil || common.BytesToHash(val) != common.HexToHash("0x01") {
+		t.Fatalf("forked slot mismatch: have %x, want %x (err %v)", val, 1, err)
+	}
+	// Transfer to the forked account and clear a forked slot
+	head, _ := client.HeaderByNumber(ctx, nil)
+	chainid, _ := client.ChainID(ctx)
+	signer := types.LatestSignerForChainID(chainid)
+	for i, to := range []common.Address{forkAccount, forkContract} {
+		tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   chainid,
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: new(big.Int).Add(head.BaseFee, big.NewInt(2*params.GWei)),
+			Gas:       100000,
+			To:        &to,
+			Value:     big.NewInt(1),
+		})
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to send transaction %d: %v", i, err)
+		}
+	}
+	sim.Commit()
+
+	want := new(big.Int).Add(big.NewInt(params.Ether), common.Big1)
+	if balance, err := client.BalanceAt(ctx, forkAccount, nil); err != nil || balance.Cmp(want) != 0 {
+		t.Fatalf("balance mismatch after transfer: have %v, want %v (err %v)", balance, want, err)
+	}
+	if nonce, err := client.NonceAt(ctx, forkAccount, nil); err != nil || nonce != 3 {
+		t.Fatalf("nonce mismatch after transfer: have %d, want %d (err %v)", nonce, 3, err)
+	}
+	if val, err := client.StorageAt(ctx, forkContract, common.HexToHash("0x00"), nil); err != nil || common.BytesToHash(val) != (common.Hash{}) {
+		t.Fatalf("cleared slot mismatch: have %x, want zero (err %v)", val, err)
+	}
+	if val, err := client.StorageAt(ctx, forkContract, common.HexToHash("0x01"), nil); err != nil || common.BytesToHash(val) != common.HexToHash("0x02") {
+		t.Fatalf("untouched slot mismatch: have %x, want %x (err %v)", val, 2, err)
+	}
+}
+
+// Tests that both collected and iterative state dumps can be forked from.
+func TestForkDump(t *testing.T) {
+	var (
+		addrHash = crypto.Keccak256Hash(forkContract.Bytes())
+		slotHash = crypto.Keccak256Hash(common.HexToHash("0x01").Bytes())
+	)
+	dumps := map[string]struct {
+		format DumpFormat
+		dump   string
+	}{
+		"collected": {StateDump, `{"root": "00", "accounts": {"` + forkContract.Hex() + `": {"balance": "5", "nonce": 1, "root": "0x", "codeHash": "0x", "code": "0x600060005500", "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "02"}}}}`},
+		"iterative": {StateDump, `{"root": "0x0000000000000000000000000000000000000000000000000000000000000000"}
+{"balance": "5", "nonce": 1, "root": "0x", "codeHash": "0x", "code": "0x600060005500", "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "02"}, "key": "` + addrHash.Hex() + `"}
+`},
+		"snapshot": {SnapshotDump, `{"root": "0x0000000000000000000000000000000000000000000000000000000000000000"}
+{"balance": "5", "nonce": 1, "root": "0x", "codeHash": "0x", "code": "0x600060005500", "storage": {"` + slotHash.Hex() + `": "8180"}, "key": "` + addrHash.Hex() + `"}
+`},
+	}
+	for name, test := range dumps {
+		want := common.HexToHash("0x02")
+		if test.format == SnapshotDump {
+			want = common.HexToHash("0x80")
+		}
+		src, err := NewDumpSource(strings.NewReader(test.dump), test.format)
+		if err != nil {
+			t.Fatalf("%s: failed to load dump: %v", name, err)
+		}
+		acc, err := src.Account(forkContract)
+		if err != nil || acc == nil {
+			t.Fatalf("%s: failed to retrieve account: %v", name, err)
+		}
+		if acc.Nonce != 1 || acc.Balance.Uint64() != 5 || !bytes.Equal(acc.Code, forkCode) {
+			t.Errorf("%s: account mismatch: %+v", name, acc)
+		}
+		if val, _ := src.Storage(forkContract, common.HexToHash("0x01")); val != want {
+			t.Errorf("%s: slot mismatch: have %x, want %x", name, val, want)
+		}
+		if acc, _ := src.Account(forkAccount); acc != nil {
+			t.Errorf("%s: unexpected account: %+v", name, acc)
+		}
+	}
+}
+
+// Tests that the forked storage of an account deleted locally is not served any
+// more, even once the account is recreated.
+func TestForkDestructedStorage(t *testing.T) {
+	empty := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	dump := `{"root": "00", "accounts": {"` + empty.Hex() + `": {"balance": "0", "nonce": 0, "root": "0x", "codeHash": "0x", "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "02"}}}}`
+
+	src, err := NewDumpSource(strings.NewReader(dump), StateDump)
+	if err != nil {
+		t.Fatalf("failed to load dump: %v", err)
+	}
+	sim := NewBackend(types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}}, WithFork(src))
+	defer sim.Close()
+
+	var (
+		ctx    = context.Background()
+		client = sim.Client()
+		slot   = common.HexToHash("0x01")
+	)
+	if val, err := client.StorageAt(ctx, empty, slot, nil); err != nil || common.BytesToHash(val) != common.HexToHash("0x02") {
+		t.Fatalf("forked slot mismatch: have %x, want %x (err %v)", val, 2, err)
+	}
+	// Touch the empty account to delete it, then recreate it
+	head, _ := client.HeaderByNumber(ctx, nil)
+	chainid, _ := client.ChainID(ctx)
+	signer := types.LatestSignerForChainID(chainid)
+	for i, value := range []int64{0, 1} {
+		tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   chainid,
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: new(big.Int).Add(head.BaseFee, big.NewInt(2*params.GWei)),
+			Gas:       100000,
+			To:        &empty,
+			Value:     big.NewInt(value),
+		})
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to send transaction %d: %v", i, err)
+		}
+		sim.Commit()
+	}
+	if balance, err := client.BalanceAt(ctx, empty, nil); err != nil || balance.Cmp(common.Big1) != 0 {
+		t.Fatalf("recreated balance mismatch: have %v, want %v (err %v)", balance, 1, err)
+	}
+	if val, err := client.StorageAt(ctx, empty, slot, nil); err != nil || common.BytesToHash(val) != (common.Hash{}) {
+		t.Fatalf("destructed slot served: have %x, want zero (err %v)", val, err)
+	}
+}
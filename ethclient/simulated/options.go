@@ -18,6 +18,7 @@ package simulated
 
 import (
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/node"
@@ -53,3 +54,39 @@ func WithMinerMinTip(tip *big.Int) func(nodeConf *node.Config, ethConf *ethconfi
 		ethConf.Miner.GasPrice = tip
 	}
 }
+
+// WithFork configures the simulated backend to fork the state of an existing
+// chain. Accounts and storage slots not present in the genesis allocation are
+// retrieved on demand from the fork source, and any local modifications are
+// applied on top.
+//
+// Note, the state root of the simulated chain only covers the state accessed
+// or modified locally, not that of the forked chain.
+func WithFork(source ForkSource) func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+	return func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		// Snapshots would serve the missing state as non-existent, bypassing the
+		// fallback to the fork source, so disable them altogether
+		ethConf.SnapshotCache = 0
+
+		forkSourcesLock.Lock()
+		forkSources[ethConf] = source
+		forkSourcesLock.Unlock()
+	}
+}
+
+var (
+	// forkSources tracks the fork sources configured by WithFork, keyed by the
+	// configuration of the backend being created.
+	forkSources     = make(map[*ethconfig.Config]ForkSource)
+	forkSourcesLock sync.Mutex
+)
+
+// takeForkSource retrieves and forgets the fork source configured for a backend.
+func takeForkSource(ethConf *ethconfig.Config) ForkSource {
+	forkSourcesLock.Lock()
+	defer forkSourcesLock.Unlock()
+
+	source := forkSources[ethConf]
+	delete(forkSources, ethConf)
+	return source
+}
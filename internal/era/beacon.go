@@ -0,0 +1,489 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	bparams "github.com/ethereum/go-ethereum/beacon/params"
+	btypes "github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era/e2store"
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/golang/snappy"
+	"github.com/protolambda/zrnt/eth2/beacon/altair"
+	"github.com/protolambda/zrnt/eth2/beacon/bellatrix"
+	"github.com/protolambda/zrnt/eth2/beacon/capella"
+	"github.com/protolambda/zrnt/eth2/beacon/deneb"
+	"github.com/protolambda/zrnt/eth2/beacon/phase0"
+	"github.com/protolambda/zrnt/eth2/configs"
+	"github.com/protolambda/ztyp/codec"
+)
+
+// Entry types of consensus-layer era files.
+//
+// See https://github.com/status-im/nimbus-eth2/blob/stable/docs/e2store.md
+// for more information.
+var (
+	TypeEmpty                       uint16 = 0x00
+	TypeCompressedSignedBeaconBlock uint16 = 0x01
+	TypeCompressedBeaconState       uint16 = 0x02
+	TypeSlotIndex                   uint16 = 0x3269
+
+	SlotsPerHistoricalRoot = 8192
+)
+
+const (
+	// stateSlotIndexSize is the size of the slot index of the beacon state,
+	// which always indexes a single entry.
+	stateSlotIndexSize = 8 + 3*8
+
+	// statePrefixSize is the size of the fixed beacon state fields up to and
+	// including the state roots, which are laid out identically in all forks:
+	// genesis time, genesis validators root, slot, fork, latest block header,
+	// block roots and state roots.
+	statePrefixSize = 8 + 32 + 8 + 16 + 112 + 2*32*8192
+
+	// stateBlockRootsOffset is the offset of the block roots in the beacon state.
+	stateBlockRootsOffset = 8 + 32 + 8 + 16 + 112
+)
+
+// beaconForkSchedule contains the activation epochs of the beacon chain forks
+// which changed the format of the beacon blocks.
+type beaconForkSchedule struct {
+	altair, bellatrix, capella, deneb uint64
+}
+
+// beaconForks are the fork schedules of the networks with consensus-layer era
+// files, needed to decode the blocks stored at each slot.
+var beaconForks = map[string]beaconForkSchedule{
+	"mainnet": {altair: 74240, bellatrix: 144896, capella: 194048, deneb: 269568},
+	"sepolia": {altair: 50, bellatrix: 100, capella: 56832, deneb: 132608},
+	"goerli":  {altair: 36660, bellatrix: 112260, capella: 162304, deneb: 231680},
+	"holesky": {altair: 0, bellatrix: 0, capella: 256, deneb: 29696},
+}
+
+// BeaconFilename returns a recognizable consensus-layer era file name for the
+// specified era and network. The root is the last historical root of the stored
+// state, or the genesis validators root for the genesis era.
+func BeaconFilename(network string, era int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s.era", network, era, root.Hex()[2:10])
+}
+
+// ReadBeaconDir reads all the consensus-layer era files in a directory for a
+// given network. The files need to be contiguous, but not necessarily start
+// from the genesis era.
+// Format: <network>-<era>-<hexroot>.era
+func ReadBeaconDir(dir, network string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	var (
+		next uint64
+		eras []string
+	)
+	for _, entry := range entries {
+		if path.Ext(entry.Name()) != ".era" {
+			continue
+		}
+		parts := strings.Split(entry.Name(), "-")
+		if len(parts) != 3 || parts[0] != network {
+			// invalid era filename, skip
+			continue
+		}
+		if number, err := strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("malformed era filename: %s", entry.Name())
+		} else if len(eras) > 0 && number != next {
+			return nil, fmt.Errorf("missing era %d", next)
+		} else {
+			next = number + 1
+		}
+		eras = append(eras, entry.Name())
+	}
+	return eras, nil
+}
+
+// BeaconEra reads a consensus-layer era file, containing the beacon blocks of
+// SlotsPerHistoricalRoot slots and the beacon state following them.
+type BeaconEra struct {
+	f     ReadAtSeekCloser   // backing era file
+	s     *e2store.Reader    // e2store reader over f
+	forks beaconForkSchedule // fork schedule to decode the blocks with
+	m     beaconMetadata     // slot index info
+	mu    *sync.Mutex        // lock for buf
+	buf   [8]byte            // buffer reading entry offsets
+}
+
+// BeaconFrom returns a BeaconEra of the given network backed by f.
+func BeaconFrom(f ReadAtSeekCloser, network string) (*BeaconEra, error) {
+	forks, ok := beaconForks[network]
+	if !ok {
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	s := e2store.NewReader(f)
+	m, err := readBeaconMetadata(f, s)
+	if err != nil {
+		return nil, err
+	}
+	return &BeaconEra{
+		f:     f,
+		s:     s,
+		forks: forks,
+		m:     m,
+		mu:    new(sync.Mutex),
+	}, nil
+}
+
+// OpenBeacon returns a BeaconEra of the given network backed by the given filename.
+func OpenBeacon(filename string, network string) (*BeaconEra, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	e, err := BeaconFrom(f, network)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *BeaconEra) Close() error {
+	return e.f.Close()
+}
+
+// Start returns the first slot of the blocks in the era.
+func (e *BeaconEra) Start() uint64 {
+	return e.m.start
+}
+
+// Count returns the number of slots covered by the era, including empty ones.
+// The genesis era contains no blocks.
+func (e *BeaconEra) Count() uint64 {
+	return e.m.count
+}
+
+// StateSlot returns the slot of the beacon state stored in the era.
+func (e *BeaconEra) StateSlot() uint64 {
+	return e.m.stateSlot
+}
+
+// GetBlockBySlot returns the beacon block of the given slot, or nil if the slot
+// is empty.
+func (e *BeaconEra) GetBlockBySlot(slot uint64) (*btypes.BeaconBlock, error) {
+	if e.m.start > slot || e.m.start+e.m.count <= slot {
+		return nil, errors.New("out-of-bounds")
+	}
+	off, err := e.readOffset(slot)
+	if err != nil {
+		return nil, err
+	}
+	if off == 0 {
+		return nil, nil
+	}
+	r, _, err := newSnappyReader(e.s, TypeCompressedSignedBeaconBlock, off)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	block, err := e.decodeBlock(slot, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding block at slot %d: %w", slot, err)
+	}
+	if block.Slot() != slot {
+		return nil, fmt.Errorf("block slot mismatch: have %d, want %d", block.Slot(), slot)
+	}
+	return block, nil
+}
+
+// decodeBlock decodes an SSZ encoded signed beacon block, using the format of
+// the fork active at the given slot.
+func (e *BeaconEra) decodeBlock(slot uint64, data []byte) (*btypes.BeaconBlock, error) {
+	var (
+		dr    = codec.NewDecodingReader(bytes.NewReader(data), uint64(len(data)))
+		epoch = slot / bparams.EpochLength
+	)
+	switch {
+	case epoch >= e.forks.deneb:
+		var block deneb.SignedBeaconBlock
+		if err := block.Deserialize(configs.Mainnet, dr); err != nil {
+			return nil, err
+		}
+		return btypes.NewBeaconBlock(&block.Message), nil
+	case epoch >= e.forks.capella:
+		var block capella.SignedBeaconBlock
+		if err := block.Deserialize(configs.Mainnet, dr); err != nil {
+			return nil, err
+		}
+		return btypes.NewBeaconBlock(&block.Message), nil
+	case epoch >= e.forks.bellatrix:
+		var block bellatrix.SignedBeaconBlock
+		if err := block.Deserialize(configs.Mainnet, dr); err != nil {
+			return nil, err
+		}
+		return btypes.NewBeaconBlock(&block.Message), nil
+	case epoch >= e.forks.altair:
+		var block altair.SignedBeaconBlock
+		if err := block.Deserialize(configs.Mainnet, dr); err != nil {
+			return nil, err
+		}
+		return btypes.NewBeaconBlock(&block.Message), nil
+	default:
+		var block phase0.SignedBeaconBlock
+		if err := block.Deserialize(configs.Mainnet, dr); err != nil {
+			return nil, err
+		}
+		return btypes.NewBeaconBlock(&block.Message), nil
+	}
+}
+
+// BeaconStateSummary contains the fields of the beacon state stored in an era
+// needed to authenticate the blocks of the era.
+type BeaconStateSummary struct {
+	GenesisValidatorsRoot common.Hash
+	Slot                  uint64
+	BlockRoots            []common.Hash // Block roots of the last SlotsPerHistoricalRoot slots
+	StateRoots            []common.Hash // State roots of the last SlotsPerHistoricalRoot slots
+}
+
+// StateSummary reads the beacon state stored in the era, decoding only the
+// fields needed to authenticate the blocks of the era.
+func (e *BeaconEra) StateSummary() (*BeaconStateSummary, error) {
+	// Mainnet states exceed the e2store value size limit, stream them instead.
+	r, _, err := e.s.StreamAt(TypeCompressedBeaconState, e.m.stateOffset)
+	if err != nil {
+		return nil, err
+	}
+	r = snappy.NewReader(r)
+
+	data := make([]byte, statePrefixSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("error reading beacon state: %w", err)
+	}
+	summary := &BeaconStateSummary{
+		GenesisValidatorsRoot: common.BytesToHash(data[8:40]),
+		Slot:                  binary.LittleEndian.Uint64(data[40:48]),
+		BlockRoots:            make([]common.Hash, SlotsPerHistoricalRoot),
+		StateRoots:            make([]common.Hash, SlotsPerHistoricalRoot),
+	}
+	if summary.Slot != e.m.stateSlot {
+		return nil, fmt.Errorf("beacon state slot mismatch: have %d, want %d", summary.Slot, e.m.stateSlot)
+	}
+	roots := data[stateBlockRootsOffset:]
+	for i := 0; i < SlotsPerHistoricalRoot; i++ {
+		summary.BlockRoots[i] = common.BytesToHash(roots[i*32 : (i+1)*32])
+		summary.StateRoots[i] = common.BytesToHash(roots[(SlotsPerHistoricalRoot+i)*32 : (SlotsPerHistoricalRoot+i+1)*32])
+	}
+	return summary, nil
+}
+
+// BlockRoot returns the root of the block at the given slot, or of the last
+// block before it if the slot is empty.
+func (s *BeaconStateSummary) BlockRoot(slot uint64) (common.Hash, error) {
+	if slot >= s.Slot || slot+uint64(SlotsPerHistoricalRoot) < s.Slot {
+		return common.Hash{}, errors.New("out-of-bounds")
+	}
+	return s.BlockRoots[slot%uint64(SlotsPerHistoricalRoot)], nil
+}
+
+// HistoricalRoot computes the SSZ hash tree root of the historical batch of the
+// block and state roots, as accumulated by the beacon chain in its historical
+// roots and summaries.
+func (s *BeaconStateSummary) HistoricalRoot() (common.Hash, error) {
+	hh := ssz.NewHasher()
+	indx := hh.Index()
+	for _, roots := range [][]common.Hash{s.BlockRoots, s.StateRoots} {
+		vector := hh.Index()
+		for _, root := range roots {
+			hh.Append(root[:])
+		}
+		hh.Merkleize(vector)
+	}
+	hh.Merkleize(indx)
+	return hh.HashRoot()
+}
+
+// Verify checks the blocks of the era against the stored beacon state, and
+// returns the root identifying the era. Only the blocks are authenticated by
+// the state, the root itself needs to be checked against a trusted source.
+func (e *BeaconEra) Verify() (common.Hash, error) {
+	summary, err := e.StateSummary()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error reading beacon state: %w", err)
+	}
+	if summary.Slot%uint64(SlotsPerHistoricalRoot) != 0 {
+		return common.Hash{}, fmt.Errorf("beacon state at slot %d not on era boundary", summary.Slot)
+	}
+	// To fully verify an era the following attributes must be checked:
+	//   1) the slot index is constructed correctly
+	//   2) the block roots match the ones accumulated in the state
+	//   3) the blocks are linked to their parents
+	//   4) the execution payloads match their block hashes, which verifies the
+	//      transactions and withdrawals
+	//   5) the execution blocks are linked to their parents
+	var (
+		last   common.Hash  // root of the last beacon block
+		parent *types.Block // last execution block
+	)
+	for slot := e.Start(); slot < e.Start()+e.Count(); slot++ {
+		// 1) reading the block at each slot walks the slot index.
+		block, err := e.GetBlockBySlot(slot)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("error reading block at slot %d: %w", slot, err)
+		}
+		// 2) check the block root, empty slots repeat the last root.
+		want, err := summary.BlockRoot(slot)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if block == nil {
+			if last != (common.Hash{}) && want != last {
+				return common.Hash{}, fmt.Errorf("block root at empty slot %d mismatch: want %s, got %s", slot, want, last)
+			}
+			continue
+		}
+		if root := block.Root(); root != want {
+			return common.Hash{}, fmt.Errorf("block root at slot %d mismatch: want %s, got %s", slot, want, root)
+		}
+		// 3) check the parent root of the block.
+		if header := block.Header(); last != (common.Hash{}) && header.ParentRoot != last {
+			return common.Hash{}, fmt.Errorf("parent root at slot %d mismatch: want %s, got %s", slot, last, header.ParentRoot)
+		}
+		last = want
+
+		// 4) converting the payload checks its block hash.
+		payload, err := block.ExecutionPayload()
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("error reading execution payload at slot %d: %w", slot, err)
+		}
+		if payload == nil {
+			continue
+		}
+		// 5) check the parent of the execution block.
+		if parent != nil && (payload.ParentHash() != parent.Hash() || payload.NumberU64() != parent.NumberU64()+1) {
+			return common.Hash{}, fmt.Errorf("execution block %d at slot %d not linked to parent %d", payload.NumberU64(), slot, parent.NumberU64())
+		}
+		parent = payload
+	}
+	return summary.Root()
+}
+
+// Root returns the root identifying the era of the state: the genesis validators
+// root for the genesis era, the historical root of the stored state otherwise.
+func (s *BeaconStateSummary) Root() (common.Hash, error) {
+	if s.Slot == 0 {
+		return s.GenesisValidatorsRoot, nil
+	}
+	return s.HistoricalRoot()
+}
+
+// readOffset reads the offset of a specific slot's block from the block index.
+// Zero is returned for empty slots.
+func (e *BeaconEra) readOffset(slot uint64) (int64, error) {
+	offOffset := e.m.blockIndex + 16 + int64(slot-e.m.start)*8 // skips header and start slot
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	clear(e.buf[:])
+	if _, err := e.f.ReadAt(e.buf[:], offOffset); err != nil {
+		return 0, err
+	}
+	// Block offsets are relative to the start of the block index record.
+	off := int64(binary.LittleEndian.Uint64(e.buf[:]))
+	if off == 0 {
+		return 0, nil
+	}
+	return e.m.blockIndex + off, nil
+}
+
+// beaconMetadata wraps the metadata in the slot indices.
+type beaconMetadata struct {
+	start       uint64 // first slot in the block index
+	count       uint64 // number of slots in the block index
+	blockIndex  int64  // offset of the block index record
+	stateSlot   uint64 // slot of the beacon state
+	stateOffset int64  // offset of the beacon state entry
+}
+
+// readBeaconMetadata reads the metadata stored in the slot indices at the end
+// of a consensus-layer era file.
+func readBeaconMetadata(f ReadAtSeekCloser, s *e2store.Reader) (m beaconMetadata, err error) {
+	length, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return m, err
+	}
+	if length < stateSlotIndexSize {
+		return m, errors.New("file too short")
+	}
+	// Read the state index, which is the last record of the file.
+	stateIndex := length - stateSlotIndexSize
+	if typ, _, err := s.ReadMetadataAt(stateIndex); err != nil {
+		return m, err
+	} else if typ != TypeSlotIndex {
+		return m, fmt.Errorf("expected state slot index, have type %d", typ)
+	}
+	b := make([]byte, stateSlotIndexSize-8)
+	if _, err := f.ReadAt(b, stateIndex+8); err != nil {
+		return m, err
+	}
+	if count := binary.LittleEndian.Uint64(b[16:]); count != 1 {
+		return m, fmt.Errorf("invalid state slot index count %d", count)
+	}
+	m.stateSlot = binary.LittleEndian.Uint64(b[:8])
+	m.stateOffset = stateIndex + int64(binary.LittleEndian.Uint64(b[8:16]))
+
+	// The genesis era contains the genesis state only, all other eras are
+	// preceded by a block index covering the slots before the state.
+	if m.stateSlot == 0 {
+		return m, nil
+	}
+	if _, err := f.ReadAt(b[:8], stateIndex-8); err != nil {
+		return m, err
+	}
+	m.count = binary.LittleEndian.Uint64(b[:8])
+	if m.count != uint64(SlotsPerHistoricalRoot) {
+		return m, fmt.Errorf("invalid block slot index count %d", m.count)
+	}
+	m.blockIndex = stateIndex - 8 - 16 - int64(m.count)*8
+	if typ, _, err := s.ReadMetadataAt(m.blockIndex); err != nil {
+		return m, err
+	} else if typ != TypeSlotIndex {
+		return m, fmt.Errorf("expected block slot index, have type %d", typ)
+	}
+	if _, err := f.ReadAt(b[:8], m.blockIndex+8); err != nil {
+		return m, err
+	}
+	m.start = binary.LittleEndian.Uint64(b[:8])
+	if m.start+m.count != m.stateSlot {
+		return m, fmt.Errorf("block slot index mismatch: start %d, count %d, state slot %d", m.start, m.count, m.stateSlot)
+	}
+	return m, nil
+}
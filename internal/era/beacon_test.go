@@ -0,0 +1,307 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bparams "github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era/e2store"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/golang/snappy"
+	"github.com/holiman/uint256"
+	"github.com/protolambda/zrnt/eth2/beacon/altair"
+	"github.com/protolambda/zrnt/eth2/beacon/capella"
+	zrntcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/protolambda/zrnt/eth2/beacon/phase0"
+	"github.com/protolambda/zrnt/eth2/configs"
+	"github.com/protolambda/ztyp/codec"
+	"github.com/protolambda/ztyp/tree"
+	"github.com/protolambda/ztyp/view"
+)
+
+// makeCapellaBlock creates a capella beacon block at the given slot, carrying an
+// execution payload with a single transaction.
+func makeCapellaBlock(t *testing.T, slot uint64, parentRoot zrntcommon.Root, parent *types.Block) (*capella.SignedBeaconBlock, *types.Block) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     slot,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(params.InitialBaseFee),
+		Gas:       params.TxGas,
+		To:        &common.Address{0x01},
+		Value:     big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{
+		ParentHash:      parent.Hash(),
+		UncleHash:       types.EmptyUncleHash,
+		Coinbase:        common.Address{0xaa},
+		Root:            common.Hash{byte(slot)},
+		ReceiptHash:     common.Hash{0xbb},
+		Difficulty:      common.Big0,
+		Number:          new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:        30_000_000,
+		GasUsed:         params.TxGas,
+		Time:            parent.Time() + 12,
+		Extra:           []byte("era"),
+		MixDigest:       common.Hash{0xcc},
+		BaseFee:         big.NewInt(params.InitialBaseFee),
+		WithdrawalsHash: &types.EmptyWithdrawalsHash,
+	}
+	block := types.NewBlock(header, &types.Body{Transactions: types.Transactions{tx}, Withdrawals: []*types.Withdrawal{}}, nil, trie.NewStackTrie(nil))
+
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := capella.ExecutionPayload{
+		ParentHash:    zrntcommon.Hash32(block.ParentHash()),
+		FeeRecipient:  zrntcommon.Eth1Address(block.Coinbase()),
+		StateRoot:     zrntcommon.Bytes32(block.Root()),
+		ReceiptsRoot:  zrntcommon.Bytes32(block.ReceiptHash()),
+		LogsBloom:     zrntcommon.LogsBloom(block.Bloom()),
+		PrevRandao:    zrntcommon.Bytes32(block.MixDigest()),
+		BlockNumber:   view.Uint64View(block.NumberU64()),
+		GasLimit:      view.Uint64View(block.GasLimit()),
+		GasUsed:       view.Uint64View(block.GasUsed()),
+		Timestamp:     zrntcommon.Timestamp(block.Time()),
+		ExtraData:     zrntcommon.ExtraData(block.Extra()),
+		BaseFeePerGas: view.Uint256View(*uint256.MustFromBig(block.BaseFee())),
+		BlockHash:     zrntcommon.Hash32(block.Hash()),
+		Transactions:  zrntcommon.PayloadTransactions{enc},
+	}
+	beaconBlock := &capella.SignedBeaconBlock{
+		Message: capella.BeaconBlock{
+			Slot:       zrntcommon.Slot(slot),
+			ParentRoot: parentRoot,
+			StateRoot:  zrntcommon.Root{byte(slot)},
+		},
+	}
+	beaconBlock.Message.Body.ExecutionPayload = payload
+	beaconBlock.Message.Body.SyncAggregate.SyncCommitteeBits = make(altair.SyncCommitteeBits, bparams.SyncCommitteeBitmaskSize)
+	return beaconBlock, block
+}
+
+// snappyEncode compresses the data into the snappy framing format.
+func snappyEncode(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := snappy.NewBufferedWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBeaconEra(t *testing.T) {
+	var (
+		// Holesky activates capella at slot 8192, so the era containing the
+		// following slots only has capella blocks.
+		network = "holesky"
+		number  = 2
+		start   = uint64(number-1) * uint64(SlotsPerHistoricalRoot)
+		slots   = []uint64{start, start + 1, start + 7, start + uint64(SlotsPerHistoricalRoot) - 1}
+
+		parentRoot = zrntcommon.Root{0x01}
+		parent     = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100), Difficulty: common.Big0})
+		beaconRoot = make(map[uint64]common.Hash)
+		execBlocks = make(map[uint64]*types.Block)
+
+		buf = new(bytes.Buffer)
+		w   = e2store.NewWriter(buf)
+	)
+	// Write the version entry and the blocks.
+	if _, err := w.Write(TypeVersion, nil); err != nil {
+		t.Fatal(err)
+	}
+	offsets := make([]int64, SlotsPerHistoricalRoot)
+	for _, slot := range slots {
+		block, execBlock := makeCapellaBlock(t, slot, parentRoot, parent)
+		var enc bytes.Buffer
+		if err := block.Serialize(configs.Mainnet, codec.NewEncodingWriter(&enc)); err != nil {
+			t.Fatalf("failed to encode block: %v", err)
+		}
+		offsets[slot-start] = int64(buf.Len())
+		if _, err := w.Write(TypeCompressedSignedBeaconBlock, snappyEncode(t, enc.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		parentRoot = block.Message.HashTreeRoot(configs.Mainnet, tree.GetHashFn())
+		parent = execBlock
+		beaconRoot[slot] = common.Hash(parentRoot)
+		execBlocks[slot] = execBlock
+	}
+	// Write a state containing the block roots, empty slots repeating the root
+	// of the previous block.
+	var (
+		stateSlot   = start + uint64(SlotsPerHistoricalRoot)
+		state       = make([]byte, statePrefixSize)
+		blockRoots  = make([]common.Hash, SlotsPerHistoricalRoot)
+		stateRoots  = make([]common.Hash, SlotsPerHistoricalRoot)
+		stateOffset = int64(buf.Len())
+		gvr         = common.Hash{0xdd}
+		root        common.Hash
+	)
+	for i := range blockRoots {
+		slot := start + uint64(i)
+		if r, ok := beaconRoot[slot]; ok {
+			root = r
+		}
+		blockRoots[slot%uint64(SlotsPerHistoricalRoot)] = root
+		stateRoots[slot%uint64(SlotsPerHistoricalRoot)] = common.Hash{byte(i), byte(i >> 8)}
+	}
+	copy(state[8:40], gvr[:])
+	binary.LittleEndian.PutUint64(state[40:48], stateSlot)
+	for i := range blockRoots {
+		copy(state[stateBlockRootsOffset+i*32:], blockRoots[i][:])
+		copy(state[stateBlockRootsOffset+(SlotsPerHistoricalRoot+i)*32:], stateRoots[i][:])
+	}
+	if _, err := w.Write(TypeCompressedBeaconState, snappyEncode(t, state)); err != nil {
+		t.Fatal(err)
+	}
+	// Write the block and state slot indices.
+	var (
+		blockIndex = int64(buf.Len())
+		index      = binary.LittleEndian.AppendUint64(nil, start)
+	)
+	for _, off := range offsets {
+		if off != 0 {
+			off -= blockIndex
+		}
+		index = binary.LittleEndian.AppendUint64(index, uint64(off))
+	}
+	index = binary.LittleEndian.AppendUint64(index, uint64(SlotsPerHistoricalRoot))
+	if _, err := w.Write(TypeSlotIndex, index); err != nil {
+		t.Fatal(err)
+	}
+	index = binary.LittleEndian.AppendUint64(nil, stateSlot)
+	index = binary.LittleEndian.AppendUint64(index, uint64(stateOffset-int64(buf.Len())))
+	index = binary.LittleEndian.AppendUint64(index, 1)
+	if _, err := w.Write(TypeSlotIndex, index); err != nil {
+		t.Fatal(err)
+	}
+	// Write the era into a directory and read it back.
+	summary := &BeaconStateSummary{BlockRoots: blockRoots, StateRoots: stateRoots}
+	historicalRoot, err := summary.HistoricalRoot()
+	if err != nil {
+		t.Fatalf("failed to compute historical root: %v", err)
+	}
+	dir := t.TempDir()
+	filename := BeaconFilename(network, number, historicalRoot)
+	if err := os.WriteFile(filepath.Join(dir, filename), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := ReadBeaconDir(dir, network); err != nil {
+		t.Fatalf("failed to read era dir: %v", err)
+	} else if len(entries) != 1 || entries[0] != filename {
+		t.Fatalf("era dir mismatch: have %v, want [%s]", entries, filename)
+	}
+	e, err := OpenBeacon(filepath.Join(dir, filename), network)
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != start || e.Count() != uint64(SlotsPerHistoricalRoot) || e.StateSlot() != stateSlot {
+		t.Fatalf("metadata mismatch: have start %d count %d state %d, want %d %d %d", e.Start(), e.Count(), e.StateSlot(), start, SlotsPerHistoricalRoot, stateSlot)
+	}
+	have, err := e.StateSummary()
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	if have.GenesisValidatorsRoot != gvr || have.Slot != stateSlot {
+		t.Fatalf("state mismatch: have gvr %x slot %d, want %x %d", have.GenesisValidatorsRoot, have.Slot, gvr, stateSlot)
+	}
+	if root, err := have.HistoricalRoot(); err != nil || root != historicalRoot {
+		t.Fatalf("historical root mismatch: have %x, want %x (err %v)", root, historicalRoot, err)
+	}
+	if root, err := e.Verify(); err != nil || root != historicalRoot {
+		t.Fatalf("verified root mismatch: have %x, want %x (err %v)", root, historicalRoot, err)
+	}
+	for slot := start; slot < stateSlot; slot++ {
+		block, err := e.GetBlockBySlot(slot)
+		if err != nil {
+			t.Fatalf("slot %d: failed to read block: %v", slot, err)
+		}
+		want, ok := execBlocks[slot]
+		if !ok {
+			if block != nil {
+				t.Fatalf("slot %d: block in empty slot", slot)
+			}
+			continue
+		}
+		if block == nil {
+			t.Fatalf("slot %d: missing block", slot)
+		}
+		if root, _ := have.BlockRoot(slot); block.Root() != root {
+			t.Fatalf("slot %d: block root mismatch: have %x, want %x", slot, block.Root(), root)
+		}
+		payload, err := block.ExecutionPayload()
+		if err != nil {
+			t.Fatalf("slot %d: failed to convert payload: %v", slot, err)
+		}
+		if payload.Hash() != want.Hash() || len(payload.Transactions()) != 1 {
+			t.Fatalf("slot %d: payload mismatch: have %x, want %x", slot, payload.Hash(), want.Hash())
+		}
+	}
+	if _, err := e.GetBlockBySlot(stateSlot); err == nil {
+		t.Fatalf("out-of-bounds slot accepted")
+	}
+}
+
+// Tests that the historical root computation matches the SSZ hash tree root of
+// the beacon chain historical batch.
+func TestHistoricalRoot(t *testing.T) {
+	var (
+		summary = &BeaconStateSummary{
+			BlockRoots: make([]common.Hash, SlotsPerHistoricalRoot),
+			StateRoots: make([]common.Hash, SlotsPerHistoricalRoot),
+		}
+		batch = &phase0.HistoricalBatch{
+			BlockRoots: make(phase0.HistoricalBatchRoots, SlotsPerHistoricalRoot),
+			StateRoots: make(phase0.HistoricalBatchRoots, SlotsPerHistoricalRoot),
+		}
+	)
+	for i := 0; i < SlotsPerHistoricalRoot; i++ {
+		summary.BlockRoots[i] = crypto.Keccak256Hash([]byte{'b', byte(i), byte(i >> 8)})
+		summary.StateRoots[i] = crypto.Keccak256Hash([]byte{'s', byte(i), byte(i >> 8)})
+		batch.BlockRoots[i] = zrntcommon.Root(summary.BlockRoots[i])
+		batch.StateRoots[i] = zrntcommon.Root(summary.StateRoots[i])
+	}
+	have, err := summary.HistoricalRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.Hash(batch.HashTreeRoot(configs.Mainnet, tree.GetHashFn())); have != want {
+		t.Fatalf("historical root mismatch: have %x, want %x", have, want)
+	}
+}
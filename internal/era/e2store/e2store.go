@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 )
 
 const (
@@ -123,6 +124,17 @@ func (r *Reader) ReadAt(entry *Entry, off int64) (int, error) {
 // the specified offset. If the entry type does not match the expected type, an
 // error is returned.
 func (r *Reader) ReaderAt(expectedType uint16, off int64) (io.Reader, int, error) {
+	return r.readerAt(expectedType, off, valueSizeLimit)
+}
+
+// StreamAt is like ReaderAt, but doesn't enforce the value size limit. It's
+// meant for values which are too large to be held in memory, and therefore
+// must be consumed as a stream.
+func (r *Reader) StreamAt(expectedType uint16, off int64) (io.Reader, int, error) {
+	return r.readerAt(expectedType, off, math.MaxUint32)
+}
+
+func (r *Reader) readerAt(expectedType uint16, off int64, limit uint32) (io.Reader, int, error) {
 	// problem = need to return length+headerSize not just value length via section reader
 	typ, length, err := r.ReadMetadataAt(off)
 	if err != nil {
@@ -131,8 +143,8 @@ func (r *Reader) ReaderAt(expectedType uint16, off int64) (io.Reader, int, error
 	if typ != expectedType {
 		return nil, headerSize, fmt.Errorf("wrong type, want %d have %d", expectedType, typ)
 	}
-	if length > valueSizeLimit {
-		return nil, headerSize, fmt.Errorf("item larger than item size limit %d: have %d", valueSizeLimit, length)
+	if length > limit {
+		return nil, headerSize, fmt.Errorf("item larger than item size limit %d: have %d", limit, length)
 	}
 	return io.NewSectionReader(r.r, off+headerSize, int64(length)), headerSize + int(length), nil
 }
@@ -18,6 +18,7 @@ package e2store
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"io"
 	"testing"
@@ -128,6 +129,25 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+// Tests that entries larger than the value size limit are rejected by ReaderAt,
+// but can still be streamed via StreamAt.
+func TestReaderAtSizeLimit(t *testing.T) {
+	// Only the header is needed, the value is never read
+	header := []byte{0x02, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(header[2:6], valueSizeLimit+1)
+
+	r := NewReader(bytes.NewReader(header))
+	if _, _, err := r.ReaderAt(0x02, 0); err == nil {
+		t.Errorf("oversized entry accepted")
+	}
+	if _, n, err := r.StreamAt(0x02, 0); err != nil || n != headerSize+valueSizeLimit+1 {
+		t.Errorf("oversized entry not streamed: n %d, err %v", n, err)
+	}
+	if _, _, err := r.StreamAt(0x03, 0); err == nil {
+		t.Errorf("entry of wrong type streamed")
+	}
+}
+
 func FuzzCodec(f *testing.F) {
 	f.Fuzz(func(t *testing.T, input []byte) {
 		r := NewReader(bytes.NewReader(input))
@@ -0,0 +1,459 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	errHistoryUnavailable = errors.New("block not available in history archive")
+	errHistoryMismatch    = errors.New("history archive content mismatch")
+)
+
+const (
+	// eraConnectTimeout is the maximum time allowed for establishing a connection
+	// to a history mirror and for it to start responding to a request.
+	eraConnectTimeout = 30 * time.Second
+
+	// eraDownloadTimeout is the maximum time allowed for retrieving a single
+	// file from a history mirror. Era1 files are around a gigabyte in size.
+	eraDownloadTimeout = 30 * time.Minute
+)
+
+// eraLinkRegexp extracts era1 file links from the index page of an HTTP mirror.
+var eraLinkRegexp = regexp.MustCompile(`href="([^"?#]+\.era1)"`)
+
+// History is an archive of Era1 files, used to fill in the pre-merge block bodies
+// and receipts during sync without retrieving them from peers, and to serve them
+// after they were expired from the local database. The archive can either be a
+// directory or an HTTP mirror serving the files and an index page linking to
+// them.
+//
+// Each file is verified against its accumulator root before use, and all served
+// data is checked against the given headers. Files failing verification are
+// skipped, leaving their blocks to be retrieved elsewhere.
+type History struct {
+	location string // Directory or mirror URL of the archive
+	remote   bool   // Whether the archive is an HTTP mirror
+	client   *http.Client
+
+	epochs []*eraEpoch // Era1 files by epoch, nil for missing ones (immutable)
+	opened []*eraEpoch // Currently opened epochs, least recently used first
+	closed bool        // Set when the archive is closed, discarding pending loads
+	lock   sync.Mutex
+}
+
+// maxOpenEras is the number of Era1 files kept open. Bodies and receipts are
+// retrieved separately, so two epochs may be in use around epoch boundaries.
+const maxOpenEras = 2
+
+// eraEpoch is a single Era1 file of the archive.
+type eraEpoch struct {
+	name   string
+	root   string        // Accumulator root prefix in the file name
+	failed atomic.Bool   // Set if the file is unusable
+	count  atomic.Uint64 // Number of blocks in the file, 0 until verified
+
+	era     *Era
+	temp    string        // Temporary download location for remote files
+	loading chan struct{} // Closed when a pending load finishes, nil if none
+}
+
+// NewHistory opens the Era1 archive of the given network at the location, which
+// is either a directory or an http(s) URL.
+func NewHistory(location string, network string) (*History, error) {
+	h := &History{
+		location: location,
+		remote:   strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"),
+		client: &http.Client{
+			Timeout: eraDownloadTimeout,
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: eraConnectTimeout}).DialContext,
+				TLSHandshakeTimeout:   eraConnectTimeout,
+				ResponseHeaderTimeout: eraConnectTimeout,
+			},
+		},
+	}
+	var (
+		names []string
+		err   error
+	)
+	if h.remote {
+		names, err = h.listRemote()
+	} else {
+		names, err = h.listLocal()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		parts := strings.Split(strings.TrimSuffix(name, ".era1"), "-")
+		if len(parts) != 3 || parts[0] != network {
+			continue
+		}
+		epoch, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || epoch >= 1<<20 {
+			continue
+		}
+		for uint64(len(h.epochs)) <= epoch {
+			h.epochs = append(h.epochs, nil)
+		}
+		h.epochs[epoch] = &eraEpoch{name: name, root: parts[2]}
+	}
+	if len(h.epochs) == 0 {
+		return nil, fmt.Errorf("no %s era1 files found at %s", network, location)
+	}
+	return h, nil
+}
+
+func (h *History) listLocal() ([]string, error) {
+	entries, err := os.ReadDir(h.location)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".era1" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (h *History) listRemote() ([]string, error) {
+	h.location = strings.TrimSuffix(h.location, "/") + "/"
+	resp, err := h.client.Get(h.location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("history mirror index: %s", resp.Status)
+	}
+	index, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, match := range eraLinkRegexp.FindAllStringSubmatch(string(index), -1) {
+		names = append(names, match[1][strings.LastIndex(match[1], "/")+1:])
+	}
+	return names, nil
+}
+
+// Remote reports whether the archive is an HTTP mirror, in which case files are
+// downloaded on demand.
+func (h *History) Remote() bool {
+	return h.remote
+}
+
+// Available reports whether the archive may be able to serve the given block.
+// It never blocks, so it is safe to call while holding other locks.
+func (h *History) Available(number uint64) bool {
+	epoch := number / uint64(MaxEra1Size)
+	if epoch >= uint64(len(h.epochs)) {
+		return false
+	}
+	e := h.epochs[epoch]
+	if e == nil || e.failed.Load() {
+		return false
+	}
+	count := e.count.Load()
+	return count == 0 || number < epoch*uint64(MaxEra1Size)+count
+}
+
+// Body retrieves the body of a block, checking it against the given header.
+func (h *History) Body(header *types.Header) (*types.Body, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	e, err := h.open(header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	block, err := e.era.GetBlockByNumber(header.Number.Uint64())
+	if err != nil {
+		return nil, h.fail(e, err)
+	}
+	if block.Hash() != header.Hash() {
+		return nil, h.fail(e, fmt.Errorf("%w: block %d hash %x, want %x", errHistoryMismatch, header.Number, block.Hash(), header.Hash()))
+	}
+	body := block.Body()
+	if types.DeriveSha(types.Transactions(body.Transactions), trie.NewStackTrie(nil)) != header.TxHash || types.CalcUncleHash(body.Uncles) != header.UncleHash {
+		return nil, h.fail(e, fmt.Errorf("%w: block %d body", errHistoryMismatch, header.Number))
+	}
+	if header.WithdrawalsHash != nil && (body.Withdrawals == nil || types.DeriveSha(types.Withdrawals(body.Withdrawals), trie.NewStackTrie(nil)) != *header.WithdrawalsHash) {
+		return nil, h.fail(e, fmt.Errorf("%w: block %d withdrawals", errHistoryMismatch, header.Number))
+	}
+	return body, nil
+}
+
+// Receipts retrieves the receipts of a block, checking them against the given
+// header.
+func (h *History) Receipts(header *types.Header) (types.Receipts, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	e, err := h.open(header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := e.era.GetReceiptsByNumber(header.Number.Uint64())
+	if err != nil {
+		return nil, h.fail(e, err)
+	}
+	if types.DeriveSha(receipts, trie.NewStackTrie(nil)) != header.ReceiptHash {
+		return nil, h.fail(e, fmt.Errorf("%w: block %d receipts", errHistoryMismatch, header.Number))
+	}
+	return receipts, nil
+}
+
+// Close releases the opened Era1 files.
+func (h *History) Close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, e := range h.opened {
+		e.close()
+	}
+	h.opened = nil
+	h.closed = true
+}
+
+// open returns the opened epoch containing the given block, switching to it and
+// verifying it if needed.
+//
+// Note, this method expects the history lock to be held. The lock is released
+// while a file is downloaded and verified, so the archive stays responsive.
+func (h *History) open(number uint64) (*eraEpoch, error) {
+	epoch := number / uint64(MaxEra1Size)
+	if epoch >= uint64(len(h.epochs)) || h.epochs[epoch] == nil {
+		return nil, errHistoryUnavailable
+	}
+	e := h.epochs[epoch]
+	for {
+		if e.failed.Load() || h.closed {
+			return nil, errHistoryUnavailable
+		}
+		if i := slices.Index(h.opened, e); i >= 0 {
+			h.opened = append(slices.Delete(h.opened, i, i+1), e)
+			break
+		}
+		// If somebody else is already loading the epoch, wait for them
+		if e.loading != nil {
+			done := e.loading
+			h.lock.Unlock()
+			<-done
+			h.lock.Lock()
+			continue
+		}
+		done := make(chan struct{})
+		e.loading = done
+
+		h.lock.Unlock()
+		f, temp, err := h.load(e, epoch)
+		h.lock.Lock()
+
+		e.loading = nil
+		close(done)
+
+		if err != nil {
+			return nil, h.fail(e, err)
+		}
+		// The epoch might have been rejected or the archive closed in the meantime
+		if e.failed.Load() || h.closed {
+			f.Close()
+			if temp != "" {
+				os.Remove(temp)
+			}
+			return nil, errHistoryUnavailable
+		}
+		if len(h.opened) >= maxOpenEras {
+			h.opened[0].close()
+			h.opened = h.opened[1:]
+		}
+		e.era, e.temp = f, temp
+		h.opened = append(h.opened, e)
+		break
+	}
+	if number >= e.era.Start()+e.era.Count() {
+		return nil, errHistoryUnavailable
+	}
+	return e, nil
+}
+
+// load opens an Era1 file, downloading it first from remote archives, and
+// verifies its accumulator. The temporary download location is returned along
+// with the file, and everything is cleaned up on failure.
+//
+// Note, this method is called without the history lock held.
+func (h *History) load(e *eraEpoch, epoch uint64) (f *Era, temp string, err error) {
+	path := filepath.Join(h.location, e.name)
+	if h.remote {
+		log.Info("Downloading era1 file", "file", e.name)
+		if path, err = h.download(e.name); err != nil {
+			return nil, "", err
+		}
+		temp = path
+	}
+	defer func() {
+		if err != nil {
+			if f != nil {
+				f.Close()
+			}
+			if temp != "" {
+				os.Remove(temp)
+			}
+			f, temp = nil, ""
+		}
+	}()
+	if f, err = Open(path); err != nil {
+		return nil, temp, err
+	}
+	if f.Start() != epoch*uint64(MaxEra1Size) {
+		return f, temp, fmt.Errorf("era1 file %s starts at block %d", e.name, f.Start())
+	}
+	// Local files only need to be verified once, downloads every time.
+	if e.count.Load() != 0 && !h.remote {
+		return f, temp, nil
+	}
+	root, err := eraAccumulator(f)
+	if err != nil {
+		return f, temp, fmt.Errorf("era1 file %s: %w", e.name, err)
+	}
+	if want, err := f.Accumulator(); err != nil || root != want || root.Hex()[2:10] != e.root {
+		return f, temp, fmt.Errorf("era1 file %s: accumulator mismatch", e.name)
+	}
+	e.count.Store(f.Count())
+	log.Debug("Opened era1 file", "file", e.name, "start", f.Start(), "count", f.Count())
+	return f, temp, nil
+}
+
+// download retrieves a remote Era1 file into a temporary file.
+func (h *History) download(name string) (string, error) {
+	resp, err := h.client.Get(h.location + name)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", name, resp.Status)
+	}
+	f, err := os.CreateTemp("", "geth-*.era1")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Reject marks the epoch containing a block unusable, after its data was found
+// to be invalid by the caller.
+func (h *History) Reject(number uint64, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if epoch := number / uint64(MaxEra1Size); epoch < uint64(len(h.epochs)) && h.epochs[epoch] != nil {
+		h.fail(h.epochs[epoch], err)
+	}
+}
+
+// fail marks an epoch unusable, so its blocks are retrieved elsewhere.
+//
+// Note, this method expects the history lock to be held.
+func (h *History) fail(e *eraEpoch, err error) error {
+	log.Warn("Skipping era1 file", "file", e.name, "err", err)
+	e.failed.Store(true)
+	if i := slices.Index(h.opened, e); i >= 0 {
+		e.close()
+		h.opened = slices.Delete(h.opened, i, i+1)
+	}
+	return err
+}
+
+func (e *eraEpoch) close() {
+	if e.era != nil {
+		e.era.Close()
+		e.era = nil
+	}
+	if e.temp != "" {
+		os.Remove(e.temp)
+		e.temp = ""
+	}
+}
+
+// eraAccumulator recomputes the accumulator root of an Era1 file from the
+// header hashes and total difficulties it contains.
+func eraAccumulator(e *Era) (common.Hash, error) {
+	td, err := e.InitialTD()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	it, err := NewRawIterator(e)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		hashes = make([]common.Hash, 0, e.Count())
+		tds    = make([]*big.Int, 0, e.Count())
+	)
+	for it.Next() {
+		if it.Error() != nil {
+			return common.Hash{}, it.Error()
+		}
+		header, err := io.ReadAll(it.Header)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		var h types.Header
+		if err := rlp.DecodeBytes(header, &h); err != nil {
+			return common.Hash{}, err
+		}
+		hashes = append(hashes, h.Hash())
+		tds = append(tds, new(big.Int).Set(td.Add(td, h.Difficulty)))
+	}
+	if it.Error() != nil {
+		return common.Hash{}, it.Error()
+	}
+	return ComputeAccumulator(hashes, tds)
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -236,8 +237,16 @@ func (api *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RP
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (api *TxPoolAPI) Status() map[string]hexutil.Uint {
+// Status returns the number of pending and queued transaction in the pool. If a
+// transaction hash is given, the recorded lifecycle of that transaction is
+// returned instead, or nil if the pool hasn't seen it recently.
+func (api *TxPoolAPI) Status(hash *common.Hash) interface{} {
+	if hash != nil {
+		if lifecycle := api.b.TxPoolLifecycle(*hash); lifecycle != nil {
+			return NewRPCTxLifecycle(lifecycle)
+		}
+		return nil
+	}
 	pending, queue := api.b.Stats()
 	return map[string]hexutil.Uint{
 		"pending": hexutil.Uint(pending),
@@ -1438,6 +1447,64 @@ func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
 	return fee
 }
 
+// RPCTxLifecycleEvent represents a step in the lifecycle of a pooled transaction
+// that will serialize to the RPC representation.
+type RPCTxLifecycleEvent struct {
+	Hash        common.Hash     `json:"hash"`
+	From        common.Address  `json:"from"`
+	Stage       string          `json:"stage"`
+	Time        hexutil.Uint64  `json:"time"`
+	Reason      string          `json:"reason,omitempty"`
+	ReplacedBy  *common.Hash    `json:"replacedBy,omitempty"`
+	BlockHash   *common.Hash    `json:"blockHash,omitempty"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+}
+
+// NewRPCTxLifecycleEvent returns the RPC representation of a transaction lifecycle
+// event. The time is given in milliseconds since the unix epoch.
+func NewRPCTxLifecycleEvent(ev *txpool.TxLifecycleEvent) *RPCTxLifecycleEvent {
+	result := &RPCTxLifecycleEvent{
+		Hash:   ev.Hash,
+		From:   ev.From,
+		Stage:  string(ev.Stage),
+		Time:   hexutil.Uint64(ev.Time.UnixMilli()),
+		Reason: string(ev.Reason),
+	}
+	switch ev.Stage {
+	case txpool.TxStageReplaced:
+		replacedBy := ev.ReplacedBy
+		result.ReplacedBy = &replacedBy
+	case txpool.TxStageIncluded:
+		blockHash, blockNumber := ev.BlockHash, hexutil.Uint64(ev.BlockNumber)
+		result.BlockHash, result.BlockNumber = &blockHash, &blockNumber
+	}
+	return result
+}
+
+// RPCTxLifecycle represents the recorded lifecycle of a pooled transaction that
+// will serialize to the RPC representation.
+type RPCTxLifecycle struct {
+	Hash   common.Hash            `json:"hash"`
+	From   common.Address         `json:"from"`
+	Status string                 `json:"status"`
+	Events []*RPCTxLifecycleEvent `json:"events"`
+}
+
+// NewRPCTxLifecycle returns the RPC representation of a transaction lifecycle,
+// its status being the last recorded stage.
+func NewRPCTxLifecycle(lifecycle *txpool.TxLifecycle) *RPCTxLifecycle {
+	result := &RPCTxLifecycle{
+		Hash:   lifecycle.Hash,
+		From:   lifecycle.From,
+		Status: string(lifecycle.Last().Stage),
+		Events: make([]*RPCTxLifecycleEvent, len(lifecycle.Events)),
+	}
+	for i := range lifecycle.Events {
+		result.Events[i] = NewRPCTxLifecycleEvent(&lifecycle.Events[i])
+	}
+	return result
+}
+
 // NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
 func NewRPCPendingTransaction(tx *types.Transaction, current *types.Header, config *params.ChainConfig) *RPCTransaction {
 	var (
@@ -1653,7 +1720,7 @@ func (api *TransactionAPI) GetTransactionByHash(ctx context.Context, hash common
 		if err == nil {
 			return nil, nil
 		}
-		return nil, NewTxIndexingError()
+		return nil, txLookupError(err)
 	}
 	header, err := api.b.HeaderByHash(ctx, blockHash)
 	if err != nil {
@@ -1673,7 +1740,7 @@ func (api *TransactionAPI) GetRawTransactionByHash(ctx context.Context, hash com
 		if err == nil {
 			return nil, nil
 		}
-		return nil, NewTxIndexingError()
+		return nil, txLookupError(err)
 	}
 	return tx.MarshalBinary()
 }
@@ -1682,7 +1749,7 @@ func (api *TransactionAPI) GetRawTransactionByHash(ctx context.Context, hash com
 func (api *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	found, tx, blockHash, blockNumber, index, err := api.b.GetTransaction(ctx, hash)
 	if err != nil {
-		return nil, NewTxIndexingError() // transaction is not fully indexed
+		return nil, txLookupError(err) // transaction is not fully indexed or expired
 	}
 	if !found {
 		return nil, nil // transaction is not existent or reachable
@@ -1855,6 +1922,46 @@ func (api *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil
 	return SubmitTransaction(ctx, api.b, tx)
 }
 
+// SendBundleArgs represents the arguments to submit a transaction bundle.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      *hexutil.Uint64 `json:"minTimestamp"`
+	MaxTimestamp      *hexutil.Uint64 `json:"maxTimestamp"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// SendBundle submits an ordered list of signed transactions to be included
+// atomically into the given block: either all of them are included in order,
+// or none of them. Bundles are kept private and never gossiped to the network.
+func (api *TransactionAPI) SendBundle(ctx context.Context, args SendBundleArgs) (common.Hash, error) {
+	bundle := &txpool.Bundle{
+		Txs:               make([]*types.Transaction, len(args.Txs)),
+		BlockNumber:       uint64(args.BlockNumber),
+		RevertingTxHashes: args.RevertingTxHashes,
+	}
+	for i, input := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return common.Hash{}, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if err := checkTxFee(tx.GasPrice(), tx.Gas(), api.b.RPCTxFeeCap()); err != nil {
+			return common.Hash{}, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		bundle.Txs[i] = tx
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = uint64(*args.MinTimestamp)
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*args.MaxTimestamp)
+	}
+	if err := api.b.SendBundle(ctx, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -2093,7 +2200,7 @@ func (api *DebugAPI) GetRawTransaction(ctx context.Context, hash common.Hash) (h
 		if err == nil {
 			return nil, nil
 		}
-		return nil, NewTxIndexingError()
+		return nil, txLookupError(err)
 	}
 	return tx.MarshalBinary()
 }
@@ -17,10 +17,12 @@
 package ethapi
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
@@ -56,6 +58,16 @@ func newRevertError(revert []byte) *revertError {
 	}
 }
 
+// txLookupError converts a failed transaction lookup into an API error. Lookups
+// into expired chain history are reported as such, any other failure means the
+// transaction indexing is still in progress.
+func txLookupError(err error) error {
+	if errors.Is(err, core.ErrHistoryPruned) {
+		return err
+	}
+	return NewTxIndexingError()
+}
+
 // TxIndexingError is an API error that indicates the transaction indexing is not
 // fully finished yet with JSON error code and a binary data blob.
 type TxIndexingError struct{}
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -48,6 +49,7 @@ type Config struct {
 	GasCeil             uint64         // Target gas ceiling for mined blocks.
 	GasPrice            *big.Int       // Minimum gas price for mining a transaction
 	Recommit            time.Duration  // The time interval for miner to re-create mining work.
+	Ordering            string         `toml:",omitempty"` // Transaction ordering strategy (greedy, fifo or profit)
 }
 
 // DefaultConfig contains default settings for miner.
@@ -60,6 +62,8 @@ var DefaultConfig = Config{
 	// for payload generation. It should be enough for Geth to
 	// run 3 rounds.
 	Recommit: 2 * time.Second,
+
+	Ordering: OrderingGreedy,
 }
 
 // Miner is the main object which takes care of submitting new work to consensus
@@ -71,13 +75,14 @@ type Miner struct {
 	engine      consensus.Engine
 	txpool      *txpool.TxPool
 	chain       *core.BlockChain
+	ordering    orderingStrategy // Strategy ordering the transactions of the sealing blocks
 	pending     *pending
 	pendingMu   sync.Mutex // Lock protects the pending block
 }
 
 // New creates a new miner with provided config.
 func New(eth Backend, config Config, engine consensus.Engine) *Miner {
-	return &Miner{
+	miner := &Miner{
 		config:      &config,
 		chainConfig: eth.BlockChain().Config(),
 		engine:      engine,
@@ -85,6 +90,13 @@ func New(eth Backend, config Config, engine consensus.Engine) *Miner {
 		chain:       eth.BlockChain(),
 		pending:     &pending{},
 	}
+	ordering, err := newOrderingStrategy(miner, config.Ordering)
+	if err != nil {
+		log.Warn("Falling back to greedy transaction ordering", "err", err)
+		ordering = greedyOrdering{}
+	}
+	miner.ordering = ordering
+	return miner
 }
 
 // Pending returns the currently pending block and associated receipts, logs
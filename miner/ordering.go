@@ -33,24 +33,41 @@ type txWithMinerFee struct {
 	fees *uint256.Int
 }
 
-// newTxWithMinerFee creates a wrapped transaction, calculating the effective
-// miner gasTipCap if a base fee is provided.
-// Returns error in case of a negative effective miner gasTipCap.
-func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, baseFee *uint256.Int) (*txWithMinerFee, error) {
-	tip := new(uint256.Int).Set(tx.GasTipCap)
-	if baseFee != nil {
-		if tx.GasFeeCap.Cmp(baseFee) < 0 {
-			return nil, types.ErrGasFeeCapTooLow
-		}
-		tip = new(uint256.Int).Sub(tx.GasFeeCap, baseFee)
-		if tip.Gt(tx.GasTipCap) {
-			tip = tx.GasTipCap
+// txPricer computes the price a transaction is ordered by among the next ones
+// of the other accounts, higher priced ones being committed first and equally
+// priced ones in their arrival order. An error signals the transaction is not
+// includable, discarding the remaining ones of its account.
+type txPricer func(tx *txpool.LazyTransaction) (*uint256.Int, error)
+
+// tipPricer creates a transaction pricer returning the effective miner gasTipCap
+// if a base fee is provided, or the gas price otherwise.
+func tipPricer(baseFee *uint256.Int) txPricer {
+	return func(tx *txpool.LazyTransaction) (*uint256.Int, error) {
+		tip := new(uint256.Int).Set(tx.GasTipCap)
+		if baseFee != nil {
+			if tx.GasFeeCap.Cmp(baseFee) < 0 {
+				return nil, types.ErrGasFeeCapTooLow
+			}
+			tip = new(uint256.Int).Sub(tx.GasFeeCap, baseFee)
+			if tip.Gt(tx.GasTipCap) {
+				tip = tx.GasTipCap
+			}
 		}
+		return tip, nil
+	}
+}
+
+// newTxWithMinerFee creates a wrapped transaction, calculating its price with
+// the given pricer. Returns error in case the transaction is not includable.
+func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, price txPricer) (*txWithMinerFee, error) {
+	fees, err := price(tx)
+	if err != nil {
+		return nil, err
 	}
 	return &txWithMinerFee{
 		tx:   tx,
 		from: from,
-		fees: tip,
+		fees: fees,
 	}, nil
 }
 
@@ -87,10 +104,10 @@ func (s *txByPriceAndTime) Pop() interface{} {
 // transactions in a profit-maximizing sorted order, while supporting removing
 // entire batches of transactions for non-executable accounts.
 type transactionsByPriceAndNonce struct {
-	txs     map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
-	heads   txByPriceAndTime                             // Next transaction for each unique account (price heap)
-	signer  types.Signer                                 // Signer for the set of transactions
-	baseFee *uint256.Int                                 // Current base fee
+	txs    map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
+	heads  txByPriceAndTime                             // Next transaction for each unique account (price heap)
+	signer types.Signer                                 // Signer for the set of transactions
+	price  txPricer                                     // Pricer of the next transactions of the accounts
 }
 
 // newTransactionsByPriceAndNonce creates a transaction set that can retrieve
@@ -104,10 +121,20 @@ func newTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address]
 	if baseFee != nil {
 		baseFeeUint = uint256.MustFromBig(baseFee)
 	}
+	return newTransactionsByPricer(signer, txs, tipPricer(baseFeeUint))
+}
+
+// newTransactionsByPricer creates a transaction set that can retrieve sorted
+// transactions by the price computed by the given pricer, in a nonce-honouring
+// way.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func newTransactionsByPricer(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, price txPricer) *transactionsByPriceAndNonce {
 	// Initialize a price and received time based heap with the head transactions
 	heads := make(txByPriceAndTime, 0, len(txs))
 	for from, accTxs := range txs {
-		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFeeUint)
+		wrapped, err := newTxWithMinerFee(accTxs[0], from, price)
 		if err != nil {
 			delete(txs, from)
 			continue
@@ -119,10 +146,10 @@ func newTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address]
 
 	// Assemble and return the transaction set
 	return &transactionsByPriceAndNonce{
-		txs:     txs,
-		heads:   heads,
-		signer:  signer,
-		baseFee: baseFeeUint,
+		txs:    txs,
+		heads:  heads,
+		signer: signer,
+		price:  price,
 	}
 }
 
@@ -138,7 +165,7 @@ func (t *transactionsByPriceAndNonce) Peek() (*txpool.LazyTransaction, *uint256.
 func (t *transactionsByPriceAndNonce) Shift() {
 	acc := t.heads[0].from
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee); err == nil {
+		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.price); err == nil {
 			t.heads[0], t.txs[acc] = wrapped, txs[1:]
 			heap.Fix(&t.heads, 0)
 			return
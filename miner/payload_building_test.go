@@ -17,8 +17,11 @@
 package miner
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"reflect"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,12 +34,14 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 )
 
 var (
@@ -53,6 +58,9 @@ var (
 	testUserKey, _  = crypto.GenerateKey()
 	testUserAddress = crypto.PubkeyToAddress(testUserKey.PublicKey)
 
+	testTraderKey, _  = crypto.GenerateKey()
+	testTraderAddress = crypto.PubkeyToAddress(testTraderKey.PublicKey)
+
 	// Test transactions
 	pendingTxs []*types.Transaction
 	newTxs     []*types.Transaction
@@ -108,7 +116,10 @@ type testWorkerBackend struct {
 func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, n int) *testWorkerBackend {
 	var gspec = &core.Genesis{
 		Config: chainConfig,
-		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+		Alloc: types.GenesisAlloc{
+			testBankAddress:   {Balance: testBankFunds},
+			testTraderAddress: {Balance: testBankFunds},
+		},
 	}
 	switch e := engine.(type) {
 	case *clique.Clique:
@@ -126,7 +137,8 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 		t.Fatalf("core.NewBlockChain failed: %v", err)
 	}
 	pool := legacypool.New(testTxPoolConfig, chain)
-	txpool, _ := txpool.New(testTxPoolConfig.PriceLimit, chain, []txpool.SubPool{pool})
+	bundles := bundlepool.New(bundlepool.DefaultConfig, chain)
+	txpool, _ := txpool.New(testTxPoolConfig.PriceLimit, chain, []txpool.SubPool{pool, bundles})
 
 	return &testWorkerBackend{
 		db:      db,
@@ -197,6 +209,235 @@ func TestBuildPayload(t *testing.T) {
 	}
 }
 
+func TestBuildPayloadWithBundles(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), db, 0)
+
+	transfer := func(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+		return newTestTransfer(key, nonce, to, value, big.NewInt(params.InitialBaseFee))
+	}
+	var (
+		timestamp = uint64(time.Now().Unix())
+
+		// Bundle failing on its second transaction, none of it may be included
+		failing = &txpool.Bundle{
+			Txs: []*types.Transaction{
+				transfer(testBankKey, 0, testUserAddress, big.NewInt(7)),
+				transfer(testUserKey, 5, testBankAddress, big.NewInt(1)),
+			},
+			BlockNumber: 1,
+		}
+		// Bundle whose second transaction depends on the first one's funding
+		funding = &txpool.Bundle{
+			Txs: []*types.Transaction{
+				transfer(testBankKey, 0, testUserAddress, big.NewInt(params.Ether/10)),
+				transfer(testUserKey, 0, testBankAddress, big.NewInt(1)),
+			},
+			BlockNumber: 1,
+		}
+		// Bundles valid on top of the previous ones, but not targeting the block
+		early = &txpool.Bundle{
+			Txs:          []*types.Transaction{transfer(testBankKey, 1, testUserAddress, big.NewInt(1))},
+			BlockNumber:  1,
+			MinTimestamp: timestamp + 100,
+		}
+		later = &txpool.Bundle{
+			Txs:         []*types.Transaction{transfer(testBankKey, 1, testUserAddress, big.NewInt(2))},
+			BlockNumber: 2,
+		}
+	)
+	for _, bundle := range []*txpool.Bundle{failing, funding, early, later} {
+		if err := b.txPool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle %x: %v", bundle.Hash(), err)
+		}
+	}
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:    b.chain.CurrentBlock().Hash(),
+		Timestamp: timestamp,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build payload %v", err)
+	}
+	// The pooled transaction of the bank is superseded by the bundle with the
+	// same nonce, so only the bundled transactions are expected.
+	full := payload.ResolveFull().ExecutionPayload
+	if len(full.Transactions) != len(funding.Txs) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(full.Transactions), len(funding.Txs))
+	}
+	for i, enc := range full.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("failed to decode transaction %d: %v", i, err)
+		}
+		if tx.Hash() != funding.Txs[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, tx.Hash(), funding.Txs[i].Hash())
+		}
+	}
+}
+
+// newTestTransfer creates a signed value transfer with the given gas price.
+func newTestTransfer(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int, gasPrice *big.Int) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(params.TestChainConfig), &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      params.TxGas,
+		GasPrice: gasPrice,
+	})
+}
+
+// testPayloadOrdering builds a payload with the given transaction ordering
+// strategy out of the given pooled transactions and bundles, and checks that
+// the expected transactions are included in the expected order.
+func testPayloadOrdering(t *testing.T, ordering string, txs []*types.Transaction, bundles []*txpool.Bundle, want []*types.Transaction) {
+	t.Helper()
+
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		engine    = ethash.NewFaker()
+		recipient = common.HexToAddress("0xdeadbeef")
+	)
+	b := newTestWorkerBackend(t, params.TestChainConfig, engine, db, 0)
+
+	config := testConfig
+	config.Ordering = ordering
+	w := New(b, config, engine)
+
+	// Assign distinct arrival times in the given order
+	start := time.Now()
+	for i, tx := range txs {
+		tx.SetTime(start.Add(time.Duration(i) * time.Second))
+	}
+	for i, err := range b.txPool.Add(txs, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	for i, bundle := range bundles {
+		if err := b.txPool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle %d: %v", i, err)
+		}
+	}
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()),
+		FeeRecipient: recipient,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build payload %v", err)
+	}
+	full := payload.ResolveFull().ExecutionPayload
+	if len(full.Transactions) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(full.Transactions), len(want))
+	}
+	for i, enc := range full.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("failed to decode transaction %d: %v", i, err)
+		}
+		if tx.Hash() != want[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, tx.Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that the greedy ordering includes the transactions paying the highest
+// tip first, regardless of their arrival order.
+func TestBuildPayloadGreedyOrdering(t *testing.T) {
+	var (
+		cheap  = newTestTransfer(testBankKey, 0, testUserAddress, big.NewInt(1), big.NewInt(params.InitialBaseFee))
+		pricey = newTestTransfer(testTraderKey, 0, testUserAddress, big.NewInt(1), big.NewInt(2*params.InitialBaseFee))
+	)
+	testPayloadOrdering(t, OrderingGreedy, []*types.Transaction{cheap, pricey}, nil, []*types.Transaction{pricey, cheap})
+}
+
+// Tests that the FIFO ordering includes the transactions in their arrival
+// order, regardless of their tip.
+func TestBuildPayloadFIFOOrdering(t *testing.T) {
+	var (
+		cheap  = newTestTransfer(testBankKey, 0, testUserAddress, big.NewInt(1), big.NewInt(params.InitialBaseFee))
+		pricey = newTestTransfer(testTraderKey, 0, testUserAddress, big.NewInt(1), big.NewInt(2*params.InitialBaseFee))
+	)
+	testPayloadOrdering(t, OrderingFIFO, []*types.Transaction{cheap, pricey}, nil, []*types.Transaction{cheap, pricey})
+}
+
+// Tests that the profit ordering includes the transactions yielding the highest
+// simulated revenue first, accounting for direct payments to the fee recipient
+// which the tip does not reflect.
+func TestBuildPayloadProfitOrdering(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		pricey    = newTestTransfer(testTraderKey, 0, testUserAddress, big.NewInt(1), big.NewInt(2*params.InitialBaseFee))
+		briber    = newTestTransfer(testBankKey, 0, recipient, big.NewInt(params.GWei*params.GWei/1000), big.NewInt(params.InitialBaseFee))
+	)
+	testPayloadOrdering(t, OrderingProfit, []*types.Transaction{pricey, briber}, nil, []*types.Transaction{briber, pricey})
+}
+
+// Tests that the profit ordering includes the bundles yielding the highest
+// simulated revenue first, while the other orderings keep their arrival order.
+func TestBuildPayloadProfitBundleOrdering(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		plain     = &txpool.Bundle{
+			Txs:         []*types.Transaction{newTestTransfer(testTraderKey, 0, testUserAddress, big.NewInt(1), big.NewInt(params.InitialBaseFee))},
+			BlockNumber: 1,
+		}
+		bribing = &txpool.Bundle{
+			Txs: []*types.Transaction{
+				newTestTransfer(testBankKey, 0, testUserAddress, big.NewInt(1), big.NewInt(params.InitialBaseFee)),
+				newTestTransfer(testBankKey, 1, recipient, big.NewInt(params.GWei*params.GWei/1000), big.NewInt(params.InitialBaseFee)),
+			},
+			BlockNumber: 1,
+		}
+	)
+	bundles := []*txpool.Bundle{plain, bribing}
+	testPayloadOrdering(t, OrderingGreedy, nil, bundles, append(slices.Clone(plain.Txs), bribing.Txs...))
+	testPayloadOrdering(t, OrderingFIFO, nil, bundles, append(slices.Clone(plain.Txs), bribing.Txs...))
+	testPayloadOrdering(t, OrderingProfit, nil, bundles, append(slices.Clone(bribing.Txs), plain.Txs...))
+}
+
+// Tests that the profit ordering reuses the simulated transaction prices across
+// the rebuilds of a payload and stops simulating once the building is interrupted.
+func TestProfitPricerCache(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		engine = ethash.NewFaker()
+	)
+	b := newTestWorkerBackend(t, params.TestChainConfig, engine, db, 0)
+	config := testConfig
+	config.Ordering = OrderingProfit
+	w := New(b, config, engine)
+
+	env, err := w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	tx := newTestTransfer(testBankKey, 0, testUserAddress, big.NewInt(1), big.NewInt(2*params.InitialBaseFee))
+	ltx := &txpool.LazyTransaction{Hash: tx.Hash(), Tx: tx, GasFeeCap: uint256.MustFromBig(tx.GasFeeCap()), GasTipCap: uint256.MustFromBig(tx.GasTipCap())}
+
+	// The first pricing simulates the transaction and caches its price
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	env.prices, env.interrupt = make(map[common.Hash]*uint256.Int), new(atomic.Int32)
+	price, err := w.ordering.pricer(env)(ltx)
+	if err != nil || price.IsZero() {
+		t.Fatalf("failed to price transaction: %v, %v", price, err)
+	}
+	if cached := env.prices[tx.Hash()]; cached == nil || !cached.Eq(price) {
+		t.Fatalf("price not cached: have %v, want %v", cached, price)
+	}
+	// Further pricings are served from the cache, even once interrupted
+	env.prices[tx.Hash()] = uint256.NewInt(1)
+	env.interrupt.Store(commitInterruptTimeout)
+	if price, _ := w.ordering.pricer(env)(ltx); !price.Eq(uint256.NewInt(1)) {
+		t.Fatalf("cached price not used: have %v, want 1", price)
+	}
+	// Uncached transactions are not simulated once interrupted
+	delete(env.prices, tx.Hash())
+	if price, _ := w.ordering.pricer(env)(ltx); !price.IsZero() {
+		t.Fatalf("transaction simulated after interrupt: price %v", price)
+	}
+}
+
 func TestPayloadId(t *testing.T) {
 	t.Parallel()
 	ids := make(map[string]int)
@@ -0,0 +1,225 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// Names of the transaction ordering strategies selectable in the config.
+const (
+	OrderingGreedy = "greedy" // Highest effective miner tip first
+	OrderingFIFO   = "fifo"   // Earliest arrival first
+	OrderingProfit = "profit" // Highest simulated revenue per gas first
+)
+
+// Orderings is the list of the selectable transaction ordering strategies.
+var Orderings = []string{OrderingGreedy, OrderingFIFO, OrderingProfit}
+
+// orderingStrategy decides the order in which the pending transactions and the
+// bundles are committed into a sealing block.
+type orderingStrategy interface {
+	// pricer returns the pricer ordering the pending transactions of the given
+	// sealing block. It is invoked before the transactions of any account are
+	// committed and its returned pricer on each newly exposed account head.
+	pricer(env *environment) txPricer
+
+	// bundles sorts the bundles targeting the given sealing block in the order
+	// they are to be committed.
+	bundles(env *environment, bundles []*txpool.Bundle) []*txpool.Bundle
+}
+
+// newOrderingStrategy creates the transaction ordering strategy with the given
+// name, defaulting to the greedy one if none is given.
+func newOrderingStrategy(miner *Miner, name string) (orderingStrategy, error) {
+	switch name {
+	case "", OrderingGreedy:
+		return greedyOrdering{}, nil
+	case OrderingFIFO:
+		return fifoOrdering{}, nil
+	case OrderingProfit:
+		return &profitOrdering{miner: miner}, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q", name)
+	}
+}
+
+// greedyOrdering commits the transactions paying the highest effective miner
+// tip first, and the bundles in their arrival order.
+type greedyOrdering struct{}
+
+func (greedyOrdering) pricer(env *environment) txPricer {
+	return tipPricer(headerBaseFee(env.header))
+}
+
+func (greedyOrdering) bundles(env *environment, bundles []*txpool.Bundle) []*txpool.Bundle {
+	return bundles
+}
+
+// fifoOrdering commits the transactions and the bundles in their arrival order,
+// regardless of their price. It is meant for private chains where the fees are
+// not an incentive.
+type fifoOrdering struct{}
+
+func (fifoOrdering) pricer(env *environment) txPricer {
+	tips := tipPricer(headerBaseFee(env.header))
+	return func(tx *txpool.LazyTransaction) (*uint256.Int, error) {
+		// Transactions below the base fee are still not includable
+		if _, err := tips(tx); err != nil {
+			return nil, err
+		}
+		return new(uint256.Int), nil
+	}
+}
+
+func (fifoOrdering) bundles(env *environment, bundles []*txpool.Bundle) []*txpool.Bundle {
+	return bundles
+}
+
+// profitOrdering commits the transactions and the bundles yielding the highest
+// revenue per gas first. The revenue is simulated against the sealing block's
+// state, accounting for the gas actually used and any direct payments to the
+// fee recipient, instead of estimating it from the gas limit and the tip.
+//
+// The simulated transaction prices are reused by the rebuilds of a payload, and
+// the simulations are skipped once the block building is interrupted.
+type profitOrdering struct {
+	miner *Miner
+}
+
+func (o *profitOrdering) pricer(env *environment) txPricer {
+	tips := tipPricer(headerBaseFee(env.header))
+	return func(ltx *txpool.LazyTransaction) (*uint256.Int, error) {
+		if _, err := tips(ltx); err != nil {
+			return nil, err
+		}
+		if price, ok := env.prices[ltx.Hash]; ok {
+			return price.Clone(), nil
+		}
+		// The building is to be aborted, order the rest last without simulating
+		if env.interrupted() {
+			return new(uint256.Int), nil
+		}
+		tx := ltx.Resolve()
+		if tx == nil {
+			return nil, errors.New("transaction evicted")
+		}
+		// Transactions failing the simulation are ordered last, leaving it up
+		// to the execution to discard them.
+		price := new(uint256.Int)
+		if revenue, gas, err := o.miner.simulateTransaction(env, tx); err == nil && gas > 0 {
+			price = revenue.Div(revenue, uint256.NewInt(gas))
+		}
+		if env.prices != nil {
+			env.prices[ltx.Hash] = price.Clone()
+		}
+		return price, nil
+	}
+}
+
+func (o *profitOrdering) bundles(env *environment, bundles []*txpool.Bundle) []*txpool.Bundle {
+	prices := make(map[*txpool.Bundle]*uint256.Int, len(bundles))
+	for _, bundle := range bundles {
+		price := new(uint256.Int)
+		if env.interrupted() {
+			prices[bundle] = price
+			continue
+		}
+		if revenue, gas, err := o.miner.simulateBundle(env, bundle); err == nil && gas > 0 {
+			price = revenue.Div(revenue, uint256.NewInt(gas))
+		}
+		prices[bundle] = price
+	}
+	sorted := slices.Clone(bundles)
+	slices.SortStableFunc(sorted, func(a, b *txpool.Bundle) int {
+		return prices[b].Cmp(prices[a])
+	})
+	return sorted
+}
+
+// headerBaseFee converts the base fee of a header to uint256 format, or nil if
+// the header predates London.
+func headerBaseFee(header *types.Header) *uint256.Int {
+	if header.BaseFee == nil {
+		return nil
+	}
+	return uint256.MustFromBig(header.BaseFee)
+}
+
+// simulateTransaction executes a transaction on top of the state of a sealing
+// block and returns the revenue of the fee recipient along with the gas used,
+// discarding any changes.
+func (miner *Miner) simulateTransaction(env *environment, tx *types.Transaction) (*uint256.Int, uint64, error) {
+	msg, err := core.TransactionToMessage(tx, env.signer, env.header.BaseFee)
+	if err != nil {
+		return nil, 0, err
+	}
+	snap := env.state.Snapshot()
+	defer env.state.RevertToSnapshot(snap)
+
+	var (
+		before = env.state.GetBalance(env.coinbase).Clone()
+		gp     = new(core.GasPool).AddGas(env.gasPool.Gas())
+		vmenv  = vm.NewEVM(core.NewEVMBlockContext(env.header, miner.chain, &env.coinbase), core.NewEVMTxContext(msg), env.state, miner.chainConfig, vm.Config{})
+	)
+	env.state.SetTxContext(tx.Hash(), env.tcount)
+	result, err := core.ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return revenue(before, env.state.GetBalance(env.coinbase)), result.UsedGas, nil
+}
+
+// simulateBundle executes the transactions of a bundle on top of the state of
+// a sealing block and returns the revenue of the fee recipient along with the
+// gas used, discarding any changes. An error is returned if the bundle is not
+// includable in full.
+func (miner *Miner) simulateBundle(env *environment, bundle *txpool.Bundle) (*uint256.Int, uint64, error) {
+	var (
+		state  = env.state.Copy()
+		before = state.GetBalance(env.coinbase).Clone()
+		gp     = new(core.GasPool).AddGas(env.gasPool.Gas())
+		used   uint64
+	)
+	for i, tx := range bundle.Txs {
+		state.SetTxContext(tx.Hash(), env.tcount+i)
+		receipt, err := core.ApplyTransaction(miner.chainConfig, miner.chain, &env.coinbase, gp, state, env.header, tx, &used, vm.Config{})
+		if err != nil {
+			return nil, 0, err
+		}
+		if receipt.Status == types.ReceiptStatusFailed && !bundle.MayRevert(tx.Hash()) {
+			return nil, 0, fmt.Errorf("transaction %x reverted", tx.Hash())
+		}
+	}
+	return revenue(before, state.GetBalance(env.coinbase)), used, nil
+}
+
+// revenue returns the increase of a balance, or zero if it decreased.
+func revenue(before, after *uint256.Int) *uint256.Int {
+	if after.Lt(before) {
+		return new(uint256.Int)
+	}
+	return new(uint256.Int).Sub(after, before)
+}
@@ -56,6 +56,14 @@ type environment struct {
 	receipts []*types.Receipt
 	sidecars []*types.BlobTxSidecar
 	blobs    int
+
+	interrupt *atomic.Int32                // Interruption signal of the block building, nil if not interruptible
+	prices    map[common.Hash]*uint256.Int // Simulated transaction prices shared by the rebuilds of a payload
+}
+
+// interrupted reports whether the building of the sealing block was interrupted.
+func (env *environment) interrupted() bool {
+	return env.interrupt != nil && env.interrupt.Load() != commitInterruptNone
 }
 
 const (
@@ -85,6 +93,8 @@ type generateParams struct {
 	withdrawals types.Withdrawals // List of withdrawals to include in block (shanghai field)
 	beaconRoot  *common.Hash      // The beacon root (cancun field).
 	noTxs       bool              // Flag whether an empty block without any transaction is expected
+
+	prices map[common.Hash]*uint256.Int // Simulated transaction prices reused across rebuilds, nil if not cached
 }
 
 // generateWork generates a sealing block based on the given parameters.
@@ -100,6 +110,7 @@ func (miner *Miner) generateWork(params *generateParams) *newPayloadResult {
 		})
 		defer timer.Stop()
 
+		work.interrupt, work.prices = interrupt, params.prices
 		err := miner.fillTransactions(interrupt, work)
 		if errors.Is(err, errBlockInterruptedByTimeout) {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(miner.config.Recommit))
@@ -273,6 +284,67 @@ func (miner *Miner) applyTransaction(env *environment, tx *types.Transaction) (*
 	return receipt, err
 }
 
+// commitBundle applies the transactions of a bundle in order, committing them
+// only if all of them succeed. A transaction failing to apply, or reverting
+// without being allowed to by the bundle, discards the entire bundle.
+func (miner *Miner) commitBundle(env *environment, bundle *txpool.Bundle) error {
+	// The state journal is flushed after each transaction, so the state can't
+	// be reverted to a snapshot across them. Work on a copy instead and only
+	// adopt it if the whole bundle succeeds.
+	var (
+		state    = env.state
+		gas      = env.gasPool.Gas()
+		gasUsed  = env.header.GasUsed
+		txs      = len(env.txs)
+		receipts = len(env.receipts)
+		tcount   = env.tcount
+	)
+	env.state = state.Copy()
+
+	var err error
+	for _, tx := range bundle.Txs {
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if err = miner.commitTransaction(env, tx); err != nil {
+			break
+		}
+		if receipt := env.receipts[len(env.receipts)-1]; receipt.Status == types.ReceiptStatusFailed && !bundle.MayRevert(tx.Hash()) {
+			err = fmt.Errorf("transaction %x reverted", tx.Hash())
+			break
+		}
+	}
+	if err != nil {
+		env.state = state
+		env.gasPool.SetGas(gas)
+		env.header.GasUsed = gasUsed
+		env.txs = env.txs[:txs]
+		env.receipts = env.receipts[:receipts]
+		env.tcount = tcount
+		return err
+	}
+	return nil
+}
+
+// commitBundles includes the bundles targeting the sealing block, each one
+// atomically on top of the previously included ones.
+func (miner *Miner) commitBundles(env *environment, interrupt *atomic.Int32) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	bundles := miner.txpool.Bundles(env.header.Number.Uint64(), env.header.Time)
+	for _, bundle := range miner.ordering.bundles(env, bundles) {
+		// Check interruption signal and abort building if it's fired.
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		if err := miner.commitBundle(env, bundle); err != nil {
+			log.Debug("Bundle failed, skipped", "hash", bundle.Hash(), "err", err)
+		}
+	}
+	return nil
+}
+
 func (miner *Miner) commitTransactions(env *environment, plainTxs, blobTxs *transactionsByPriceAndNonce, interrupt *atomic.Int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
@@ -311,7 +383,7 @@ func (miner *Miner) commitTransactions(env *environment, plainTxs, blobTxs *tran
 		case bltx == nil:
 			txs, ltx = plainTxs, pltx
 		default:
-			if ptip.Lt(btip) {
+			if ptip.Lt(btip) || (ptip.Eq(btip) && bltx.Time.Before(pltx.Time)) {
 				txs, ltx = blobTxs, bltx
 			} else {
 				txs, ltx = plainTxs, pltx
@@ -381,6 +453,10 @@ func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment)
 	tip := miner.config.GasPrice
 	miner.confMu.RUnlock()
 
+	// Include the bundles first, they target the block specifically
+	if err := miner.commitBundles(env, interrupt); err != nil {
+		return err
+	}
 	// Retrieve the pending transactions pre-filtered by the 1559/4844 dynamic fees
 	filter := txpool.PendingFilter{
 		MinTip: uint256.MustFromBig(tip),
@@ -413,16 +489,16 @@ func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment)
 	}
 	// Fill the block with all available pending transactions.
 	if len(localPlainTxs) > 0 || len(localBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, localPlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, localBlobTxs, env.header.BaseFee)
+		plainTxs := newTransactionsByPricer(env.signer, localPlainTxs, miner.ordering.pricer(env))
+		blobTxs := newTransactionsByPricer(env.signer, localBlobTxs, miner.ordering.pricer(env))
 
 		if err := miner.commitTransactions(env, plainTxs, blobTxs, interrupt); err != nil {
 			return err
 		}
 	}
 	if len(remotePlainTxs) > 0 || len(remoteBlobTxs) > 0 {
-		plainTxs := newTransactionsByPriceAndNonce(env.signer, remotePlainTxs, env.header.BaseFee)
-		blobTxs := newTransactionsByPriceAndNonce(env.signer, remoteBlobTxs, env.header.BaseFee)
+		plainTxs := newTransactionsByPricer(env.signer, remotePlainTxs, miner.ordering.pricer(env))
+		blobTxs := newTransactionsByPricer(env.signer, remoteBlobTxs, miner.ordering.pricer(env))
 
 		if err := miner.commitTransactions(env, plainTxs, blobTxs, interrupt); err != nil {
 			return err
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -120,6 +121,70 @@ func (api *adminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer bans a remote node, given by its enode URL or node ID, for the given
+// duration (e.g. "1h"). The ban is permanent if no duration is given. Banned nodes
+// are disconnected and neither dialed nor accepted until the ban expires.
+func (api *adminAPI) BanPeer(node string, duration *string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	var d time.Duration
+	if duration != nil {
+		if d, err = time.ParseDuration(*duration); err != nil {
+			return false, fmt.Errorf("invalid ban duration: %v", err)
+		}
+	}
+	if err := server.BanPeer(id, d); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a remote node, given by its enode URL or node ID.
+func (api *adminAPI) UnbanPeer(node string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if err := server.UnbanPeer(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PeerScores retrieves the reputation scores of the recently seen remote nodes,
+// along with the currently banned ones.
+func (api *adminAPI) PeerScores() ([]*p2p.PeerReputation, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeerReputations(), nil
+}
+
+// parseNodeID parses a node given either as an enode URL or a hex node ID.
+func parseNodeID(node string) (enode.ID, error) {
+	if n, err := enode.Parse(enode.ValidSchemes, node); err == nil {
+		return n.ID(), nil
+	}
+	id, err := enode.ParseID(node)
+	if err != nil {
+		return enode.ID{}, fmt.Errorf("invalid node: %v", err)
+	}
+	return id, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -204,6 +204,10 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCLimits configures rate limiting and API key scoping of the HTTP and
+	// WebSocket RPC endpoints. Nothing is limited if nil.
+	RPCLimits *RPCLimitConfig `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
@@ -406,6 +406,13 @@ func (n *Node) startRPC() error {
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
 	}
+	if n.config.RPCLimits != nil {
+		limiter, err := newRPCLimiter(*n.config.RPCLimits)
+		if err != nil {
+			return fmt.Errorf("invalid RPC limits: %w", err)
+		}
+		rpcConfig.limiter = limiter
+	}
 
 	initHttp := func(server *httpServer, port int) error {
 		if err := server.setListenAddr(n.config.HTTPHost, port); err != nil {
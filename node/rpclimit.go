@@ -0,0 +1,369 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rpcLimitClients is the number of clients whose rate limits are tracked.
+	rpcLimitClients = 4096
+
+	// Error codes returned for rejected calls, following EIP-1474.
+	rpcLimitExceededCode    = -32005
+	rpcMethodNotAllowedCode = -32601
+)
+
+var (
+	errRPCKeyMissing = errors.New("missing API key")
+	errRPCKeyInvalid = errors.New("invalid API key")
+
+	rpcLimitRejectedMeter = metrics.NewRegisteredMeter("rpc/limit/rejected", nil)
+	rpcScopeRejectedMeter = metrics.NewRegisteredMeter("rpc/scope/rejected", nil)
+)
+
+// DefaultRPCCosts are the request units charged for expensive calls if no costs
+// are configured.
+var DefaultRPCCosts = map[string]int{
+	"debug_trace*":      20,
+	"eth_getLogs":       10,
+	"eth_getFilterLogs": 10,
+}
+
+// RPCLimitConfig configures rate limiting and API key scoping of the HTTP and
+// WebSocket RPC endpoints.
+//
+// Method patterns used throughout the configuration are either a method name
+// (e.g. "eth_getLogs"), a method name prefix ending in '*' (e.g. "debug_trace*"),
+// or a namespace (e.g. "debug").
+type RPCLimitConfig struct {
+	// Rate is the number of request units per second a single client, identified
+	// by its API key or IP address, may spend. Zero disables the limit.
+	Rate float64 `toml:",omitempty"`
+
+	// Burst is the number of request units a client may spend at once. It defaults
+	// to the rate, or the highest call cost if that is larger. Calls costing more
+	// than the burst are always rejected.
+	Burst int `toml:",omitempty"`
+
+	// Limits are additional limits per client applying to the calls matching a
+	// method pattern, on top of the client-wide limit.
+	Limits map[string]RPCRateLimit `toml:",omitempty"`
+
+	// Costs are the request units charged for the calls matching a method pattern.
+	// Calls not matching any pattern cost one unit. DefaultRPCCosts is used if nil.
+	Costs map[string]int `toml:",omitempty"`
+
+	// APIKeys are the keys accepted as bearer tokens. If any keys are configured,
+	// requests without a valid key are rejected.
+	APIKeys []RPCKey `toml:",omitempty"`
+}
+
+// RPCRateLimit is a rate limit on request units.
+type RPCRateLimit struct {
+	Rate  float64 // Request units per second
+	Burst int     `toml:",omitempty"` // Request units spendable at once
+}
+
+// RPCKey is an API key granting access to the RPC endpoints.
+type RPCKey struct {
+	Name string // Name of the key holder, for logging
+	Key  string // Bearer token of the key
+
+	// Allowed are the method patterns callable with the key, all methods being
+	// allowed if empty.
+	Allowed []string `toml:",omitempty"`
+
+	// Rate and Burst override the client-wide limit for the key if non-zero.
+	Rate  float64 `toml:",omitempty"`
+	Burst int     `toml:",omitempty"`
+}
+
+// rpcLimitError is the error returned for calls rejected by the limiter.
+type rpcLimitError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcLimitError) Error() string  { return e.msg }
+func (e *rpcLimitError) ErrorCode() int { return e.code }
+
+// rpcClient is a client of the RPC endpoints, as identified by the limiter.
+type rpcClient struct {
+	id  string  // API key name or IP address
+	key *RPCKey // API key used by the client, nil if anonymous
+}
+
+type rpcClientKey struct{}
+
+// rpcClientLimits are the rate limiters of a single client.
+type rpcClientLimits struct {
+	global *rate.Limiter            // Client-wide limit, nil if unlimited
+	limits map[string]*rate.Limiter // Limits by method pattern
+}
+
+// rpcLimiter enforces the configured rate limits and API key scopes on the calls
+// made through the HTTP and WebSocket RPC endpoints.
+type rpcLimiter struct {
+	config  RPCLimitConfig
+	keys    map[string]*RPCKey // API keys by bearer token
+	costs   map[string]int
+	maxCost int
+
+	clients *lru.Cache[string, *rpcClientLimits]
+	lock    sync.Mutex // Protects the creation of client limiters
+}
+
+// newRPCLimiter creates a limiter from the given configuration.
+func newRPCLimiter(config RPCLimitConfig) (*rpcLimiter, error) {
+	l := &rpcLimiter{
+		config:  config,
+		keys:    make(map[string]*RPCKey),
+		costs:   config.Costs,
+		maxCost: 1,
+		clients: lru.NewCache[string, *rpcClientLimits](rpcLimitClients),
+	}
+	if l.costs == nil {
+		l.costs = DefaultRPCCosts
+	}
+	for pattern, cost := range l.costs {
+		if cost < 0 {
+			return nil, fmt.Errorf("negative cost %d for %q", cost, pattern)
+		}
+		l.maxCost = max(l.maxCost, cost)
+	}
+	if config.Rate < 0 {
+		return nil, fmt.Errorf("negative rate limit %v", config.Rate)
+	}
+	for pattern, limit := range config.Limits {
+		if limit.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %v for %q", limit.Rate, pattern)
+		}
+	}
+	for i := range config.APIKeys {
+		key := &config.APIKeys[i]
+		switch {
+		case key.Key == "":
+			return nil, fmt.Errorf("API key %q has no token", key.Name)
+		case l.keys[key.Key] != nil:
+			return nil, fmt.Errorf("duplicate API key %q", key.Name)
+		case key.Rate < 0:
+			return nil, fmt.Errorf("negative rate limit %v for API key %q", key.Rate, key.Name)
+		}
+		if key.Name == "" {
+			key.Name = fmt.Sprintf("key-%d", i)
+		}
+		l.keys[key.Key] = key
+	}
+	return l, nil
+}
+
+// identify determines the client making an HTTP request, authenticating it if
+// API keys are configured.
+func (l *rpcLimiter) identify(r *http.Request) (*rpcClient, error) {
+	if len(l.keys) == 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return &rpcClient{id: host}, nil
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, errRPCKeyMissing
+	}
+	key := l.keys[strings.TrimPrefix(auth, "Bearer ")]
+	if key == nil {
+		return nil, errRPCKeyInvalid
+	}
+	return &rpcClient{id: key.Name, key: key}, nil
+}
+
+// filter implements rpc.CallFilter, rejecting the calls not allowed for the API
+// key of the client, or exceeding its rate limits.
+func (l *rpcLimiter) filter(ctx context.Context, method string) error {
+	client, ok := ctx.Value(rpcClientKey{}).(*rpcClient)
+	if !ok {
+		return nil // Not served through a limited endpoint
+	}
+	// Dropping subscriptions is always free
+	if strings.HasSuffix(method, "_unsubscribe") {
+		return nil
+	}
+	if client.key != nil && len(client.key.Allowed) > 0 && !matchAnyMethod(client.key.Allowed, method) {
+		rpcScopeRejectedMeter.Mark(1)
+		log.Debug("Rejected RPC call outside of API key scope", "key", client.id, "method", method)
+		return &rpcLimitError{rpcMethodNotAllowedCode, fmt.Sprintf("method %s not allowed", method)}
+	}
+	if !l.allow(client, method) {
+		rpcLimitRejectedMeter.Mark(1)
+		log.Debug("Rejected rate limited RPC call", "client", client.id, "method", method)
+		return &rpcLimitError{rpcLimitExceededCode, "rate limit exceeded"}
+	}
+	return nil
+}
+
+// allow charges the cost of a method call against all limits applying to it,
+// reporting whether the call is within all of them. Nothing is charged if not.
+func (l *rpcLimiter) allow(client *rpcClient, method string) bool {
+	var (
+		cost    = l.cost(method)
+		now     = time.Now()
+		limits  = l.limits(client)
+		charged []*rate.Reservation
+	)
+	charge := func(limiter *rate.Limiter) bool {
+		r := limiter.ReserveN(now, cost)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			r.CancelAt(now)
+			return false
+		}
+		charged = append(charged, r)
+		return true
+	}
+	ok := limits.global == nil || charge(limits.global)
+	for pattern, limiter := range limits.limits {
+		if !ok {
+			break
+		}
+		if matchMethod(pattern, method) {
+			ok = charge(limiter)
+		}
+	}
+	if !ok {
+		for _, r := range charged {
+			r.CancelAt(now)
+		}
+	}
+	return ok
+}
+
+// limits retrieves the rate limiters of a client, creating them if needed.
+func (l *rpcLimiter) limits(client *rpcClient) *rpcClientLimits {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if limits, ok := l.clients.Get(client.id); ok {
+		return limits
+	}
+	limits := &rpcClientLimits{
+		limits: make(map[string]*rate.Limiter, len(l.config.Limits)),
+	}
+	globalRate, globalBurst := l.config.Rate, l.config.Burst
+	if client.key != nil && client.key.Rate > 0 {
+		globalRate, globalBurst = client.key.Rate, client.key.Burst
+	}
+	if globalRate > 0 {
+		limits.global = l.newLimiter(globalRate, globalBurst)
+	}
+	for pattern, limit := range l.config.Limits {
+		limits.limits[pattern] = l.newLimiter(limit.Rate, limit.Burst)
+	}
+	l.clients.Add(client.id, limits)
+	return limits
+}
+
+// newLimiter creates a rate limiter, defaulting the burst to allow any call.
+func (l *rpcLimiter) newLimiter(r float64, burst int) *rate.Limiter {
+	if burst <= 0 {
+		burst = max(int(math.Ceil(r)), l.maxCost)
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
+
+// cost returns the request units charged for calling a method, preferring exact
+// method matches over prefixes, and prefixes over namespaces.
+func (l *rpcLimiter) cost(method string) int {
+	if cost, ok := l.costs[method]; ok {
+		return cost
+	}
+	var (
+		cost   = -1
+		prefix = -1
+	)
+	for pattern, c := range l.costs {
+		if p, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(method, p) && len(p) > prefix {
+			cost, prefix = c, len(p)
+		}
+	}
+	if cost >= 0 {
+		return cost
+	}
+	if cost, ok := l.costs[methodNamespace(method)]; ok {
+		return cost
+	}
+	return 1
+}
+
+// rpcLimitHandler is an http.Handler identifying the clients of the RPC server,
+// making them available to the call filter of the limiter.
+type rpcLimitHandler struct {
+	limiter *rpcLimiter
+	next    http.Handler
+}
+
+func newRPCLimitHandler(limiter *rpcLimiter, next http.Handler) http.Handler {
+	return &rpcLimitHandler{limiter: limiter, next: next}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *rpcLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client, err := h.limiter.identify(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rpcClientKey{}, client)))
+}
+
+// matchAnyMethod reports whether a method matches any of the given patterns.
+func matchAnyMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if matchMethod(pattern, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMethod reports whether a method matches a method pattern.
+func matchMethod(pattern, method string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(method, prefix)
+	}
+	return pattern == method || pattern == methodNamespace(method)
+}
+
+// methodNamespace returns the namespace of an RPC method.
+func methodNamespace(method string) string {
+	namespace, _, _ := strings.Cut(method, "_")
+	return namespace
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// startLimitedServer starts an HTTP and WebSocket server limited by the given
+// configuration.
+func startLimitedServer(t *testing.T, config RPCLimitConfig) *httpServer {
+	t.Helper()
+
+	limiter, err := newRPCLimiter(config)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+	if err := srv.enableRPC(apis(), httpConfig{rpcEndpointConfig: rpcEndpointConfig{limiter: limiter}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.enableWS(apis(), wsConfig{Origins: []string{"*"}, rpcEndpointConfig: rpcEndpointConfig{limiter: limiter}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.setListenAddr("localhost", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.stop)
+	return srv
+}
+
+// dialLimited connects to a limited server, authenticating with the given key.
+func dialLimited(t *testing.T, url, key string) *rpc.Client {
+	t.Helper()
+
+	var opts []rpc.ClientOption
+	if key != "" {
+		opts = append(opts, rpc.WithHeader("Authorization", "Bearer "+key))
+	}
+	client, err := rpc.DialOptions(context.Background(), url, opts...)
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", url, err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// checkRPCError checks that a call failed with the given RPC error code, or
+// succeeded if the code is zero.
+func checkRPCError(t *testing.T, client *rpc.Client, method string, code int) {
+	t.Helper()
+
+	var result interface{}
+	err := client.Call(&result, method)
+	if code == 0 {
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		return
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != code {
+		t.Fatalf("%s: error mismatch: have %v, want code %d", method, err, code)
+	}
+}
+
+// Tests that API keys are required if configured, and only grant access to the
+// methods they are scoped to.
+func TestRPCLimitScope(t *testing.T) {
+	srv := startLimitedServer(t, RPCLimitConfig{
+		APIKeys: []RPCKey{
+			{Name: "alice", Key: "secret-a", Allowed: []string{"test"}},
+			{Name: "bob", Key: "secret-b", Allowed: []string{"rpc_modules", "test_gr*"}},
+			{Name: "carol", Key: "secret-c"},
+		},
+	})
+	httpURL := "http://" + srv.listenAddr()
+	wsURL := "ws://" + srv.listenAddr()
+
+	for _, url := range []string{httpURL, wsURL} {
+		alice := dialLimited(t, url, "secret-a")
+		checkRPCError(t, alice, "test_greet", 0)
+		checkRPCError(t, alice, "rpc_modules", rpcMethodNotAllowedCode)
+
+		bob := dialLimited(t, url, "secret-b")
+		checkRPCError(t, bob, "test_greet", 0)
+		checkRPCError(t, bob, "rpc_modules", 0)
+
+		carol := dialLimited(t, url, "secret-c")
+		checkRPCError(t, carol, "test_greet", 0)
+		checkRPCError(t, carol, "rpc_modules", 0)
+	}
+	// Requests with missing or unknown keys must be rejected
+	if resp := rpcRequest(t, httpURL, "test_greet"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("request without key: status mismatch: have %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := rpcRequest(t, httpURL, "test_greet", "Authorization", "Bearer wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("request with unknown key: status mismatch: have %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if err := wsRequest(t, wsURL, "Authorization", "Bearer wrong"); err == nil {
+		t.Errorf("websocket with unknown key accepted")
+	}
+}
+
+// Tests that clients are rate limited according to the costs of the methods,
+// and that rejected calls are not charged.
+func TestRPCLimitRate(t *testing.T) {
+	srv := startLimitedServer(t, RPCLimitConfig{
+		Rate:   0.001,
+		Burst:  3,
+		Costs:  map[string]int{"test_greet": 2},
+		Limits: map[string]RPCRateLimit{"rpc": {Rate: 0.001, Burst: 1}},
+	})
+	client := dialLimited(t, "http://"+srv.listenAddr(), "")
+
+	checkRPCError(t, client, "rpc_modules", 0)                    // 2 units left, rpc namespace exhausted
+	checkRPCError(t, client, "rpc_modules", rpcLimitExceededCode) // rejected by the namespace limit
+	checkRPCError(t, client, "test_greet", 0)                     // 0 units left
+	checkRPCError(t, client, "test_greet", rpcLimitExceededCode)  // rejected by the client limit
+}
+
+// Tests the resolution of the costs of method calls.
+func TestRPCLimitCost(t *testing.T) {
+	limiter, err := newRPCLimiter(RPCLimitConfig{
+		Costs: map[string]int{
+			"debug":         5,
+			"debug_trace*":  20,
+			"debug_traceB*": 30,
+			"eth_getLogs":   10,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]int{
+		"debug_traceTransaction": 20,
+		"debug_traceBlock":       30,
+		"debug_getRawBlock":      5,
+		"eth_getLogs":            10,
+		"eth_getLogsFoo":         1,
+		"eth_blockNumber":        1,
+	}
+	for method, want := range tests {
+		if have := limiter.cost(method); have != want {
+			t.Errorf("%s: cost mismatch: have %d, want %d", method, have, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/time/rate"
+)
+
+// minBandwidthBurst is the smallest number of bytes transferable at once under a
+// bandwidth quota, so that small quotas don't degrade into per-byte waits.
+const minBandwidthBurst = 64 * 1024
+
+var (
+	egressThrottleMeter  = metrics.NewRegisteredMeter("p2p/throttle/egress", nil)
+	ingressThrottleMeter = metrics.NewRegisteredMeter("p2p/throttle/ingress", nil)
+)
+
+// BandwidthConfig configures the bandwidth quotas of the server, in bytes per
+// second. Zero quotas mean unlimited bandwidth.
+//
+// Quotas are enforced on the messages of the subprotocols. Messages flagged as
+// priority by their protocol (e.g. block and transaction propagation) are never
+// delayed, but still count against the quotas, delaying bulk transfers instead.
+type BandwidthConfig struct {
+	Upload   uint64 `toml:",omitempty"` // Upload quota across all protocols
+	Download uint64 `toml:",omitempty"` // Download quota across all protocols
+
+	ProtocolUpload   map[string]uint64 `toml:",omitempty"` // Upload quotas by protocol name
+	ProtocolDownload map[string]uint64 `toml:",omitempty"` // Download quotas by protocol name
+}
+
+// ParseBandwidthQuotas parses a bandwidth quota specification of the form
+// "<global>,<protocol>=<quota>,...", with quotas given in KiB per second, e.g.
+// "8192,snap=2048". All entries are optional.
+func ParseBandwidthQuotas(spec string) (global uint64, protocols map[string]uint64, err error) {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, isProto := strings.Cut(entry, "=")
+		if !isProto {
+			value = name
+		}
+		quota, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid bandwidth quota %q: %v", entry, err)
+		}
+		if !isProto {
+			global = quota * 1024
+			continue
+		}
+		if protocols == nil {
+			protocols = make(map[string]uint64)
+		}
+		protocols[strings.TrimSpace(name)] = quota * 1024
+	}
+	return global, protocols, nil
+}
+
+// bandwidthLimiter enforces the bandwidth quotas of the server.
+type bandwidthLimiter struct {
+	upload   *rate.Limiter // Global upload quota, nil if unlimited
+	download *rate.Limiter // Global download quota, nil if unlimited
+
+	protoUpload   map[string]*rate.Limiter
+	protoDownload map[string]*rate.Limiter
+}
+
+// newBandwidthLimiter creates a limiter enforcing the given quotas, or returns
+// nil if no quotas are configured.
+func newBandwidthLimiter(config BandwidthConfig) *bandwidthLimiter {
+	if config.Upload == 0 && config.Download == 0 && len(config.ProtocolUpload) == 0 && len(config.ProtocolDownload) == 0 {
+		return nil
+	}
+	b := &bandwidthLimiter{
+		upload:        newBandwidthBucket(config.Upload),
+		download:      newBandwidthBucket(config.Download),
+		protoUpload:   make(map[string]*rate.Limiter),
+		protoDownload: make(map[string]*rate.Limiter),
+	}
+	for name, quota := range config.ProtocolUpload {
+		if l := newBandwidthBucket(quota); l != nil {
+			b.protoUpload[name] = l
+		}
+	}
+	for name, quota := range config.ProtocolDownload {
+		if l := newBandwidthBucket(quota); l != nil {
+			b.protoDownload[name] = l
+		}
+	}
+	return b
+}
+
+// newBandwidthBucket creates a token bucket for the given quota, allowing a
+// second worth of traffic at once.
+func newBandwidthBucket(quota uint64) *rate.Limiter {
+	if quota == 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(quota), int(max(quota, minBandwidthBurst)))
+}
+
+// waitUpload blocks until a message of the given protocol may be sent, returning
+// the time spent waiting. Priority messages are charged but not delayed.
+func (b *bandwidthLimiter) waitUpload(proto string, size uint32, priority bool, closed <-chan struct{}) (time.Duration, error) {
+	wait, err := b.wait(size, priority, closed, b.upload, b.protoUpload[proto])
+	if wait > 0 {
+		egressThrottleMeter.Mark(int64(wait))
+	}
+	return wait, err
+}
+
+// waitDownload blocks until a received message of the given protocol may be
+// delivered, returning the time spent waiting. Delaying the delivery also delays
+// reading further messages from the peer, throttling its transfers.
+func (b *bandwidthLimiter) waitDownload(proto string, size uint32, priority bool, closed <-chan struct{}) (time.Duration, error) {
+	wait, err := b.wait(size, priority, closed, b.download, b.protoDownload[proto])
+	if wait > 0 {
+		ingressThrottleMeter.Mark(int64(wait))
+	}
+	return wait, err
+}
+
+// wait charges a transfer against the given token buckets, waiting until all of
+// them allow it unless the transfer has priority.
+func (b *bandwidthLimiter) wait(size uint32, priority bool, closed <-chan struct{}, buckets ...*rate.Limiter) (time.Duration, error) {
+	var (
+		now          = time.Now()
+		delay        time.Duration
+		reservations []*rate.Reservation
+	)
+	for _, bucket := range buckets {
+		if bucket == nil {
+			continue
+		}
+		// Transfers larger than the burst are charged in multiple chunks
+		for n := int(size); n > 0; n -= bucket.Burst() {
+			r := bucket.ReserveN(now, min(n, bucket.Burst()))
+			reservations = append(reservations, r)
+			delay = max(delay, r.DelayFrom(now))
+		}
+	}
+	if priority || delay == 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-closed:
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+		return time.Since(now), ErrShuttingDown
+	}
+}
+
+// protoTraffic tracks the traffic of a peer on a single protocol.
+type protoTraffic struct {
+	ingress   atomic.Uint64 // Bytes received
+	egress    atomic.Uint64 // Bytes sent
+	throttled atomic.Int64  // Time spent waiting on bandwidth quotas
+}
+
+// PeerTraffic is the traffic exchanged with a peer on a protocol, as reported in
+// the PeerInfo.
+type PeerTraffic struct {
+	Ingress   uint64 `json:"ingress"`   // Bytes received from the peer
+	Egress    uint64 `json:"egress"`    // Bytes sent to the peer
+	Throttled uint64 `json:"throttled"` // Milliseconds spent waiting on bandwidth quotas
+}
+
+func (t *protoTraffic) info() *PeerTraffic {
+	return &PeerTraffic{
+		Ingress:   t.ingress.Load(),
+		Egress:    t.egress.Load(),
+		Throttled: uint64(time.Duration(t.throttled.Load()).Milliseconds()),
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthQuotas(t *testing.T) {
+	tests := []struct {
+		spec      string
+		global    uint64
+		protocols map[string]uint64
+		err       bool
+	}{
+		{spec: ""},
+		{spec: "8", global: 8192},
+		{spec: "8, snap=2", global: 8192, protocols: map[string]uint64{"snap": 2048}},
+		{spec: "eth=1,snap=2", protocols: map[string]uint64{"eth": 1024, "snap": 2048}},
+		{spec: "fast", err: true},
+		{spec: "snap=-1", err: true},
+	}
+	for _, test := range tests {
+		global, protocols, err := ParseBandwidthQuotas(test.spec)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error", test.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.spec, err)
+			continue
+		}
+		if global != test.global || !reflect.DeepEqual(protocols, test.protocols) {
+			t.Errorf("%q: mismatch: have %d %v, want %d %v", test.spec, global, protocols, test.global, test.protocols)
+		}
+	}
+}
+
+// discardWriter is a MsgWriter dropping all messages.
+type discardWriter struct{}
+
+func (discardWriter) WriteMsg(msg Msg) error { return msg.Discard() }
+
+// Tests that bulk messages wait on the bandwidth quotas, while priority messages
+// are sent right away.
+func TestBandwidthPriority(t *testing.T) {
+	var (
+		wstart = make(chan struct{}, 10)
+		closed = make(chan struct{})
+		rw     = &protoRW{
+			Protocol: Protocol{
+				Name:        "test",
+				Length:      2,
+				PriorityMsg: func(code uint64) bool { return code == 1 },
+			},
+			closed:    closed,
+			wstart:    wstart,
+			werr:      make(chan error, 10),
+			w:         discardWriter{},
+			traffic:   new(protoTraffic),
+			bandwidth: newBandwidthLimiter(BandwidthConfig{ProtocolUpload: map[string]uint64{"test": minBandwidthBurst}}),
+		}
+	)
+	write := func(code uint64, size int) error {
+		wstart <- struct{}{}
+		return rw.WriteMsg(Msg{Code: code, Size: uint32(size), Payload: bytes.NewReader(make([]byte, size))})
+	}
+	// Exhaust the quota, further priority messages should still go through
+	if err := write(0, minBandwidthBurst); err != nil {
+		t.Fatalf("bulk write failed: %v", err)
+	}
+	if err := write(1, minBandwidthBurst); err != nil {
+		t.Fatalf("priority write failed: %v", err)
+	}
+	if throttled := rw.traffic.throttled.Load(); throttled != 0 {
+		t.Fatalf("writes throttled for %v", time.Duration(throttled))
+	}
+	// Bulk messages should be held back until the peer shuts down
+	errc := make(chan error, 1)
+	go func() { errc <- write(0, 1024) }()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("bulk write not throttled: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(closed)
+	if err := <-errc; !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("throttled write error mismatch: have %v, want %v", err, ErrShuttingDown)
+	}
+	if egress := rw.traffic.egress.Load(); egress != 2*minBandwidthBurst {
+		t.Fatalf("egress mismatch: have %d, want %d", egress, 2*minBandwidthBurst)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// CaptureRecord is a sub-protocol message exchanged with a peer, as stored in a
+// capture file. Capture files are a sequence of RLP encoded records.
+type CaptureRecord struct {
+	Time     uint64   // Unix time of the message in nanoseconds
+	Peer     enode.ID // Node the message was exchanged with
+	Protocol string   // Name of the sub-protocol
+	Version  uint     // Negotiated version of the sub-protocol
+	Received bool     // Whether the message was received or sent
+	Code     uint64   // Message code, relative to the sub-protocol
+	Payload  []byte   // Decrypted and decompressed message payload
+}
+
+// CaptureReader reads the records of a capture file.
+type CaptureReader struct {
+	stream *rlp.Stream
+}
+
+// NewCaptureReader creates a reader for the capture file contents in r.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{stream: rlp.NewStream(r, 0)}
+}
+
+// Next reads the next record, returning io.EOF at the end of the capture.
+func (r *CaptureReader) Next() (*CaptureRecord, error) {
+	rec := new(CaptureRecord)
+	if err := r.stream.Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// captureWriter appends the records of all peers to a capture file.
+type captureWriter struct {
+	lock   sync.Mutex
+	file   *os.File
+	failed bool // set after the first write error, to avoid flooding the log
+}
+
+func newCaptureWriter(path string) (*captureWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &captureWriter{file: file}, nil
+}
+
+func (w *captureWriter) write(rec *CaptureRecord) {
+	enc, err := rlp.EncodeToBytes(rec)
+	if err != nil {
+		panic(err) // can't happen
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, err := w.file.Write(enc); err != nil && !w.failed {
+		log.Warn("Failed to write message capture", "file", w.file.Name(), "err", err)
+		w.failed = true
+	}
+}
+
+func (w *captureWriter) close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.file.Close()
+}
+
+// msgCapturer wraps a MsgReadWriter and records all messages sent and received
+// to a capture file.
+type msgCapturer struct {
+	MsgReadWriter
+
+	writer  *captureWriter
+	peerID  enode.ID
+	proto   string
+	version uint
+}
+
+func newMsgCapturer(rw MsgReadWriter, writer *captureWriter, peerID enode.ID, proto Protocol) *msgCapturer {
+	return &msgCapturer{
+		MsgReadWriter: rw,
+		writer:        writer,
+		peerID:        peerID,
+		proto:         proto.Name,
+		version:       proto.Version,
+	}
+}
+
+// ReadMsg reads a message from the underlying MsgReadWriter and records it.
+func (c *msgCapturer) ReadMsg() (Msg, error) {
+	msg, err := c.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	c.record(msg.ReceivedAt, true, msg.Code, payload)
+	return msg, nil
+}
+
+// WriteMsg writes a message to the underlying MsgReadWriter and records it.
+func (c *msgCapturer) WriteMsg(msg Msg) error {
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	if err := c.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	c.record(time.Now(), false, msg.Code, payload)
+	return nil
+}
+
+func (c *msgCapturer) record(t time.Time, received bool, code uint64, payload []byte) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	c.writer.write(&CaptureRecord{
+		Time:     uint64(t.UnixNano()),
+		Peer:     c.peerID,
+		Protocol: c.proto,
+		Version:  c.version,
+		Received: received,
+		Code:     code,
+		Payload:  payload,
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that captured messages are recorded in both directions, without
+// disturbing the protocol reading them.
+func TestMsgCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.rlp")
+	writer, err := newCaptureWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		rw1, rw2 = MsgPipe()
+		capturer = newMsgCapturer(rw1, writer, uintID(1), Protocol{Name: "test", Version: 2})
+	)
+	defer rw1.Close()
+
+	go Send(rw2, 3, []string{"ping"})
+	if err := ExpectMsg(capturer, 3, []string{"ping"}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := ExpectMsg(rw2, 4, []string{"pong"}); err != nil {
+			t.Error(err)
+		}
+	}()
+	if err := Send(capturer, 4, []string{"pong"}); err != nil {
+		t.Fatal(err)
+	}
+	writer.close()
+
+	// Check the records in the capture file
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := NewCaptureReader(file)
+	for _, want := range []struct {
+		received bool
+		code     uint64
+		content  string
+	}{
+		{true, 3, "ping"},
+		{false, 4, "pong"},
+	} {
+		rec, err := reader.Next()
+		if err != nil {
+			t.Fatalf("failed to read record: %v", err)
+		}
+		var content []string
+		if err := rlp.DecodeBytes(rec.Payload, &content); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		if rec.Peer != uintID(1) || rec.Protocol != "test" || rec.Version != 2 || rec.Time == 0 {
+			t.Errorf("record metadata mismatch: %+v", rec)
+		}
+		if rec.Received != want.received || rec.Code != want.code || len(content) != 1 || content[0] != want.content {
+			t.Errorf("record mismatch: have received=%t code=%d %v, want received=%t code=%d %s",
+				rec.Received, rec.Code, content, want.received, want.code, want.content)
+		}
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected end of capture, got %v", err)
+	}
+}
+
+// Tests that the capture file is released if the server fails to start.
+func TestMsgCaptureStartFailure(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			ListenAddr:  "invalid address",
+			NoDiscovery: true,
+			CaptureFile: filepath.Join(t.TempDir(), "capture.rlp"),
+		},
+	}
+	if err := srv.Start(); err == nil {
+		srv.Stop()
+		t.Fatal("server started with invalid listen address")
+	}
+	if srv.capture != nil {
+		t.Fatal("capture file left open after failed start")
+	}
+}
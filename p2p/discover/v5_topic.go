@@ -0,0 +1,530 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Topic advertisement lets nodes find each other by an application-defined topic
+// instead of walking the whole DHT. Nodes advertise themselves by registering with
+// the 'registrars' closest to the topic hash, which answer topic queries with the
+// registered nodes.
+//
+// Registrars store a limited number of ads. When a topic is full, registrants get
+// a ticket with the time until a slot frees up. Slots are reserved for ticket
+// holders in the order the tickets were issued, and registrants present their
+// ticket after the waiting time to claim the slot.
+
+const (
+	topicAdLifetime       = 15 * time.Minute // Time until ads must be renewed
+	topicQueueLimit       = 100              // Ads stored per topic
+	topicTableLimit       = 10000            // Ads stored across all topics
+	topicTicketGrace      = 10 * time.Second // Time allowed for claiming a slot after the waiting time
+	topicQueryResultLimit = 16               // Nodes returned by TOPICQUERY
+
+	topicRegistrars      = 8                // Registrars the local node advertises itself with
+	topicRenewMargin     = 1 * time.Minute  // Time before ad expiry when ads are renewed
+	topicLookupInterval  = 5 * time.Minute  // Time between registrar lookups
+	topicSearchInterval  = 30 * time.Second // Time between topic search rounds
+	topicRegisterBackoff = 10 * time.Second // Time before retrying registrations after a failure
+)
+
+var errInvalidTicket = errors.New("invalid ticket")
+
+// Topic identifies an advertisement topic.
+type Topic [32]byte
+
+// NewTopic returns the topic with the given name.
+func NewTopic(name string) Topic {
+	return Topic(crypto.Keccak256Hash([]byte(name)))
+}
+
+// String returns the topic hash in hex.
+func (t Topic) String() string {
+	return hexutil.Encode(t[:])
+}
+
+// topicAd is a node advertised for a topic.
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// topicTicket is the content of tickets handed out by registrars. Tickets are
+// authenticated by the registrar, so they can be trusted when presented later.
+type topicTicket struct {
+	Node   enode.ID
+	Topic  Topic
+	Issued uint64 // mclock.AbsTime
+	Wait   uint64 // time.Duration
+}
+
+// topicTable stores the ads of a registrar. It is only accessed by the dispatch
+// loop and thus not safe for concurrent use.
+type topicTable struct {
+	clock  mclock.Clock
+	secret []byte // ticket authentication key
+	ads    map[Topic][]*topicAd
+	total  int
+
+	// reservations holds the slots reserved for the holders of tickets, with the
+	// deadline for claiming them.
+	reservations map[Topic]map[enode.ID]mclock.AbsTime
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	secret := make([]byte, 32)
+	crand.Read(secret)
+	return &topicTable{
+		clock:        clock,
+		secret:       secret,
+		ads:          make(map[Topic][]*topicAd),
+		reservations: make(map[Topic]map[enode.ID]mclock.AbsTime),
+	}
+}
+
+// register handles a registration attempt for a topic. It either accepts the
+// registration, or returns a ticket and the time to wait before using it.
+func (tab *topicTable) register(n *enode.Node, topic Topic, ticket []byte) (confirmed bool, wait time.Duration, newTicket []byte) {
+	now := tab.clock.Now()
+	tab.expire(now)
+
+	// Renewals of existing ads are always accepted.
+	ads := tab.ads[topic]
+	for i, ad := range ads {
+		if ad.node.ID() == n.ID() {
+			copy(ads[i:], ads[i+1:])
+			ads[len(ads)-1] = &topicAd{node: n, expires: now.Add(topicAdLifetime)}
+			return true, 0, nil
+		}
+	}
+	// Claim the reserved slot if a ticket is presented in time. Reservations of
+	// later tickets don't count here, their waiting time includes this slot.
+	var (
+		reserved = tab.reservations[topic]
+		claim    bool
+	)
+	if t, err := tab.decodeTicket(ticket); err == nil && t.Node == n.ID() && t.Topic == topic {
+		due := mclock.AbsTime(t.Issued).Add(time.Duration(t.Wait))
+		if now < due {
+			return false, time.Duration(due - now), ticket
+		}
+		_, claim = reserved[n.ID()]
+		delete(reserved, n.ID())
+	}
+	if (claim && len(ads) < topicQueueLimit && tab.total < topicTableLimit) || tab.hasSpace(topic) {
+		tab.ads[topic] = append(ads, &topicAd{node: n, expires: now.Add(topicAdLifetime)})
+		tab.total++
+		return true, 0, nil
+	}
+	// No space, hand out a ticket for the next free slot.
+	wait = tab.nextSlot(topic, now)
+	if reserved == nil {
+		reserved = make(map[enode.ID]mclock.AbsTime)
+		tab.reservations[topic] = reserved
+	}
+	if len(reserved) < topicQueueLimit && tab.reserved() < topicTableLimit {
+		reserved[n.ID()] = now.Add(wait + topicTicketGrace)
+	}
+	return false, wait, tab.encodeTicket(&topicTicket{Node: n.ID(), Topic: topic, Issued: uint64(now), Wait: uint64(wait)})
+}
+
+// hasSpace reports whether a new ad can be stored for the topic without using
+// the slots reserved for ticket holders.
+func (tab *topicTable) hasSpace(topic Topic) bool {
+	return len(tab.ads[topic])+len(tab.reservations[topic]) < topicQueueLimit && tab.total+tab.reserved() < topicTableLimit
+}
+
+// reserved returns the number of slots reserved across all topics.
+func (tab *topicTable) reserved() int {
+	var n int
+	for _, r := range tab.reservations {
+		n += len(r)
+	}
+	return n
+}
+
+// nextSlot returns the time until a slot frees up for the topic, after all the
+// slots reserved for earlier tickets.
+func (tab *topicTable) nextSlot(topic Topic, now mclock.AbsTime) time.Duration {
+	var (
+		ads    = tab.ads[topic]
+		wait   = topicAdLifetime
+		needed = len(ads) + len(tab.reservations[topic]) - topicQueueLimit
+	)
+	if needed < 0 {
+		// The topic has space, so the table must be full. Wait for the oldest ad.
+		for _, ads := range tab.ads {
+			wait = min(wait, time.Duration(ads[0].expires-now))
+		}
+		return wait
+	}
+	if needed < len(ads) {
+		wait = time.Duration(ads[needed].expires - now)
+	}
+	return wait
+}
+
+// expire removes expired ads and reservations.
+func (tab *topicTable) expire(now mclock.AbsTime) {
+	for topic, ads := range tab.ads {
+		i := 0
+		for i < len(ads) && ads[i].expires <= now {
+			i++
+		}
+		tab.total -= i
+		if i == len(ads) {
+			delete(tab.ads, topic)
+		} else {
+			tab.ads[topic] = ads[i:]
+		}
+	}
+	for topic, reserved := range tab.reservations {
+		for id, deadline := range reserved {
+			if deadline <= now {
+				delete(reserved, id)
+			}
+		}
+		if len(reserved) == 0 {
+			delete(tab.reservations, topic)
+		}
+	}
+}
+
+// nodes returns the most recently registered nodes of a topic.
+func (tab *topicTable) nodes(topic Topic, limit int) []*enode.Node {
+	tab.expire(tab.clock.Now())
+
+	ads := tab.ads[topic]
+	nodes := make([]*enode.Node, 0, min(len(ads), limit))
+	for i := len(ads) - 1; i >= 0 && len(nodes) < limit; i-- {
+		nodes = append(nodes, ads[i].node)
+	}
+	return nodes
+}
+
+func (tab *topicTable) encodeTicket(t *topicTicket) []byte {
+	enc, _ := rlp.EncodeToBytes(t)
+	mac := hmac.New(sha256.New, tab.secret)
+	mac.Write(enc)
+	return mac.Sum(enc)
+}
+
+func (tab *topicTable) decodeTicket(ticket []byte) (*topicTicket, error) {
+	if len(ticket) <= sha256.Size {
+		return nil, errInvalidTicket
+	}
+	enc, sum := ticket[:len(ticket)-sha256.Size], ticket[len(ticket)-sha256.Size:]
+	mac := hmac.New(sha256.New, tab.secret)
+	mac.Write(enc)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil, errInvalidTicket
+	}
+	t := new(topicTicket)
+	if err := rlp.DecodeBytes(enc, t); err != nil {
+		return nil, errInvalidTicket
+	}
+	return t, nil
+}
+
+// handleRegtopic processes a topic registration request.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) {
+	n, err := t.verifyRegistrant(p, fromID, fromAddr)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	confirmed, wait, ticket := t.topics.register(n, p.Topic, p.Ticket)
+	if confirmed {
+		t.sendResponse(fromID, fromAddr, &v5wire.Regconfirmation{ReqID: p.ReqID, Topic: p.Topic})
+		return
+	}
+	waitMs := uint64((wait + time.Millisecond - 1) / time.Millisecond)
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{ReqID: p.ReqID, Ticket: ticket, WaitTime: waitMs})
+}
+
+// verifyRegistrant checks that the record in a registration request belongs to
+// the sender and matches its endpoint.
+func (t *UDPv5) verifyRegistrant(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) (*enode.Node, error) {
+	if p.ENR == nil {
+		return nil, errors.New("missing record")
+	}
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != fromID {
+		return nil, errors.New("record of another node")
+	}
+	if n.IPAddr() != fromAddr.Addr().Unmap() {
+		return nil, fmt.Errorf("record IP %v does not match sender", n.IPAddr())
+	}
+	if t.netrestrict != nil && !t.netrestrict.ContainsAddr(n.IPAddr()) {
+		return nil, errors.New("not contained in netrestrict list")
+	}
+	return n, nil
+}
+
+// handleTopicQuery returns the nodes registered for a topic.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr netip.AddrPort) {
+	nodes := t.topics.nodes(p.Topic, topicQueryResultLimit)
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// regtopic calls REGTOPIC on a node and waits for the confirmation or ticket.
+func (t *UDPv5) regtopic(n *enode.Node, topic Topic, ticket []byte) (confirmed bool, wait time.Duration, newTicket []byte, err error) {
+	req := &v5wire.Regtopic{Topic: topic, ENR: t.localNode.Node().Record(), Ticket: ticket}
+	c := t.callToNode(n, v5wire.TicketMsg, req)
+	defer t.callDone(c)
+
+	select {
+	case resp := <-c.ch:
+		if resp, ok := resp.(*v5wire.Ticket); ok {
+			return false, time.Duration(resp.WaitTime) * time.Millisecond, resp.Ticket, nil
+		}
+		return true, 0, nil, nil
+	case err := <-c.err:
+		return false, 0, nil, err
+	}
+}
+
+// topicQuery calls TOPICQUERY on a node and waits for the NODES responses.
+func (t *UDPv5) topicQuery(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	c := t.callToNode(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return t.waitForNodes(c, nil)
+}
+
+// TopicRegistration advertises the local node for a topic until stopped.
+type TopicRegistration struct {
+	t     *UDPv5
+	topic Topic
+	ctx   context.Context
+	stop  context.CancelFunc
+	wg    sync.WaitGroup
+
+	mu         sync.Mutex
+	registered map[enode.ID]time.Time // registrars currently advertising the node
+}
+
+// RegisterTopic starts advertising the local node for the given topic, with the
+// nodes closest to the topic hash.
+func (t *UDPv5) RegisterTopic(topic Topic) *TopicRegistration {
+	ctx, stop := context.WithCancel(t.closeCtx)
+	r := &TopicRegistration{
+		t:          t,
+		topic:      topic,
+		ctx:        ctx,
+		stop:       stop,
+		registered: make(map[enode.ID]time.Time),
+	}
+	r.wg.Add(1)
+	go r.loop()
+	return r
+}
+
+// Stop ends the registration. Existing ads remain until they expire.
+func (r *TopicRegistration) Stop() {
+	r.stop()
+	r.wg.Wait()
+}
+
+// Registrars returns the number of nodes currently advertising the local node.
+func (r *TopicRegistration) Registrars() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for _, expires := range r.registered {
+		if expires.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *TopicRegistration) loop() {
+	defer r.wg.Done()
+
+	var (
+		lookup = time.NewTimer(0)
+		active = make(map[enode.ID]bool)
+		done   = make(chan enode.ID)
+	)
+	defer lookup.Stop()
+
+	for {
+		select {
+		case <-lookup.C:
+			for _, n := range r.t.newLookup(r.ctx, enode.ID(r.topic)).run() {
+				if len(active) >= topicRegistrars {
+					break
+				}
+				if !active[n.ID()] {
+					active[n.ID()] = true
+					go r.registerWith(n, done)
+				}
+			}
+			lookup.Reset(topicLookupInterval)
+
+		case id := <-done:
+			delete(active, id)
+			// Find a replacement soon, but don't hammer the network.
+			lookup.Reset(topicRegisterBackoff)
+
+		case <-r.ctx.Done():
+			for len(active) > 0 {
+				delete(active, <-done)
+			}
+			return
+		}
+	}
+}
+
+// registerWith keeps the local node registered with a registrar, until the
+// registrar fails to respond.
+func (r *TopicRegistration) registerWith(n *enode.Node, done chan<- enode.ID) {
+	defer func() { done <- n.ID() }()
+
+	var ticket []byte
+	for {
+		confirmed, wait, newTicket, err := r.t.regtopic(n, r.topic, ticket)
+		if err != nil {
+			r.t.log.Debug("Topic registration failed", "topic", r.topic, "id", n.ID(), "err", err)
+			r.mu.Lock()
+			delete(r.registered, n.ID())
+			r.mu.Unlock()
+			return
+		}
+		if confirmed {
+			r.t.log.Trace("Topic registration confirmed", "topic", r.topic, "id", n.ID())
+			r.mu.Lock()
+			r.registered[n.ID()] = time.Now().Add(topicAdLifetime)
+			r.mu.Unlock()
+			ticket, wait = nil, topicAdLifetime-topicRenewMargin
+		} else {
+			r.t.log.Trace("Topic registration ticket received", "topic", r.topic, "id", n.ID(), "wait", wait)
+			ticket = newTicket
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// TopicSearch returns an iterator over the nodes advertised for a topic. The
+// iterator repeats its search periodically, returning all found nodes again.
+func (t *UDPv5) TopicSearch(topic Topic) enode.Iterator {
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicSearchIterator{t: t, topic: topic, ctx: ctx, cancel: cancel}
+}
+
+// topicSearchIterator queries the registrars of a topic for its advertised nodes.
+type topicSearchIterator struct {
+	t      *UDPv5
+	topic  Topic
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	buffer    []*enode.Node
+	lastRound time.Time
+}
+
+// Next moves to the next node.
+func (it *topicSearchIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	for len(it.buffer) == 0 {
+		if it.ctx.Err() != nil {
+			it.buffer = nil
+			return false
+		}
+		// Rate-limit the search rounds.
+		if wait := topicSearchInterval - time.Since(it.lastRound); !it.lastRound.IsZero() && wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-it.ctx.Done():
+				timer.Stop()
+				continue
+			}
+		}
+		it.lastRound = time.Now()
+		it.buffer = it.search()
+	}
+	return true
+}
+
+// search queries the registrars closest to the topic hash.
+func (it *topicSearchIterator) search() []*enode.Node {
+	var (
+		found []*enode.Node
+		seen  = make(map[enode.ID]bool)
+	)
+	for _, registrar := range it.t.newLookup(it.ctx, enode.ID(it.topic)).run() {
+		if it.ctx.Err() != nil {
+			break
+		}
+		nodes, err := it.t.topicQuery(registrar, it.topic)
+		if err != nil {
+			it.t.log.Debug("Topic query failed", "topic", it.topic, "id", registrar.ID(), "err", err)
+		}
+		for _, n := range nodes {
+			if !seen[n.ID()] && n.ID() != it.t.Self().ID() {
+				seen[n.ID()] = true
+				found = append(found, n)
+			}
+		}
+	}
+	return found
+}
+
+// Node returns the current node.
+func (it *topicSearchIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Close ends the iterator.
+func (it *topicSearchIterator) Close() {
+	it.cancel()
+}
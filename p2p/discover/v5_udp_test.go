@@ -29,6 +29,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
@@ -534,6 +535,143 @@ func TestUDPv5_talkRequest(t *testing.T) {
 	}
 }
 
+// This test checks that REGTOPIC registers the sender, hands out tickets when a
+// topic is full, and that TOPICQUERY returns the registered nodes.
+func TestUDPv5_regtopicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	clock := new(mclock.Simulated)
+	test.udp.topics.clock = clock
+	remote := test.getNode(test.remotekey, test.remoteaddr).Node()
+
+	// Registration succeeds when the topic has space.
+	topic := NewTopic("test")
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{1}, Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr netip.AddrPort, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte{1}) || p.Topic != topic {
+			t.Errorf("wrong confirmation: %v", p)
+		}
+	})
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte{2}, Topic: topic})
+	test.waitPacketOut(func(p *v5wire.Nodes, addr netip.AddrPort, _ v5wire.Nonce) {
+		if len(p.Nodes) != 1 || p.Nodes[0].Seq() != remote.Seq() {
+			t.Fatalf("wrong nodes in response: %v", p.Nodes)
+		}
+		if n, _ := enode.New(enode.ValidSchemesForTesting, p.Nodes[0]); n == nil || n.ID() != remote.ID() {
+			t.Errorf("wrong node in response: %v", n)
+		}
+	})
+
+	// Fill another topic, staggering the ads so the first one expires after a minute.
+	full := NewTopic("full")
+	for i := 0; i < topicQueueLimit; i++ {
+		var r enr.Record
+		n := enode.SignNull(&r, enode.ID{byte(i), 1})
+		test.udp.topics.ads[full] = append(test.udp.topics.ads[full], &topicAd{node: n, expires: clock.Now().Add(time.Minute + time.Duration(i)*time.Second)})
+		test.udp.topics.total++
+	}
+	var ticket []byte
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{3}, Topic: full, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if p.WaitTime != uint64(time.Minute/time.Millisecond) {
+			t.Errorf("wrong wait time %d", p.WaitTime)
+		}
+		ticket = p.Ticket
+	})
+
+	// Using the ticket too early returns the remaining time.
+	clock.Run(30 * time.Second)
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{4}, Topic: full, ENR: remote.Record(), Ticket: ticket})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if p.WaitTime != uint64(30*time.Second/time.Millisecond) {
+			t.Errorf("wrong wait time %d", p.WaitTime)
+		}
+	})
+
+	// Other nodes can't take the reserved slot.
+	clock.Run(30 * time.Second)
+	otherKey := newkey()
+	other := test.getNode(otherKey, netip.MustParseAddrPort("10.0.1.98:30303")).Node()
+	test.packetInFrom(otherKey, netip.MustParseAddrPort("10.0.1.98:30303"), &v5wire.Regtopic{ReqID: []byte{5}, Topic: full, ENR: other.Record()})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if p.WaitTime != uint64(time.Second/time.Millisecond) {
+			t.Errorf("wrong wait time %d", p.WaitTime)
+		}
+	})
+
+	// The ticket holder can claim it.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{6}, Topic: full, ENR: remote.Record(), Ticket: ticket})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr netip.AddrPort, _ v5wire.Nonce) {})
+}
+
+// This test checks that outgoing REGTOPIC and TOPICQUERY calls work.
+func TestUDPv5_topicCalls(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic   = NewTopic("test")
+		remote  = test.getNode(test.remotekey, test.remoteaddr).Node()
+		advert  = test.getNode(newkey(), netip.MustParseAddrPort("10.0.1.98:30303")).Node()
+		done    = make(chan error, 1)
+		waited  time.Duration
+		tickets [][]byte
+	)
+	// The registrar responds with a ticket, then confirms.
+	for _, resp := range []v5wire.Packet{&v5wire.Ticket{Ticket: []byte("ticket"), WaitTime: 500}, &v5wire.Regconfirmation{Topic: topic}} {
+		var confirmed bool
+		go func() {
+			var (
+				ticket []byte
+				err    error
+			)
+			if len(tickets) > 0 {
+				ticket = tickets[len(tickets)-1]
+			}
+			confirmed, waited, ticket, err = test.udp.regtopic(remote, topic, ticket)
+			tickets = append(tickets, ticket)
+			done <- err
+		}()
+		test.waitPacketOut(func(p *v5wire.Regtopic, addr netip.AddrPort, _ v5wire.Nonce) {
+			if p.Topic != topic || p.ENR.Seq() != test.udp.Self().Seq() {
+				t.Errorf("wrong registration: %v", p)
+			}
+			resp.SetRequestID(p.ReqID)
+			test.packetIn(resp)
+		})
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := resp.(*v5wire.Regconfirmation); ok != confirmed {
+			t.Errorf("wrong confirmation status %t", confirmed)
+		}
+	}
+	if waited != 0 || len(tickets) != 2 || string(tickets[0]) != "ticket" {
+		t.Errorf("wrong ticket results: wait %v, tickets %q", waited, tickets)
+	}
+
+	// Topic queries return the advertised nodes.
+	go func() {
+		nodes, err := test.udp.topicQuery(remote, topic)
+		if err == nil && (len(nodes) != 1 || nodes[0].ID() != advert.ID()) {
+			err = fmt.Errorf("wrong nodes %v", nodes)
+		}
+		done <- err
+	}()
+	test.waitPacketOut(func(p *v5wire.TopicQuery, addr netip.AddrPort, _ v5wire.Nonce) {
+		if p.Topic != topic {
+			t.Errorf("wrong topic %v", p.Topic)
+		}
+		test.packetIn(&v5wire.Nodes{ReqID: p.ReqID, RespCount: 1, Nodes: []*enr.Record{advert.Record()}})
+	})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
 // This test checks that lookupDistances works.
 func TestUDPv5_lookupDistances(t *testing.T) {
 	test := newUDPV5Test(t)
@@ -41,6 +41,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbBanPrefix    = "ban:" // Identifier to prefix node bans with, the full key is "ban:<ID>"
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -442,6 +443,58 @@ func (db *DB) storeLocalSeq(id ID, n uint64) {
 	db.storeUint64(localItemKey(id, dbLocalSeq), n)
 }
 
+// banKey returns the database key of a node ban.
+func banKey(id ID) []byte {
+	return append([]byte(dbBanPrefix), id[:]...)
+}
+
+// BanExpiry retrieves the time until which a node is banned. The zero time is
+// returned if the node is not banned, or the ban has expired.
+func (db *DB) BanExpiry(id ID) time.Time {
+	expiry := db.fetchInt64(banKey(id))
+	if expiry == 0 || time.Now().Unix() >= expiry {
+		return time.Time{}
+	}
+	return time.Unix(expiry, 0)
+}
+
+// UpdateBanExpiry bans a node until the given time.
+func (db *DB) UpdateBanExpiry(id ID, expiry time.Time) error {
+	return db.storeInt64(banKey(id), expiry.Unix())
+}
+
+// DeleteBan lifts the ban of a node.
+func (db *DB) DeleteBan(id ID) error {
+	return db.lvl.Delete(banKey(id), nil)
+}
+
+// Bans retrieves all nodes currently banned, along with the expiry of their bans.
+// Expired bans are removed from the database.
+func (db *DB) Bans() map[ID]time.Time {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	var (
+		now  = time.Now().Unix()
+		bans = make(map[ID]time.Time)
+	)
+	for it.Next() {
+		var id ID
+		if len(it.Key()) != len(dbBanPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(dbBanPrefix):])
+
+		expiry, _ := binary.Varint(it.Value())
+		if expiry <= now {
+			db.lvl.Delete(it.Key(), nil)
+			continue
+		}
+		bans[id] = time.Unix(expiry, 0)
+	}
+	return bans
+}
+
 // QuerySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *DB) QuerySeeds(n int, maxAge time.Duration) []*Node {
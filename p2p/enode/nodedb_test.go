@@ -470,3 +470,39 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		banned  = ID{0x01}
+		expired = ID{0x02}
+		unknown = ID{0x03}
+		expiry  = time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	)
+	if err := db.UpdateBanExpiry(banned, expiry); err != nil {
+		t.Fatalf("failed to ban node: %v", err)
+	}
+	if err := db.UpdateBanExpiry(expired, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("failed to ban node: %v", err)
+	}
+	if have := db.BanExpiry(banned); !have.Equal(expiry) {
+		t.Errorf("ban expiry mismatch: have %v, want %v", have, expiry)
+	}
+	if have := db.BanExpiry(expired); !have.IsZero() {
+		t.Errorf("expired ban reported: %v", have)
+	}
+	if have := db.BanExpiry(unknown); !have.IsZero() {
+		t.Errorf("unknown ban reported: %v", have)
+	}
+	if bans := db.Bans(); len(bans) != 1 || !bans[banned].Equal(expiry) {
+		t.Errorf("ban list mismatch: %v", bans)
+	}
+	if err := db.DeleteBan(banned); err != nil {
+		t.Fatalf("failed to unban node: %v", err)
+	}
+	if have := db.BanExpiry(banned); !have.IsZero() {
+		t.Errorf("lifted ban reported: %v", have)
+	}
+}
@@ -284,6 +284,21 @@ func (t *Trackers) MedianRoundTrip() time.Duration {
 	return t.medianRoundTrip()
 }
 
+// RoundTrips returns the currently measured RTTs of all known trackers, allowing
+// callers to find the peers lagging behind the others.
+func (t *Trackers) RoundTrips() map[string]time.Duration {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	rtts := make(map[string]time.Duration, len(t.trackers))
+	for id, tt := range t.trackers {
+		tt.lock.RLock()
+		rtts[id] = tt.roundtrip
+		tt.lock.RUnlock()
+	}
+	return rtts
+}
+
 // medianRoundTrip is the internal lockless version of MedianRoundTrip to be used
 // by the QoS tuner.
 func (t *Trackers) medianRoundTrip() time.Duration {
@@ -115,6 +115,15 @@ type Peer struct {
 	pingRecv chan struct{}
 	disc     chan DiscReason
 
+	// reputation tracks the behavior of the peer if set
+	reputation *reputation
+
+	// bandwidth enforces the bandwidth quotas of the server if set
+	bandwidth *bandwidthLimiter
+
+	// capture records the sub-protocol messages if set
+	capture *captureWriter
+
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
@@ -244,6 +253,23 @@ func (p *Peer) Log() log.Logger {
 	return p.log
 }
 
+// Report adjusts the reputation of the peer by the given amount, reflecting its
+// good or bad behavior. See ReputationUseful and friends for typical values. If
+// the reputation drops too low, the peer is disconnected and banned for a while.
+// Trusted and static peers are never banned.
+func (p *Peer) Report(delta float64, reason string) {
+	if p.reputation == nil {
+		return
+	}
+	if delta < 0 {
+		p.log.Trace("Peer misbehaved", "penalty", -delta, "reason", reason)
+	}
+	if p.reputation.report(p.ID(), delta, p.rw.is(trustedConn|staticDialedConn)) {
+		p.log.Debug("Banning peer with low reputation", "reason", reason)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 func (p *Peer) run() (remoteRequested bool, err error) {
 	var (
 		writeStart = make(chan struct{}, 1)
@@ -362,6 +388,20 @@ func (p *Peer) handle(msg Msg) error {
 			metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
 			metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
 		}
+		size := msg.meterSize
+		if size == 0 {
+			size = msg.Size
+		}
+		proto.traffic.ingress.Add(uint64(size))
+		if p.bandwidth != nil {
+			// Delaying the delivery also delays reading the next message,
+			// applying backpressure on the peer.
+			wait, err := p.bandwidth.waitDownload(proto.Name, size, proto.priority(msg.Code-proto.offset), p.closed)
+			proto.traffic.throttled.Add(int64(wait))
+			if err != nil {
+				return io.EOF
+			}
+		}
 		select {
 		case proto.in <- msg:
 			return nil
@@ -399,7 +439,7 @@ outer:
 					offset -= old.Length
 				}
 				// Assign the new match
-				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw}
+				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw, traffic: new(protoTraffic)}
 				offset += proto.Length
 
 				continue outer
@@ -416,10 +456,14 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
+		proto.bandwidth = p.bandwidth
 		var rw MsgReadWriter = proto
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name, p.Info().Network.RemoteAddress, p.Info().Network.LocalAddress)
 		}
+		if p.capture != nil {
+			rw = newMsgCapturer(rw, p.capture, p.ID(), proto.Protocol)
+		}
 		p.log.Trace(fmt.Sprintf("Starting protocol %s/%d", proto.Name, proto.Version))
 		go func() {
 			defer p.wg.Done()
@@ -454,6 +498,9 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+
+	bandwidth *bandwidthLimiter // enforces bandwidth quotas if set
+	traffic   *protoTraffic     // traffic exchanged on the protocol
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
@@ -465,6 +512,13 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 
 	msg.Code += rw.offset
 
+	if rw.bandwidth != nil {
+		wait, err := rw.bandwidth.waitUpload(rw.Name, msg.Size, rw.priority(msg.meterCode), rw.closed)
+		rw.traffic.throttled.Add(int64(wait))
+		if err != nil {
+			return err
+		}
+	}
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
@@ -473,12 +527,21 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 		// otherwise. The calling protocol code should exit for errors
 		// as well but we don't want to rely on that.
 		rw.werr <- err
+		if err == nil {
+			rw.traffic.egress.Add(uint64(msg.Size))
+		}
 	case <-rw.closed:
 		err = ErrShuttingDown
 	}
 	return err
 }
 
+// priority reports whether messages with the given protocol-relative code are
+// exempt from waiting on the bandwidth quotas.
+func (rw *protoRW) priority(code uint64) bool {
+	return rw.PriorityMsg != nil && rw.PriorityMsg(code)
+}
+
 func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
@@ -506,6 +569,8 @@ type PeerInfo struct {
 		Static        bool   `json:"static"`
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+
+	Traffic map[string]*PeerTraffic `json:"traffic,omitempty"` // Traffic exchanged by sub-protocol
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -522,6 +587,7 @@ func (p *Peer) Info() *PeerInfo {
 		Name:      p.Fullname(),
 		Caps:      caps,
 		Protocols: make(map[string]interface{}, len(p.running)),
+		Traffic:   make(map[string]*PeerTraffic, len(p.running)),
 	}
 	if p.Node().Seq() > 0 {
 		info.ENR = p.Node().String()
@@ -543,6 +609,7 @@ func (p *Peer) Info() *PeerInfo {
 			}
 		}
 		info.Protocols[proto.Name] = protoInfo
+		info.Traffic[proto.Name] = proto.traffic.info()
 	}
 	return info
 }
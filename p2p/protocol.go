@@ -62,6 +62,12 @@ type Protocol struct {
 
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
+
+	// PriorityMsg is an optional helper method reporting whether messages with the
+	// given code are latency sensitive (e.g. block and transaction propagation).
+	// Priority messages are exempt from waiting on the bandwidth quotas, slowing
+	// down the bulk transfers of the protocol instead.
+	PriorityMsg func(code uint64) bool
 }
 
 func (p Protocol) cap() Cap {
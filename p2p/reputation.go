@@ -0,0 +1,171 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Reputation adjustments reported by protocols about the behavior of their peers.
+const (
+	ReputationUseful  = 1   // Peer served a useful response
+	ReputationUseless = -10 // Peer could not serve data it should have
+	ReputationInvalid = -50 // Peer sent invalid data or violated the protocol
+)
+
+const (
+	reputationHalfLife     = 15 * time.Minute // Time for scores to decay halfway towards zero
+	reputationMax          = 100              // Highest score, to avoid banking goodwill
+	reputationBanThreshold = -100             // Score at which peers are banned
+	reputationBanDuration  = time.Hour        // Duration of bans for low reputation
+	reputationPruneLimit   = 1024             // Number of scores tracked before pruning
+	permanentBanDuration   = 100 * 365 * 24 * time.Hour
+)
+
+// PeerReputation is the reputation of a node, as reported by admin_peerScores.
+type PeerReputation struct {
+	ID          enode.ID   `json:"id"`
+	Score       float64    `json:"score"`
+	BannedUntil *time.Time `json:"bannedUntil,omitempty"`
+}
+
+// peerScore is the decaying reputation score of a node.
+type peerScore struct {
+	value   float64
+	updated time.Time
+}
+
+// decayed returns the value of the score at the given time.
+func (s *peerScore) decayed(now time.Time) float64 {
+	elapsed := now.Sub(s.updated)
+	if elapsed <= 0 {
+		return s.value
+	}
+	return s.value * math.Exp2(-float64(elapsed)/float64(reputationHalfLife))
+}
+
+// reputation tracks the behavior of peers as reported by the protocols, banning
+// the nodes whose score drops too low. Bans are persisted in the node database.
+type reputation struct {
+	db     *enode.DB
+	now    func() time.Time
+	lock   sync.Mutex
+	scores map[enode.ID]*peerScore
+}
+
+func newReputation(db *enode.DB) *reputation {
+	return &reputation{
+		db:     db,
+		now:    time.Now,
+		scores: make(map[enode.ID]*peerScore),
+	}
+}
+
+// report adjusts the score of a node, returning whether it dropped below the
+// ban threshold and got banned.
+func (r *reputation) report(id enode.ID, delta float64, exempt bool) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	score := r.scores[id]
+	if score == nil {
+		if len(r.scores) >= reputationPruneLimit {
+			r.prune(now)
+		}
+		score = new(peerScore)
+		r.scores[id] = score
+	}
+	score.value = min(score.decayed(now)+delta, reputationMax)
+	score.updated = now
+
+	if exempt || score.value > reputationBanThreshold {
+		return false
+	}
+	// Score too low, ban the node and reset its reputation for when it's back
+	delete(r.scores, id)
+	if err := r.db.UpdateBanExpiry(id, now.Add(reputationBanDuration)); err != nil {
+		log.Warn("Failed to store peer ban", "id", id, "err", err)
+	}
+	return true
+}
+
+// prune drops the scores which have decayed to insignificance.
+func (r *reputation) prune(now time.Time) {
+	for id, score := range r.scores {
+		if math.Abs(score.decayed(now)) < 1 {
+			delete(r.scores, id)
+		}
+	}
+}
+
+// ban bans a node for the given duration, or permanently if not positive.
+func (r *reputation) ban(id enode.ID, duration time.Duration) error {
+	if duration <= 0 {
+		duration = permanentBanDuration
+	}
+	r.lock.Lock()
+	delete(r.scores, id)
+	r.lock.Unlock()
+
+	return r.db.UpdateBanExpiry(id, r.now().Add(duration))
+}
+
+// unban lifts the ban of a node.
+func (r *reputation) unban(id enode.ID) error {
+	return r.db.DeleteBan(id)
+}
+
+// banned reports whether a node is currently banned.
+func (r *reputation) banned(id enode.ID) bool {
+	return !r.db.BanExpiry(id).IsZero()
+}
+
+// reputations returns the scores of all tracked nodes, along with all bans.
+func (r *reputation) reputations() []*PeerReputation {
+	r.lock.Lock()
+	var (
+		now  = r.now()
+		reps = make(map[enode.ID]*PeerReputation, len(r.scores))
+	)
+	for id, score := range r.scores {
+		reps[id] = &PeerReputation{ID: id, Score: score.decayed(now)}
+	}
+	r.lock.Unlock()
+
+	for id, expiry := range r.db.Bans() {
+		expiry := expiry
+		if reps[id] == nil {
+			reps[id] = &PeerReputation{ID: id}
+		}
+		reps[id].BannedUntil = &expiry
+	}
+	list := make([]*PeerReputation, 0, len(reps))
+	for _, rep := range reps {
+		list = append(list, rep)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Score < list[j].Score
+	})
+	return list
+}
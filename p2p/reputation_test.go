@@ -0,0 +1,124 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Tests that reputation scores decay over time, and that nodes are banned when
+// their score drops too low.
+func TestReputationScores(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep     = newReputation(db)
+		now     = time.Now()
+		node    = enode.ID{0x01}
+		trusted = enode.ID{0x02}
+	)
+	rep.now = func() time.Time { return now }
+
+	// Penalties decaying away should not lead to bans
+	if rep.report(node, ReputationInvalid, false) {
+		t.Fatal("banned after a single penalty")
+	}
+	now = now.Add(reputationHalfLife)
+	if rep.report(node, ReputationInvalid, false) {
+		t.Fatal("banned after decayed penalties")
+	}
+	if score := rep.reputations()[0].Score; math.Abs(score-(-75)) > 1e-9 {
+		t.Fatalf("score mismatch: have %v, want %v", score, -75)
+	}
+	// Goodwill is capped, so it can't offset unlimited penalties
+	for i := 0; i < 1000; i++ {
+		rep.report(trusted, ReputationUseful, true)
+	}
+	if !rep.report(node, ReputationInvalid, false) {
+		t.Fatal("not banned after repeated penalties")
+	}
+	if !rep.banned(node) {
+		t.Fatal("ban not persisted")
+	}
+	// Exempt nodes should never be banned
+	for i := 0; i < 10; i++ {
+		if rep.report(trusted, ReputationInvalid, true) {
+			t.Fatal("exempt node banned")
+		}
+	}
+	reps := rep.reputations()
+	if len(reps) != 2 || reps[0].ID != trusted || reps[1].ID != node || reps[1].BannedUntil == nil {
+		t.Fatalf("unexpected reputations: %v", reps)
+	}
+	if err := rep.unban(node); err != nil || rep.banned(node) {
+		t.Fatalf("failed to unban node: %v", err)
+	}
+}
+
+// Tests that banned nodes are neither dialed nor accepted, unless explicitly
+// configured as static or trusted.
+func TestServerBanPeer(t *testing.T) {
+	srv := startTestServer(t, newkey().Public().(*ecdsa.PublicKey), nil)
+	defer srv.Stop()
+
+	var (
+		banned = enode.NewV4(&newkey().PublicKey, net.IP{127, 0, 0, 1}, 30303, 0)
+		static = enode.NewV4(&newkey().PublicKey, net.IP{127, 0, 0, 1}, 30304, 0)
+	)
+	for _, n := range []*enode.Node{banned, static} {
+		if err := srv.BanPeer(n.ID(), time.Hour); err != nil {
+			t.Fatalf("failed to ban node: %v", err)
+		}
+	}
+	if reps := srv.PeerReputations(); len(reps) != 2 || reps[0].BannedUntil == nil || reps[1].BannedUntil == nil {
+		t.Fatalf("bans not reported: %v", reps)
+	}
+	// Check that banned nodes are rejected by the dialer and handshake checks
+	d := &dialScheduler{
+		dialConfig: dialConfig{banned: srv.reputation.banned},
+		dialing:    make(map[enode.ID]*dialTask),
+		peers:      make(map[enode.ID]struct{}),
+		static:     map[enode.ID]*dialTask{static.ID(): newDialTask(static, staticDialedConn)},
+	}
+
+	if err := d.checkDial(banned); err != errBanned {
+		t.Errorf("banned node dial check error mismatch: have %v, want %v", err, errBanned)
+	}
+	if err := d.checkDial(static); err != nil {
+		t.Errorf("banned static node rejected: %v", err)
+	}
+	if err := srv.postHandshakeChecks(nil, 0, &conn{flags: inboundConn, node: banned}); err != DiscUselessPeer {
+		t.Errorf("banned node handshake check error mismatch: have %v, want %v", err, DiscUselessPeer)
+	}
+	if err := srv.postHandshakeChecks(nil, 0, &conn{flags: inboundConn | trustedConn, node: banned}); err != nil {
+		t.Errorf("banned trusted node rejected: %v", err)
+	}
+	// Check that lifting the ban allows the node again
+	if err := srv.UnbanPeer(banned.ID()); err != nil {
+		t.Fatalf("failed to unban node: %v", err)
+	}
+	if err := d.checkDial(banned); err != nil {
+		t.Errorf("unbanned node rejected: %v", err)
+	}
+}
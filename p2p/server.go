@@ -102,6 +102,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// DiscoveryTopics are advertised by the node using discovery v5. Nodes
+	// advertising the same topics are also searched for and dialed.
+	DiscoveryTopics []string `toml:",omitempty"`
+
 	// Name sets the node name of this server.
 	Name string `toml:"-"`
 
@@ -160,6 +164,14 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// Bandwidth configures the upload and download quotas of the
+	// server, globally and by sub-protocol.
+	Bandwidth BandwidthConfig `toml:",omitempty"`
+
+	// CaptureFile is the path of a file to record all sub-protocol messages
+	// exchanged with peers to, for debugging. Capture is disabled if empty.
+	CaptureFile string `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
@@ -197,6 +209,10 @@ type Server struct {
 	discmix   *enode.FairMix
 	dialsched *dialScheduler
 
+	reputation *reputation       // Peer reputation tracker and ban list
+	bandwidth  *bandwidthLimiter // Bandwidth quota enforcer, nil if unlimited
+	capture    *captureWriter    // Message capture file, nil if disabled
+
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
 
@@ -385,6 +401,50 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// isRunning reports whether the server is running.
+func (srv *Server) isRunning() bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.running
+}
+
+// BanPeer bans the given node for the given duration, or permanently if the duration
+// is not positive. The node is disconnected if connected, and won't be dialed or
+// accepted again until the ban expires. Bans are persisted in the node database.
+//
+// Trusted and static nodes are exempt from bans, as they are explicitly configured.
+func (srv *Server) BanPeer(id enode.ID, duration time.Duration) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	if err := srv.reputation.ban(id, duration); err != nil {
+		return err
+	}
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if peer := peers[id]; peer != nil && !peer.rw.is(trustedConn|staticDialedConn) {
+			peer.Disconnect(DiscUselessPeer)
+		}
+	})
+	return nil
+}
+
+// UnbanPeer lifts the ban of the given node.
+func (srv *Server) UnbanPeer(id enode.ID) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	return srv.reputation.unban(id)
+}
+
+// PeerReputations returns the reputation scores of the recently seen nodes and
+// the currently banned ones, lowest scores first.
+func (srv *Server) PeerReputations() []*PeerReputation {
+	if !srv.isRunning() {
+		return nil
+	}
+	return srv.reputation.reputations()
+}
+
 // SubscribeEvents subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -428,6 +488,11 @@ func (srv *Server) Stop() {
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+
+	if srv.capture != nil {
+		srv.capture.close()
+		srv.capture = nil
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -486,6 +551,21 @@ func (srv *Server) Start() (err error) {
 	if srv.listenFunc == nil {
 		srv.listenFunc = net.Listen
 	}
+	srv.bandwidth = newBandwidthLimiter(srv.Bandwidth)
+	if srv.CaptureFile != "" {
+		if srv.capture, err = newCaptureWriter(srv.CaptureFile); err != nil {
+			return err
+		}
+		srv.log.Warn("Capturing all peer messages", "file", srv.CaptureFile)
+
+		// Don't leak the capture file if the rest of the startup fails.
+		defer func() {
+			if err != nil {
+				srv.capture.close()
+				srv.capture = nil
+			}
+		}()
+	}
 	srv.quit = make(chan struct{})
 	srv.delpeer = make(chan peerDrop)
 	srv.checkpointPostHandshake = make(chan *conn)
@@ -530,6 +610,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -591,6 +672,14 @@ func (srv *Server) setupDiscovery() error {
 		if err != nil {
 			return err
 		}
+		// Registrations and searches end when discovery is closed.
+		for _, name := range srv.Config.DiscoveryTopics {
+			topic := discover.NewTopic(name)
+			srv.discv5.RegisterTopic(topic)
+			srv.discmix.AddSource(srv.discv5.TopicSearch(topic))
+		}
+	} else if len(srv.Config.DiscoveryTopics) > 0 {
+		srv.log.Warn("Discovery topics require discovery v5", "topics", srv.Config.DiscoveryTopics)
 	}
 
 	// Add protocol-specific discovery sources.
@@ -611,6 +700,7 @@ func (srv *Server) setupDialScheduler() {
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.Logger,
 		netRestrict:    srv.NetRestrict,
+		banned:         srv.reputation.banned,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
 	}
@@ -841,6 +931,8 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn|staticDialedConn) && srv.reputation.banned(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -1045,6 +1137,9 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.reputation = srv.reputation
+	p.bandwidth = srv.bandwidth
+	p.capture = srv.capture
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
@@ -87,6 +87,7 @@ type Client struct {
 
 	// config fields
 	batchItemLimit       int
+	callFilter           CallFilter
 	batchResponseMaxSize int
 
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
@@ -117,9 +118,12 @@ type clientConn struct {
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.Background()
+	if wc, ok := conn.(*websocketCodec); ok && wc.connCtx != nil {
+		ctx = wc.connCtx
+	}
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
-	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize)
+	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize, c.callFilter)
 	return &clientConn{conn, handler}
 }
 
@@ -191,35 +195,44 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 //
 // The client reconnects automatically when the connection is lost.
 func DialOptions(ctx context.Context, rawurl string, options ...ClientOption) (*Client, error) {
-	u, err := url.Parse(rawurl)
-	if err != nil {
-		return nil, err
-	}
-
 	cfg := new(clientConfig)
 	for _, opt := range options {
 		opt.applyOption(cfg)
 	}
+	var (
+		reconnect reconnectFunc
+		err       error
+	)
+	if len(cfg.failoverURLs) > 0 {
+		reconnect, err = newClientTransportFailover(append([]string{rawurl}, cfg.failoverURLs...), cfg)
+	} else {
+		reconnect, err = newClientTransport(rawurl, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newClient(ctx, cfg, reconnect)
+}
 
-	var reconnect reconnectFunc
+// newClientTransport creates the reconnect function for the transport matching
+// the scheme of the given URL.
+func newClientTransport(rawurl string, cfg *clientConfig) (reconnectFunc, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
 	switch u.Scheme {
 	case "http", "https":
-		reconnect = newClientTransportHTTP(rawurl, cfg)
+		return newClientTransportHTTP(rawurl, cfg), nil
 	case "ws", "wss":
-		rc, err := newClientTransportWS(rawurl, cfg)
-		if err != nil {
-			return nil, err
-		}
-		reconnect = rc
+		return newClientTransportWS(rawurl, cfg)
 	case "stdio":
-		reconnect = newClientTransportIO(os.Stdin, os.Stdout)
+		return newClientTransportIO(os.Stdin, os.Stdout), nil
 	case "":
-		reconnect = newClientTransportIPC(rawurl)
+		return newClientTransportIPC(rawurl), nil
 	default:
 		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
 	}
-
-	return newClient(ctx, cfg, reconnect)
 }
 
 // ClientFromContext retrieves the client from the context, if any. This can be used to perform
@@ -247,6 +260,7 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		callFilter:           cfg.callFilter,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
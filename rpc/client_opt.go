@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -41,6 +42,11 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	callFilter         CallFilter
+
+	// Failover options
+	failoverURLs        []string
+	healthCheckInterval time.Duration
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -142,3 +148,22 @@ func WithBatchResponseSizeLimit(sizeLimit int) ClientOption {
 		cfg.batchResponseLimit = sizeLimit
 	})
 }
+
+// WithFailover configures additional endpoints for the RPC client to fail over to.
+// Calls are routed to the healthy endpoints at the highest head block, and
+// idempotent calls are retried on another endpoint if one fails. Subscriptions
+// and filters stick to the endpoint they were created on, subscriptions being
+// recreated on another endpoint when it fails.
+func WithFailover(endpoints ...string) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.failoverURLs = append(cfg.failoverURLs, endpoints...)
+	})
+}
+
+// WithHealthCheckInterval configures how often the endpoints of a failover client
+// are checked for their health and head block.
+func WithHealthCheckInterval(interval time.Duration) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.healthCheckInterval = interval
+	})
+}
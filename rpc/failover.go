@@ -0,0 +1,598 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+)
+
+var errNoHealthyEndpoint = errors.New("no healthy endpoint available")
+
+// failoverEndpoint is a single endpoint of a failover connection.
+type failoverEndpoint struct {
+	url  string
+	dial func(context.Context) (*Client, error)
+
+	client  *Client // Connection to the endpoint, nil if not (yet) connected
+	healthy bool    // Whether the last health check or request succeeded
+	head    uint64  // Head block number reported by the last health check
+}
+
+// failoverSub is a subscription made through a failover connection. It is bound
+// to a single endpoint until that fails, when it's recreated on another one.
+type failoverSub struct {
+	id        ID
+	namespace string
+	args      []interface{}
+
+	endpoint *failoverEndpoint
+	sub      *ClientSubscription
+	ch       chan json.RawMessage
+	quit     chan struct{}
+}
+
+// failoverConn is a client connection multiplexing requests over a set of
+// endpoints. Calls are routed to the healthy endpoints at the highest head
+// block in a round-robin fashion, idempotent ones being retried on another
+// endpoint if the chosen one fails. Subscriptions and filters stick to the
+// endpoint they were created on, subscriptions being recreated on another
+// endpoint if it fails.
+//
+// It implements ServerCodec, so it can be driven by a regular Client.
+type failoverConn struct {
+	endpoints []*failoverEndpoint
+	interval  time.Duration
+	next      int                          // Round-robin counter for picking endpoints
+	subs      map[ID]*failoverSub          // Active subscriptions by their client facing ID
+	filters   map[string]*failoverEndpoint // Endpoints where the filters were installed
+	lock      sync.Mutex
+
+	incoming  chan readOp
+	closeCh   chan interface{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newClientTransportFailover creates the reconnect function of a client failing
+// over between the given endpoints.
+func newClientTransportFailover(urls []string, cfg *clientConfig) (reconnectFunc, error) {
+	// Dial the endpoints with the same options, but without the failover
+	single := *cfg
+	single.failoverURLs = nil
+
+	endpoints := make([]*failoverEndpoint, len(urls))
+	for i, url := range urls {
+		connect, err := newClientTransport(url, &single)
+		if err != nil {
+			return nil, err
+		}
+		endpoints[i] = &failoverEndpoint{
+			url: url,
+			dial: func(ctx context.Context) (*Client, error) {
+				return newClient(ctx, &single, connect)
+			},
+		}
+	}
+	return func(ctx context.Context) (ServerCodec, error) {
+		return newFailoverConn(ctx, endpoints, cfg.healthCheckInterval)
+	}, nil
+}
+
+// newFailoverConn connects to the given endpoints and starts monitoring their
+// health. An error is returned if none of them is reachable.
+func newFailoverConn(ctx context.Context, endpoints []*failoverEndpoint, interval time.Duration) (*failoverConn, error) {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	fc := &failoverConn{
+		endpoints: endpoints,
+		interval:  interval,
+		subs:      make(map[ID]*failoverSub),
+		filters:   make(map[string]*failoverEndpoint),
+		incoming:  make(chan readOp),
+		closeCh:   make(chan interface{}),
+	}
+	fc.checkHealth(ctx)
+
+	fc.lock.Lock()
+	var connected bool
+	for _, ep := range fc.endpoints {
+		connected = connected || ep.client != nil
+	}
+	fc.lock.Unlock()
+	if !connected {
+		fc.close()
+		return nil, errNoHealthyEndpoint
+	}
+	fc.wg.Add(1)
+	go fc.loop()
+	return fc, nil
+}
+
+// loop periodically checks the health of the endpoints until closed.
+func (fc *failoverConn) loop() {
+	defer fc.wg.Done()
+
+	ticker := time.NewTicker(fc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			fc.checkHealth(ctx)
+			cancel()
+		case <-fc.closeCh:
+			return
+		}
+	}
+}
+
+// checkHealth connects to any endpoints not yet connected and retrieves the
+// head block of all of them, updating their health.
+func (fc *failoverConn) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, ep := range fc.endpoints {
+		wg.Add(1)
+		go func(ep *failoverEndpoint) {
+			defer wg.Done()
+
+			fc.lock.Lock()
+			client := ep.client
+			fc.lock.Unlock()
+
+			if client == nil {
+				c, err := ep.dial(ctx)
+				if err != nil {
+					log.Debug("Failed to connect to RPC endpoint", "url", ep.url, "err", err)
+					return
+				}
+				fc.lock.Lock()
+				select {
+				case <-fc.closeCh:
+					fc.lock.Unlock()
+					c.Close()
+					return
+				default:
+				}
+				ep.client, client = c, c
+				fc.lock.Unlock()
+			}
+			// Endpoints responding with an error (e.g. not serving the eth
+			// namespace) are reachable, so consider them healthy
+			var head hexutil.Uint64
+			err := client.CallContext(ctx, &head, "eth_blockNumber")
+
+			fc.lock.Lock()
+			defer fc.lock.Unlock()
+			if err != nil && !isRPCError(err) {
+				if ep.healthy {
+					log.Debug("RPC endpoint became unhealthy", "url", ep.url, "err", err)
+				}
+				ep.healthy = false
+				return
+			}
+			ep.healthy, ep.head = true, uint64(head)
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// pick chooses an endpoint to send a request to, among the healthy ones at the
+// highest head block, not in the exclusion set. If no endpoint is healthy, any
+// connected one is returned.
+func (fc *failoverConn) pick(exclude map[*failoverEndpoint]bool) *failoverEndpoint {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	var (
+		best     []*failoverEndpoint
+		fallback *failoverEndpoint
+		head     uint64
+	)
+	for _, ep := range fc.endpoints {
+		if ep.client == nil || exclude[ep] {
+			continue
+		}
+		if !ep.healthy {
+			if fallback == nil {
+				fallback = ep
+			}
+			continue
+		}
+		switch {
+		case len(best) == 0 || ep.head > head:
+			best, head = []*failoverEndpoint{ep}, ep.head
+		case ep.head == head:
+			best = append(best, ep)
+		}
+	}
+	if len(best) == 0 {
+		return fallback
+	}
+	fc.next++
+	return best[fc.next%len(best)]
+}
+
+// markUnhealthy flags an endpoint as unhealthy after a failed request, until the
+// next health check.
+func (fc *failoverConn) markUnhealthy(ep *failoverEndpoint, err error) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	if ep.healthy {
+		log.Debug("RPC endpoint failed", "url", ep.url, "err", err)
+	}
+	ep.healthy = false
+}
+
+// writeJSON implements ServerCodec, dispatching the requests of the client to
+// the endpoints and delivering the responses asynchronously.
+func (fc *failoverConn) writeJSON(ctx context.Context, v interface{}, isError bool) error {
+	select {
+	case <-fc.closeCh:
+		return ErrClientQuit
+	default:
+	}
+	switch msg := v.(type) {
+	case *jsonrpcMessage:
+		if !msg.isCall() {
+			return nil // Responses to server-initiated calls are not supported
+		}
+		fc.wg.Add(1)
+		go func() {
+			defer fc.wg.Done()
+			fc.deliver(readOp{msgs: []*jsonrpcMessage{fc.handle(ctx, msg)}})
+		}()
+
+	case []*jsonrpcMessage:
+		fc.wg.Add(1)
+		go func() {
+			defer fc.wg.Done()
+
+			var resps []*jsonrpcMessage
+			for _, m := range msg {
+				if m.isCall() {
+					resps = append(resps, fc.handle(ctx, m))
+				}
+			}
+			fc.deliver(readOp{msgs: resps, batch: true})
+		}()
+	}
+	return nil
+}
+
+// deliver passes messages to the client, unless the connection is closed.
+func (fc *failoverConn) deliver(op readOp) {
+	select {
+	case fc.incoming <- op:
+	case <-fc.closeCh:
+	}
+}
+
+// handle serves a single request through the endpoints, returning the response.
+func (fc *failoverConn) handle(ctx context.Context, msg *jsonrpcMessage) *jsonrpcMessage {
+	var params []json.RawMessage
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return fc.errorResponse(msg, &invalidParamsError{"non-array args"})
+		}
+	}
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+	switch {
+	case msg.isSubscribe():
+		return fc.subscribe(ctx, msg, args)
+	case msg.isUnsubscribe():
+		return fc.unsubscribe(msg, params)
+	default:
+		return fc.call(ctx, msg, args, params)
+	}
+}
+
+// call executes a regular method call on one of the endpoints, retrying it on
+// other endpoints if it's idempotent.
+func (fc *failoverConn) call(ctx context.Context, msg *jsonrpcMessage, args []interface{}, params []json.RawMessage) *jsonrpcMessage {
+	// Filters are only known to the endpoint that installed them
+	var sticky *failoverEndpoint
+	if isFilterMethod(msg.Method) && len(params) > 0 {
+		var id string
+		if err := json.Unmarshal(params[0], &id); err == nil {
+			fc.lock.Lock()
+			sticky = fc.filters[id]
+			if msg.Method == "eth_uninstallFilter" {
+				delete(fc.filters, id)
+			}
+			fc.lock.Unlock()
+		}
+	}
+	var (
+		tried = make(map[*failoverEndpoint]bool)
+		err   = errNoHealthyEndpoint
+	)
+	for {
+		ep := sticky
+		if ep == nil {
+			ep = fc.pick(tried)
+		}
+		if ep == nil {
+			return fc.errorResponse(msg, err)
+		}
+		tried[ep] = true
+
+		var result json.RawMessage
+		if err = ep.client.CallContext(ctx, &result, msg.Method, args...); err == nil {
+			if strings.HasPrefix(msg.Method, "eth_new") && strings.HasSuffix(msg.Method, "Filter") {
+				var id string
+				if json.Unmarshal(result, &id) == nil {
+					fc.lock.Lock()
+					fc.filters[id] = ep
+					fc.lock.Unlock()
+				}
+			}
+			return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+		}
+		if isRPCError(err) || ctx.Err() != nil {
+			return fc.errorResponse(msg, err)
+		}
+		fc.markUnhealthy(ep, err)
+		if sticky != nil || !isIdempotent(msg.Method) {
+			return fc.errorResponse(msg, err)
+		}
+	}
+}
+
+// subscribe creates a subscription on one of the endpoints, returning the ID
+// under which it's known to the client.
+func (fc *failoverConn) subscribe(ctx context.Context, msg *jsonrpcMessage, args []interface{}) *jsonrpcMessage {
+	s := &failoverSub{
+		id:        NewID(),
+		namespace: strings.TrimSuffix(msg.Method, subscribeMethodSuffix),
+		args:      args,
+		ch:        make(chan json.RawMessage),
+		quit:      make(chan struct{}),
+	}
+	if err := fc.resubscribe(ctx, s, nil); err != nil {
+		return fc.errorResponse(msg, err)
+	}
+	fc.lock.Lock()
+	fc.subs[s.id] = s
+	fc.lock.Unlock()
+
+	fc.wg.Add(1)
+	go fc.forward(s)
+
+	result, _ := json.Marshal(s.id)
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+}
+
+// resubscribe (re)creates a subscription on one of the endpoints, other than
+// the given failed one if possible.
+func (fc *failoverConn) resubscribe(ctx context.Context, s *failoverSub, failed *failoverEndpoint) error {
+	var (
+		tried = map[*failoverEndpoint]bool{failed: true}
+		err   = errNoHealthyEndpoint
+	)
+	for {
+		ep := fc.pick(tried)
+		if ep == nil && failed != nil && !tried[nil] {
+			// Retry the failed endpoint too if there's nothing else
+			tried[nil], tried[failed] = true, false
+			continue
+		}
+		if ep == nil {
+			return err
+		}
+		tried[ep] = true
+
+		var sub *ClientSubscription
+		sub, err = ep.client.Subscribe(ctx, s.namespace, s.ch, s.args...)
+		if err == nil {
+			s.endpoint, s.sub = ep, sub
+			return nil
+		}
+		switch {
+		case errors.Is(err, ErrNotificationsUnsupported):
+			// Endpoint is fine, it just can't do subscriptions (e.g. HTTP)
+		case isRPCError(err) || ctx.Err() != nil:
+			return err
+		default:
+			fc.markUnhealthy(ep, err)
+		}
+	}
+}
+
+// forward relays the notifications of a subscription to the client, recreating
+// it on another endpoint if the current one fails.
+func (fc *failoverConn) forward(s *failoverSub) {
+	defer fc.wg.Done()
+
+	for {
+		select {
+		case result := <-s.ch:
+			params, _ := json.Marshal(&subscriptionResult{ID: string(s.id), Result: result})
+			fc.deliver(readOp{msgs: []*jsonrpcMessage{{
+				Version: vsn,
+				Method:  s.namespace + notificationMethodSuffix,
+				Params:  params,
+			}}})
+
+		case err, ok := <-s.sub.Err():
+			if !ok {
+				return // Unsubscribed, nothing to do
+			}
+			if err == nil {
+				err = errDead
+			}
+			fc.markUnhealthy(s.endpoint, err)
+			log.Debug("RPC subscription failed, resubscribing", "url", s.endpoint.url, "id", s.id, "err", err)
+
+			// Keep retrying until the subscription is recreated or dropped
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+				err := fc.resubscribe(ctx, s, s.endpoint)
+				cancel()
+				if err == nil {
+					break
+				}
+				log.Debug("Failed to resubscribe", "id", s.id, "err", err)
+				select {
+				case <-time.After(fc.interval):
+				case <-s.quit:
+					return
+				case <-fc.closeCh:
+					return
+				}
+			}
+
+		case <-s.quit:
+			s.sub.Unsubscribe()
+			return
+
+		case <-fc.closeCh:
+			return
+		}
+	}
+}
+
+// unsubscribe drops a subscription made through the connection.
+func (fc *failoverConn) unsubscribe(msg *jsonrpcMessage, params []json.RawMessage) *jsonrpcMessage {
+	var id ID
+	if len(params) == 0 || json.Unmarshal(params[0], &id) != nil {
+		return fc.errorResponse(msg, &invalidParamsError{"missing subscription ID"})
+	}
+	fc.lock.Lock()
+	s, ok := fc.subs[id]
+	delete(fc.subs, id)
+	fc.lock.Unlock()
+
+	if !ok {
+		return fc.errorResponse(msg, ErrSubscriptionNotFound)
+	}
+	close(s.quit)
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: json.RawMessage("true")}
+}
+
+// errorResponse creates an error response to a request.
+func (fc *failoverConn) errorResponse(msg *jsonrpcMessage, err error) *jsonrpcMessage {
+	resp := errorMessage(err)
+	resp.ID = msg.ID
+	return resp
+}
+
+// readBatch implements ServerCodec, returning the responses and notifications
+// delivered by the endpoints.
+func (fc *failoverConn) readBatch() ([]*jsonrpcMessage, bool, error) {
+	select {
+	case op := <-fc.incoming:
+		return op.msgs, op.batch, nil
+	case <-fc.closeCh:
+		return nil, false, errDead
+	}
+}
+
+// close implements ServerCodec, disconnecting from all endpoints.
+func (fc *failoverConn) close() {
+	fc.closeOnce.Do(func() {
+		close(fc.closeCh)
+
+		fc.lock.Lock()
+		clients := make([]*Client, 0, len(fc.endpoints))
+		for _, ep := range fc.endpoints {
+			if ep.client != nil {
+				clients = append(clients, ep.client)
+				ep.client, ep.healthy = nil, false
+			}
+		}
+		fc.lock.Unlock()
+
+		for _, client := range clients {
+			client.Close()
+		}
+		fc.wg.Wait()
+	})
+}
+
+// closed implements ServerCodec.
+func (fc *failoverConn) closed() <-chan interface{} {
+	return fc.closeCh
+}
+
+// remoteAddr implements ServerCodec.
+func (fc *failoverConn) remoteAddr() string {
+	urls := make([]string, len(fc.endpoints))
+	for i, ep := range fc.endpoints {
+		urls[i] = ep.url
+	}
+	return strings.Join(urls, ",")
+}
+
+// peerInfo implements ServerCodec.
+func (fc *failoverConn) peerInfo() PeerInfo {
+	return PeerInfo{Transport: "failover", RemoteAddr: fc.remoteAddr()}
+}
+
+// isRPCError reports whether an error was returned by the server, as opposed to
+// a transport failure.
+func isRPCError(err error) bool {
+	var rpcErr Error
+	return errors.As(err, &rpcErr)
+}
+
+// isFilterMethod reports whether a method operates on a filter installed on the
+// server, requiring the request to be routed to the same endpoint.
+func isFilterMethod(method string) bool {
+	switch method {
+	case "eth_getFilterChanges", "eth_getFilterLogs", "eth_uninstallFilter":
+		return true
+	}
+	return false
+}
+
+// isIdempotent reports whether a method does not modify the state of the server,
+// so it can be safely retried on another endpoint.
+func isIdempotent(method string) bool {
+	if isFilterMethod(method) {
+		return false
+	}
+	if strings.HasPrefix(method, "eth_get") {
+		return true
+	}
+	switch method {
+	case "eth_blockNumber", "eth_call", "eth_chainId", "eth_estimateGas", "eth_gasPrice",
+		"eth_maxPriorityFeePerGas", "eth_blobBaseFee", "eth_feeHistory", "eth_syncing",
+		"eth_createAccessList", "net_version", "net_listening", "net_peerCount",
+		"web3_clientVersion", "web3_sha3", "rpc_modules":
+		return true
+	}
+	return false
+}
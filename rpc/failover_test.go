@@ -0,0 +1,208 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// failoverTestService is a fake node serving a fixed head block.
+type failoverTestService struct {
+	name string
+	head uint64
+	sent atomic.Int32
+}
+
+func (s *failoverTestService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head)
+}
+
+func (s *failoverTestService) GetName() string {
+	return s.name
+}
+
+func (s *failoverTestService) SendRawTransaction() string {
+	s.sent.Add(1)
+	return s.name
+}
+
+func (s *failoverTestService) Names(ctx context.Context) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				if err := notifier.Notify(sub.ID, s.name); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// newFailoverTestClient creates a failover client over in-process servers
+// running the given services, checking their health at the given interval.
+func newFailoverTestClient(t *testing.T, interval time.Duration, services ...*failoverTestService) (*Client, []*Server) {
+	t.Helper()
+
+	var (
+		servers   = make([]*Server, len(services))
+		endpoints = make([]*failoverEndpoint, len(services))
+	)
+	for i, service := range services {
+		server := NewServer()
+		if err := server.RegisterName("eth", service); err != nil {
+			t.Fatal(err)
+		}
+		servers[i] = server
+		endpoints[i] = &failoverEndpoint{
+			url: service.name,
+			dial: func(context.Context) (*Client, error) {
+				return DialInProc(server), nil
+			},
+		}
+	}
+	client, err := newClient(context.Background(), new(clientConfig), func(ctx context.Context) (ServerCodec, error) {
+		return newFailoverConn(ctx, endpoints, interval)
+	})
+	if err != nil {
+		t.Fatalf("failed to create failover client: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		for _, server := range servers {
+			server.Stop()
+		}
+	})
+	return client, servers
+}
+
+// Tests that calls are routed to the endpoints at the highest head block.
+func TestFailoverHighestHead(t *testing.T) {
+	client, _ := newFailoverTestClient(t, time.Minute,
+		&failoverTestService{name: "a", head: 10},
+		&failoverTestService{name: "b", head: 12},
+		&failoverTestService{name: "c", head: 12},
+		&failoverTestService{name: "d", head: 11},
+	)
+	seen := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		var name string
+		if err := client.Call(&name, "eth_getName"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		seen[name]++
+	}
+	if len(seen) != 2 || seen["b"] == 0 || seen["c"] == 0 {
+		t.Fatalf("calls not balanced over the highest endpoints: %v", seen)
+	}
+}
+
+// Tests that idempotent calls are retried on another endpoint when the chosen
+// one fails, whereas other calls are not.
+func TestFailoverRetry(t *testing.T) {
+	var (
+		primary   = &failoverTestService{name: "primary", head: 2}
+		secondary = &failoverTestService{name: "secondary", head: 1}
+	)
+	// Disable periodic health checks so only the failed calls flag the endpoint
+	client, servers := newFailoverTestClient(t, time.Minute, primary, secondary)
+
+	var name string
+	if err := client.Call(&name, "eth_getName"); err != nil || name != "primary" {
+		t.Fatalf("wrong endpoint called: have %q, want %q (err %v)", name, "primary", err)
+	}
+	servers[0].Stop()
+
+	// Non-idempotent calls must surface the failure
+	if err := client.Call(&name, "eth_sendRawTransaction"); err == nil {
+		t.Fatalf("non-idempotent call succeeded on failed endpoint: %q", name)
+	}
+	if sent := secondary.sent.Load(); sent != 0 {
+		t.Fatalf("non-idempotent call retried %d times", sent)
+	}
+	// Idempotent calls must be retried on the healthy endpoint
+	if err := client.Call(&name, "eth_getName"); err != nil || name != "secondary" {
+		t.Fatalf("wrong endpoint called: have %q, want %q (err %v)", name, "secondary", err)
+	}
+	// With the failed endpoint marked unhealthy, non-idempotent calls go to the healthy one
+	if err := client.Call(&name, "eth_sendRawTransaction"); err != nil || name != "secondary" {
+		t.Fatalf("wrong endpoint called: have %q, want %q (err %v)", name, "secondary", err)
+	}
+}
+
+// Tests that subscriptions are recreated on another endpoint when the one they
+// were made on fails.
+func TestFailoverResubscribe(t *testing.T) {
+	client, servers := newFailoverTestClient(t, 20*time.Millisecond,
+		&failoverTestService{name: "primary", head: 2},
+		&failoverTestService{name: "secondary", head: 1},
+	)
+	ch := make(chan string)
+	sub, err := client.EthSubscribe(context.Background(), ch, "names")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	expect := func(want string) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case name := <-ch:
+				if name == want {
+					return
+				}
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-timeout:
+				t.Fatalf("timed out waiting for notification from %q", want)
+			}
+		}
+	}
+	expect("primary")
+	servers[0].Stop()
+	expect("secondary")
+}
+
+// Tests that dialing fails if none of the endpoints is reachable.
+func TestFailoverNoEndpoint(t *testing.T) {
+	endpoints := []*failoverEndpoint{{
+		url: "dead",
+		dial: func(context.Context) (*Client, error) {
+			return nil, fmt.Errorf("unreachable")
+		},
+	}}
+	if _, err := newFailoverConn(context.Background(), endpoints, time.Second); err != errNoHealthyEndpoint {
+		t.Fatalf("wrong error: have %v, want %v", err, errNoHealthyEndpoint)
+	}
+}
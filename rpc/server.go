@@ -52,6 +52,7 @@ type Server struct {
 	batchItemLimit     int
 	batchResponseLimit int
 	httpBodyLimit      int
+	callFilter         CallFilter
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -80,6 +81,20 @@ func (s *Server) SetBatchLimits(itemLimit, maxResponseSize int) {
 	s.batchResponseLimit = maxResponseSize
 }
 
+// CallFilter decides whether a method call is served. It is invoked with the context of
+// the call, which carries the PeerInfo of the connection, and the name of the method.
+// Returning an error rejects the call, with the error being sent back as the response.
+type CallFilter func(ctx context.Context, method string) error
+
+// SetCallFilter sets a filter deciding whether method calls are served. This can be used
+// to implement authorization and rate limiting of the calls.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetCallFilter(filter CallFilter) {
+	s.callFilter = filter
+}
+
 // SetHTTPBodyLimit sets the size limit for HTTP requests.
 //
 // This method should be called before processing any requests via ServeHTTP.
@@ -112,6 +127,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		callFilter:         s.callFilter,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -145,7 +161,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
+	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit, s.callFilter)
 	h.allowSubscribe = false
 	defer h.close(io.EOF, nil)
 
@@ -19,6 +19,7 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"net"
 	"os"
@@ -192,3 +193,33 @@ func TestServerBatchResponseSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestServerCallFilter(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+
+	var filtered []string
+	server.SetCallFilter(func(ctx context.Context, method string) error {
+		if PeerInfoFromContext(ctx).Transport != "ipc" {
+			t.Errorf("call filter context lacks peer info")
+		}
+		filtered = append(filtered, method)
+		if method == "test_echo" {
+			return &invalidRequestError{"rejected"}
+		}
+		return nil
+	})
+	client := DialInProc(server)
+	defer client.Close()
+
+	if err := client.Call(nil, "test_null"); err != nil {
+		t.Fatalf("allowed call failed: %v", err)
+	}
+	err := client.Call(nil, "test_echo", "x", 1)
+	if re, ok := err.(Error); !ok || re.ErrorCode() != -32600 {
+		t.Fatalf("wrong error for rejected call: %v", err)
+	}
+	if len(filtered) != 2 || filtered[0] != "test_null" || filtered[1] != "test_echo" {
+		t.Fatalf("wrong calls filtered: %v", filtered)
+	}
+}
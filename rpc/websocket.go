@@ -61,6 +61,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header, wsDefaultReadLimit)
+		codec.(*websocketCodec).connCtx = context.WithoutCancel(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -286,6 +287,10 @@ type websocketCodec struct {
 	conn *websocket.Conn
 	info PeerInfo
 
+	// connCtx is the base context of server-side connections, carrying the values
+	// of the upgrade request.
+	connCtx context.Context
+
 	wg           sync.WaitGroup
 	pingReset    chan struct{}
 	pongReceived chan struct{}
@@ -39,4 +39,12 @@ var (
 	// errStateUnrecoverable is returned if state is required to be reverted to
 	// a destination without associated state history available.
 	errStateUnrecoverable = errors.New("state is unrecoverable")
+
+	// errStateHistoryPruned is returned if a historic state is requested, but
+	// the state histories required for resolving it have been pruned already.
+	errStateHistoryPruned = errors.New("state history pruned")
+
+	// errStateTooDeep is returned if a historic state is requested, but it's
+	// further behind the disk layer than the configured state history window.
+	errStateTooDeep = errors.New("historic state too deep")
 )
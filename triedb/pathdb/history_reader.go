@@ -0,0 +1,320 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+// Historical state access
+//
+// Each state history object records the original value of the states mutated
+// by the corresponding state transition. The value of a state at the historic
+// point n can therefore be resolved by locating the first state history with
+// id greater than n in which the state was mutated: the recorded origin is the
+// value at point n. If no such history exists, the state has not been changed
+// since then and the value can be resolved from the current disk layer.
+//
+//          target state (id n)                         disk layer (id m)
+//                 |                                            |
+//                 v                                            v
+//   +-----------+     +-------------+     +-----+     +-------------+
+//   |  State n  |---->| History n+1 |---->| ... |---->|  History m  |
+//   +-----------+     +-------------+     +-----+     +-------------+
+//
+// The lookup is linear in the distance between the target state and the disk
+// layer: an account read costs up to one freezer read per state history in
+// between, a storage read up to two. There is no index over the histories, so
+// the cost of a single read is bounded by the configured number of retained
+// state histories only.
+
+// layerDatabase is a node database which serves all trie node reads from the
+// given layer, regardless of the requested state root.
+type layerDatabase struct {
+	reader *reader
+}
+
+// Reader implements database.Database, returning the node reader of the layer.
+func (db *layerDatabase) Reader(root common.Hash) (database.Reader, error) {
+	return db.reader, nil
+}
+
+// searchAccount locates the account index of the given address within the
+// encoded account indexes of a state history with binary search.
+func searchAccount(indexes []byte, address common.Address) (accountIndex, bool) {
+	var (
+		index accountIndex
+		count = len(indexes) / accountIndexSize
+	)
+	pos := sort.Search(count, func(i int) bool {
+		off := i * accountIndexSize
+		return bytes.Compare(indexes[off:off+common.AddressLength], address.Bytes()) >= 0
+	})
+	if pos == count {
+		return accountIndex{}, false
+	}
+	index.decode(indexes[pos*accountIndexSize : (pos+1)*accountIndexSize])
+	if index.address != address {
+		return accountIndex{}, false
+	}
+	return index, true
+}
+
+// searchSlot locates the slot index of the given storage slot hash within the
+// encoded storage indexes belonging to the given account.
+func searchSlot(indexes []byte, account accountIndex, slot common.Hash) (slotIndex, bool, error) {
+	var (
+		index slotIndex
+		start = int(account.storageOffset) * slotIndexSize
+		end   = int(account.storageOffset+account.storageSlots) * slotIndexSize
+	)
+	if end > len(indexes) {
+		return slotIndex{}, false, errors.New("storage index buffer is corrupted")
+	}
+	list := indexes[start:end]
+
+	pos := sort.Search(int(account.storageSlots), func(i int) bool {
+		off := i * slotIndexSize
+		return bytes.Compare(list[off:off+common.HashLength], slot.Bytes()) >= 0
+	})
+	if pos == int(account.storageSlots) {
+		return slotIndex{}, false, nil
+	}
+	index.decode(list[pos*slotIndexSize : (pos+1)*slotIndexSize])
+	if index.hash != slot {
+		return slotIndex{}, false, nil
+	}
+	return index, true, nil
+}
+
+// HistoricalStateReader provides read access to a historic state which is no
+// longer maintained by the layer tree, by resolving the state values from the
+// state histories on top of the current disk layer.
+type HistoricalStateReader struct {
+	db   *Database
+	root common.Hash // The state root of the target state
+	id   uint64      // The state id of the target state
+}
+
+// HistoricReader constructs a reader for accessing the requested historic state.
+// The state must be canonical and not older than the retained state histories.
+func (db *Database) HistoricReader(root common.Hash) (*HistoricalStateReader, error) {
+	// Bail out if the state history is not maintained at all.
+	if db.freezer == nil {
+		return nil, errors.New("state history is not available")
+	}
+	root = types.TrieRootHash(root)
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	// Ensure the requested state is not newer than the disk layer, the
+	// states above are accessible via the normal reader.
+	dl := db.tree.bottom()
+	if *id > dl.stateID() {
+		return nil, fmt.Errorf("state %#x is not historic, id: %d, disk: %d", root, *id, dl.stateID())
+	}
+	if *id == dl.stateID() {
+		if dl.rootHash() != root {
+			return nil, fmt.Errorf("state %#x is not canonical", root)
+		}
+		return &HistoricalStateReader{db: db, root: root, id: *id}, nil
+	}
+	// Ensure the state histories required for resolving the state are
+	// still retained. The first retained history is the one right after
+	// the freezer tail, whose parent state is the oldest accessible one.
+	tail, err := db.freezer.Tail()
+	if err != nil {
+		return nil, err
+	}
+	if *id < tail {
+		return nil, fmt.Errorf("%w: state %#x is older than the retained state history, id: %d, tail: %d", errStateHistoryPruned, root, *id, tail)
+	}
+	// Reject states outside of the configured history window, which might still
+	// be retained if the window was shrunk but not yet pruned.
+	if limit := db.config.StateHistory; limit != 0 && dl.stateID()-*id > limit {
+		return nil, fmt.Errorf("%w: state %#x is older than the state history window of %d blocks, id: %d, disk: %d", errStateTooDeep, root, limit, *id, dl.stateID())
+	}
+	// Ensure the requested state is a canonical state, by checking that
+	// the next state history is built on top of it.
+	blob := rawdb.ReadStateHistoryMeta(db.freezer, *id+1)
+	if len(blob) == 0 {
+		return nil, fmt.Errorf("state history not found %d", *id+1)
+	}
+	var m meta
+	if err := m.decode(blob); err != nil {
+		return nil, err
+	}
+	if m.parent != root {
+		return nil, fmt.Errorf("state %#x is not canonical", root)
+	}
+	return &HistoricalStateReader{db: db, root: root, id: *id}, nil
+}
+
+// Root returns the state root of the historic state.
+func (r *HistoricalStateReader) Root() common.Hash {
+	return r.root
+}
+
+// resolve walks the state histories from the target state towards the disk
+// layer, returning the first value reported by find. The disk callback is
+// invoked with the current disk layer if the value is not found in any state
+// history. The procedure continues from the last examined history in case the
+// disk layer is flattened concurrently.
+//
+// The cost is one find call per state history between the target state and the
+// disk layer, which is bounded by the state history window when opening the
+// reader.
+func (r *HistoricalStateReader) resolve(find func(id uint64) ([]byte, bool, error), disk func(dl *diskLayer) ([]byte, error)) ([]byte, error) {
+	next := r.id + 1
+	for {
+		dl := r.db.tree.bottom()
+		for ; next <= dl.stateID(); next++ {
+			blob, found, err := find(next)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				return blob, nil
+			}
+		}
+		blob, err := disk(dl)
+		if errors.Is(err, errSnapshotStale) {
+			continue
+		}
+		return blob, err
+	}
+}
+
+// accountHistory looks up the original value of the given account in the
+// specified state history.
+func (r *HistoricalStateReader) accountHistory(id uint64, address common.Address) (accountIndex, []byte, bool, error) {
+	indexes := rawdb.ReadStateAccountIndex(r.db.freezer, id)
+	if len(indexes) == 0 || len(indexes)%accountIndexSize != 0 {
+		return accountIndex{}, nil, false, fmt.Errorf("state history not found %d", id)
+	}
+	index, found := searchAccount(indexes, address)
+	if !found {
+		return accountIndex{}, nil, false, nil
+	}
+	data := rawdb.ReadStateAccountHistory(r.db.freezer, id)
+	if uint32(len(data)) < index.offset+uint32(index.length) {
+		return accountIndex{}, nil, false, errors.New("account data buffer is corrupted")
+	}
+	return index, data[index.offset : index.offset+uint32(index.length)], true, nil
+}
+
+// diskAccount retrieves the account in the slim format from the given disk layer.
+func diskAccount(dl *diskLayer, address common.Address) ([]byte, *types.StateAccount, error) {
+	tr, err := trie.New(trie.StateTrieID(dl.rootHash()), &layerDatabase{reader: &reader{layer: dl, noHashCheck: dl.db.isVerkle}})
+	if err != nil {
+		return nil, nil, err
+	}
+	h := newHasher()
+	defer h.release()
+
+	blob, err := tr.Get(h.hash(address.Bytes()).Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(blob) == 0 {
+		return nil, nil, nil
+	}
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return nil, nil, err
+	}
+	return types.SlimAccountRLP(account), &account, nil
+}
+
+// Account retrieves the account at the historic state. Nil is returned if the
+// account is not present.
+func (r *HistoricalStateReader) Account(address common.Address) (*types.SlimAccount, error) {
+	blob, err := r.resolve(func(id uint64) ([]byte, bool, error) {
+		_, data, found, err := r.accountHistory(id, address)
+		return data, found, err
+	}, func(dl *diskLayer) ([]byte, error) {
+		blob, _, err := diskAccount(dl, address)
+		return blob, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	account := new(types.SlimAccount)
+	if err := rlp.DecodeBytes(blob, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Storage retrieves the storage slot at the historic state, identified by the
+// account address and the hash of the raw slot key. The returned value is the
+// RLP-decoded slot content and nil is returned if the slot is not present.
+func (r *HistoricalStateReader) Storage(address common.Address, slot common.Hash) ([]byte, error) {
+	blob, err := r.resolve(func(id uint64) ([]byte, bool, error) {
+		account, _, found, err := r.accountHistory(id, address)
+		if err != nil || !found || account.storageSlots == 0 {
+			return nil, false, err
+		}
+		index, found, err := searchSlot(rawdb.ReadStateStorageIndex(r.db.freezer, id), account, slot)
+		if err != nil || !found {
+			return nil, false, err
+		}
+		data := rawdb.ReadStateStorageHistory(r.db.freezer, id)
+		if uint32(len(data)) < index.offset+uint32(index.length) {
+			return nil, false, errors.New("storage data buffer is corrupted")
+		}
+		return data[index.offset : index.offset+uint32(index.length)], true, nil
+	}, func(dl *diskLayer) ([]byte, error) {
+		_, account, err := diskAccount(dl, address)
+		if err != nil || account == nil {
+			return nil, err
+		}
+		h := newHasher()
+		defer h.release()
+
+		tr, err := trie.New(trie.StorageTrieID(dl.rootHash(), h.hash(address.Bytes()), account.Root), &layerDatabase{reader: &reader{layer: dl, noHashCheck: dl.db.isVerkle}})
+		if err != nil {
+			return nil, err
+		}
+		return tr.Get(slot.Bytes())
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func checkHistoricState(tester *tester, reader *HistoricalStateReader, root common.Hash) error {
+	var (
+		accounts = tester.snapAccounts[root]
+		storages = tester.snapStorages[root]
+	)
+	for addrHash, addr := range tester.preimages {
+		account, err := reader.Account(addr)
+		if err != nil {
+			return err
+		}
+		want := accounts[addrHash]
+		if len(want) == 0 {
+			if account != nil {
+				return errors.New("unexpected account")
+			}
+			continue
+		}
+		blob, err := rlp.EncodeToBytes(account)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(blob, want) {
+			return errors.New("account is mismatched")
+		}
+	}
+	// Check all the slots ever created, including the deleted ones
+	for _, snap := range tester.snapStorages {
+		for addrHash, slots := range snap {
+			for slotHash := range slots {
+				got, err := reader.Storage(tester.preimages[addrHash], slotHash)
+				if err != nil {
+					return err
+				}
+				var want []byte
+				if blob := storages[addrHash][slotHash]; len(blob) != 0 {
+					_, want, _, _ = rlp.Split(blob)
+				}
+				if !bytes.Equal(got, want) {
+					return errors.New("slot is mismatched")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestHistoricReader(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Historic states up to the disk layer are readable
+	bottom := tester.bottomIndex()
+	for i := 0; i <= bottom; i++ {
+		reader, err := tester.db.HistoricReader(tester.roots[i])
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", i, err)
+		}
+		if err := checkHistoricState(tester, reader, tester.roots[i]); err != nil {
+			t.Fatalf("Unexpected historic state %d: %v", i, err)
+		}
+	}
+	// States above the disk layer are not historic
+	if _, err := tester.db.HistoricReader(tester.roots[bottom+1]); err == nil {
+		t.Fatal("Expected error for non-historic state")
+	}
+	// Unknown states are not readable
+	if _, err := tester.db.HistoricReader(common.Hash{0x1}); err == nil {
+		t.Fatal("Expected error for unknown state")
+	}
+	// Historic states should remain readable while the disk layer progresses
+	reader, err := tester.db.HistoricReader(tester.roots[0])
+	if err != nil {
+		t.Fatalf("Failed to open historic state: %v", err)
+	}
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to commit state: %v", err)
+	}
+	if err := checkHistoricState(tester, reader, tester.roots[0]); err != nil {
+		t.Fatalf("Unexpected historic state: %v", err)
+	}
+}
+
+func TestHistoricReaderPruned(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 2)
+	defer tester.release()
+
+	// Two histories are retained, the state root to id mappings of the
+	// pruned states are removed along with the histories.
+	var (
+		bottom = tester.bottomIndex()
+		oldest = bottom - 1
+	)
+	if _, err := tester.db.HistoricReader(tester.roots[oldest-1]); err == nil {
+		t.Fatal("Expected error for pruned state")
+	}
+	if _, err := tester.db.HistoricReader(types.EmptyRootHash); !errors.Is(err, errStateHistoryPruned) {
+		t.Fatalf("Unexpected error, want: %v, got: %v", errStateHistoryPruned, err)
+	}
+	for i := oldest; i <= bottom; i++ {
+		reader, err := tester.db.HistoricReader(tester.roots[i])
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", i, err)
+		}
+		if err := checkHistoricState(tester, reader, tester.roots[i]); err != nil {
+			t.Fatalf("Unexpected historic state %d: %v", i, err)
+		}
+	}
+}
+
+func TestHistoricReaderTooDeep(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Shrink the history window without pruning the state histories, as if
+	// the node was restarted with a lower limit.
+	tester.db.config.StateHistory = 2
+
+	bottom := tester.bottomIndex()
+	if _, err := tester.db.HistoricReader(tester.roots[bottom-3]); !errors.Is(err, errStateTooDeep) {
+		t.Fatalf("Unexpected error, want: %v, got: %v", errStateTooDeep, err)
+	}
+	for i := bottom - 2; i <= bottom; i++ {
+		reader, err := tester.db.HistoricReader(tester.roots[i])
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", i, err)
+		}
+		if err := checkHistoricState(tester, reader, tester.roots[i]); err != nil {
+			t.Fatalf("Unexpected historic state %d: %v", i, err)
+		}
+	}
+}